@@ -0,0 +1,126 @@
+package otp
+
+import (
+	"crypto"
+	"hash"
+	"strings"
+	"sync"
+)
+
+// algorithms maps the names of the algorithm parameter of Key URIs to their hash function, and hash functions to
+// the name they were first registered with.
+// BLAKE2 hash functions are only available once their golang.org/x/crypto package is imported, e.g. with
+// import _ "golang.org/x/crypto/blake2b". They aren't supported by Google Authenticator.
+// Hash functions without a crypto.Hash value, such as SM3, are registered by function in byFunc.
+var algorithms = struct {
+	sync.RWMutex
+	byName map[string]crypto.Hash
+	byHash map[crypto.Hash]string
+	byFunc map[string]func() hash.Hash
+}{
+	byName: map[string]crypto.Hash{
+		"SHA1":   crypto.SHA1,
+		"SHA224": crypto.SHA224,
+		"SHA256": crypto.SHA256,
+		"SHA384": crypto.SHA384,
+		"SHA512": crypto.SHA512,
+
+		"BLAKE2B256": crypto.BLAKE2b_256,
+		"BLAKE2B384": crypto.BLAKE2b_384,
+		"BLAKE2B512": crypto.BLAKE2b_512,
+		"BLAKE2S256": crypto.BLAKE2s_256,
+	},
+	byHash: map[crypto.Hash]string{
+		crypto.SHA1:   "SHA1",
+		crypto.SHA224: "SHA224",
+		crypto.SHA256: "SHA256",
+		crypto.SHA384: "SHA384",
+		crypto.SHA512: "SHA512",
+
+		crypto.BLAKE2b_256: "BLAKE2B256",
+		crypto.BLAKE2b_384: "BLAKE2B384",
+		crypto.BLAKE2b_512: "BLAKE2B512",
+		crypto.BLAKE2s_256: "BLAKE2S256",
+	},
+	byFunc: map[string]func() hash.Hash{},
+}
+
+// RegisterAlgorithm registers the name of a hash function, as used by the algorithm parameter of Key URIs.
+// Names are case insensitive. Registering an existing name replaces its hash function, so that non standard
+// names used by some vendors can be supported, and the replaced hash function loses the name.
+func RegisterAlgorithm(name string, h crypto.Hash) {
+	algorithms.Lock()
+	defer algorithms.Unlock()
+
+	name = strings.ToUpper(name)
+	unregisterAlgorithm(name)
+	algorithms.byName[name] = h
+	if _, ok := algorithms.byHash[h]; !ok {
+		algorithms.byHash[h] = name
+	}
+}
+
+// RegisterAlgorithmFunc registers the name of a hash function which has no crypto.Hash value, such as SM3 (GM/T
+// 0004-2012) whose implementation can be registered with RegisterAlgorithmFunc("SM3", sm3.New).
+// Names are case insensitive. Registering an existing name replaces its hash function.
+func RegisterAlgorithmFunc(name string, fn func() hash.Hash) {
+	algorithms.Lock()
+	defer algorithms.Unlock()
+
+	name = strings.ToUpper(name)
+	unregisterAlgorithm(name)
+	algorithms.byFunc[name] = fn
+}
+
+// unregisterAlgorithm removes the hash function registered with a given name, with the algorithms lock held. The
+// hash function keeps the first, in alphabetical order, of its other names, if any.
+func unregisterAlgorithm(name string) {
+	delete(algorithms.byFunc, name)
+	h, ok := algorithms.byName[name]
+	if !ok {
+		return
+	}
+	delete(algorithms.byName, name)
+	if algorithms.byHash[h] != name {
+		return
+	}
+
+	delete(algorithms.byHash, h)
+	for other, otherHash := range algorithms.byName {
+		if current, ok := algorithms.byHash[h]; otherHash == h && (!ok || other < current) {
+			algorithms.byHash[h] = other
+		}
+	}
+}
+
+// AlgorithmFuncByName returns the function creating the hash function registered with a given name, whether it was
+// registered with RegisterAlgorithm or RegisterAlgorithmFunc. The result can be used as HOTPOptions.Algorithm.
+func AlgorithmFuncByName(name string) (func() hash.Hash, bool) {
+	algorithms.RLock()
+	defer algorithms.RUnlock()
+
+	name = strings.ToUpper(name)
+	if h, ok := algorithms.byName[name]; ok {
+		return hashFunc(h), true
+	}
+	fn, ok := algorithms.byFunc[name]
+	return fn, ok
+}
+
+// AlgorithmByName returns the hash function registered with a given name.
+func AlgorithmByName(name string) (crypto.Hash, bool) {
+	algorithms.RLock()
+	defer algorithms.RUnlock()
+
+	h, ok := algorithms.byName[strings.ToUpper(name)]
+	return h, ok
+}
+
+// AlgorithmName returns the name a hash function was first registered with, in upper case.
+func AlgorithmName(h crypto.Hash) (string, bool) {
+	algorithms.RLock()
+	defer algorithms.RUnlock()
+
+	name, ok := algorithms.byHash[h]
+	return name, ok
+}
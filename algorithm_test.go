@@ -0,0 +1,119 @@
+package otp
+
+import (
+	"crypto"
+	"crypto/sha256"
+	"crypto/sha512"
+	"maps"
+	"testing"
+)
+
+func TestAlgorithmByName(t *testing.T) {
+	expected := map[string]crypto.Hash{"SHA1": crypto.SHA1, "sha256": crypto.SHA256, "Sha512": crypto.SHA512, "BLAKE2b512": crypto.BLAKE2b_512, "BLAKE2S256": crypto.BLAKE2s_256}
+	for name, h := range expected {
+		res, ok := AlgorithmByName(name)
+		if !ok || res != h {
+			t.Errorf("Error in AlgorithmByName (name = %s, expected = %s, got = %s)", name, h, res)
+		}
+	}
+
+	// round trip of every standard name
+	for _, name := range []string{"SHA1", "SHA224", "SHA256", "SHA384", "SHA512"} {
+		h, _ := AlgorithmByName(name)
+		res, ok := AlgorithmName(h)
+		if !ok || res != name || !h.Available() {
+			t.Errorf("Error in AlgorithmByName (name = %s, got = %s, available = %t)", name, res, h.Available())
+		}
+	}
+
+	if _, ok := AlgorithmByName("MD5"); ok {
+		t.Errorf("Error in AlgorithmByName (MD5 is registered)")
+	}
+}
+
+func TestRegisterAlgorithm(t *testing.T) {
+	RegisterAlgorithm("HmacSHA256", crypto.SHA256)
+	defer func() {
+		algorithms.Lock()
+		delete(algorithms.byName, "HMACSHA256")
+		algorithms.Unlock()
+	}()
+
+	res, ok := AlgorithmByName("HMACSHA256")
+	if !ok || res != crypto.SHA256 {
+		t.Errorf("Error in RegisterAlgorithm (expected = %s, got = %s)", crypto.SHA256, res)
+	}
+
+	// standard names are kept for serialization
+	name, ok := AlgorithmName(crypto.SHA256)
+	if !ok || name != "SHA256" {
+		t.Errorf("Error in RegisterAlgorithm (expected name = SHA256, got = %s)", name)
+	}
+}
+
+func TestRegisterAlgorithmReplace(t *testing.T) {
+	algorithms.Lock()
+	byName, byHash, byFunc := maps.Clone(algorithms.byName), maps.Clone(algorithms.byHash), maps.Clone(algorithms.byFunc)
+	algorithms.Unlock()
+	defer func() {
+		algorithms.Lock()
+		algorithms.byName, algorithms.byHash, algorithms.byFunc = byName, byHash, byFunc
+		algorithms.Unlock()
+	}()
+
+	RegisterAlgorithm("Custom", crypto.MD5)
+	RegisterAlgorithm("Custom", crypto.SHA3_256)
+	if name, ok := AlgorithmName(crypto.MD5); ok {
+		t.Errorf("Error in RegisterAlgorithmReplace (MD5 kept the name %s)", name)
+	}
+	if name, _ := AlgorithmName(crypto.SHA3_256); name != "CUSTOM" {
+		t.Errorf("Error in RegisterAlgorithmReplace (expected = CUSTOM, got = %s)", name)
+	}
+
+	RegisterAlgorithmFunc("Custom", sha256.New)
+	if name, ok := AlgorithmName(crypto.SHA3_256); ok {
+		t.Errorf("Error in RegisterAlgorithmReplace (SHA3-256 kept the name %s)", name)
+	}
+
+	// the hash function keeps its other names
+	RegisterAlgorithm("HmacSHA1", crypto.SHA1)
+	RegisterAlgorithm("SHA1", crypto.SHA3_256)
+	if name, _ := AlgorithmName(crypto.SHA1); name != "HMACSHA1" {
+		t.Errorf("Error in RegisterAlgorithmReplace (expected = HMACSHA1, got = %s)", name)
+	}
+}
+
+func TestAlgorithmName(t *testing.T) {
+	name, ok := AlgorithmName(crypto.SHA1)
+	if !ok || name != "SHA1" {
+		t.Errorf("Error in AlgorithmName (expected = SHA1, got = %s)", name)
+	}
+
+	if _, ok := AlgorithmName(crypto.MD5); ok {
+		t.Errorf("Error in AlgorithmName (MD5 has a name)")
+	}
+}
+
+func TestRegisterAlgorithmFunc(t *testing.T) {
+	// sha256.New stands in for an SM3 implementation
+	RegisterAlgorithmFunc("sm3", sha256.New)
+	defer func() {
+		algorithms.Lock()
+		delete(algorithms.byFunc, "SM3")
+		algorithms.Unlock()
+	}()
+
+	fn, ok := AlgorithmFuncByName("SM3")
+	if !ok || HOTP(hotpSecret, 0, HOTPOptions{Algorithm: fn}) != HOTP(hotpSecret, 0, HOTPOptions{Algorithm: sha256.New}) {
+		t.Errorf("Error in RegisterAlgorithmFunc (SM3 not registered)")
+	}
+
+	if _, ok := AlgorithmByName("SM3"); ok {
+		t.Errorf("Error in RegisterAlgorithmFunc (SM3 has a crypto.Hash)")
+	}
+
+	fn, ok = AlgorithmFuncByName("sha512")
+	if !ok || fn().Size() != sha512.Size {
+		t.Errorf("Error in RegisterAlgorithmFunc (SHA512 not found)")
+	}
+}
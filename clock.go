@@ -0,0 +1,26 @@
+package otp
+
+import (
+	"time"
+)
+
+// Clock provides the current time to the functions working with it, such as WatchTOTP.
+type Clock interface {
+	Now() time.Time
+}
+
+// ClockFunc is an adapter to use a function as a Clock.
+type ClockFunc func() time.Time
+
+// Now implements Clock.
+func (f ClockFunc) Now() time.Time {
+	return f()
+}
+
+// systemClock is the default Clock, using time.Now.
+type systemClock struct{}
+
+// Now implements Clock.
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
@@ -0,0 +1,25 @@
+package otp
+
+import (
+	"testing"
+	"time"
+)
+
+func TestClockFunc(t *testing.T) {
+	now := time.Unix(1111111109, 0)
+	clock := ClockFunc(func() time.Time {
+		return now
+	})
+
+	if res := clock.Now(); !res.Equal(now) {
+		t.Errorf("Error in ClockFunc (expected = %s, got = %s)", now, res)
+	}
+}
+
+func TestSystemClock(t *testing.T) {
+	before := time.Now()
+	res := systemClock{}.Now()
+	if res.Before(before) || res.After(time.Now()) {
+		t.Errorf("Error in SystemClock (got = %s)", res)
+	}
+}
@@ -0,0 +1,16 @@
+//go:build js && wasm
+
+// Command otp-wasm is the WebAssembly module of package jsotp, registering its functions as the global object otp:
+//
+//	GOOS=js GOARCH=wasm go build -o otp.wasm github.com/xrjr/otp/cmd/otp-wasm
+package main
+
+import (
+	"github.com/xrjr/otp/jsotp"
+)
+
+func main() {
+	jsotp.Register("otp")
+	// the functions are called by JavaScript until the page is closed
+	select {}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/xrjr/otp"
+	"github.com/xrjr/otp/vault"
+)
+
+// Formats of the export command.
+const (
+	formatAegis     = "aegis"
+	formatURI       = "uri"
+	formatMigration = "migration"
+)
+
+// runExport exports the keys of the vault to a backup file, as imported by the import command and by authenticator
+// applications: an export of Aegis Authenticator, optionally encrypted with a password read from
+// $OTP_EXPORT_PASSPHRASE or the standard input, otpauth URIs, or the otpauth-migration URIs of Google
+// Authenticator, one per line.
+func runExport(e *env, args []string) error {
+	fs := newFlagSet(e, "export", "")
+	var vf vaultFlags
+	vf.register(e, fs)
+	format := fs.String("format", formatURI, "format of the backup, aegis, uri or migration")
+	encrypt := fs.Bool("encrypt", false, "encrypt the backup with a password, only supported by the aegis format")
+	batch := fs.Int("batch", 10, "keys per otpauth-migration URI, so that their QR codes stay readable, or 0 for a single URI")
+	output := fs.String("o", "", "write the backup to a file instead of the standard output")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 || *batch < 0 {
+		fs.Usage()
+		return errUsage
+	}
+	switch *format {
+	case formatAegis:
+	case formatURI, formatMigration:
+		if *encrypt {
+			return fmt.Errorf("the %s format can't be encrypted", *format)
+		}
+	default:
+		return fmt.Errorf("invalid format %q", *format)
+	}
+
+	v, passphrase, err := vf.open(e, false)
+	if err != nil {
+		return err
+	}
+	otp.WipeBytes(passphrase)
+
+	var data []byte
+	switch *format {
+	case formatAegis:
+		if !*encrypt {
+			data, err = vault.MarshalAegis(v.Entries)
+			break
+		}
+		var password []byte
+		if password, err = readSecret(e, "OTP_EXPORT_PASSPHRASE", "Backup password", true); err != nil {
+			return err
+		}
+		data, err = vault.SealAegis(v.Entries, password)
+		otp.WipeBytes(password)
+	case formatURI:
+		var sb strings.Builder
+		for _, entry := range v.Entries {
+			sb.WriteString(entry.Key.URI() + "\n")
+		}
+		data = []byte(sb.String())
+	case formatMigration:
+		keys := make([]otp.Key, len(v.Entries))
+		for i, entry := range v.Entries {
+			keys[i] = entry.Key
+		}
+		var uris []string
+		if uris, err = otp.MigrationURIs(keys, *batch); err == nil {
+			data = []byte(strings.Join(uris, "\n") + "\n")
+		}
+	}
+	if err != nil {
+		return err
+	}
+	defer otp.WipeBytes(data)
+
+	if *output == "" {
+		_, err := e.stdout.Write(data)
+		return err
+	}
+	f, err := os.OpenFile(*output, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	return errors.Join(err, f.Close())
+}
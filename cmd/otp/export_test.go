@@ -0,0 +1,78 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/xrjr/otp"
+	"github.com/xrjr/otp/vault"
+)
+
+func TestExport(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "vault.json")
+	v := &vault.Vault{Iterations: 10}
+	secretBytes, _ := otp.ParseSecret(secret)
+	v.Add(vault.Entry{Name: "alice", Key: otp.Key{Type: otp.TypeTOTP, AccountName: "alice", Secret: secretBytes, Digits: 8}})
+	v.Add(vault.Entry{Name: "Example:bob", Key: otp.Key{Type: otp.TypeHOTP, Issuer: "Example", AccountName: "bob", Secret: secretBytes, Counter: 3}})
+	if err := v.Save(path, []byte("passphrase")); err != nil {
+		t.Fatalf("Error in Save (err = %v)", err)
+	}
+	vars := map[string]string{"OTP_VAULT": path, "OTP_PASSPHRASE": "passphrase"}
+
+	code, stdout, stderr := runTest(vars, "", "export")
+	expected := "otpauth://totp/alice?digits=8&secret=" + secret + "\notpauth://hotp/Example:bob?counter=3&issuer=Example&secret=" + secret + "\n"
+	if code != exitOK || stdout != expected {
+		t.Errorf("Error in export (expected = %q, got = %d %q, stderr = %q)", expected, code, stdout, stderr)
+	}
+
+	code, stdout, stderr = runTest(vars, "", "export", "-format", "migration", "-batch", "1")
+	if lines := strings.Split(strings.TrimSpace(stdout), "\n"); code != exitOK || len(lines) != 2 || !strings.HasPrefix(lines[0], "otpauth-migration://") {
+		t.Errorf("Error in export (expected = 2 migration URIs, got = %d %q, stderr = %q)", code, stdout, stderr)
+	}
+
+	for i, args := range [][]string{
+		{"export", "-format", "uri", "-encrypt"},
+		{"export", "-format", "unknown"},
+		{"export", "extra"},
+	} {
+		if code, _, _ := runTest(vars, "", args...); code == exitOK {
+			t.Errorf("Error in export (i = %d, args = %v, expected failure)", i, args)
+		}
+	}
+
+	// the backups are imported into a new vault
+	for i, test := range []struct {
+		args  []string
+		stdin string
+	}{
+		{[]string{"-format", "uri"}, ""},
+		{[]string{"-format", "migration"}, ""},
+		{[]string{"-format", "aegis"}, ""},
+		{[]string{"-format", "aegis", "-encrypt"}, "password\npassword\n"},
+	} {
+		backup := filepath.Join(dir, "backup")
+		if code, _, stderr := runTest(vars, test.stdin, append([]string{"export", "-o", backup}, test.args...)...); code != exitOK {
+			t.Fatalf("Error in export (i = %d, got = %d, stderr = %q)", i, code, stderr)
+		}
+		if info, err := os.Stat(backup); err != nil || info.Mode().Perm() != 0o600 {
+			t.Errorf("Error in export (i = %d, unexpected backup file %v, err = %v)", i, info, err)
+		}
+
+		imported := filepath.Join(dir, "imported.json")
+		os.Remove(imported)
+		vars := map[string]string{"OTP_VAULT": imported, "OTP_PASSPHRASE": "passphrase"}
+		if code, _, stderr := runTest(vars, test.stdin, "import", backup); code != exitOK {
+			t.Fatalf("Error in import (i = %d, got = %d, stderr = %q)", i, code, stderr)
+		}
+		if code, stdout, _ := runTest(vars, "", "list", "-codes"); stdout != "alice        totp  94287082\nExample:bob  hotp  -\n" {
+			t.Errorf("Error in import (i = %d, got = %d %q)", i, code, stdout)
+		}
+	}
+
+	if code, _, stderr := runTest(vars, "wrong\n", "import", filepath.Join(dir, "backup")); code != exitError || !strings.Contains(stderr, vault.ErrDecrypt.Error()) {
+		t.Errorf("Error in import (expected = %v, got = %d, stderr = %q)", vault.ErrDecrypt, code, stderr)
+	}
+}
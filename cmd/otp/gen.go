@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/xrjr/otp"
+)
+
+// runGen prints the codes of a key, one per line, from the previous codes to the next ones.
+func runGen(e *env, args []string) error {
+	fs := newFlagSet(e, "gen", "<otpauth-uri|secret>")
+	var kf keyFlags
+	kf.register(fs)
+	at := fs.String("time", "", "time of the codes of a totp key, in RFC 3339 or Unix seconds, instead of now")
+	prev := fs.Uint("prev", 0, "number of previous codes printed before the current one")
+	next := fs.Uint("next", 0, "number of next codes printed after the current one")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errUsage
+	}
+
+	key, err := kf.key(fs, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	t, err := parseTime(e, *at)
+	if err != nil {
+		return err
+	}
+	codes, err := generate(key, t, int(*prev), int(*next))
+	if err != nil {
+		return err
+	}
+	for _, code := range codes {
+		fmt.Fprintln(e.stdout, code)
+	}
+	return nil
+}
+
+// generate returns the codes of a key from prev codes before the current one to next codes after it, the current
+// code of TOTP keys being the one of t. The codes of HOTP keys are the ones of the counters around the counter of
+// the key, the counters below zero being skipped.
+func generate(key otp.Key, t time.Time, prev, next int) ([]string, error) {
+	var codes []string
+	switch key.Type {
+	case otp.TypeTOTP:
+		opts, err := key.TOTPOptions()
+		if err != nil {
+			return nil, err
+		}
+		for step := -prev; step <= next; step++ {
+			opts.Step = step
+			code, err := otp.TOTPE(key.Secret, t, opts)
+			if err != nil {
+				return nil, err
+			}
+			codes = append(codes, code.String())
+		}
+	case otp.TypeHOTP:
+		opts, err := key.HOTPOptions()
+		if err != nil {
+			return nil, err
+		}
+		for step := -prev; step <= next; step++ {
+			if step < 0 && uint64(-step) > key.Counter {
+				continue
+			}
+			code, err := otp.HOTPE(key.Secret, key.Counter+uint64(step), opts)
+			if err != nil {
+				return nil, err
+			}
+			codes = append(codes, code.String())
+		}
+	default:
+		return nil, fmt.Errorf("%w: %q is neither %s nor %s", otp.ErrInvalidType, key.Type, otp.TypeHOTP, otp.TypeTOTP)
+	}
+	return codes, nil
+}
@@ -0,0 +1,202 @@
+// Command otp computes OTP codes from otpauth URIs or base32 secrets, for operations and scripts.
+//
+// Usage:
+//
+//	otp <command> [flags] [arguments]
+//
+// The commands are:
+//
+//	gen     print the current code of a key, and optionally the previous and next ones
+//	verify  check a code, the exit code reporting whether it is valid
+//	add     add a key to the vault
+//	list    list the keys of the vault, and optionally their current codes
+//	rm      remove a key from the vault
+//	import  import the keys of a backup file into the vault
+//	export  export the keys of the vault to a backup file
+//	qr      print the QR code provisioning a key, or write it to a PNG file
+//
+// The vault is a file encrypted by a passphrase, as implemented by the vault package: $OTP_VAULT, or vault.json in
+// the otp directory of the user configuration directory by default. The passphrase is read from $OTP_PASSPHRASE,
+// or from the first line of the standard input, e.g. piped from a password manager; it is echoed when typed. The
+// password of encrypted backups is read likewise, from $OTP_IMPORT_PASSPHRASE or $OTP_EXPORT_PASSPHRASE, or from
+// a line of the standard input, after its prompt.
+//
+// Run "otp <command> -h" for the flags of a command.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xrjr/otp"
+)
+
+// Exit codes of the commands.
+const (
+	exitOK    = 0
+	exitError = 1
+	exitUsage = 2
+)
+
+// env is the environment of a command, replaced by tests.
+type env struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+	clock  otp.Clock
+	getenv func(key string) string
+}
+
+// command is a command of the tool.
+type command struct {
+	name    string
+	summary string
+	run     func(e *env, args []string) error
+}
+
+// commands returns the commands of the tool, in the order of the usage.
+func commands() []command {
+	return []command{
+		{"gen", "print the current code of a key, and optionally the previous and next ones", runGen},
+		{"verify", "check a code, the exit code reporting whether it is valid", runVerify},
+		{"add", "add a key to the vault", runAdd},
+		{"list", "list the keys of the vault, and optionally their current codes", runList},
+		{"rm", "remove a key from the vault", runRm},
+		{"import", "import the keys of a backup file into the vault", runImport},
+		{"export", "export the keys of the vault to a backup file", runExport},
+		{"qr", "print the QR code provisioning a key, or write it to a PNG file", runQR},
+	}
+}
+
+// errUsage is returned by commands called with invalid arguments, once the usage is printed.
+var errUsage = errors.New("usage")
+
+func main() {
+	os.Exit(run(&env{stdin: os.Stdin, stdout: os.Stdout, stderr: os.Stderr, clock: otp.ClockFunc(time.Now), getenv: os.Getenv}, os.Args[1:]))
+}
+
+// run runs the command of args, and returns the exit code of the tool.
+func run(e *env, args []string) int {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "-help" || args[0] == "help" {
+		usage(e.stderr)
+		return exitUsage
+	}
+
+	for _, c := range commands() {
+		if c.name != args[0] {
+			continue
+		}
+
+		err := c.run(e, args[1:])
+		switch {
+		case err == nil:
+			return exitOK
+		case errors.Is(err, errUsage), errors.Is(err, flag.ErrHelp):
+			return exitUsage
+		default:
+			fmt.Fprintf(e.stderr, "otp %s: %v\n", c.name, err)
+			return exitError
+		}
+	}
+
+	fmt.Fprintf(e.stderr, "otp: unknown command %q\n", args[0])
+	usage(e.stderr)
+	return exitUsage
+}
+
+// usage prints the usage of the tool.
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: otp <command> [flags] [arguments]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, c := range commands() {
+		fmt.Fprintf(w, "  %-8s %s\n", c.name, c.summary)
+	}
+}
+
+// newFlagSet returns the flag set of a command, printing its usage to the stderr of the environment.
+func newFlagSet(e *env, name string, arguments string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(e.stderr, "Usage: otp %s [flags] %s\n\nFlags:\n", name, arguments)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// keyFlags are the flags describing the key of a command, given by an otpauth URI or a base32 secret.
+type keyFlags struct {
+	typ       string
+	algorithm string
+	digits    uint
+	period    int
+	counter   uint64
+}
+
+// register registers the flags on a flag set.
+func (f *keyFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.typ, "type", otp.TypeTOTP, "type of a key given by its secret, totp or hotp")
+	fs.StringVar(&f.algorithm, "algorithm", "", "hash algorithm, e.g. SHA256, overriding the one of the key")
+	fs.UintVar(&f.digits, "digits", 0, "number of digits, overriding the one of the key")
+	fs.IntVar(&f.period, "period", 0, "time period in seconds of a totp key, overriding the one of the key")
+	fs.Uint64Var(&f.counter, "counter", 0, "counter of a hotp key, overriding the one of the key")
+}
+
+// key returns the key of an otpauth URI or a base32 secret, with the fields set by the flags of fs.
+func (f *keyFlags) key(fs *flag.FlagSet, arg string) (otp.Key, error) {
+	var key otp.Key
+	if isURI(arg) {
+		var err error
+		if key, err = otp.ParseURI(arg); err != nil {
+			return otp.Key{}, err
+		}
+	} else {
+		secret, err := otp.ParseSecret(arg)
+		if err != nil {
+			return otp.Key{}, err
+		}
+		key = otp.Key{Type: f.typ, Secret: secret}
+	}
+
+	fs.Visit(func(fl *flag.Flag) {
+		switch fl.Name {
+		case "algorithm":
+			key.Algorithm = f.algorithm
+		case "digits":
+			key.Digits = f.digits
+		case "period":
+			key.Period = f.period
+		case "counter":
+			key.Counter = f.counter
+		}
+	})
+	return key, nil
+}
+
+// parseTime returns the time of the time flag of a command, in RFC 3339 or Unix seconds, or the time of the clock
+// when the flag isn't set.
+func parseTime(e *env, s string) (time.Time, error) {
+	if s == "" {
+		return e.clock.Now(), nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	seconds, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q", s)
+	}
+	return time.Unix(seconds, 0), nil
+}
+
+// isURI reports whether an argument is an otpauth URI rather than a secret.
+func isURI(arg string) bool {
+	return strings.HasPrefix(arg, "otpauth://")
+}
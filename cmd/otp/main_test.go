@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xrjr/otp"
+)
+
+// secret is the secret of the test vectors of rfc 4226 and rfc 6238, in base32.
+const secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// runTest runs the tool with args at the time 59 of the test vectors, with the environment variables vars, and
+// returns its exit code and outputs.
+func runTest(vars map[string]string, stdin string, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	e := &env{
+		stdin:  strings.NewReader(stdin),
+		stdout: &stdout,
+		stderr: &stderr,
+		clock: otp.ClockFunc(func() time.Time {
+			return time.Unix(59, 0)
+		}),
+		getenv: func(key string) string {
+			return vars[key]
+		},
+	}
+	code := run(e, args)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		args []string
+		code int
+	}{
+		{nil, exitUsage},
+		{[]string{"help"}, exitUsage},
+		{[]string{"unknown"}, exitUsage},
+		{[]string{"gen"}, exitUsage},
+		{[]string{"gen", "-h"}, exitUsage},
+		{[]string{"gen", "not base32!"}, exitError},
+	}
+
+	for i, test := range tests {
+		if code, _, stderr := runTest(nil, "", test.args...); code != test.code || stderr == "" {
+			t.Errorf("Error in run (i = %d, expected = %d, got = %d, stderr = %q)", i, test.code, code, stderr)
+		}
+	}
+}
+
+func TestGen(t *testing.T) {
+	tests := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"gen", "-digits", "8", secret}, "94287082\n"},
+		{[]string{"gen", "otpauth://totp/Example:alice?secret=" + secret + "&digits=8"}, "94287082\n"},
+		{[]string{"gen", "-digits", "8", "-time", "1111111109", secret}, "07081804\n"},
+		{[]string{"gen", "-digits", "8", "-time", "2005-03-18T01:58:29Z", secret}, "07081804\n"},
+		{[]string{"gen", "-prev", "1", "-next", "1", "-type", "hotp", "-counter", "1", secret}, "755224\n287082\n359152\n"},
+		{[]string{"gen", "-prev", "1", "otpauth://hotp/alice?secret=" + secret}, "755224\n"},
+		{[]string{"gen", "-digits", "8", "-algorithm", "SHA256", "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQGEZA"}, "46119246\n"},
+	}
+
+	for i, test := range tests {
+		code, stdout, stderr := runTest(nil, "", test.args...)
+		if code != exitOK || stdout != test.expected {
+			t.Errorf("Error in gen (i = %d, expected = %q, got = %q, code = %d, stderr = %q)", i, test.expected, stdout, code, stderr)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"image/png"
+	"os"
+	"strings"
+
+	"github.com/xrjr/otp"
+	"github.com/xrjr/otp/qr"
+)
+
+// levels are the error correction levels of the level flag.
+var levels = map[string]qr.Level{"L": qr.LevelL, "M": qr.LevelM, "Q": qr.LevelQ, "H": qr.LevelH}
+
+// runQR prints the QR code provisioning a key of the vault, or given by its URI, or writes it to a PNG file.
+// otpauth-migration URIs are printed as well, to transfer keys to Google Authenticator.
+func runQR(e *env, args []string) error {
+	fs := newFlagSet(e, "qr", "<name|otpauth-uri|otpauth-migration-uri>")
+	var vf vaultFlags
+	vf.register(e, fs)
+	pngPath := fs.String("png", "", "write the QR code to a PNG file instead of the terminal")
+	scale := fs.Int("scale", 8, "pixels per module of the PNG file")
+	level := fs.String("level", "M", "error correction level, L, M, Q or H")
+	inverse := fs.Bool("inverse", true, "draw the light modules, for terminals writing light text on a dark background")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errUsage
+	}
+	l, ok := levels[*level]
+	if !ok {
+		return fmt.Errorf("invalid level %q", *level)
+	}
+
+	var text string
+	switch {
+	case strings.HasPrefix(fs.Arg(0), "otpauth-migration://"):
+		// migration URIs are provisioned as they are, once checked
+		if _, err := otp.ParseMigrationURI(fs.Arg(0)); err != nil {
+			return err
+		}
+		text = fs.Arg(0)
+	case isURI(fs.Arg(0)):
+		key, err := otp.ParseURI(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		text = key.URI()
+	default:
+		v, _, err := vf.open(e, false)
+		if err != nil {
+			return err
+		}
+		entry, err := v.Get(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		text = entry.Key.URI()
+	}
+
+	code, err := qr.New(text, l)
+	if err != nil {
+		return err
+	}
+	if *pngPath == "" {
+		fmt.Fprint(e.stdout, code.Text(*inverse))
+		return nil
+	}
+
+	f, err := os.OpenFile(*pngPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	return errors.Join(png.Encode(f, code.Image(*scale)), f.Close())
+}
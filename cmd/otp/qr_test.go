@@ -0,0 +1,52 @@
+package main
+
+import (
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/xrjr/otp"
+	"github.com/xrjr/otp/qr"
+	"github.com/xrjr/otp/vault"
+)
+
+func TestQR(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "vault.json")
+	key := otp.Key{Type: otp.TypeTOTP, Issuer: "Example", AccountName: "alice", Secret: []byte("12345678901234567890")}
+	if err := (&vault.Vault{Entries: []vault.Entry{{Name: "alice", Key: key}}, Iterations: 10}).Save(path, []byte("passphrase")); err != nil {
+		t.Fatalf("Error in Save (err = %v)", err)
+	}
+	vars := map[string]string{"OTP_VAULT": path, "OTP_PASSPHRASE": "passphrase"}
+
+	expected, _ := qr.New(key.URI(), qr.LevelM)
+	if code, stdout, stderr := runTest(vars, "", "qr", "alice"); code != exitOK || stdout != expected.Text(true) {
+		t.Errorf("Error in qr (expected = %q, got = %d %q, stderr = %q)", expected.Text(true), code, stdout, stderr)
+	}
+	if code, stdout, _ := runTest(nil, "", "qr", "-inverse=false", key.URI()); code != exitOK || stdout != expected.Text(false) {
+		t.Errorf("Error in qr (expected = %q, got = %d %q)", expected.Text(false), code, stdout)
+	}
+	uris, _ := otp.MigrationURIs([]otp.Key{key}, 0)
+	migration, _ := qr.New(uris[0], qr.LevelM)
+	if code, stdout, _ := runTest(nil, "", "qr", uris[0]); code != exitOK || stdout != migration.Text(true) {
+		t.Errorf("Error in qr (expected = %q, got = %d %q)", migration.Text(true), code, stdout)
+	}
+	if code, _, _ := runTest(vars, "", "qr", "bob"); code != exitError {
+		t.Errorf("Error in qr (expected = %d, got = %d)", exitError, code)
+	}
+
+	out := filepath.Join(dir, "qr.png")
+	if code, _, stderr := runTest(vars, "", "qr", "-png", out, "-scale", "2", "alice"); code != exitOK {
+		t.Fatalf("Error in qr (expected = %d, got = %d, stderr = %q)", exitOK, code, stderr)
+	}
+	f, err := os.Open(out)
+	if err != nil {
+		t.Fatalf("Error in qr (err = %v)", err)
+	}
+	defer f.Close()
+	img, err := png.Decode(f)
+	if err != nil || img.Bounds().Dx() != (expected.Size+8)*2 {
+		t.Errorf("Error in qr (unexpected image %v, err = %v)", img, err)
+	}
+}
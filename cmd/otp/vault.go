@@ -0,0 +1,299 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/xrjr/otp"
+	"github.com/xrjr/otp/vault"
+)
+
+// vaultFlags are the flags of the commands using the vault.
+type vaultFlags struct {
+	path string
+}
+
+// register registers the flags on a flag set.
+func (f *vaultFlags) register(e *env, fs *flag.FlagSet) {
+	fs.StringVar(&f.path, "vault", e.getenv("OTP_VAULT"), "path of the vault (default $OTP_VAULT, or otp/vault.json in the user configuration directory)")
+}
+
+// open opens the vault, and returns it with its passphrase. A vault which doesn't exist is created empty when
+// create is set, its passphrase being confirmed when typed.
+func (f *vaultFlags) open(e *env, create bool) (*vault.Vault, []byte, error) {
+	if f.path == "" {
+		dir, err := os.UserConfigDir()
+		if err != nil {
+			return nil, nil, err
+		}
+		f.path = filepath.Join(dir, "otp", "vault.json")
+	}
+
+	_, err := os.Stat(f.path)
+	if create && errors.Is(err, os.ErrNotExist) {
+		passphrase, err := readPassphrase(e, true)
+		if err != nil {
+			return nil, nil, err
+		}
+		return &vault.Vault{}, passphrase, os.MkdirAll(filepath.Dir(f.path), 0o700)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	passphrase, err := readPassphrase(e, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	v, err := vault.Load(f.path, passphrase)
+	return v, passphrase, err
+}
+
+// save saves the vault.
+func (f *vaultFlags) save(v *vault.Vault, passphrase []byte) error {
+	return v.Save(f.path, passphrase)
+}
+
+// readPassphrase returns the passphrase of the vault, of $OTP_PASSPHRASE or read from the standard input. The
+// passphrase of a new vault is read twice, to confirm it.
+func readPassphrase(e *env, confirm bool) ([]byte, error) {
+	return readSecret(e, "OTP_PASSPHRASE", "Passphrase", confirm)
+}
+
+// readSecret returns the passphrase of the environment variable, or reads it from the standard input after
+// prompting for it. Passphrases encrypting new files are read twice, to confirm them.
+func readSecret(e *env, variable string, prompt string, confirm bool) ([]byte, error) {
+	if passphrase := e.getenv(variable); passphrase != "" {
+		return []byte(passphrase), nil
+	}
+
+	fmt.Fprintf(e.stderr, "%s: ", prompt)
+	passphrase, err := readLine(e.stdin)
+	if err != nil {
+		return nil, err
+	}
+	if passphrase == "" {
+		return nil, errors.New("empty passphrase")
+	}
+	if confirm {
+		fmt.Fprintf(e.stderr, "Confirm %s: ", strings.ToLower(prompt))
+		confirmed, err := readLine(e.stdin)
+		if err != nil {
+			return nil, err
+		}
+		if confirmed != passphrase {
+			return nil, errors.New("passphrases don't match")
+		}
+	}
+	return []byte(passphrase), nil
+}
+
+// readLine reads a line of r, without its line ending. It reads one byte at a time, so that the next line is left
+// to the next read.
+func readLine(r io.Reader) (string, error) {
+	var line []byte
+	b := make([]byte, 1)
+	for {
+		n, err := r.Read(b)
+		if n == 1 {
+			if b[0] == '\n' {
+				break
+			}
+			line = append(line, b[0])
+		}
+		if errors.Is(err, io.EOF) && len(line) > 0 {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	if len(line) > 0 && line[len(line)-1] == '\r' {
+		line = line[:len(line)-1]
+	}
+	return string(line), nil
+}
+
+// runAdd adds a key to the vault.
+func runAdd(e *env, args []string) error {
+	fs := newFlagSet(e, "add", "<otpauth-uri|secret>")
+	var vf vaultFlags
+	vf.register(e, fs)
+	var kf keyFlags
+	kf.register(fs)
+	name := fs.String("name", "", "name of the key in the vault (default issuer:account)")
+	issuer := fs.String("issuer", "", "issuer of the key, overriding the one of the key")
+	account := fs.String("account", "", "account name of the key, overriding the one of the key")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errUsage
+	}
+
+	key, err := kf.key(fs, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	if *issuer != "" {
+		key.Issuer = *issuer
+	}
+	if *account != "" {
+		key.AccountName = *account
+	}
+	if *name == "" {
+		*name = vault.Name(key)
+	}
+
+	v, passphrase, err := vf.open(e, true)
+	if err != nil {
+		return err
+	}
+	if err := v.Add(vault.Entry{Name: *name, Key: key}); err != nil {
+		return err
+	}
+	return vf.save(v, passphrase)
+}
+
+// runList lists the keys of the vault.
+func runList(e *env, args []string) error {
+	fs := newFlagSet(e, "list", "")
+	var vf vaultFlags
+	vf.register(e, fs)
+	codes := fs.Bool("codes", false, "print the current code of the totp keys")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return errUsage
+	}
+
+	v, _, err := vf.open(e, false)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(e.stdout, 0, 4, 2, ' ', 0)
+	now := e.clock.Now()
+	for _, entry := range v.Entries {
+		if !*codes {
+			fmt.Fprintf(w, "%s\t%s\n", entry.Name, entry.Key.Type)
+			continue
+		}
+
+		// the codes of hotp keys aren't printed, as they would have to advance the counters
+		code := "-"
+		if entry.Key.Type == otp.TypeTOTP {
+			generated, err := generate(entry.Key, now, 0, 0)
+			if err != nil {
+				return fmt.Errorf("%s: %w", entry.Name, err)
+			}
+			code = generated[0]
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", entry.Name, entry.Key.Type, code)
+	}
+	return w.Flush()
+}
+
+// runRm removes keys from the vault.
+func runRm(e *env, args []string) error {
+	fs := newFlagSet(e, "rm", "<name>...")
+	var vf vaultFlags
+	vf.register(e, fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errUsage
+	}
+
+	v, passphrase, err := vf.open(e, false)
+	if err != nil {
+		return err
+	}
+	for _, name := range fs.Args() {
+		if err := v.Remove(name); err != nil {
+			return err
+		}
+	}
+	return vf.save(v, passphrase)
+}
+
+// runImport imports the keys of a backup file into the vault: an export of Aegis Authenticator, whose password is
+// read from $OTP_IMPORT_PASSPHRASE or the standard input when encrypted, or a text holding otpauth and
+// otpauth-migration URIs, such as the export of Google Authenticator decoded from its QR codes. The keys which
+// can't be imported are reported, the other ones being imported anyway.
+func runImport(e *env, args []string) error {
+	fs := newFlagSet(e, "import", "<backup-file>")
+	var vf vaultFlags
+	vf.register(e, fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errUsage
+	}
+
+	data, err := os.ReadFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer otp.WipeBytes(data)
+
+	var entries []vault.Entry
+	var errs []error
+	if vault.IsAegis(data) {
+		entries, err = vault.ParseAegis(data)
+		if errors.Is(err, vault.ErrEncryptedAegis) {
+			// the password of the backup is read before the passphrase of the vault
+			var password []byte
+			if password, err = readSecret(e, "OTP_IMPORT_PASSPHRASE", "Backup password", false); err != nil {
+				return err
+			}
+			entries, err = vault.OpenAegis(data, password)
+			otp.WipeBytes(password)
+			if errors.Is(err, vault.ErrDecrypt) || errors.Is(err, vault.ErrInvalidFormat) {
+				return err
+			}
+		}
+		errs = append(errs, err)
+	} else {
+		keys, scanErrs := otp.ScanURIs(bytes.NewReader(data))
+		for _, key := range keys {
+			entries = append(entries, vault.Entry{Name: vault.Name(key), Key: key})
+		}
+		errs = append(errs, scanErrs...)
+	}
+
+	v, passphrase, err := vf.open(e, true)
+	if err != nil {
+		return err
+	}
+	imported := 0
+	for _, entry := range entries {
+		if err := v.Add(entry); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		imported++
+	}
+	if imported > 0 {
+		if err := vf.save(v, passphrase); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(e.stderr, "%d keys imported\n", imported)
+	return errors.Join(errs...)
+}
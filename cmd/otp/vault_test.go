@@ -0,0 +1,71 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/xrjr/otp"
+	"github.com/xrjr/otp/vault"
+)
+
+func TestVaultCommands(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "vault.json")
+	// few iterations, kept by the commands, so that the test is fast
+	if err := (&vault.Vault{Iterations: 10}).Save(path, []byte("passphrase")); err != nil {
+		t.Fatalf("Error in Save (err = %v)", err)
+	}
+	vars := map[string]string{"OTP_VAULT": path, "OTP_PASSPHRASE": "passphrase"}
+
+	backup := filepath.Join(dir, "backup.txt")
+	os.WriteFile(backup, []byte("otpauth://hotp/Example:bob?secret="+secret+"&issuer=Example\notpauth://totp/invalid\n"), 0o600)
+
+	tests := []struct {
+		args     []string
+		code     int
+		expected string
+	}{
+		{[]string{"add", "-digits", "8", "-account", "alice", secret}, exitOK, ""},
+		{[]string{"add", "-account", "alice", secret}, exitError, ""},
+		{[]string{"add", "-name", "carol", "otpauth://totp/Example:carol?secret=" + secret}, exitOK, ""},
+		{[]string{"import", backup}, exitError, ""},
+		{[]string{"list"}, exitOK, "alice        totp\ncarol        totp\nExample:bob  hotp\n"},
+		{[]string{"list", "-codes"}, exitOK, "alice        totp  94287082\ncarol        totp  287082\nExample:bob  hotp  -\n"},
+		{[]string{"rm", "carol", "Example:bob"}, exitOK, ""},
+		{[]string{"rm", "carol"}, exitError, ""},
+		{[]string{"list"}, exitOK, "alice  totp\n"},
+	}
+
+	for i, test := range tests {
+		code, stdout, stderr := runTest(vars, "", test.args...)
+		if code != test.code || stdout != test.expected {
+			t.Errorf("Error in vault commands (i = %d, expected = %d %q, got = %d %q, stderr = %q)", i, test.code, test.expected, code, stdout, stderr)
+		}
+	}
+
+	if code, _, stderr := runTest(map[string]string{"OTP_VAULT": path, "OTP_PASSPHRASE": "wrong"}, "", "list"); code != exitError || !strings.Contains(stderr, vault.ErrDecrypt.Error()) {
+		t.Errorf("Error in vault commands (expected = %v, got = %d, stderr = %q)", vault.ErrDecrypt, code, stderr)
+	}
+}
+
+func TestVaultCreate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "otp", "vault.json")
+	vars := map[string]string{"OTP_VAULT": path}
+
+	if code, _, _ := runTest(vars, "passphrase\nmistyped\n", "add", secret); code != exitError {
+		t.Errorf("Error in vault creation (expected = %d, got = %d)", exitError, code)
+	}
+	if code, _, stderr := runTest(vars, "passphrase\npassphrase\n", "add", "-account", "alice", secret); code != exitOK {
+		t.Fatalf("Error in vault creation (expected = %d, got = %d, stderr = %q)", exitOK, code, stderr)
+	}
+
+	v, err := vault.Load(path, []byte("passphrase"))
+	if err != nil || len(v.Entries) != 1 || !v.Entries[0].Key.Equal(otp.Key{Type: otp.TypeTOTP, AccountName: "alice", Secret: []byte("12345678901234567890")}) {
+		t.Errorf("Error in vault creation (unexpected vault %+v, err = %v)", v, err)
+	}
+	if code, stdout, _ := runTest(vars, "passphrase\n", "list"); code != exitOK || stdout != "alice  totp\n" {
+		t.Errorf("Error in vault creation (expected = %q, got = %d %q)", "alice  totp\n", code, stdout)
+	}
+}
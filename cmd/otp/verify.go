@@ -0,0 +1,90 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/xrjr/otp"
+)
+
+// errInvalidCode is returned by the verify command when the code is invalid.
+var errInvalidCode = errors.New("invalid code")
+
+// runVerify checks a code of a key, the exit code of the tool reporting whether it is valid. The next counter of
+// HOTP keys is printed, for the scripts keeping it.
+func runVerify(e *env, args []string) error {
+	fs := newFlagSet(e, "verify", "")
+	var kf keyFlags
+	kf.register(fs)
+	uri := fs.String("uri", "", "otpauth URI of the key")
+	secret := fs.String("secret", "", "base32 secret of the key, instead of its URI")
+	code := fs.String("code", "", `code verified, or "-" to read it from the first line of the standard input`)
+	window := fs.Int("window", 1, "time periods accepted before and after the current one, or counters accepted after the one of the key")
+	at := fs.String("time", "", "time of the verification of a totp key, in RFC 3339 or Unix seconds, instead of now")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 || (*uri == "") == (*secret == "") || *code == "" || *window < 0 {
+		fs.Usage()
+		return errUsage
+	}
+
+	key, err := kf.key(fs, *uri+*secret)
+	if err != nil {
+		return err
+	}
+	if *code == "-" {
+		if *code, err = readLine(e.stdin); err != nil {
+			return err
+		}
+	}
+	t, err := parseTime(e, *at)
+	if err != nil {
+		return err
+	}
+
+	next, err := verify(key, otp.NormalizeCode(*code), t, *window)
+	if err != nil {
+		return err
+	}
+	if key.Type == otp.TypeHOTP {
+		fmt.Fprintln(e.stdout, next)
+	}
+	return nil
+}
+
+// verify checks a code of a key against the codes of the window time periods around t, or of the window counters
+// following the counter of the key. It returns the counter following the one of the code of HOTP keys.
+func verify(key otp.Key, code string, t time.Time, window int) (uint64, error) {
+	if len(key.Secret) == 0 {
+		return 0, otp.ErrInvalidSecret
+	}
+
+	switch key.Type {
+	case otp.TypeTOTP:
+		opts, err := key.TOTPOptions()
+		if err != nil {
+			return 0, err
+		}
+		opts.Clock = otp.ClockFunc(func() time.Time {
+			return t
+		})
+		if _, ok := otp.VerifyTOTP(key.Secret, code, window, opts); !ok {
+			return 0, errInvalidCode
+		}
+		return 0, nil
+	case otp.TypeHOTP:
+		opts, err := key.HOTPOptions()
+		if err != nil {
+			return 0, err
+		}
+		match, ok := otp.VerifyHOTP(key.Secret, code, key.Counter, window, opts)
+		if !ok {
+			return 0, errInvalidCode
+		}
+		return match.Counter + 1, nil
+	default:
+		return 0, fmt.Errorf("%w: %q is neither %s nor %s", otp.ErrInvalidType, key.Type, otp.TypeHOTP, otp.TypeTOTP)
+	}
+}
@@ -0,0 +1,33 @@
+package main
+
+import "testing"
+
+func TestVerify(t *testing.T) {
+	uri := "otpauth://totp/alice?secret=" + secret + "&digits=8"
+	tests := []struct {
+		stdin    string
+		args     []string
+		code     int
+		expected string
+	}{
+		{"", []string{"verify", "-uri", uri, "-code", "94287082"}, exitOK, ""},
+		{"", []string{"verify", "-uri", uri, "-code", "9428 7082"}, exitOK, ""},
+		{"", []string{"verify", "-uri", uri, "-code", "94287083"}, exitError, ""},
+		// the code of the time 89, in the next time period
+		{"", []string{"verify", "-uri", uri, "-code", "37359152"}, exitOK, ""},
+		{"", []string{"verify", "-uri", uri, "-code", "37359152", "-window", "0"}, exitError, ""},
+		{"", []string{"verify", "-uri", uri, "-code", "07081804", "-time", "1111111109"}, exitOK, ""},
+		{"94287082\n", []string{"verify", "-digits", "8", "-secret", secret, "-code", "-"}, exitOK, ""},
+		{"", []string{"verify", "-type", "hotp", "-secret", secret, "-counter", "1", "-code", "359152", "-window", "2"}, exitOK, "3\n"},
+		{"", []string{"verify", "-type", "hotp", "-secret", secret, "-counter", "1", "-code", "755224", "-window", "2"}, exitError, ""},
+		{"", []string{"verify", "-uri", uri}, exitUsage, ""},
+		{"", []string{"verify", "-uri", uri, "-secret", secret, "-code", "94287082"}, exitUsage, ""},
+	}
+
+	for i, test := range tests {
+		code, stdout, stderr := runTest(nil, test.stdin, test.args...)
+		if code != test.code || stdout != test.expected {
+			t.Errorf("Error in verify (i = %d, expected = %d %q, got = %d %q, stderr = %q)", i, test.code, test.expected, code, stdout, stderr)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package otp
+
+import (
+	"time"
+)
+
+// Code is an OTP code along with the values it was generated for.
+type Code struct {
+	Value      string    // code, encoded with the Encoder of the options
+	Digits     uint      // number of characters of the code
+	Counter    uint64    // counter of HOTP codes, or time step (called T in rfc) of TOTP codes
+	ValidFrom  time.Time // start of the time period of TOTP codes, zero for HOTP codes
+	ValidUntil time.Time // end (excluded) of the time period of TOTP codes, zero for HOTP codes
+}
+
+// String returns the value of the code.
+func (c Code) String() string {
+	return c.Value
+}
+
+// HOTPCode computes the OTP code of a given counter.
+func HOTPCode(key []byte, counter uint64, opts HOTPOptions) Code {
+	opts = opts.withDefaults()
+
+	return Code{
+		Value:   HOTPString(key, counter, opts),
+		Digits:  opts.Digits,
+		Counter: counter,
+	}
+}
+
+// HOTPCodeInt computes the OTP code of a given counter, as HOTPCode did before its counter became a uint64.
+//
+// Deprecated: use HOTPCode, converting the counter to a uint64.
+func HOTPCodeInt(key []byte, counter int, opts HOTPOptions) Code {
+	return HOTPCode(key, uint64(counter), opts)
+}
+
+// TOTPCode computes the OTP code of a given time.
+func TOTPCode(key []byte, t time.Time, opts TOTPOptions) Code {
+	opts = opts.withDefaults()
+
+	counter := timePeriodCounter(t.Unix(), opts.TimeReference, opts.Period) + int64(opts.Step)
+	code := HOTPCode(key, uint64(counter), opts.HOTPOptions)
+	code.ValidFrom = periodStart(counter, opts)
+	code.ValidUntil = periodStart(counter+1, opts)
+	return code
+}
@@ -0,0 +1,60 @@
+package otp
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestHOTPCode(t *testing.T) {
+	for _, testValue := range hotpTestValues {
+		res := HOTPCode(testValue.Secret, testValue.Counter, HOTPOptions{})
+		expected := Code{
+			Value:   fmt.Sprintf("%06d", testValue.OTP),
+			Digits:  6,
+			Counter: testValue.Counter,
+		}
+		if res != expected {
+			t.Errorf("Error in HOTPCode for Counter = %d (expected %+v, got %+v)", testValue.Counter, expected, res)
+		}
+	}
+}
+
+func TestTOTPCode(t *testing.T) {
+	for i, testValue := range totpTestValues {
+		res := TOTPCode(testValue.Secret, testValue.Time, TOTPOptions{
+			HOTPOptions: HOTPOptions{
+				Digits:    testValue.Digits,
+				Algorithm: testValue.Mode,
+			},
+			TimeReference: testValue.TimeReference,
+			Period:        testValue.Period,
+		})
+		if res.Value != fmt.Sprintf("%08d", testValue.OTP) || res.Digits != testValue.Digits || res.Counter != uint64(testValue.T) {
+			t.Errorf("Error in TOTPCode (i = %d, got = %+v)", i, res)
+		}
+		if res.ValidFrom.After(testValue.Time) || !res.ValidUntil.After(testValue.Time) || res.ValidUntil.Sub(res.ValidFrom) != time.Duration(testValue.Period)*time.Second {
+			t.Errorf("Error in TOTPCode (i = %d, time = %d, valid from = %d, valid until = %d)", i, testValue.Time.Unix(), res.ValidFrom.Unix(), res.ValidUntil.Unix())
+		}
+	}
+}
+
+func TestTOTPCodeValidity(t *testing.T) {
+	opts := TOTPOptions{
+		TimeReference: 10,
+		Period:        60,
+		Step:          1,
+	}
+
+	// time steps before the time reference are counted backwards
+	for _, now := range []int64{-50, 5, 69, 70, 129} {
+		res := TOTPCode(totpSecretSha1, time.Unix(now, 0), opts)
+		current := TOTPCode(totpSecretSha1, time.Unix(now, 0), TOTPOptions{TimeReference: 10, Period: 60})
+		if res.ValidFrom != current.ValidUntil || res.Counter != current.Counter+1 {
+			t.Errorf("Error in TOTPCodeValidity (now = %d, current = %+v, next = %+v)", now, current, res)
+		}
+		if current.ValidFrom.Unix() > now || current.ValidUntil.Unix() <= now {
+			t.Errorf("Error in TOTPCodeValidity (now = %d, valid from = %d, valid until = %d)", now, current.ValidFrom.Unix(), current.ValidUntil.Unix())
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package otp
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// CompatibilityWarning is a parameter of a key which Google Authenticator silently ignores, generating codes with
+// its default instead, so that users provisioning the key would be rejected.
+type CompatibilityWarning struct {
+	Field   string // name of the field of Key, e.g. "Digits"
+	Value   string // value of the field
+	Default string // value used by Google Authenticator instead
+}
+
+// String returns a description of the warning, e.g. for provisioning UIs.
+func (w CompatibilityWarning) String() string {
+	return fmt.Sprintf("%s %s is ignored by Google Authenticator, which uses %s", w.Field, w.Value, w.Default)
+}
+
+// CompatibilityWarnings returns the parameters of the key which Google Authenticator ignores: algorithms other than
+// SHA1, digits other than 6, periods other than 30 seconds, and secrets not encoded in base32. Other authenticator
+// applications support them, but admins provisioning the key should be warned before its users are locked out.
+// Unset parameters are their defaults, and don't warn.
+func (k Key) CompatibilityWarnings() []CompatibilityWarning {
+	var warnings []CompatibilityWarning
+
+	if k.Algorithm != "" && !strings.EqualFold(k.Algorithm, "SHA1") {
+		warnings = append(warnings, CompatibilityWarning{Field: "Algorithm", Value: k.Algorithm, Default: "SHA1"})
+	}
+
+	if k.Digits != 0 && k.Digits != 6 {
+		warnings = append(warnings, CompatibilityWarning{Field: "Digits", Value: strconv.FormatUint(uint64(k.Digits), 10), Default: "6"})
+	}
+
+	if k.Type == TypeTOTP && k.Period != 0 && k.Period != 30 {
+		warnings = append(warnings, CompatibilityWarning{Field: "Period", Value: strconv.Itoa(k.Period), Default: "30"})
+	}
+
+	if k.Encoding != "" && k.Encoding != EncodingBase32 {
+		warnings = append(warnings, CompatibilityWarning{Field: "Encoding", Value: k.Encoding, Default: EncodingBase32})
+	}
+
+	return warnings
+}
@@ -0,0 +1,36 @@
+package otp
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCompatibilityWarnings(t *testing.T) {
+	if warnings := testKey.CompatibilityWarnings(); len(warnings) != 0 {
+		t.Errorf("Error in CompatibilityWarnings (expected = [], got = %v)", warnings)
+	}
+	if warnings := (Key{Type: TypeHOTP, Secret: hotpSecret}).CompatibilityWarnings(); len(warnings) != 0 {
+		t.Errorf("Error in CompatibilityWarnings (expected = [], got = %v)", warnings)
+	}
+
+	key := testKey
+	key.Algorithm = "SHA512"
+	key.Digits = 8
+	key.Period = 60
+	key.Encoding = EncodingHex
+
+	expected := []CompatibilityWarning{
+		{Field: "Algorithm", Value: "SHA512", Default: "SHA1"},
+		{Field: "Digits", Value: "8", Default: "6"},
+		{Field: "Period", Value: "60", Default: "30"},
+		{Field: "Encoding", Value: EncodingHex, Default: EncodingBase32},
+	}
+	if warnings := key.CompatibilityWarnings(); !slices.Equal(warnings, expected) {
+		t.Errorf("Error in CompatibilityWarnings (expected = %v, got = %v)", expected, warnings)
+	}
+
+	expectedString := "Digits 8 is ignored by Google Authenticator, which uses 6"
+	if s := expected[1].String(); s != expectedString {
+		t.Errorf("Error in CompatibilityWarning.String (expected = %s, got = %s)", expectedString, s)
+	}
+}
@@ -0,0 +1,72 @@
+package otp
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Encoder converts the truncated value of the hmac (a 31 bits integer) into a code of the given length.
+type Encoder interface {
+	Encode(value uint, length uint) string
+}
+
+// AlphabetEncoder encodes codes with the characters of the alphabet it contains, which are ASCII characters.
+// As for Steam Guard codes, the first character of the code is the least significant one. Alphabets which aren't
+// constant should be checked by NewAlphabetEncoder, as Encode panics if the alphabet is empty.
+type AlphabetEncoder string
+
+// NewAlphabetEncoder returns the AlphabetEncoder of an alphabet, or ErrInvalidAlphabet if it has less than 2
+// characters, or characters which are repeated or not ASCII.
+func NewAlphabetEncoder(alphabet string) (AlphabetEncoder, error) {
+	if len(alphabet) < 2 {
+		return "", fmt.Errorf("%w: %d characters", ErrInvalidAlphabet, len(alphabet))
+	}
+	var seen [128]bool
+	for i := range len(alphabet) {
+		c := alphabet[i]
+		if c >= 128 || seen[c] {
+			return "", fmt.Errorf("%w: invalid or repeated character %q", ErrInvalidAlphabet, alphabet[i:i+1])
+		}
+		seen[c] = true
+	}
+	return AlphabetEncoder(alphabet), nil
+}
+
+var (
+	// DecimalEncoder encodes codes as zero padded decimal numbers, as described in rfc 4226.
+	DecimalEncoder Encoder = decimalEncoder{}
+	// HexEncoder encodes codes with uppercase hexadecimal digits.
+	HexEncoder Encoder = AlphabetEncoder("0123456789ABCDEF")
+	// Base32Encoder encodes codes with the base32 alphabet of rfc 4648.
+	Base32Encoder Encoder = AlphabetEncoder("ABCDEFGHIJKLMNOPQRSTUVWXYZ234567")
+	// AlphanumericEncoder encodes codes with digits and uppercase letters, without the ambiguous 0, 1, I and O.
+	AlphanumericEncoder Encoder = AlphabetEncoder("23456789ABCDEFGHJKLMNPQRSTUVWXYZ")
+	// SteamEncoder encodes codes with the alphabet of Steam Guard codes.
+	SteamEncoder Encoder = AlphabetEncoder("23456789BCDFGHJKMNPQRTVWXY")
+)
+
+// Encode implements Encoder.
+func (a AlphabetEncoder) Encode(value uint, length uint) string {
+	if len(a) == 0 {
+		panic("otp: AlphabetEncoder with an empty alphabet")
+	}
+
+	code := make([]byte, length)
+	for i := range code {
+		code[i] = a[value%uint(len(a))]
+		value /= uint(len(a))
+	}
+	return string(code)
+}
+
+type decimalEncoder struct{}
+
+// Encode implements Encoder.
+func (decimalEncoder) Encode(value uint, length uint) string {
+	code := strconv.FormatUint(truncate(uint64(value), length), 10)
+	if uint(len(code)) < length {
+		code = strings.Repeat("0", int(length)-len(code)) + code
+	}
+	return code
+}
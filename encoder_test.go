@@ -0,0 +1,57 @@
+package otp
+
+import (
+	"errors"
+	"testing"
+)
+
+type EncoderTestValue struct {
+	Encoder Encoder
+	Value   uint
+	Length  uint
+	Code    string
+}
+
+var encoderTestValues = []EncoderTestValue{
+	{Encoder: DecimalEncoder, Value: 0x4c93cf18, Length: 6, Code: "755224"},
+	{Encoder: DecimalEncoder, Value: 7081804, Length: 8, Code: "07081804"},
+	{Encoder: DecimalEncoder, Value: 42, Length: 6, Code: "000042"},
+	{Encoder: HexEncoder, Value: 0x4c93cf18, Length: 6, Code: "81FC39"},
+	{Encoder: Base32Encoder, Value: 0, Length: 4, Code: "AAAA"},
+	{Encoder: AlphabetEncoder("01"), Value: 6, Length: 4, Code: "0110"},
+}
+
+func TestEncoder(t *testing.T) {
+	for i, testValue := range encoderTestValues {
+		res := testValue.Encoder.Encode(testValue.Value, testValue.Length)
+		if res != testValue.Code {
+			t.Errorf("Error in Encoder (i = %d, expected = %s, got = %s)", i, testValue.Code, res)
+		}
+	}
+}
+
+func TestDecimalEncoderLength(t *testing.T) {
+	res := DecimalEncoder.Encode(0x7fffffff, 12)
+	if res != "002147483647" {
+		t.Errorf("Error in DecimalEncoderLength (expected = 002147483647, got = %s)", res)
+	}
+}
+
+func TestNewAlphabetEncoder(t *testing.T) {
+	if e, err := NewAlphabetEncoder("23456789BCDFGHJKMNPQRTVWXY"); err != nil || e != SteamEncoder {
+		t.Errorf("Error in NewAlphabetEncoder (expected = %v, got = %v, err = %v)", SteamEncoder, e, err)
+	}
+
+	for i, alphabet := range []string{"", "0", "0120", "01é"} {
+		if _, err := NewAlphabetEncoder(alphabet); !errors.Is(err, ErrInvalidAlphabet) {
+			t.Errorf("Error in NewAlphabetEncoder (i = %d, expected = %v, got = %v)", i, ErrInvalidAlphabet, err)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Error in NewAlphabetEncoder (expected panic with an empty alphabet)")
+		}
+	}()
+	AlphabetEncoder("").Encode(42, 6)
+}
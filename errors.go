@@ -0,0 +1,29 @@
+package otp
+
+import (
+	"errors"
+)
+
+var (
+	// ErrInvalidDigits is returned when the number of digits of a code isn't between 1 and 10.
+	ErrInvalidDigits = errors.New("otp: invalid digits")
+	// ErrInvalidPeriod is returned when the time period of TOTP isn't a positive number of seconds.
+	ErrInvalidPeriod = errors.New("otp: invalid period")
+	// ErrInvalidAlgorithm is returned when the hash function can't be used to compute codes.
+	ErrInvalidAlgorithm = errors.New("otp: invalid algorithm")
+	// ErrInvalidAlphabet is returned by NewAlphabetEncoder when an alphabet can't encode codes.
+	ErrInvalidAlphabet = errors.New("otp: invalid alphabet")
+	// ErrInvalidSecret is returned when the secret is empty.
+	ErrInvalidSecret = errors.New("otp: invalid secret")
+	// ErrInvalidSecretEncoding is returned when a secret can't be decoded.
+	ErrInvalidSecretEncoding = errors.New("otp: invalid secret encoding")
+	// ErrInvalidType is returned when the type of a Key is neither TypeHOTP nor TypeTOTP.
+	ErrInvalidType = errors.New("otp: invalid type")
+	// ErrInvalidCounter is returned when the counter of a Key is invalid.
+	ErrInvalidCounter = errors.New("otp: invalid counter")
+	// ErrInvalidURI is returned when an otpauth URI can't be parsed.
+	ErrInvalidURI = errors.New("otp: invalid uri")
+	// ErrMissingLabel is returned by strict parsing when an otpauth URI has no account name, e.g.
+	// otpauth://totp?secret=... It is wrapped along with ErrInvalidURI.
+	ErrMissingLabel = errors.New("otp: missing label")
+)
@@ -0,0 +1,39 @@
+package otp
+
+import (
+	"strings"
+	"unicode"
+)
+
+// FormatCode formats a code for display. Each '#' of the pattern is replaced by the next character of the code,
+// other characters of the pattern are kept as is (e.g. "### ###" formats "123456" as "123 456").
+// The pattern is cut after the last character of the code, and characters of the code left once the pattern
+// is exhausted are appended.
+func FormatCode(code string, pattern string) string {
+	var b strings.Builder
+	i := 0
+	for _, c := range pattern {
+		if i == len(code) {
+			break
+		}
+		if c == '#' {
+			b.WriteByte(code[i])
+			i++
+		} else {
+			b.WriteRune(c)
+		}
+	}
+	b.WriteString(code[i:])
+	return b.String()
+}
+
+// NormalizeCode removes the separators (whitespaces, dashes and dots) of a code typed by a user,
+// so that it can be compared to a generated code.
+func NormalizeCode(input string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) || r == '-' || r == '.' {
+			return -1
+		}
+		return r
+	}, input)
+}
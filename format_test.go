@@ -0,0 +1,39 @@
+package otp
+
+import (
+	"testing"
+)
+
+type FormatCodeTestValue struct {
+	Code      string
+	Pattern   string
+	Formatted string
+}
+
+var formatCodeTestValues = []FormatCodeTestValue{
+	{Code: "123456", Pattern: "### ###", Formatted: "123 456"},
+	{Code: "12345678", Pattern: "####-####", Formatted: "1234-5678"},
+	{Code: "123456", Pattern: "", Formatted: "123456"},
+	{Code: "123456", Pattern: "## ##", Formatted: "12 3456"},
+	{Code: "1234", Pattern: "### ###", Formatted: "123 4"},
+	{Code: "123", Pattern: "### ###", Formatted: "123"},
+}
+
+func TestFormatCode(t *testing.T) {
+	for i, testValue := range formatCodeTestValues {
+		res := FormatCode(testValue.Code, testValue.Pattern)
+		if res != testValue.Formatted {
+			t.Errorf("Error in FormatCode (i = %d, expected = %q, got = %q)", i, testValue.Formatted, res)
+		}
+	}
+}
+
+func TestNormalizeCode(t *testing.T) {
+	inputs := []string{"123456", "123 456", " 123-456\n", "12.34.56", "1 2 3 4 5 6"}
+	for _, input := range inputs {
+		res := NormalizeCode(input)
+		if res != "123456" {
+			t.Errorf("Error in NormalizeCode (input = %q, got = %q)", input, res)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package otp
+
+import (
+	"crypto/hmac"
+	"encoding/binary"
+	"hash"
+	"sync"
+)
+
+// Generator computes the codes of a fixed key. Its hmac is keyed once and reset between codes, so that validators
+// computing many codes of the same key, e.g. to resynchronize a counter or to check a wide window, don't pay the
+// key schedule of the hmac for each of them.
+// TOTP codes are computed by TOTPMAC, a Generator being a MACer.
+// A Generator is not safe for concurrent use.
+type Generator struct {
+	opts HOTPOptions
+	mac  hash.Hash
+	buf  [8]byte
+	sum  []byte
+}
+
+// NewGenerator returns the Generator of a key, or an error if the key is empty or the options are invalid.
+func NewGenerator(key []byte, opts HOTPOptions) (*Generator, error) {
+	if len(key) == 0 {
+		return nil, ErrInvalidSecret
+	}
+
+	opts, err := opts.checkedDefaults()
+	if err != nil {
+		return nil, err
+	}
+
+	return newGenerator(key, opts), nil
+}
+
+// newGenerator returns the Generator of a key, with options whose defaults are already applied.
+func newGenerator(key []byte, opts HOTPOptions) *Generator {
+	mac := hmac.New(opts.hashFunc(), key)
+	return &Generator{
+		opts: opts,
+		mac:  mac,
+		sum:  make([]byte, 0, mac.Size()),
+	}
+}
+
+// MAC implements MACer. The returned hmac is overwritten by the next call.
+func (g *Generator) MAC(counter uint64) ([]byte, error) {
+	return g.hmac(counter), nil
+}
+
+// HOTP computes the OTP code of a given counter, as HOTP does.
+func (g *Generator) HOTP(counter uint64) uint {
+	return uint(truncate(uint64(g.opts.truncation(g.hmac(counter))), g.opts.Digits))
+}
+
+// HOTPString computes the OTP code of a given counter, as HOTPString does.
+func (g *Generator) HOTPString(counter uint64) string {
+	return g.opts.Encoder.Encode(g.opts.truncation(g.hmac(counter)), g.opts.Digits)
+}
+
+// HOTPCode computes the OTP code of a given counter, as HOTPCode does.
+func (g *Generator) HOTPCode(counter uint64) Code {
+	return Code{
+		Value:   g.HOTPString(counter),
+		Digits:  g.opts.Digits,
+		Counter: counter,
+	}
+}
+
+// hmac computes the hmac of a counter, restoring the keyed state of the hmac first.
+func (g *Generator) hmac(counter uint64) []byte {
+	binary.BigEndian.PutUint64(g.buf[:], counter)
+	g.mac.Reset()
+	g.mac.Write(g.buf[:])
+	g.sum = g.mac.Sum(g.sum[:0])
+	return g.sum
+}
+
+// Token generates the successive HOTP codes of a key, as a hardware token or a software token generator does: each
+// code advances its counter.
+// A Token is safe for concurrent use, each code being returned once.
+type Token struct {
+	mu      sync.Mutex
+	g       *Generator
+	counter uint64
+}
+
+// NewToken returns the Token of a key, whose next code is the one of counter, or an error if the key is empty or the
+// options are invalid.
+func NewToken(key []byte, counter uint64, opts HOTPOptions) (*Token, error) {
+	g, err := NewGenerator(key, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &Token{g: g, counter: counter}, nil
+}
+
+// Next returns the code of the current counter and advances it, along with the counter of the next code, which
+// should be persisted so that codes aren't generated twice.
+func (t *Token) Next() (Code, uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	code := t.g.HOTPCode(t.counter)
+	t.counter++
+	return code, t.counter
+}
+
+// Counter returns the counter of the next code.
+func (t *Token) Counter() uint64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.counter
+}
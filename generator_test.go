@@ -0,0 +1,96 @@
+package otp
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestGenerator(t *testing.T) {
+	g, err := NewGenerator(hotpSecret, HOTPOptions{})
+	if err != nil {
+		t.Fatalf("Error in NewGenerator (err = %v)", err)
+	}
+
+	for _, testValue := range hotpTestValues {
+		if res := g.HOTP(testValue.Counter); res != testValue.OTP {
+			t.Errorf("Error in Generator.HOTP for Counter = %d (expected %d, got %d)", testValue.Counter, testValue.OTP, res)
+		}
+		if res, _ := g.MAC(testValue.Counter); !bytes.Equal(res, testValue.IntermediateHmacSha1) {
+			t.Errorf("Error in Generator.MAC for Counter = %d", testValue.Counter)
+		}
+		if res := g.HOTPCode(testValue.Counter); res != HOTPCode(hotpSecret, testValue.Counter, HOTPOptions{}) {
+			t.Errorf("Error in Generator.HOTPCode for Counter = %d (got %+v)", testValue.Counter, res)
+		}
+	}
+
+	// TOTP codes, with the generator as MACer
+	g, _ = NewGenerator(totpSecretSha256, HOTPOptions{Algorithm: sha256.New})
+	opts := TOTPOptions{HOTPOptions: HOTPOptions{Digits: 8}}
+	expected := TOTPCode(totpSecretSha256, time.Unix(59, 0), TOTPOptions{HOTPOptions: HOTPOptions{Digits: 8, Algorithm: sha256.New}})
+	if res, err := TOTPMAC(g, time.Unix(59, 0), opts); err != nil || res != expected {
+		t.Errorf("Error in TOTPMAC with Generator (expected %+v, got %+v, err = %v)", expected, res, err)
+	}
+
+	if allocs := testing.AllocsPerRun(100, func() { g.HOTP(1) }); allocs != 0 {
+		t.Errorf("Error in Generator.HOTP (expected = 0 allocations, got = %v)", allocs)
+	}
+
+	if _, err := NewGenerator(nil, HOTPOptions{}); !errors.Is(err, ErrInvalidSecret) {
+		t.Errorf("Error in NewGenerator (expected = %v, got = %v)", ErrInvalidSecret, err)
+	}
+	if _, err := NewGenerator(hotpSecret, HOTPOptions{Digits: 11}); !errors.Is(err, ErrInvalidDigits) {
+		t.Errorf("Error in NewGenerator (expected = %v, got = %v)", ErrInvalidDigits, err)
+	}
+}
+
+func TestToken(t *testing.T) {
+	token, err := NewToken(hotpSecret, 0, HOTPOptions{})
+	if err != nil {
+		t.Fatalf("Error in NewToken (err = %v)", err)
+	}
+
+	for _, testValue := range hotpTestValues {
+		code, next := token.Next()
+		if code.Counter != testValue.Counter || code.Value != fmt.Sprintf("%06d", testValue.OTP) || next != testValue.Counter+1 {
+			t.Errorf("Error in Token.Next for Counter = %d (expected %06d, got %+v, next = %d)", testValue.Counter, testValue.OTP, code, next)
+		}
+	}
+
+	// concurrent calls return distinct counters
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	seen := map[uint64]bool{}
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 10 {
+				code, _ := token.Next()
+				mu.Lock()
+				seen[code.Counter] = true
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if len(seen) != 80 || token.Counter() != uint64(len(hotpTestValues))+80 {
+		t.Errorf("Error in Token.Next (expected 80 distinct counters, got = %d, counter = %d)", len(seen), token.Counter())
+	}
+
+	if _, err := NewToken(nil, 0, HOTPOptions{}); !errors.Is(err, ErrInvalidSecret) {
+		t.Errorf("Error in NewToken (expected = %v, got = %v)", ErrInvalidSecret, err)
+	}
+}
+
+func BenchmarkGenerator(b *testing.B) {
+	b.ReportAllocs()
+	g, _ := NewGenerator(hotpSecret, HOTPOptions{})
+	for i := range b.N {
+		g.HOTP(uint64(i))
+	}
+}
@@ -0,0 +1,213 @@
+// Package grpcotp serves the enrollment and verification of keys over gRPC, as described by otp.proto, and
+// requires OTP codes on selected methods with an Interceptor.
+//
+// The package doesn't depend on gRPC, and ships no generated code: the Service and the Interceptor are adapted to
+// the code generated from otp.proto in a package of the application, e.g. otppb with protoc's
+// --go_opt=Motp.proto=example.com/app/otppb, and to google.golang.org/grpc in a few lines:
+//
+//	type service struct {
+//		otppb.UnimplementedOTPServiceServer
+//		service *grpcotp.Service
+//	}
+//
+//	func (s service) Verify(ctx context.Context, req *otppb.VerifyRequest) (*otppb.VerifyResponse, error) {
+//		res, err := s.service.Verify(ctx, &grpcotp.VerifyRequest{Code: req.Code})
+//		if err != nil {
+//			return nil, status.Error(codes.Code(grpcotp.Code(err)), err.Error())
+//		}
+//		return &otppb.VerifyResponse{Enrolled: res.Enrolled}, nil
+//	}
+//
+//	interceptor := &grpcotp.Interceptor{
+//		Accounts: accounts,
+//		Methods:  map[string]bool{"/bank.v1.Transfers/Create": true},
+//		User:     userOf,
+//		Code: func(ctx context.Context) string {
+//			md, _ := metadata.FromIncomingContext(ctx)
+//			if codes := md.Get("x-otp"); len(codes) > 0 {
+//				return codes[0]
+//			}
+//			return ""
+//		},
+//		Error: func(err error) error {
+//			return status.Error(codes.Code(grpcotp.Code(err)), err.Error())
+//		},
+//	}
+//	srv := grpc.NewServer(grpc.UnaryInterceptor(func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+//		return interceptor.Intercept(ctx, info.FullMethod, req, handler)
+//	}))
+package grpcotp
+
+import (
+	"context"
+	"encoding/base32"
+	"errors"
+
+	"github.com/xrjr/otp"
+	"github.com/xrjr/otp/server"
+)
+
+// Codes of the gRPC status of errors, as defined by google.golang.org/grpc/codes.
+const (
+	CodeOK                 uint32 = 0
+	CodeResourceExhausted  uint32 = 8
+	CodeFailedPrecondition uint32 = 9
+	CodeInternal           uint32 = 13
+	CodeUnauthenticated    uint32 = 16
+)
+
+// ErrNoUser is returned by the User function of a Service or an Interceptor when the call has no user, so that the
+// call is refused as unauthenticated.
+var ErrNoUser = errors.New("grpcotp: no user")
+
+// ErrMissingCode is returned by the Interceptor when a call of a protected method holds no code.
+var ErrMissingCode = errors.New("grpcotp: missing code")
+
+// EnrollRequest mirrors the EnrollRequest message of otp.proto.
+type EnrollRequest struct {
+	AccountName string
+	Code        string // code of the current key of the user, required when the user already has a key
+}
+
+// EnrollResponse mirrors the EnrollResponse message of otp.proto.
+type EnrollResponse struct {
+	URI        string // otpauth URI provisioning the key
+	Secret     string // secret of the key in base32, for manual entry
+	ExpiryUnix int64  // end of the enrollment, in seconds since the Unix epoch
+}
+
+// VerifyRequest mirrors the VerifyRequest message of otp.proto.
+type VerifyRequest struct {
+	Code string
+}
+
+// VerifyResponse mirrors the VerifyResponse message of otp.proto.
+type VerifyResponse struct {
+	Enrolled bool // whether the code activated the key of a pending enrollment
+}
+
+// ResyncRequest mirrors the ResyncRequest message of otp.proto.
+type ResyncRequest struct {
+	FirstCode  string
+	SecondCode string
+}
+
+// ResyncResponse mirrors the ResyncResponse message of otp.proto.
+type ResyncResponse struct {
+	Counter uint64 // counter of the second code
+}
+
+// Service implements the OTPService of otp.proto with the flow of Accounts. The user of a call is the
+// authenticated user of its context, never a field of the request, so that a caller only enrolls and verifies its
+// own keys.
+type Service struct {
+	Accounts *server.Accounts
+	User     func(ctx context.Context) (id string, err error) // authenticated user of a call, or ErrNoUser, required
+}
+
+// Enroll starts the enrollment of a new key of the user, replacing a pending one. A user who already has a key
+// must give a code of it, as with server.Accounts.Enroll.
+func (s *Service) Enroll(ctx context.Context, req *EnrollRequest) (*EnrollResponse, error) {
+	id, err := s.User(ctx)
+	if err != nil {
+		return nil, err
+	}
+	e, err := s.Accounts.EnrollContext(ctx, id, req.AccountName, otp.NormalizeCode(req.Code))
+	if err != nil {
+		return nil, err
+	}
+	return &EnrollResponse{
+		URI:        e.URI(),
+		Secret:     base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(e.Device.Key.Secret),
+		ExpiryUnix: e.Expiry.Unix(),
+	}, nil
+}
+
+// Verify checks a code of the user, confirming its pending enrollment if any.
+func (s *Service) Verify(ctx context.Context, req *VerifyRequest) (*VerifyResponse, error) {
+	id, err := s.User(ctx)
+	if err != nil {
+		return nil, err
+	}
+	_, enrolled, err := s.Accounts.VerifyContext(ctx, id, otp.NormalizeCode(req.Code))
+	if err != nil {
+		return nil, err
+	}
+	return &VerifyResponse{Enrolled: enrolled}, nil
+}
+
+// Resync resynchronizes the counter of the HOTP key of the user with two consecutive codes.
+func (s *Service) Resync(ctx context.Context, req *ResyncRequest) (*ResyncResponse, error) {
+	id, err := s.User(ctx)
+	if err != nil {
+		return nil, err
+	}
+	res, err := s.Accounts.ResyncContext(ctx, id, otp.NormalizeCode(req.FirstCode), otp.NormalizeCode(req.SecondCode))
+	if err != nil {
+		return nil, err
+	}
+	return &ResyncResponse{Counter: res.Counter}, nil
+}
+
+// Interceptor requires a valid code of the key of the user of the calls of selected methods, the code being
+// checked by Accounts.VerifyKeyContext.
+type Interceptor struct {
+	Accounts *server.Accounts
+	Methods  map[string]bool // full names of the methods protected, e.g. "/bank.v1.Transfers/Create"
+
+	User func(ctx context.Context) (id string, err error) // user of a call, or ErrNoUser, required
+	Code func(ctx context.Context) string                 // code of a call, e.g. from its metadata, required
+
+	// Error converts the errors refusing calls, e.g. to a gRPC status with the code of Code. It defaults to
+	// returning the error as is.
+	Error func(err error) error
+}
+
+// Intercept calls handler with ctx and req when the method isn't protected, or when the call holds a valid code.
+// Otherwise, the call is refused with ErrMissingCode, or the error of the verification, converted by Error.
+func (i *Interceptor) Intercept(ctx context.Context, method string, req any, handler func(context.Context, any) (any, error)) (any, error) {
+	if !i.Methods[method] {
+		return handler(ctx, req)
+	}
+	if err := i.verify(ctx); err != nil {
+		if i.Error != nil {
+			err = i.Error(err)
+		}
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// verify checks the code of a call.
+func (i *Interceptor) verify(ctx context.Context) error {
+	code := otp.NormalizeCode(i.Code(ctx))
+	if code == "" {
+		return ErrMissingCode
+	}
+	id, err := i.User(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = i.Accounts.VerifyKeyContext(ctx, id, code)
+	return err
+}
+
+// Code returns the code of the gRPC status of an error: CodeOK for nil, CodeUnauthenticated for missing, invalid
+// or replayed codes and users without key, CodeResourceExhausted for rate limited or locked out users,
+// CodeFailedPrecondition for expired enrollments, and CodeInternal for other errors.
+func Code(err error) uint32 {
+	switch {
+	case err == nil:
+		return CodeOK
+	case errors.Is(err, server.ErrInvalidCode), errors.Is(err, server.ErrReplayedCode),
+		errors.Is(err, server.ErrNoKey), errors.Is(err, server.ErrCodeRequired), errors.Is(err, ErrNoUser),
+		errors.Is(err, ErrMissingCode):
+		return CodeUnauthenticated
+	case errors.Is(err, server.ErrRateLimited), errors.Is(err, server.ErrLockedOut):
+		return CodeResourceExhausted
+	case errors.Is(err, server.ErrEnrollmentExpired):
+		return CodeFailedPrecondition
+	default:
+		return CodeInternal
+	}
+}
@@ -0,0 +1,155 @@
+package grpcotp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/xrjr/otp"
+	"github.com/xrjr/otp/server"
+)
+
+var clock = otp.ClockFunc(func() time.Time {
+	return time.Unix(1111111109, 0)
+})
+
+func totpCode(key otp.Key) string {
+	opts, _ := key.TOTPOptions()
+	return otp.TOTPString(key.Secret, clock.Now(), opts)
+}
+
+func newAccounts() *server.Accounts {
+	return &server.Accounts{
+		Validator: &server.Validator{Replay: &server.MemoryReplayStore{Clock: clock}, Clock: clock},
+		Store:     &server.MemoryKeyStore{},
+	}
+}
+
+// userKey is the key of the authenticated user in the context of the calls of the tests.
+type userKey struct{}
+
+func TestService(t *testing.T) {
+	s := &Service{
+		Accounts: newAccounts(),
+		User: func(ctx context.Context) (string, error) {
+			if id, _ := ctx.Value(userKey{}).(string); id != "" {
+				return id, nil
+			}
+			return "", ErrNoUser
+		},
+	}
+	ctx := context.WithValue(context.Background(), userKey{}, "alice")
+
+	if _, err := s.Verify(ctx, &VerifyRequest{Code: "123456"}); Code(err) != CodeUnauthenticated {
+		t.Errorf("Error in Service (expected = %d, got = %d, err = %v)", CodeUnauthenticated, Code(err), err)
+	}
+	if _, err := s.Enroll(context.Background(), &EnrollRequest{}); !errors.Is(err, ErrNoUser) {
+		t.Errorf("Error in Service (expected = %v, got = %v)", ErrNoUser, err)
+	}
+
+	enrollment, err := s.Enroll(ctx, &EnrollRequest{AccountName: "alice@example.com"})
+	if err != nil {
+		t.Fatalf("Error in Service (err = %v)", err)
+	}
+	key, err := otp.ParseURI(enrollment.URI)
+	if err != nil {
+		t.Fatalf("Error in Service (err = %v)", err)
+	}
+	if enrollment.ExpiryUnix != clock.Now().Add(10*time.Minute).Unix() || key.AccountName != "alice@example.com" {
+		t.Errorf("Error in Service (unexpected enrollment %+v)", enrollment)
+	}
+
+	code := totpCode(key)
+	if res, err := s.Verify(ctx, &VerifyRequest{Code: code}); err != nil || !res.Enrolled {
+		t.Errorf("Error in Service (expected enrolled, got = %+v, err = %v)", res, err)
+	}
+	if _, err := s.Verify(ctx, &VerifyRequest{Code: code}); Code(err) != CodeUnauthenticated {
+		t.Errorf("Error in Service (expected = %d, got = %d, err = %v)", CodeUnauthenticated, Code(err), err)
+	}
+
+	// the key of alice is only replaced with a code of it
+	if _, err := s.Enroll(ctx, &EnrollRequest{AccountName: "alice@example.com"}); !errors.Is(err, server.ErrCodeRequired) || Code(err) != CodeUnauthenticated {
+		t.Errorf("Error in Service (expected = %v, got = %v)", server.ErrCodeRequired, err)
+	}
+	if _, err := s.Enroll(ctx, &EnrollRequest{AccountName: "alice@example.com", Code: "000000"}); !errors.Is(err, server.ErrInvalidCode) {
+		t.Errorf("Error in Service (expected = %v, got = %v)", server.ErrInvalidCode, err)
+	}
+}
+
+func TestInterceptor(t *testing.T) {
+	accounts := newAccounts()
+	key := otp.Key{Type: otp.TypeTOTP, Secret: []byte("12345678901234567890")}
+	accounts.Store.SetKey(context.Background(), "alice", key)
+
+	type metadata struct {
+		user, code string
+	}
+	interceptor := &Interceptor{
+		Accounts: accounts,
+		Methods:  map[string]bool{"/bank.v1.Transfers/Create": true},
+		User: func(ctx context.Context) (string, error) {
+			if md := ctx.Value(metadata{}).(metadata); md.user != "" {
+				return md.user, nil
+			}
+			return "", ErrNoUser
+		},
+		Code: func(ctx context.Context) string {
+			return ctx.Value(metadata{}).(metadata).code
+		},
+		Error: func(err error) error {
+			return fmt.Errorf("status %d: %w", Code(err), err)
+		},
+	}
+	handler := func(ctx context.Context, req any) (any, error) {
+		return req, nil
+	}
+
+	code := totpCode(key)
+	tests := []struct {
+		method   string
+		md       metadata
+		expected error
+	}{
+		{"/bank.v1.Transfers/List", metadata{}, nil},
+		{"/bank.v1.Transfers/Create", metadata{user: "alice"}, ErrMissingCode},
+		{"/bank.v1.Transfers/Create", metadata{code: code}, ErrNoUser},
+		{"/bank.v1.Transfers/Create", metadata{user: "bob", code: code}, server.ErrNoKey},
+		{"/bank.v1.Transfers/Create", metadata{user: "alice", code: "000000"}, server.ErrInvalidCode},
+		{"/bank.v1.Transfers/Create", metadata{user: "alice", code: code}, nil},
+		{"/bank.v1.Transfers/Create", metadata{user: "alice", code: code}, server.ErrReplayedCode},
+	}
+
+	for i, test := range tests {
+		ctx := context.WithValue(context.Background(), metadata{}, test.md)
+		res, err := interceptor.Intercept(ctx, test.method, "req", handler)
+		if !errors.Is(err, test.expected) || (err == nil && res != "req") {
+			t.Errorf("Error in Interceptor (i = %d, expected = %v, got = %v, %v)", i, test.expected, res, err)
+		}
+		if err != nil && err.Error() != fmt.Sprintf("status %d: %v", CodeUnauthenticated, test.expected) {
+			t.Errorf("Error in Interceptor (i = %d, unexpected error %q)", i, err)
+		}
+	}
+}
+
+func TestCode(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected uint32
+	}{
+		{nil, CodeOK},
+		{server.ErrInvalidCode, CodeUnauthenticated},
+		{ErrMissingCode, CodeUnauthenticated},
+		{&server.LockedOutError{RetryAfter: time.Minute}, CodeResourceExhausted},
+		{&server.RateLimitedError{RetryAfter: time.Minute}, CodeResourceExhausted},
+		{server.ErrEnrollmentExpired, CodeFailedPrecondition},
+		{errors.New("connection refused"), CodeInternal},
+	}
+
+	for i, test := range tests {
+		if got := Code(test.err); got != test.expected {
+			t.Errorf("Error in Code (i = %d, expected = %d, got = %d)", i, test.expected, got)
+		}
+	}
+}
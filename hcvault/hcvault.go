@@ -0,0 +1,156 @@
+// Package hcvault converts keys to and from the JSON of the TOTP secrets engine of HashiCorp Vault, so that
+// services can sync keys in and out of Vault:
+//
+//	req, err := hcvault.NewCreateKeyRequest(key)
+//	// POST the JSON of req to /v1/totp/keys/<name>
+//
+//	// the JSON of GET /v1/totp/keys/<name>
+//	key, err := hcvault.ParseKey(body)
+//
+// The package doesn't depend on a Vault client, the requests and responses being sent and received by any HTTP
+// client or by the Vault API client.
+package hcvault
+
+import (
+	"encoding/base32"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/xrjr/otp"
+)
+
+// ErrInvalidResponse is returned when a response isn't a response of the TOTP secrets engine.
+var ErrInvalidResponse = errors.New("hcvault: invalid response")
+
+// CreateKeyRequest is the body of the request creating a key of the TOTP secrets engine (POST /totp/keys/:name).
+type CreateKeyRequest struct {
+	// Generate makes Vault generate the secret of the key, instead of Key or URL.
+	Generate bool `json:"generate,omitempty"`
+	// Exported makes Vault return the URI of a generated key, in the URL of the response.
+	Exported bool `json:"exported,omitempty"`
+	// KeySize is the size in bytes of the secret generated, 20 by default.
+	KeySize int `json:"key_size,omitempty"`
+	// Key is the base32 secret of a key which isn't generated.
+	Key string `json:"key,omitempty"`
+	// URL is the otpauth URI of a key which isn't generated, instead of Key and the parameters of the key.
+	URL string `json:"url,omitempty"`
+
+	Issuer      string `json:"issuer,omitempty"`
+	AccountName string `json:"account_name,omitempty"`
+	Period      int    `json:"period,omitempty"`    // time period in seconds, 30 by default
+	Algorithm   string `json:"algorithm,omitempty"` // SHA1, SHA256 or SHA512, SHA1 by default
+	Digits      uint   `json:"digits,omitempty"`    // 6 or 8, 6 by default
+	Skew        *int   `json:"skew,omitempty"`      // time steps accepted before and after the current one, 0 or 1, 1 when nil
+}
+
+// KeyResponse is the data of the responses of the TOTP secrets engine describing a key: the response reading a key
+// (GET /totp/keys/:name), which holds its parameters but not its secret, and the response creating an exported key,
+// which holds its URI.
+type KeyResponse struct {
+	Issuer      string `json:"issuer"`
+	AccountName string `json:"account_name"`
+	Period      int    `json:"period"`
+	Algorithm   string `json:"algorithm"`
+	Digits      uint   `json:"digits"`
+
+	URL     string `json:"url"`
+	Barcode string `json:"barcode"` // PNG image of the QR code of URL, in base64
+}
+
+// NewCreateKeyRequest returns the request creating a TOTP key in Vault, with its secret and parameters.
+// Vault only supports TOTP keys of 6 or 8 digits, with the SHA1, SHA256 or SHA512 algorithm.
+func NewCreateKeyRequest(key otp.Key) (CreateKeyRequest, error) {
+	if err := check(key); err != nil {
+		return CreateKeyRequest{}, err
+	}
+	if len(key.Secret) == 0 {
+		return CreateKeyRequest{}, otp.ErrInvalidSecret
+	}
+
+	return CreateKeyRequest{
+		Key:         base32.StdEncoding.EncodeToString(key.Secret),
+		Issuer:      key.Issuer,
+		AccountName: key.AccountName,
+		Period:      key.Period,
+		Algorithm:   strings.ToUpper(key.Algorithm),
+		Digits:      key.Digits,
+	}, nil
+}
+
+// NewGenerateKeyRequest returns the request making Vault generate a TOTP key with the parameters of template,
+// whose secret is ignored. The secret is returned in the URI of the response when exported is set, for keys
+// provisioned by the service rather than verified by Vault.
+func NewGenerateKeyRequest(template otp.Key, exported bool) (CreateKeyRequest, error) {
+	if err := check(template); err != nil {
+		return CreateKeyRequest{}, err
+	}
+
+	return CreateKeyRequest{
+		Generate:    true,
+		Exported:    exported,
+		KeySize:     len(template.Secret),
+		Issuer:      template.Issuer,
+		AccountName: template.AccountName,
+		Period:      template.Period,
+		Algorithm:   strings.ToUpper(template.Algorithm),
+		Digits:      template.Digits,
+	}, nil
+}
+
+// check reports the parameters of a key which aren't supported by Vault.
+func check(key otp.Key) error {
+	var errs []error
+	if key.Type != otp.TypeTOTP {
+		errs = append(errs, fmt.Errorf("%w: Vault only supports %s keys", otp.ErrInvalidType, otp.TypeTOTP))
+	}
+	switch strings.ToUpper(key.Algorithm) {
+	case "", "SHA1", "SHA256", "SHA512":
+	default:
+		errs = append(errs, fmt.Errorf("%w: %s is not supported by Vault", otp.ErrInvalidAlgorithm, key.Algorithm))
+	}
+	if key.Digits != 0 && key.Digits != 6 && key.Digits != 8 {
+		errs = append(errs, fmt.Errorf("%w: %d digits are not supported by Vault", otp.ErrInvalidDigits, key.Digits))
+	}
+	if key.Period < 0 {
+		errs = append(errs, fmt.Errorf("%w: %d is negative", otp.ErrInvalidPeriod, key.Period))
+	}
+	return errors.Join(errs...)
+}
+
+// ParseKey parses a response of the TOTP secrets engine describing a key, whose data is a KeyResponse. The key of
+// a read response has no secret, Vault never returning it, while the key of the response creating an exported key
+// is parsed from its URI.
+func ParseKey(response []byte) (otp.Key, error) {
+	var r struct {
+		Data *KeyResponse `json:"data"`
+	}
+	if err := json.Unmarshal(response, &r); err != nil {
+		return otp.Key{}, fmt.Errorf("%w: %w", ErrInvalidResponse, err)
+	}
+	if r.Data == nil {
+		return otp.Key{}, fmt.Errorf("%w: no data", ErrInvalidResponse)
+	}
+	return r.Data.Key()
+}
+
+// Key returns the key described by the response.
+func (r KeyResponse) Key() (otp.Key, error) {
+	if r.URL != "" {
+		return otp.ParseURI(r.URL)
+	}
+
+	key := otp.Key{
+		Type:        otp.TypeTOTP,
+		Issuer:      r.Issuer,
+		AccountName: r.AccountName,
+		Algorithm:   strings.ToUpper(r.Algorithm),
+		Digits:      r.Digits,
+		Period:      r.Period,
+	}
+	if err := check(key); err != nil {
+		return otp.Key{}, fmt.Errorf("%w: %w", ErrInvalidResponse, err)
+	}
+	return key, nil
+}
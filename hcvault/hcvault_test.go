@@ -0,0 +1,107 @@
+package hcvault
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/xrjr/otp"
+)
+
+func TestNewCreateKeyRequest(t *testing.T) {
+	key := otp.Key{
+		Type:        otp.TypeTOTP,
+		Issuer:      "Example",
+		AccountName: "alice@example.com",
+		Secret:      []byte("12345678901234567890"),
+		Algorithm:   "sha256",
+		Digits:      8,
+		Period:      60,
+	}
+
+	req, err := NewCreateKeyRequest(key)
+	if err != nil {
+		t.Fatalf("Error in NewCreateKeyRequest (expected = nil, got = %v)", err)
+	}
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"key":"GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ","issuer":"Example","account_name":"alice@example.com","period":60,"algorithm":"SHA256","digits":8}`
+	if string(data) != expected {
+		t.Errorf("Error in NewCreateKeyRequest (expected = %s, got = %s)", expected, data)
+	}
+
+	// a skew of 0 is sent, rather than left to the default of Vault
+	skew := 0
+	req.Skew = &skew
+	if data, _ := json.Marshal(req); !strings.HasSuffix(string(data), `"digits":8,"skew":0}`) {
+		t.Errorf("Error in NewCreateKeyRequest (skew of 0 left out of %s)", data)
+	}
+
+	invalid := []struct {
+		key otp.Key
+		err error
+	}{
+		{otp.Key{Type: otp.TypeHOTP, Secret: key.Secret}, otp.ErrInvalidType},
+		{otp.Key{Type: otp.TypeTOTP, Secret: key.Secret, Digits: 7}, otp.ErrInvalidDigits},
+		{otp.Key{Type: otp.TypeTOTP, Secret: key.Secret, Algorithm: "SHA384"}, otp.ErrInvalidAlgorithm},
+		{otp.Key{Type: otp.TypeTOTP}, otp.ErrInvalidSecret},
+	}
+	for i, v := range invalid {
+		if _, err := NewCreateKeyRequest(v.key); !errors.Is(err, v.err) {
+			t.Errorf("Error in NewCreateKeyRequest (i = %d, expected = %v, got = %v)", i, v.err, err)
+		}
+	}
+}
+
+func TestNewGenerateKeyRequest(t *testing.T) {
+	req, err := NewGenerateKeyRequest(otp.Key{Type: otp.TypeTOTP, Issuer: "Example", AccountName: "alice", Secret: make([]byte, 32)}, true)
+	if err != nil {
+		t.Fatalf("Error in NewGenerateKeyRequest (expected = nil, got = %v)", err)
+	}
+	if !req.Generate || !req.Exported || req.KeySize != 32 || req.Key != "" {
+		t.Errorf("Error in NewGenerateKeyRequest (got = %+v)", req)
+	}
+}
+
+func TestParseKey(t *testing.T) {
+	read := `{"request_id":"1","data":{"account_name":"alice@example.com","algorithm":"SHA256","digits":8,"issuer":"Example","period":60}}`
+	key, err := ParseKey([]byte(read))
+	if err != nil {
+		t.Fatalf("Error in ParseKey (expected = nil, got = %v)", err)
+	}
+	expected := otp.Key{
+		Type:        otp.TypeTOTP,
+		Issuer:      "Example",
+		AccountName: "alice@example.com",
+		Algorithm:   "SHA256",
+		Digits:      8,
+		Period:      60,
+	}
+	if !key.Equal(expected) {
+		t.Errorf("Error in ParseKey (expected = %v, got = %v)", expected, key)
+	}
+
+	created := `{"data":{"barcode":"iVBORw0KGgo=","url":"otpauth://totp/Example:alice@example.com?algorithm=SHA1&digits=6&issuer=Example&period=30&secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"}}`
+	key, err = ParseKey([]byte(created))
+	if err != nil {
+		t.Fatalf("Error in ParseKey (expected = nil, got = %v)", err)
+	}
+	if string(key.Secret) != "12345678901234567890" || key.AccountName != "alice@example.com" || key.Period != 30 {
+		t.Errorf("Error in ParseKey (got = %v)", key)
+	}
+
+	invalid := []string{
+		`not json`,
+		`{"errors":["permission denied"]}`,
+		`{"data":{"algorithm":"MD5","digits":6,"period":30}}`,
+	}
+	for i, v := range invalid {
+		if _, err := ParseKey([]byte(v)); !errors.Is(err, ErrInvalidResponse) {
+			t.Errorf("Error in ParseKey (i = %d, expected = %v, got = %v)", i, ErrInvalidResponse, err)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package otp
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"hash"
+	"slices"
+	"sync"
+)
+
+// maxHashSize is the size of the arrays receiving the hmac, the largest hash size of the standard library.
+const maxHashSize = sha512.Size
+
+// hashFunc returns the function creating a hash function, which is the function of its package for the hash
+// functions of fixedHashes so that they are recognized.
+func hashFunc(h crypto.Hash) func() hash.Hash {
+	switch h {
+	case crypto.SHA1:
+		return sha1.New
+	case crypto.SHA256:
+		return sha256.New
+	case crypto.SHA512:
+		return sha512.New
+	default:
+		return h.New
+	}
+}
+
+// hashSize returns the size of the sums of a hash function.
+func hashSize(fn func() hash.Hash) int {
+	if h, ok := fixedHash(fn); ok {
+		return h.Size()
+	}
+	return fn().Size()
+}
+
+// HMACCounter computes the hmac of a counter encoded as 8 big-endian bytes, as done by HOTP (section 5.2 of rfc
+// 4226) with the hash function created by h, e.g. sha1.New. It is the hmac step of HOTP, for schemes building on
+// it, its result being truncated by DynamicTruncation.
+func HMACCounter(h func() hash.Hash, key []byte, counter uint64) []byte {
+	var sum [maxHashSize]byte
+	return slices.Clone(hmacShaN(h, key, counter, &sum))
+}
+
+// hashFunc returns the function creating the hash function of options whose defaults are applied.
+func (opts HOTPOptions) hashFunc() func() hash.Hash {
+	if opts.Hash != 0 {
+		return opts.Hash.New
+	}
+	return opts.Algorithm
+}
+
+// hmac generates the hmac of a counter into sum with the hash function of options whose defaults are applied.
+func (opts HOTPOptions) hmac(key []byte, counter uint64, sum *[maxHashSize]byte) []byte {
+	if opts.Hash != 0 {
+		return pooledHMAC(opts.Hash, key, counter, sum)
+	}
+	return hmacShaN(opts.Algorithm, key, counter, sum)
+}
+
+// hmacShaN generates a hmac-sha-n into sum, and returns the part of sum holding it. The hash function is passed as a
+// parameter.
+func hmacShaN(hashFunc func() hash.Hash, key []byte, counter uint64, sum *[maxHashSize]byte) []byte {
+	if h, ok := fixedHash(hashFunc); ok {
+		return fixedHMAC(h, key, counter, sum)
+	}
+
+	hasher := hmac.New(hashFunc, key)
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], counter)
+	hasher.Write(buf[:])
+	return append(sum[:0], hasher.Sum(nil)...)
+}
+
+// fixedHMAC generates the hmac of a counter with SHA1, SHA256 or SHA512, as defined by rfc 2104, without
+// allocating: the padded keys are hashed from arrays with the Sum functions of the hash packages, instead of the
+// hash.Hash values of crypto/hmac.
+func fixedHMAC(h crypto.Hash, key []byte, counter uint64, sum *[maxHashSize]byte) []byte {
+	blockSize := sha1.BlockSize
+	if h == crypto.SHA512 {
+		blockSize = sha512.BlockSize
+	}
+
+	// keys longer than the block size are hashed
+	var k [sha512.BlockSize]byte
+	if len(key) > blockSize {
+		copy(k[:], sum[:fixedSum(h, key, sum)])
+	} else {
+		copy(k[:], key)
+	}
+
+	var buf [sha512.BlockSize + maxHashSize]byte
+	for i := range blockSize {
+		buf[i] = k[i] ^ 0x36
+	}
+	binary.BigEndian.PutUint64(buf[blockSize:], counter)
+	n := fixedSum(h, buf[:blockSize+8], sum)
+
+	for i := range blockSize {
+		buf[i] = k[i] ^ 0x5c
+	}
+	copy(buf[blockSize:], sum[:n])
+	n = fixedSum(h, buf[:blockSize+n], sum)
+
+	WipeBytes(k[:])
+	WipeBytes(buf[:])
+	return sum[:n]
+}
+
+// fixedSum hashes data with SHA1, SHA256 or SHA512 into sum, and returns the size of the hash.
+func fixedSum(h crypto.Hash, data []byte, sum *[maxHashSize]byte) int {
+	switch h {
+	case crypto.SHA1:
+		s := sha1.Sum(data)
+		return copy(sum[:], s[:])
+	case crypto.SHA256:
+		s := sha256.Sum256(data)
+		return copy(sum[:], s[:])
+	default:
+		s := sha512.Sum512(data)
+		return copy(sum[:], s[:])
+	}
+}
+
+// pooledHash is a hash instance of a pool of hashPools, along with the buffers computing a hmac.
+type pooledHash struct {
+	hash.Hash
+	pad     []byte
+	counter [8]byte
+	sum     []byte
+}
+
+// hashPools are the pools of hash instances of the crypto.Hash values, indexed by them. Multi-tenant servers
+// computing the codes of many keys would otherwise allocate the hash instances of crypto/hmac for every code.
+var hashPools [32]sync.Pool
+
+func init() {
+	for i := range hashPools {
+		h := crypto.Hash(i)
+		hashPools[i].New = func() any {
+			hasher := h.New()
+			return &pooledHash{
+				Hash: hasher,
+				pad:  make([]byte, hasher.BlockSize()),
+				sum:  make([]byte, 0, max(hasher.Size(), hasher.BlockSize())),
+			}
+		}
+	}
+}
+
+// pooledHMAC generates the hmac of a counter with the hash function of a crypto.Hash value, as defined by rfc 2104,
+// with a hash instance of its pool.
+func pooledHMAC(h crypto.Hash, key []byte, counter uint64, sum *[maxHashSize]byte) []byte {
+	if int(h) >= len(hashPools) {
+		return hmacShaN(h.New, key, counter, sum)
+	}
+	p := hashPools[h].Get().(*pooledHash)
+	defer hashPools[h].Put(p)
+
+	// keys longer than the block size are hashed
+	if len(key) > len(p.pad) {
+		p.Reset()
+		p.Write(key)
+		key = p.Sum(p.sum[:0])
+	}
+
+	clear(p.pad)
+	for i := range p.pad {
+		if i < len(key) {
+			p.pad[i] = key[i]
+		}
+		p.pad[i] ^= 0x36
+	}
+	binary.BigEndian.PutUint64(p.counter[:], counter)
+	p.Reset()
+	p.Write(p.pad)
+	p.Write(p.counter[:])
+	inner := p.Sum(p.sum[:0])
+
+	for i := range p.pad {
+		p.pad[i] ^= 0x36 ^ 0x5c
+	}
+	p.Reset()
+	p.Write(p.pad)
+	p.Write(inner)
+	n := copy(sum[:], p.Sum(p.sum[:0]))
+
+	WipeBytes(p.pad)
+	WipeBytes(p.sum[:cap(p.sum)])
+	return sum[:n]
+}
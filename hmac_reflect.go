@@ -0,0 +1,26 @@
+//go:build !tinygo
+
+package otp
+
+import (
+	"crypto"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"hash"
+	"reflect"
+)
+
+// fixedHashes maps the functions creating SHA1, SHA256 and SHA512 to their crypto.Hash value. The hmac of these
+// hash functions, used by almost every key, is computed without allocating by fixedHMAC.
+var fixedHashes = map[uintptr]crypto.Hash{
+	reflect.ValueOf(sha1.New).Pointer():   crypto.SHA1,
+	reflect.ValueOf(sha256.New).Pointer(): crypto.SHA256,
+	reflect.ValueOf(sha512.New).Pointer(): crypto.SHA512,
+}
+
+// fixedHash returns the crypto.Hash value of the function creating a hash function of fixedHashes.
+func fixedHash(fn func() hash.Hash) (crypto.Hash, bool) {
+	h, ok := fixedHashes[reflect.ValueOf(fn).Pointer()]
+	return h, ok
+}
@@ -0,0 +1,131 @@
+package otp
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"hash"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestHmacShaNFixed(t *testing.T) {
+	funcs := []func() hash.Hash{sha1.New, sha256.New, sha512.New, crypto.SHA384.New, hashFunc(crypto.SHA256)}
+	// keys shorter than, as long as, and longer than the block sizes
+	keys := [][]byte{nil, hotpSecret, bytes.Repeat([]byte{0xaa}, 64), bytes.Repeat([]byte{0xbb}, 128), bytes.Repeat([]byte{0xcc}, 131)}
+
+	for i, fn := range funcs {
+		for j, key := range keys {
+			mac := hmac.New(fn, key)
+			mac.Write(binary.BigEndian.AppendUint64(nil, 42))
+			expected := mac.Sum(nil)
+
+			var sum [maxHashSize]byte
+			if got := hmacShaN(fn, key, 42, &sum); !bytes.Equal(got, expected) {
+				t.Errorf("Error in hmacShaN (i = %d, j = %d, expected = %x, got = %x)", i, j, expected, got)
+			}
+		}
+	}
+}
+
+func TestPooledHMAC(t *testing.T) {
+	hashes := []crypto.Hash{crypto.SHA224, crypto.SHA384, crypto.SHA512_224, crypto.SHA512_256, crypto.SHA1}
+	keys := [][]byte{nil, hotpSecret, bytes.Repeat([]byte{0xaa}, 64), bytes.Repeat([]byte{0xbb}, 128), bytes.Repeat([]byte{0xcc}, 131)}
+
+	// the pools are used concurrently, with different keys
+	var wg sync.WaitGroup
+	for i, h := range hashes {
+		for j, key := range keys {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				mac := hmac.New(h.New, key)
+				mac.Write(binary.BigEndian.AppendUint64(nil, uint64(j)))
+				expected := mac.Sum(nil)
+
+				for range 10 {
+					var sum [maxHashSize]byte
+					if got := pooledHMAC(h, key, uint64(j), &sum); !bytes.Equal(got, expected) {
+						t.Errorf("Error in pooledHMAC (i = %d, j = %d, expected = %x, got = %x)", i, j, expected, got)
+						return
+					}
+				}
+			}()
+		}
+	}
+	wg.Wait()
+
+	// the codes of a SHA384 key are computed with its pool
+	k := Key{Type: TypeHOTP, Secret: hotpSecret, Algorithm: "SHA384"}
+	opts, _ := k.HOTPOptions()
+	expected := HOTP(hotpSecret, 0, HOTPOptions{Algorithm: crypto.SHA384.New})
+	if opts.Hash != crypto.SHA384 || HOTP(hotpSecret, 0, opts) != expected {
+		t.Errorf("Error in pooledHMAC (expected = %d with %v, got = %d with %v)", expected, crypto.SHA384, HOTP(hotpSecret, 0, opts), opts.Hash)
+	}
+}
+
+func TestHOTPAllocs(t *testing.T) {
+	if _, ok := fixedHash(sha256.New); !ok {
+		t.Skip("hash functions aren't recognized by fixedHash under TinyGo")
+	}
+
+	tests := []HOTPOptions{
+		{},
+		{Digits: 8, Algorithm: sha256.New},
+		{Hash: crypto.SHA512},
+	}
+
+	for i, opts := range tests {
+		if allocs := testing.AllocsPerRun(100, func() { HOTP(hotpSecret, 1, opts) }); allocs != 0 {
+			t.Errorf("Error in HOTP (i = %d, expected = 0 allocations, got = %v)", i, allocs)
+		}
+	}
+	if allocs := testing.AllocsPerRun(100, func() { TOTP(hotpSecret, time.Unix(59, 0), TOTPOptions{}) }); allocs != 0 {
+		t.Errorf("Error in TOTP (expected = 0 allocations, got = %v)", allocs)
+	}
+}
+
+func BenchmarkHOTP(b *testing.B) {
+	b.ReportAllocs()
+	for i := range b.N {
+		HOTP(hotpSecret, uint64(i), HOTPOptions{})
+	}
+}
+
+func BenchmarkHOTPSHA512(b *testing.B) {
+	b.ReportAllocs()
+	for i := range b.N {
+		HOTP(hotpSecret, uint64(i), HOTPOptions{Hash: crypto.SHA512})
+	}
+}
+
+// BenchmarkHmacShaNGeneric and BenchmarkPooledHMAC compare crypto/hmac to the pools of hash instances, computing
+// the codes of different keys in parallel as multi-tenant servers do.
+func BenchmarkHmacShaNGeneric(b *testing.B) {
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		var sum [maxHashSize]byte
+		key := bytes.Clone(hotpSecret)
+		for i := uint64(0); pb.Next(); i++ {
+			key[0] = byte(i)
+			hmacShaN(crypto.SHA384.New, key, i, &sum)
+		}
+	})
+}
+
+func BenchmarkPooledHMAC(b *testing.B) {
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		var sum [maxHashSize]byte
+		key := bytes.Clone(hotpSecret)
+		for i := uint64(0); pb.Next(); i++ {
+			key[0] = byte(i)
+			pooledHMAC(crypto.SHA384, key, i, &sum)
+		}
+	})
+}
@@ -1,21 +1,127 @@
 package otp
 
 import (
-	"crypto/hmac"
+	"crypto"
 	"crypto/sha1"
+	_ "crypto/sha256" // make crypto.SHA224 and crypto.SHA256 available
+	_ "crypto/sha512" // make crypto.SHA384 and crypto.SHA512 available
 	"encoding/binary"
+	"errors"
+	"fmt"
 	"hash"
+	"slices"
 )
 
 type HOTPOptions struct {
 	Digits    uint
 	Algorithm func() hash.Hash
+	Hash      crypto.Hash // hash function, alternative to Algorithm (only one of them can be set), e.g. crypto.BLAKE2b_256
+	Encoder   Encoder     // only used by HOTPString and TOTPString
+
+	// Truncate extracts the value of a code from the hmac, instead of the dynamic truncation of the rfc, for
+	// vendors truncating it otherwise, e.g. FixedTruncation. Values with more than Digits digits are reduced
+	// modulo 10^Digits.
+	Truncate func(hs []byte) uint32
 }
 
 // HOTP computes the OTP code of a given counter.
-func HOTP(key []byte, counter int, opts HOTPOptions) uint {
-	// defaults
-	if opts.Algorithm == nil {
+// The code is returned as an integer, so leading zeros are lost: use HOTPString to get exactly opts.Digits characters.
+func HOTP(key []byte, counter uint64, opts HOTPOptions) uint {
+	opts = opts.withDefaults()
+
+	// compute
+	var sum [maxHashSize]byte
+	return uint(truncate(uint64(opts.truncation(opts.hmac(key, counter, &sum))), opts.Digits))
+}
+
+// HOTPString computes the OTP code of a given counter, encoded with opts.Encoder.
+// With the default DecimalEncoder, the code is zero padded to opts.Digits characters.
+func HOTPString(key []byte, counter uint64, opts HOTPOptions) string {
+	opts = opts.withDefaults()
+
+	// compute
+	var sum [maxHashSize]byte
+	return opts.Encoder.Encode(opts.truncation(opts.hmac(key, counter, &sum)), opts.Digits)
+}
+
+// HOTPInt computes the OTP code of a given counter, as HOTP did before its counter became a uint64. Negative
+// counters are used as their two's complement.
+//
+// Deprecated: use HOTP, converting the counter to a uint64.
+func HOTPInt(key []byte, counter int, opts HOTPOptions) uint {
+	return HOTP(key, uint64(counter), opts)
+}
+
+// HOTPStringInt computes the OTP code of a given counter, as HOTPString did before its counter became a uint64.
+//
+// Deprecated: use HOTPString, converting the counter to a uint64.
+func HOTPStringInt(key []byte, counter int, opts HOTPOptions) string {
+	return HOTPString(key, uint64(counter), opts)
+}
+
+// HOTPRange computes the OTP codes of count successive counters, starting with a given counter.
+// The hmac is keyed once and reused for the whole range, by a Generator.
+func HOTPRange(key []byte, start, count uint64, opts HOTPOptions) []Code {
+	opts = opts.withDefaults()
+
+	g := newGenerator(key, opts)
+	codes := make([]Code, count)
+	for i := range codes {
+		codes[i] = g.HOTPCode(start + uint64(i))
+	}
+	return codes
+}
+
+// HOTPE computes the OTP code of a given counter, as HOTPCode does, but returns an error instead of panicking
+// when the options are invalid. An empty secret is also reported as an error.
+func HOTPE(key []byte, counter uint64, opts HOTPOptions) (Code, error) {
+	if len(key) == 0 {
+		return Code{}, ErrInvalidSecret
+	}
+
+	opts, err := opts.checkedDefaults()
+	if err != nil {
+		return Code{}, err
+	}
+
+	return HOTPCode(key, counter, opts), nil
+}
+
+// MustHOTP is like HOTPE but panics if the secret or the options are invalid.
+// It simplifies the use of fixed configurations, such as in tests.
+func MustHOTP(key []byte, counter uint64, opts HOTPOptions) Code {
+	code, err := HOTPE(key, counter, opts)
+	if err != nil {
+		panic(err)
+	}
+	return code
+}
+
+// withDefaults returns the options with defaults applied to unset fields.
+// It panics if the options are invalid.
+func (opts HOTPOptions) withDefaults() HOTPOptions {
+	opts, err := opts.checkedDefaults()
+	if err != nil {
+		panic(err)
+	}
+	return opts
+}
+
+// checkedDefaults returns the options with defaults applied to unset fields, or an error if they are invalid.
+func (opts HOTPOptions) checkedDefaults() (HOTPOptions, error) {
+	if err := opts.Validate(); err != nil {
+		return opts, err
+	}
+
+	// the hmac of SHA1, SHA256 and SHA512 is computed from their function by fixedHMAC, the other hash functions of
+	// Hash being kept for the pools of pooledHMAC. Hash is cleared so that defaults can be applied again to the
+	// returned options
+	if opts.Hash == crypto.SHA1 || opts.Hash == crypto.SHA256 || opts.Hash == crypto.SHA512 {
+		opts.Algorithm = hashFunc(opts.Hash)
+		opts.Hash = 0
+	}
+
+	if opts.Algorithm == nil && opts.Hash == 0 {
 		opts.Algorithm = sha1.New
 	}
 
@@ -23,30 +129,96 @@ func HOTP(key []byte, counter int, opts HOTPOptions) uint {
 		opts.Digits = 6
 	}
 
-	// compute
-	return dynamicTruncation(hmacShaN(opts.Algorithm, key, counter)) % pow10(opts.Digits)
+	if opts.Encoder == nil {
+		opts.Encoder = DecimalEncoder
+	}
+
+	return opts, nil
+}
+
+// Validate checks the options, and reports all the problems found. Unset fields are valid, as they are defaulted.
+func (opts HOTPOptions) Validate() error {
+	var errs []error
+
+	if opts.Digits != 0 {
+		if err := validateDigits(opts.Digits); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	// dynamic truncation reads 4 bytes at an offset up to 15
+	switch {
+	case opts.Algorithm != nil && opts.Hash != 0:
+		errs = append(errs, fmt.Errorf("%w: both Algorithm and Hash are set", ErrInvalidAlgorithm))
+	case opts.Hash != 0 && !opts.Hash.Available():
+		errs = append(errs, fmt.Errorf("%w: %s is not available", ErrInvalidAlgorithm, opts.Hash))
+	case opts.Hash != 0 && opts.Hash.Size() < 20:
+		errs = append(errs, fmt.Errorf("%w: hash size is lower than 20 bytes", ErrInvalidAlgorithm))
+	case opts.Algorithm != nil && hashSize(opts.Algorithm) < 20:
+		errs = append(errs, fmt.Errorf("%w: hash size is lower than 20 bytes", ErrInvalidAlgorithm))
+	}
+
+	return errors.Join(errs...)
 }
 
-// hmacShaN generates a hmac-sha-n. The hash function is passed as a parameter.
-func hmacShaN(hashFunc func() hash.Hash, key []byte, counter int) []byte {
-	hasher := hmac.New(hashFunc, key)
-	buf := make([]byte, 8)
-	binary.BigEndian.PutUint64(buf, uint64(counter))
-	hasher.Write(buf)
-	return hasher.Sum(nil)
+// validateDigits checks that a number of digits is between 1 and 10, as the truncated value of the hmac has at
+// most 10 digits.
+func validateDigits(digits uint) error {
+	if digits < 1 || digits > 10 {
+		return fmt.Errorf("%w: %d is not between 1 and 10", ErrInvalidDigits, digits)
+	}
+	return nil
+}
+
+// truncate keeps the given number of least significant decimal digits of a value.
+// 64-bit arithmetic is used, as 10^10 overflows a 32-bit uint.
+func truncate(value uint64, digits uint) uint64 {
+	// values of the truncated hmac are lower than 2^31, so they have at most 10 digits
+	if digits >= 10 {
+		return value
+	}
+	return value % pow10(digits)
 }
 
 // pow10 computes the n-th power of 10.
 // Here we doesn't use math.Pow10 to avoid type casting and high complexity of this function.
 // This might change in the future.
-func pow10(n uint) uint {
-	res := uint(1)
+func pow10(n uint) uint64 {
+	res := uint64(1)
 	for i := uint(0); i < n; i++ {
 		res *= 10
 	}
 	return res
 }
 
+// DynamicTruncation is the DT function of section 5.4 of rfc 4226: it extracts 31 bits of a hmac, at an offset
+// given by its last 4 bits. It panics if hs is shorter than 20 bytes, the size of a hmac-sha-1.
+// HOTP codes are the truncated value modulo 10^Digits, and other schemes such as OCRA (rfc 6287) build on it.
+func DynamicTruncation(hs []byte) uint32 {
+	if len(hs) < 20 {
+		panic(fmt.Errorf("%w: mac is shorter than 20 bytes", ErrInvalidAlgorithm))
+	}
+	return uint32(dynamicTruncation(hs))
+}
+
+// FixedTruncation returns a truncation function for HOTPOptions.Truncate, extracting 31 bits of the hmac at a fixed
+// offset instead of the dynamic offset of DynamicTruncation, as the truncationOffset parameter of the reference
+// implementation of rfc 4226. The truncation panics if the hmac is too short for the offset.
+func FixedTruncation(offset int) func(hs []byte) uint32 {
+	return func(hs []byte) uint32 {
+		return binary.BigEndian.Uint32(hs[offset:offset+4]) & 0x7fffffff
+	}
+}
+
+// truncation extracts the value of a code from the hmac, with opts.Truncate when set.
+func (opts HOTPOptions) truncation(hs []byte) uint {
+	if opts.Truncate == nil {
+		return dynamicTruncation(hs)
+	}
+	// hs is cloned so that it doesn't escape to the heap, as the hmac of fixedHMAC is kept on the stack
+	return uint(opts.Truncate(slices.Clone(hs)))
+}
+
 // dynamicTruncation is the DT function of the section 5.4 of the rfc.
 func dynamicTruncation(hs []byte) uint {
 	offset := hs[len(hs)-1] & 0xf
@@ -2,12 +2,17 @@ package otp
 
 import (
 	"bytes"
+	"crypto"
+	"crypto/md5"
 	"crypto/sha1"
+	"encoding/binary"
+	"errors"
+	"fmt"
 	"testing"
 )
 
 type HOTPTestValue struct {
-	Counter              int
+	Counter              uint64
 	Secret               []byte
 	IntermediateHmacSha1 []byte
 	Truncated            uint
@@ -91,13 +96,54 @@ var hotpTestValues = []HOTPTestValue{
 
 func TestHmacShaN1(t *testing.T) {
 	for _, testValue := range hotpTestValues {
-		res := hmacShaN(sha1.New, testValue.Secret, testValue.Counter)
+		var sum [maxHashSize]byte
+		res := hmacShaN(sha1.New, testValue.Secret, testValue.Counter, &sum)
 		if !bytes.Equal(res, testValue.IntermediateHmacSha1) {
 			t.Errorf("Error in hmacSha1 for Counter = %d", testValue.Counter)
 		}
 	}
 }
 
+func TestHMACCounter(t *testing.T) {
+	for _, testValue := range hotpTestValues {
+		res := HMACCounter(sha1.New, testValue.Secret, testValue.Counter)
+		if !bytes.Equal(res, testValue.IntermediateHmacSha1) {
+			t.Errorf("Error in HMACCounter for Counter = %d", testValue.Counter)
+		}
+		if res := DynamicTruncation(res); res != uint32(testValue.Truncated) {
+			t.Errorf("Error in DynamicTruncation for Counter = %d (expected %d, got %d)", testValue.Counter, testValue.Truncated, res)
+		}
+	}
+
+	defer func() {
+		if err, _ := recover().(error); !errors.Is(err, ErrInvalidAlgorithm) {
+			t.Errorf("Error in DynamicTruncation (expected = %v, got = %v)", ErrInvalidAlgorithm, err)
+		}
+	}()
+	DynamicTruncation(make([]byte, 16))
+}
+
+func TestHOTPTruncate(t *testing.T) {
+	for _, testValue := range hotpTestValues {
+		// the dynamic offset, given as a fixed one
+		offset := int(testValue.IntermediateHmacSha1[19] & 0xf)
+		opts := HOTPOptions{Truncate: FixedTruncation(offset)}
+		if res := HOTP(testValue.Secret, testValue.Counter, opts); res != testValue.OTP {
+			t.Errorf("Error in HOTPTruncate for Counter = %d (expected %d, got %d)", testValue.Counter, testValue.OTP, res)
+		}
+
+		expected := uint(binary.BigEndian.Uint32(testValue.IntermediateHmacSha1[2:6]) & 0x7fffffff)
+		opts = HOTPOptions{Digits: 10, Truncate: FixedTruncation(2)}
+		if res := HOTP(testValue.Secret, testValue.Counter, opts); res != expected {
+			t.Errorf("Error in HOTPTruncate for Counter = %d (expected %d, got %d)", testValue.Counter, expected, res)
+		}
+		g, _ := NewGenerator(testValue.Secret, opts)
+		if res := g.HOTPString(testValue.Counter); res != fmt.Sprintf("%010d", expected) {
+			t.Errorf("Error in HOTPTruncate for Counter = %d (expected %010d, got %s)", testValue.Counter, expected, res)
+		}
+	}
+}
+
 func TestDynamicTruncation(t *testing.T) {
 	for _, testValue := range hotpTestValues {
 		res := dynamicTruncation(testValue.IntermediateHmacSha1)
@@ -116,6 +162,21 @@ func TestHOTP(t *testing.T) {
 	}
 }
 
+func TestHOTPInt(t *testing.T) {
+	for _, testValue := range hotpTestValues {
+		counter := int(testValue.Counter)
+		if res := HOTPInt(testValue.Secret, counter, HOTPOptions{}); res != testValue.OTP {
+			t.Errorf("Error in HOTPInt for Counter = %d (expected %d, got %d)", counter, testValue.OTP, res)
+		}
+		if res := HOTPStringInt(testValue.Secret, counter, HOTPOptions{}); res != HOTPString(testValue.Secret, testValue.Counter, HOTPOptions{}) {
+			t.Errorf("Error in HOTPStringInt for Counter = %d (got %s)", counter, res)
+		}
+		if res := HOTPCodeInt(testValue.Secret, counter, HOTPOptions{}); res != HOTPCode(testValue.Secret, testValue.Counter, HOTPOptions{}) {
+			t.Errorf("Error in HOTPCodeInt for Counter = %d (got %v)", counter, res)
+		}
+	}
+}
+
 func TestHOTPDefaults(t *testing.T) {
 	testValue := hotpTestValues[0]
 
@@ -129,3 +190,178 @@ func TestHOTPDefaults(t *testing.T) {
 		t.Errorf("Error in HOTPDefaults (expected = %d, got = %d)", resCustom, resDefaults)
 	}
 }
+
+func TestHOTPString(t *testing.T) {
+	for _, testValue := range hotpTestValues {
+		res := HOTPString(testValue.Secret, testValue.Counter, HOTPOptions{})
+		expected := fmt.Sprintf("%06d", testValue.OTP)
+		if res != expected {
+			t.Errorf("Error in HOTPString for Counter = %d (expected %s, got %s)", testValue.Counter, expected, res)
+		}
+	}
+}
+
+func TestHOTPStringEncoder(t *testing.T) {
+	testValue := hotpTestValues[0]
+
+	res := HOTPString(testValue.Secret, testValue.Counter, HOTPOptions{Encoder: HexEncoder})
+	expected := HexEncoder.Encode(testValue.Truncated, 6)
+	if res != expected {
+		t.Errorf("Error in HOTPStringEncoder (expected = %s, got = %s)", expected, res)
+	}
+}
+
+func TestHOTPStringLeadingZeros(t *testing.T) {
+	// the 8 digits code of counter 0x23523EC starts with a zero
+	res := HOTPString(hotpSecret, 0x23523EC, HOTPOptions{Digits: 8})
+	if res != "07081804" {
+		t.Errorf("Error in HOTPStringLeadingZeros (expected = 07081804, got = %s)", res)
+	}
+}
+
+func TestHOTPRange(t *testing.T) {
+	res := HOTPRange(hotpSecret, 2, 5, HOTPOptions{})
+	if len(res) != 5 {
+		t.Fatalf("Error in HOTPRange (expected 5 codes, got = %d)", len(res))
+	}
+
+	for i, code := range res {
+		expected := HOTPCode(hotpSecret, 2+uint64(i), HOTPOptions{})
+		if code != expected {
+			t.Errorf("Error in HOTPRange (i = %d, expected = %+v, got = %+v)", i, expected, code)
+		}
+	}
+}
+
+func TestHOTPLargeCounter(t *testing.T) {
+	expected := map[uint64]uint{1<<32 + 1: 108930, 1<<64 - 1: 94451}
+	for counter, otp := range expected {
+		res := HOTP(hotpSecret, counter, HOTPOptions{})
+		if res != otp {
+			t.Errorf("Error in HOTPLargeCounter for Counter = %d (expected %d, got %d)", counter, otp, res)
+		}
+	}
+}
+
+func TestHOTPTenDigits(t *testing.T) {
+	for _, testValue := range hotpTestValues {
+		res := HOTP(testValue.Secret, testValue.Counter, HOTPOptions{Digits: 10})
+		if res != testValue.Truncated {
+			t.Errorf("Error in HOTPTenDigits for Counter = %d (expected %d, got %d)", testValue.Counter, testValue.Truncated, res)
+		}
+
+		res = HOTP(testValue.Secret, testValue.Counter, HOTPOptions{Digits: 9})
+		if res != testValue.Truncated%1000000000 {
+			t.Errorf("Error in HOTPNineDigits for Counter = %d (expected %d, got %d)", testValue.Counter, testValue.Truncated%1000000000, res)
+		}
+
+		code := HOTPString(testValue.Secret, testValue.Counter, HOTPOptions{Digits: 10})
+		expected := fmt.Sprintf("%010d", testValue.Truncated)
+		if code != expected {
+			t.Errorf("Error in HOTPStringTenDigits for Counter = %d (expected %s, got %s)", testValue.Counter, expected, code)
+		}
+	}
+}
+
+func TestHOTPTooManyDigits(t *testing.T) {
+	defer func() {
+		if err, _ := recover().(error); !errors.Is(err, ErrInvalidDigits) {
+			t.Errorf("Error in HOTPTooManyDigits (expected = %v, got = %v)", ErrInvalidDigits, err)
+		}
+	}()
+	HOTP(hotpSecret, 0, HOTPOptions{Digits: 11})
+}
+
+func TestValidateDigits(t *testing.T) {
+	for digits := uint(0); digits <= 12; digits++ {
+		err := validateDigits(digits)
+		valid := digits >= 1 && digits <= 10
+		if valid && err != nil || !valid && !errors.Is(err, ErrInvalidDigits) {
+			t.Errorf("Error in ValidateDigits (digits = %d, got = %v)", digits, err)
+		}
+	}
+}
+
+func TestHOTPE(t *testing.T) {
+	testValue := hotpTestValues[0]
+
+	res, err := HOTPE(testValue.Secret, testValue.Counter, HOTPOptions{})
+	expected := HOTPCode(testValue.Secret, testValue.Counter, HOTPOptions{})
+	if err != nil || res != expected {
+		t.Errorf("Error in HOTPE (expected = %+v, got = %+v, err = %v)", expected, res, err)
+	}
+
+	if _, err := HOTPE(nil, testValue.Counter, HOTPOptions{}); !errors.Is(err, ErrInvalidSecret) {
+		t.Errorf("Error in HOTPE (expected = %v, got = %v)", ErrInvalidSecret, err)
+	}
+
+	if _, err := HOTPE(testValue.Secret, testValue.Counter, HOTPOptions{Digits: 11}); !errors.Is(err, ErrInvalidDigits) {
+		t.Errorf("Error in HOTPE (expected = %v, got = %v)", ErrInvalidDigits, err)
+	}
+}
+
+func TestMustHOTP(t *testing.T) {
+	testValue := hotpTestValues[0]
+
+	res := MustHOTP(testValue.Secret, testValue.Counter, HOTPOptions{})
+	if res.Value != "755224" {
+		t.Errorf("Error in MustHOTP (expected = 755224, got = %s)", res.Value)
+	}
+
+	defer func() {
+		if err, _ := recover().(error); !errors.Is(err, ErrInvalidSecret) {
+			t.Errorf("Error in MustHOTP (expected = %v, got = %v)", ErrInvalidSecret, err)
+		}
+	}()
+	MustHOTP(nil, testValue.Counter, HOTPOptions{})
+}
+
+func TestHOTPOptionsValidate(t *testing.T) {
+	if err := (HOTPOptions{}).Validate(); err != nil {
+		t.Errorf("Error in HOTPOptionsValidate (defaults rejected, err = %v)", err)
+	}
+
+	err := HOTPOptions{Digits: 11, Algorithm: md5.New}.Validate()
+	if !errors.Is(err, ErrInvalidDigits) || !errors.Is(err, ErrInvalidAlgorithm) {
+		t.Errorf("Error in HOTPOptionsValidate (expected = %v and %v, got = %v)", ErrInvalidDigits, ErrInvalidAlgorithm, err)
+	}
+}
+
+func TestHOTPHash(t *testing.T) {
+	hashes := map[int]crypto.Hash{20: crypto.SHA1, 32: crypto.SHA256, 64: crypto.SHA512}
+	for i, testValue := range totpTestValues {
+		counter := uint64(testValue.T)
+
+		resAlgorithm := HOTP(testValue.Secret, counter, HOTPOptions{Digits: 8, Algorithm: testValue.Mode})
+		resHash := HOTP(testValue.Secret, counter, HOTPOptions{Digits: 8, Hash: hashes[len(testValue.Secret)]})
+		if resHash != resAlgorithm {
+			t.Errorf("Error in HOTPHash (i = %d, expected = %d, got = %d)", i, resAlgorithm, resHash)
+		}
+	}
+}
+
+func TestHOTPHashValidate(t *testing.T) {
+	invalid := []HOTPOptions{
+		{Hash: crypto.BLAKE2b_256}, // not linked in
+		{Hash: crypto.MD5},
+		{Hash: crypto.SHA1, Algorithm: sha1.New},
+	}
+
+	for i, opts := range invalid {
+		if err := opts.Validate(); !errors.Is(err, ErrInvalidAlgorithm) {
+			t.Errorf("Error in HOTPHashValidate (i = %d, expected = %v, got = %v)", i, ErrInvalidAlgorithm, err)
+		}
+	}
+}
+
+func TestHOTPSHA224SHA384(t *testing.T) {
+	testValue := hotpTestValues[0]
+	expected := map[crypto.Hash]uint{crypto.SHA224: 893239, crypto.SHA384: 502125}
+
+	for h, otp := range expected {
+		res := HOTP(testValue.Secret, testValue.Counter, HOTPOptions{Hash: h})
+		if res != otp {
+			t.Errorf("Error in HOTPSHA224SHA384 (hash = %s, expected = %d, got = %d)", h, otp, res)
+		}
+	}
+}
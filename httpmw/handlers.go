@@ -0,0 +1,194 @@
+package httpmw
+
+import (
+	"encoding/base32"
+	"encoding/json"
+	"errors"
+	"image/png"
+	"io"
+	"math"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/xrjr/otp"
+	"github.com/xrjr/otp/qr"
+	"github.com/xrjr/otp/server"
+)
+
+// UserFunc returns the id of the user of a request, e.g. from its session, and the account name of its keys. It
+// returns ErrNoKey when the request has no user.
+type UserFunc func(r *http.Request) (id string, accountName string, err error)
+
+// EnrollRequest is the request of the enroll handler, also accepted as the code form field. The code of the
+// current key of the user is required when the user already has one.
+type EnrollRequest struct {
+	Code string `json:"code"`
+}
+
+// EnrollResponse is the response of the enroll handler.
+type EnrollResponse struct {
+	URI    string    `json:"uri"`    // otpauth URI provisioning the key
+	Secret string    `json:"secret"` // secret of the key in base32, for manual entry
+	Expiry time.Time `json:"expiry"` // end of the enrollment
+}
+
+// VerifyRequest is the request of the verify handler, also accepted as the code form field.
+type VerifyRequest struct {
+	Code string `json:"code"`
+}
+
+// VerifyResponse is the response of the verify handler to an accepted code.
+type VerifyResponse struct {
+	Verified bool `json:"verified"`
+	// Enrolled reports whether the code activated the key of a pending enrollment. More codes are expected while
+	// the enrollment is pending and Enrolled is false.
+	Enrolled bool `json:"enrolled,omitempty"`
+}
+
+// ErrorResponse is the response of the handlers to refused requests.
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// Handlers serves the flow of Accounts over HTTP:
+//
+//   - POST /otp/enroll starts the enrollment of a new key, requiring the code of an EnrollRequest when the user
+//     already has a key, and returns an EnrollResponse;
+//   - GET /otp/qr.png returns the QR code provisioning the key of the pending enrollment;
+//   - POST /otp/verify checks a code of a VerifyRequest, confirming the pending enrollment if any, and returns a
+//     VerifyResponse.
+//
+// Refused requests get an ErrorResponse, with the status code of Status.
+type Handlers struct {
+	Accounts *server.Accounts
+	User     UserFunc
+	QR       qr.Encoder // encoder of QR codes, /otp/qr.png is not found when nil
+}
+
+// Register registers the handlers on a mux.
+func (h *Handlers) Register(mux *http.ServeMux) {
+	mux.HandleFunc("POST /otp/enroll", h.enroll)
+	mux.HandleFunc("GET /otp/qr.png", h.qr)
+	mux.HandleFunc("POST /otp/verify", h.verify)
+}
+
+// enroll starts the enrollment of a new key, replacing a pending one.
+func (h *Handlers) enroll(w http.ResponseWriter, r *http.Request) {
+	id, accountName, err := h.User(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	// the request may be empty, the code being only required from users with a key
+	var req EnrollRequest
+	if err := readRequest(w, r, &req.Code, &req); err != nil && !errors.Is(err, io.EOF) {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	e, err := h.Accounts.EnrollContext(r.Context(), id, accountName, otp.NormalizeCode(req.Code))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, EnrollResponse{URI: e.URI(), Secret: encodeBase32(e.Device.Key.Secret), Expiry: e.Expiry})
+}
+
+// qr writes the QR code of the pending enrollment.
+func (h *Handlers) qr(w http.ResponseWriter, r *http.Request) {
+	if h.QR == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	id, _, err := h.User(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	e, err := h.Accounts.Store.Enrollment(r.Context(), id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	img, err := qr.EncodeKey(h.QR, e.Device.Key)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Cache-Control", "no-store")
+	png.Encode(w, img)
+}
+
+// verify checks a code, of the pending enrollment if any, or of the key of the user.
+func (h *Handlers) verify(w http.ResponseWriter, r *http.Request) {
+	id, _, err := h.User(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	var req VerifyRequest
+	if err := readRequest(w, r, &req.Code, &req); err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	_, enrolled, err := h.Accounts.VerifyContext(r.Context(), id, otp.NormalizeCode(req.Code))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, VerifyResponse{Verified: true, Enrolled: enrolled})
+}
+
+// maxRequestSize is the maximum size of the body of the requests of the handlers, which only hold a code.
+const maxRequestSize = 4 << 10
+
+// readRequest decodes the JSON body of a request into req, or reads the code field of its form body into code.
+// Bodies larger than maxRequestSize are refused.
+func readRequest(w http.ResponseWriter, r *http.Request, code *string, req any) error {
+	r.Body = http.MaxBytesReader(w, r.Body, maxRequestSize)
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "application/json" {
+		return json.NewDecoder(r.Body).Decode(req)
+	}
+	if err := r.ParseForm(); err != nil {
+		return err
+	}
+	// the URL query isn't read, as it ends up in access logs and browser histories
+	*code = r.PostFormValue("code")
+	return nil
+}
+
+// encodeBase32 returns a secret in base32 without padding, as shown for manual entry.
+func encodeBase32(secret []byte) string {
+	return base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(secret)
+}
+
+// writeJSON writes a JSON response.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes the ErrorResponse of an error, with the status code of Status. The messages of internal
+// errors aren't written, as they may reveal details of the stores.
+func writeError(w http.ResponseWriter, err error) {
+	status := Status(err)
+	if retryAfter, ok := RetryAfter(err); ok {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	}
+
+	message := http.StatusText(status)
+	if status != http.StatusInternalServerError {
+		message = err.Error()
+	}
+	writeJSON(w, status, ErrorResponse{Error: message})
+}
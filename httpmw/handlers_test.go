@@ -0,0 +1,152 @@
+package httpmw
+
+import (
+	"context"
+	"encoding/json"
+	"image"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xrjr/otp"
+	"github.com/xrjr/otp/qr"
+	"github.com/xrjr/otp/server"
+)
+
+func TestHandlers(t *testing.T) {
+	current := now
+	clock := otp.ClockFunc(func() time.Time {
+		return current
+	})
+
+	mux := http.NewServeMux()
+	(&Handlers{
+		Accounts: &server.Accounts{
+			Validator: &server.Validator{Replay: &server.MemoryReplayStore{Clock: clock}, Clock: clock},
+			Store:     &server.MemoryKeyStore{},
+			Template:  otp.Key{Issuer: "Example"},
+		},
+		User: func(r *http.Request) (string, string, error) {
+			if r.Header.Get("User") == "" {
+				return "", "", ErrNoKey
+			}
+			return r.Header.Get("User"), r.Header.Get("User") + "@example.com", nil
+		},
+		QR: qr.EncoderFunc(func(text string) (image.Image, error) {
+			return image.NewGray(image.Rect(0, 0, 1, 1)), nil
+		}),
+	}).Register(mux)
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, path, strings.NewReader(body))
+		r.Header.Set("User", "alice")
+		r.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+		return w
+	}
+
+	// no enrollment nor key yet
+	if w := do("GET", "/otp/qr.png", ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("Error in Handlers (expected = 401, got = %d)", w.Code)
+	}
+
+	w := do("POST", "/otp/enroll", "")
+	var enrollment EnrollResponse
+	if err := json.NewDecoder(w.Body).Decode(&enrollment); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("Error in Handlers (status = %d, err = %v)", w.Code, err)
+	}
+	key, err := otp.ParseURI(enrollment.URI)
+	if err != nil || key.Issuer != "Example" || key.AccountName != "alice@example.com" || !enrollment.Expiry.Equal(now.Add(10*time.Minute)) {
+		t.Fatalf("Error in Handlers (unexpected enrollment %+v, err = %v)", enrollment, err)
+	}
+
+	if w := do("GET", "/otp/qr.png", ""); w.Code != http.StatusOK || w.Header().Get("Content-Type") != "image/png" {
+		t.Errorf("Error in Handlers (expected = 200 image/png, got = %d %s)", w.Code, w.Header().Get("Content-Type"))
+	}
+
+	code := otp.TOTPString(key.Secret, current, otp.TOTPOptions{})
+	tests := []struct {
+		Code   string
+		Status int
+		Body   string
+	}{
+		{"000000", http.StatusUnauthorized, `{"error":"server: invalid code"}`},
+		{code, http.StatusOK, `{"verified":true,"enrolled":true}`},
+		// the code confirming the enrollment stays used
+		{code, http.StatusUnauthorized, `{"error":"server: replayed code"}`},
+		{otp.TOTPString(key.Secret, current.Add(30*time.Second), otp.TOTPOptions{}), http.StatusOK, `{"verified":true}`},
+	}
+	for i, test := range tests {
+		if i == 3 {
+			current = current.Add(30 * time.Second)
+		}
+		w := do("POST", "/otp/verify", `{"code":"`+test.Code+`"}`)
+		if w.Code != test.Status || strings.TrimSpace(w.Body.String()) != test.Body {
+			t.Errorf("Error in Handlers (i = %d, expected = %d %s, got = %d %s)", i, test.Status, test.Body, w.Code, w.Body.String())
+		}
+	}
+
+	// media type parameters, large bodies and codes of the URL query
+	current = current.Add(30 * time.Second)
+	r := httptest.NewRequest("POST", "/otp/verify", strings.NewReader(`{"code":"`+otp.TOTPString(key.Secret, current, otp.TOTPOptions{})+`"}`))
+	r.Header.Set("User", "alice")
+	r.Header.Set("Content-Type", "application/json; charset=utf-8")
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Errorf("Error in Handlers (expected = 200, got = %d %s)", w.Code, w.Body.String())
+	}
+	if w := do("POST", "/otp/verify", `{"code":"`+strings.Repeat(" ", maxRequestSize)+`"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Error in Handlers (expected = 400, got = %d %s)", w.Code, w.Body.String())
+	}
+	current = current.Add(30 * time.Second)
+	r = httptest.NewRequest("POST", "/otp/verify?code="+otp.TOTPString(key.Secret, current, otp.TOTPOptions{}), nil)
+	r.Header.Set("User", "alice")
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Error in Handlers (code of the URL query accepted, got = %d %s)", w.Code, w.Body.String())
+	}
+
+	// a new key is only enrolled with a code of the current key
+	if w := do("POST", "/otp/enroll", ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("Error in Handlers (expected = 401, got = %d %s)", w.Code, w.Body.String())
+	}
+	current = current.Add(30 * time.Second)
+	if w := do("POST", "/otp/enroll", `{"code":"`+otp.TOTPString(key.Secret, current, otp.TOTPOptions{})+`"}`); w.Code != http.StatusOK {
+		t.Errorf("Error in Handlers (expected = 200, got = %d %s)", w.Code, w.Body.String())
+	}
+}
+
+func TestHandlersExpired(t *testing.T) {
+	current := now
+	clock := otp.ClockFunc(func() time.Time {
+		return current
+	})
+	store := &server.MemoryKeyStore{}
+	mux := http.NewServeMux()
+	(&Handlers{
+		Accounts: &server.Accounts{Validator: &server.Validator{Clock: clock}, Store: store},
+		User: func(r *http.Request) (string, string, error) {
+			return "alice", "alice", nil
+		},
+	}).Register(mux)
+
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/otp/enroll", nil))
+	e, _ := store.Enrollment(context.Background(), "alice")
+	current = current.Add(time.Hour)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("POST", "/otp/verify", strings.NewReader("code="+otp.TOTPString(e.Device.Key.Secret, current, otp.TOTPOptions{})))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	mux.ServeHTTP(w, r)
+	if w.Code != http.StatusGone {
+		t.Errorf("Error in HandlersExpired (expected = 410, got = %d)", w.Code)
+	}
+	if _, err := store.Enrollment(context.Background(), "alice"); err != server.ErrNoKey {
+		t.Errorf("Error in HandlersExpired (expired enrollment kept, err = %v)", err)
+	}
+}
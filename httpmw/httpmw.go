@@ -0,0 +1,119 @@
+// Package httpmw protects HTTP endpoints with OTP codes, e.g. to require a second factor on administration
+// endpoints, and serves the enrollment and verification of keys with Handlers.
+//
+//	mux.Handle("/admin/", httpmw.Require(httpmw.Options{
+//		Validator: validator,
+//		Key: func(r *http.Request) (string, otp.Key, error) {
+//			user := userOf(r)
+//			return user.ID, user.OTPKey, nil
+//		},
+//	})(adminHandler))
+package httpmw
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/xrjr/otp"
+	"github.com/xrjr/otp/server"
+)
+
+// ErrNoKey is returned by a KeyFunc or a UserFunc when the request has no user, or the user has no key, so that
+// the request is refused as unauthorized, as with server.ErrNoKey.
+var ErrNoKey = errors.New("httpmw: no key")
+
+// KeyFunc returns the key of the user of a request, and its id in the stores of the validator.
+type KeyFunc func(r *http.Request) (id string, key otp.Key, err error)
+
+// Options configures the middleware returned by Require.
+type Options struct {
+	Validator server.Verifier // validator of the codes, e.g. a *server.Validator whose ReplayStore prevents replays
+	Key       KeyFunc         // key of the user of a request, required
+
+	Header string // header holding the code, defaults to "X-OTP"
+	Field  string // field of the form body holding the code when the header is missing, defaults to "otp"
+
+	// Error writes the response of refused requests, with the status code chosen by the middleware. It defaults
+	// to http.Error with the status text.
+	Error func(w http.ResponseWriter, r *http.Request, status int, err error)
+}
+
+// Require returns a middleware serving the requests holding a valid code of the key of their user, and refusing
+// the others: with 401 Unauthorized when the code is missing, invalid or replayed, or when the KeyFunc returns
+// ErrNoKey, with 429 Too Many Requests and a Retry-After header when the user is rate limited or locked out, and
+// with 500 Internal Server Error on other errors.
+func Require(opts Options) func(http.Handler) http.Handler {
+	if opts.Header == "" {
+		opts.Header = "X-OTP"
+	}
+	if opts.Field == "" {
+		opts.Field = "otp"
+	}
+	if opts.Error == nil {
+		opts.Error = func(w http.ResponseWriter, r *http.Request, status int, err error) {
+			http.Error(w, http.StatusText(status), status)
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			code := r.Header.Get(opts.Header)
+			if code == "" {
+				// the URL query isn't read, as it ends up in access logs and browser histories
+				code = r.PostFormValue(opts.Field)
+			}
+			if code == "" {
+				opts.Error(w, r, http.StatusUnauthorized, server.ErrInvalidCode)
+				return
+			}
+
+			id, key, err := opts.Key(r)
+			if err == nil {
+				_, err = opts.Validator.VerifyContext(r.Context(), id, key, otp.NormalizeCode(code))
+			}
+			if err != nil {
+				status := Status(err)
+				if retryAfter, ok := RetryAfter(err); ok {
+					w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+				}
+				opts.Error(w, r, status, err)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Status returns the HTTP status code of an error of a verification: 401 Unauthorized for missing, invalid or
+// replayed codes, 429 Too Many Requests for rate limited or locked out users, 410 Gone for expired enrollments,
+// and 500 Internal Server Error for other errors.
+func Status(err error) int {
+	switch {
+	case errors.Is(err, server.ErrInvalidCode), errors.Is(err, server.ErrReplayedCode),
+		errors.Is(err, ErrNoKey), errors.Is(err, server.ErrNoKey), errors.Is(err, server.ErrCodeRequired):
+		return http.StatusUnauthorized
+	case errors.Is(err, server.ErrRateLimited), errors.Is(err, server.ErrLockedOut):
+		return http.StatusTooManyRequests
+	case errors.Is(err, server.ErrEnrollmentExpired):
+		return http.StatusGone
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// RetryAfter returns the time left before a rate limited or locked out user may retry, when known.
+func RetryAfter(err error) (time.Duration, bool) {
+	var rateLimited *server.RateLimitedError
+	if errors.As(err, &rateLimited) {
+		return rateLimited.RetryAfter, true
+	}
+	var lockedOut *server.LockedOutError
+	if errors.As(err, &lockedOut) {
+		return lockedOut.RetryAfter, true
+	}
+	return 0, false
+}
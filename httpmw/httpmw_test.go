@@ -0,0 +1,127 @@
+package httpmw
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xrjr/otp"
+	"github.com/xrjr/otp/server"
+)
+
+// time of a test value of rfc 6238, whose code is 07081804
+var now = time.Unix(1111111109, 0)
+
+var key = otp.Key{Type: otp.TypeTOTP, Secret: []byte("12345678901234567890"), Digits: 8}
+
+func newHandler(v *server.Validator) http.Handler {
+	return Require(Options{
+		Validator: v,
+		Key: func(r *http.Request) (string, otp.Key, error) {
+			if r.URL.Query().Get("user") != "alice" {
+				return "", otp.Key{}, ErrNoKey
+			}
+			return "alice", key, nil
+		},
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+}
+
+// request returns a request of a user, with a code in the X-OTP header unless it is empty.
+func request(user, code string) *http.Request {
+	r := httptest.NewRequest("GET", "/?user="+user, nil)
+	if code != "" {
+		r.Header.Set("X-OTP", code)
+	}
+	return r
+}
+
+func TestRequire(t *testing.T) {
+	clock := otp.ClockFunc(func() time.Time {
+		return now
+	})
+	h := newHandler(&server.Validator{
+		Replay:  &server.MemoryReplayStore{Clock: clock},
+		Limiter: &server.TokenBucket{Store: &server.MemoryBucketStore{}, Burst: 4, Clock: clock},
+		Clock:   clock,
+	})
+
+	tests := []struct {
+		Name       string
+		Request    *http.Request
+		Status     int
+		RetryAfter string
+	}{
+		{"no code", request("alice", ""), http.StatusUnauthorized, ""},
+		{"no key", request("bob", "07081804"), http.StatusUnauthorized, ""},
+		{"invalid code", request("alice", "12345678"), http.StatusUnauthorized, ""},
+		{"valid code", request("alice", "0708-1804"), http.StatusOK, ""},
+		{"replayed code", request("alice", "07081804"), http.StatusUnauthorized, ""},
+		{"replayed code", request("alice", "07081804"), http.StatusUnauthorized, ""},
+		{"rate limited", request("alice", "07081804"), http.StatusTooManyRequests, "30"},
+	}
+
+	for i, test := range tests {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, test.Request)
+		if w.Code != test.Status || w.Header().Get("Retry-After") != test.RetryAfter {
+			t.Errorf("Error in Require (i = %d, name = %s, expected = %d %q, got = %d %q)", i, test.Name, test.Status, test.RetryAfter, w.Code, w.Header().Get("Retry-After"))
+		}
+	}
+}
+
+func TestRequireSources(t *testing.T) {
+	clock := otp.ClockFunc(func() time.Time {
+		return now
+	})
+
+	header := httptest.NewRequest("GET", "/?user=alice", nil)
+	header.Header.Set("X-OTP", "07081804")
+
+	form := httptest.NewRequest("POST", "/?user=alice", strings.NewReader(url.Values{"otp": {"07081804"}}.Encode()))
+	form.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	for i, r := range []*http.Request{header, form} {
+		w := httptest.NewRecorder()
+		newHandler(&server.Validator{Clock: clock}).ServeHTTP(w, r)
+		if w.Code != http.StatusOK || w.Body.String() != "ok" {
+			t.Errorf("Error in RequireSources (i = %d, expected = 200, got = %d)", i, w.Code)
+		}
+	}
+
+	// codes in the URL query are refused, as they would be logged
+	w := httptest.NewRecorder()
+	newHandler(&server.Validator{Clock: clock}).ServeHTTP(w, httptest.NewRequest("POST", "/?user=alice&otp=07081804", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Error in RequireSources (expected = 401, got = %d)", w.Code)
+	}
+}
+
+func TestStatus(t *testing.T) {
+	tests := []struct {
+		Err    error
+		Status int
+	}{
+		{server.ErrInvalidCode, http.StatusUnauthorized},
+		{server.ErrReplayedCode, http.StatusUnauthorized},
+		{ErrNoKey, http.StatusUnauthorized},
+		{server.ErrRateLimited, http.StatusTooManyRequests},
+		{&server.LockedOutError{RetryAfter: time.Minute}, http.StatusTooManyRequests},
+		{errors.New("database unavailable"), http.StatusInternalServerError},
+	}
+
+	for i, test := range tests {
+		if res := Status(test.Err); res != test.Status {
+			t.Errorf("Error in Status (i = %d, expected = %d, got = %d)", i, test.Status, res)
+		}
+	}
+
+	if retryAfter, ok := RetryAfter(&server.LockedOutError{RetryAfter: time.Minute}); !ok || retryAfter != time.Minute {
+		t.Errorf("Error in Status (expected = 1m0s, got = %s)", retryAfter)
+	}
+}
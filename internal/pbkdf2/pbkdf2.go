@@ -0,0 +1,40 @@
+// Package pbkdf2 implements PBKDF2-HMAC-SHA256, shared by the secret derivation of the otp package and the key
+// derivation of the vault package.
+package pbkdf2
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"runtime"
+)
+
+// Key derives a key of keyLen bytes from a password, as defined by section 5.2 of rfc 8018 with HMAC-SHA256.
+func Key(password, salt []byte, iterations, keyLen int) []byte {
+	prf := hmac.New(sha256.New, password)
+	size := prf.Size()
+
+	dk := make([]byte, 0, (keyLen+size-1)/size*size)
+	u := make([]byte, size)
+	for block := uint32(1); len(dk) < keyLen; block++ {
+		prf.Reset()
+		prf.Write(salt)
+		prf.Write(binary.BigEndian.AppendUint32(nil, block))
+		dk = prf.Sum(dk)
+
+		t := dk[len(dk)-size:]
+		copy(u, t)
+		for range iterations - 1 {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+			for i := range u {
+				t[i] ^= u[i]
+			}
+		}
+	}
+	// as otp.WipeBytes, which can't be imported here
+	clear(u)
+	runtime.KeepAlive(u)
+	return dk[:keyLen]
+}
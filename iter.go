@@ -0,0 +1,21 @@
+package otp
+
+import (
+	"iter"
+	"time"
+)
+
+// TOTPCodes returns the codes of successive time periods, starting with the one of a given time, along with the
+// start of their time period. The sequence is infinite.
+func TOTPCodes(key []byte, from time.Time, opts TOTPOptions) iter.Seq2[time.Time, Code] {
+	opts = opts.withDefaults()
+
+	return func(yield func(time.Time, Code) bool) {
+		for t := from; ; t = t.Add(time.Duration(opts.Period) * time.Second) {
+			code := TOTPCode(key, t, opts)
+			if !yield(code.ValidFrom, code) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package otp
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTOTPCodes(t *testing.T) {
+	opts := TOTPOptions{Period: 60, Step: 1}
+	from := time.Unix(1111111109, 0)
+
+	i := 0
+	for start, code := range TOTPCodes(totpSecretSha1, from, opts) {
+		expected := TOTPCode(totpSecretSha1, from.Add(time.Duration(i)*time.Minute), opts)
+		if code != expected || !start.Equal(expected.ValidFrom) {
+			t.Errorf("Error in TOTPCodes (i = %d, expected = %+v, got = %+v)", i, expected, code)
+		}
+
+		i++
+		if i == 5 {
+			break
+		}
+	}
+
+	if i != 5 {
+		t.Errorf("Error in TOTPCodes (expected 5 codes, got = %d)", i)
+	}
+}
@@ -0,0 +1,29 @@
+// Package jsotp exposes the code generation of otp to JavaScript, so that browser authenticators and Electron
+// applications compute their codes with the same code as Go services. It is only available with GOOS=js
+// GOARCH=wasm:
+//
+//	GOOS=js GOARCH=wasm go build -o otp.wasm github.com/xrjr/otp/cmd/otp-wasm
+//	cp "$(go env GOROOT)/lib/wasm/wasm_exec.js" .
+//
+// Register sets an object of functions on the global object, named otp by cmd/otp-wasm:
+//
+//	const go = new Go();
+//	const { instance } = await WebAssembly.instantiateStreaming(fetch("otp.wasm"), go.importObject);
+//	go.run(instance);
+//
+//	const key = otp.parseURI("otpauth://totp/Example:alice?secret=JBSWY3DPEHPK3PXP");
+//	const { code, validUntil } = otp.totp("otpauth://totp/Example:alice?secret=JBSWY3DPEHPK3PXP");
+//
+// The functions are:
+//
+//   - totp(uri, [time]) returns {code, counter, validFrom, validUntil} for the key of an otpauth URI, at a time given
+//     as a Date or milliseconds since the epoch, now by default;
+//   - hotp(uri, [counter]) returns {code, counter} for the key of an otpauth URI, at the counter of the URI by
+//     default;
+//   - parseURI(uri) returns {type, issuer, accountName, secret, algorithm, digits, period, counter}, with the
+//     secret in base32;
+//   - generateSecret([size]) returns a random secret of size bytes in base32, 20 by default.
+//
+// Times are in milliseconds since the epoch, as Date.now. Functions don't throw: errors are returned as {error},
+// with the message of the error.
+package jsotp
@@ -0,0 +1,130 @@
+//go:build js && wasm
+
+package jsotp
+
+import (
+	"encoding/base32"
+	"syscall/js"
+	"time"
+
+	"github.com/xrjr/otp"
+)
+
+// Register sets the object of the functions on the global object, under name.
+func Register(name string) {
+	js.Global().Set(name, js.ValueOf(map[string]any{
+		"totp":           js.FuncOf(totp),
+		"hotp":           js.FuncOf(hotp),
+		"parseURI":       js.FuncOf(parseURI),
+		"generateSecret": js.FuncOf(generateSecret),
+	}))
+}
+
+// totp implements totp(uri, [time]).
+func totp(this js.Value, args []js.Value) any {
+	key, err := parseKey(args)
+	if err != nil {
+		return errorValue(err)
+	}
+	opts, err := key.TOTPOptions()
+	if err != nil {
+		return errorValue(err)
+	}
+
+	t := time.Now()
+	if len(args) > 1 {
+		switch arg := args[1]; {
+		case arg.Type() == js.TypeNumber:
+			t = time.UnixMilli(int64(arg.Float()))
+		case arg.InstanceOf(js.Global().Get("Date")):
+			t = time.UnixMilli(int64(arg.Call("getTime").Float()))
+		}
+	}
+
+	code, err := otp.TOTPE(key.Secret, t, opts)
+	if err != nil {
+		return errorValue(err)
+	}
+	return map[string]any{
+		"code":       code.Value,
+		"counter":    float64(code.Counter),
+		"validFrom":  code.ValidFrom.UnixMilli(),
+		"validUntil": code.ValidUntil.UnixMilli(),
+	}
+}
+
+// hotp implements hotp(uri, [counter]).
+func hotp(this js.Value, args []js.Value) any {
+	key, err := parseKey(args)
+	if err != nil {
+		return errorValue(err)
+	}
+	opts, err := key.HOTPOptions()
+	if err != nil {
+		return errorValue(err)
+	}
+
+	counter := key.Counter
+	if len(args) > 1 && args[1].Type() == js.TypeNumber {
+		counter = uint64(args[1].Float())
+	}
+
+	code, err := otp.HOTPE(key.Secret, counter, opts)
+	if err != nil {
+		return errorValue(err)
+	}
+	return map[string]any{
+		"code":    code.Value,
+		"counter": float64(code.Counter),
+	}
+}
+
+// parseURI implements parseURI(uri).
+func parseURI(this js.Value, args []js.Value) any {
+	key, err := parseKey(args)
+	if err != nil {
+		return errorValue(err)
+	}
+	return map[string]any{
+		"type":        key.Type,
+		"issuer":      key.Issuer,
+		"accountName": key.AccountName,
+		"secret":      encodeBase32(key.Secret),
+		"algorithm":   key.Algorithm,
+		"digits":      key.Digits,
+		"period":      key.Period,
+		"counter":     float64(key.Counter),
+	}
+}
+
+// generateSecret implements generateSecret([size]).
+func generateSecret(this js.Value, args []js.Value) any {
+	size := 20
+	if len(args) > 0 && args[0].Type() == js.TypeNumber {
+		size = args[0].Int()
+	}
+
+	secret, err := otp.GenerateSecret(size)
+	if err != nil {
+		return errorValue(err)
+	}
+	return encodeBase32(secret)
+}
+
+// parseKey parses the otpauth URI of the first argument.
+func parseKey(args []js.Value) (otp.Key, error) {
+	if len(args) == 0 || args[0].Type() != js.TypeString {
+		return otp.ParseURI("")
+	}
+	return otp.ParseURI(args[0].String())
+}
+
+// errorValue returns the value of an error, as returned by the functions.
+func errorValue(err error) any {
+	return map[string]any{"error": err.Error()}
+}
+
+// encodeBase32 returns a secret in base32 without padding, as in otpauth URIs.
+func encodeBase32(secret []byte) string {
+	return base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(secret)
+}
@@ -0,0 +1,64 @@
+//go:build js && wasm
+
+package jsotp
+
+import (
+	"syscall/js"
+	"testing"
+)
+
+func TestTOTP(t *testing.T) {
+	Register("otp")
+	uri := "otpauth://totp/Example:alice?secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ&digits=8"
+
+	res := js.Global().Get("otp").Call("totp", uri, 59000)
+	if code := res.Get("code").String(); code != "94287082" {
+		t.Errorf("Error in totp (expected = 94287082, got = %s)", code)
+	}
+	if from, until := res.Get("validFrom").Int(), res.Get("validUntil").Int(); from != 30000 || until != 60000 {
+		t.Errorf("Error in totp (expected = 30000 and 60000, got = %d and %d)", from, until)
+	}
+
+	date := js.Global().Get("Date").New(1111111109000)
+	if code := js.Global().Get("otp").Call("totp", uri, date).Get("code").String(); code != "07081804" {
+		t.Errorf("Error in totp (expected = 07081804, got = %s)", code)
+	}
+
+	if msg := js.Global().Get("otp").Call("totp", "https://example.com").Get("error"); msg.Type() != js.TypeString {
+		t.Errorf("Error in totp (expected = error, got = %v)", msg)
+	}
+}
+
+func TestHOTP(t *testing.T) {
+	Register("otp")
+	uri := "otpauth://hotp/alice?secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ&counter=1"
+
+	if code := js.Global().Get("otp").Call("hotp", uri).Get("code").String(); code != "287082" {
+		t.Errorf("Error in hotp (expected = 287082, got = %s)", code)
+	}
+	if code := js.Global().Get("otp").Call("hotp", uri, 9).Get("code").String(); code != "520489" {
+		t.Errorf("Error in hotp (expected = 520489, got = %s)", code)
+	}
+}
+
+func TestParseURI(t *testing.T) {
+	Register("otp")
+
+	key := js.Global().Get("otp").Call("parseURI", "otpauth://totp/Example:alice?secret=JBSWY3DPEHPK3PXP&period=60")
+	for name, expected := range map[string]any{"issuer": "Example", "accountName": "alice", "secret": "JBSWY3DPEHPK3PXP", "period": 60} {
+		if got := key.Get(name); !got.Equal(js.ValueOf(expected)) {
+			t.Errorf("Error in parseURI (%s, expected = %v, got = %v)", name, expected, got)
+		}
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	Register("otp")
+
+	if secret := js.Global().Get("otp").Call("generateSecret").String(); len(secret) != 32 {
+		t.Errorf("Error in generateSecret (expected = 32 characters, got = %q)", secret)
+	}
+	if msg := js.Global().Get("otp").Call("generateSecret", 4).Get("error"); msg.Type() != js.TypeString {
+		t.Errorf("Error in generateSecret (expected = error, got = %v)", msg)
+	}
+}
@@ -0,0 +1,51 @@
+package otp
+
+import (
+	"fmt"
+
+	"github.com/xrjr/otp/internal/pbkdf2"
+)
+
+// DefaultKDFIterations is the default number of iterations of PBKDF2-HMAC-SHA256, as recommended by OWASP.
+const DefaultKDFIterations = 600_000
+
+// KDFParams are the parameters of DeriveSecret.
+type KDFParams struct {
+	Iterations int // iterations of PBKDF2-HMAC-SHA256, defaults to DefaultKDFIterations
+	Length     int // length of the secret in bytes, at least MinSecretLength, defaults to 20 (160 bits)
+
+	// Func derives the secret instead of PBKDF2, e.g. Argon2id which isn't available in the standard library:
+	//
+	//	func(passphrase, salt []byte, length int) []byte {
+	//		return argon2.IDKey(passphrase, salt, 1, 64*1024, 4, uint32(length))
+	//	}
+	Func func(passphrase, salt []byte, length int) []byte
+}
+
+// DeriveSecret derives a secret from a passphrase and a salt, for deployments provisioning the secrets of their
+// keys deterministically from a master secret, e.g. with the id of the user as salt. It returns ErrInvalidSecret if
+// the length is shorter than MinSecretLength.
+//
+// Anyone knowing the passphrase derives the secrets of every key, and the secrets of a leaked passphrase can't be
+// replaced without changing the salts: random secrets of GenerateSecret should be preferred when they can be
+// stored. The passphrase should be a random master secret rather than a password, as the codes of a key let
+// attackers check guesses of the passphrase offline.
+func DeriveSecret(passphrase, salt []byte, params KDFParams) ([]byte, error) {
+	if params.Iterations == 0 {
+		params.Iterations = DefaultKDFIterations
+	}
+	if params.Length == 0 {
+		params.Length = 20
+	}
+	if params.Iterations < 0 {
+		return nil, fmt.Errorf("otp: invalid iterations: %d is negative", params.Iterations)
+	}
+	if params.Length < MinSecretLength {
+		return nil, fmt.Errorf("%w: %d bytes is shorter than %d", ErrInvalidSecret, params.Length, MinSecretLength)
+	}
+
+	if params.Func != nil {
+		return params.Func(passphrase, salt, params.Length), nil
+	}
+	return pbkdf2.Key(passphrase, salt, params.Iterations, params.Length), nil
+}
@@ -0,0 +1,53 @@
+package otp
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+func TestDeriveSecret(t *testing.T) {
+	// test vectors of PBKDF2-HMAC-SHA256 from rfc 7914 and the draft-josefsson-pbkdf2-test-vectors
+	tests := []struct {
+		password, salt string
+		params         KDFParams
+		expected       string
+	}{
+		{"password", "salt", KDFParams{Iterations: 1, Length: 32}, "120fb6cffcf8b32c43e7225256c4f837a86548c92ccc35480805987cb70be17b"},
+		{"password", "salt", KDFParams{Iterations: 2, Length: 32}, "ae4d0c95af6b46d32d0adff928f06dd02a303f8ef3c251dfd6e2d85a95474c43"},
+		{"password", "salt", KDFParams{Iterations: 4096, Length: 32}, "c5e478d59288c841aa530db6845c4c8d962893a001ce4e11a4963873aa98134a"},
+		{"password", "salt", KDFParams{Iterations: 4096}, "c5e478d59288c841aa530db6845c4c8d962893a0"},
+		{"passwd", "salt", KDFParams{Iterations: 1, Length: 64}, "55ac046e56e3089fec1691c22544b605f94185216dde0465e68b9d57c20dacbc49ca9cccf179b645991664b39d77ef317c71b845b1e30bd509112041d3a19783"},
+	}
+
+	for i, test := range tests {
+		secret, err := DeriveSecret([]byte(test.password), []byte(test.salt), test.params)
+		if got := hex.EncodeToString(secret); got != test.expected || err != nil {
+			t.Errorf("Error in DeriveSecret (i = %d, expected = %s, got = %s, err = %v)", i, test.expected, got, err)
+		}
+	}
+
+	var length int
+	derived, _ := DeriveSecret([]byte("master"), []byte("alice"), KDFParams{Func: func(passphrase, salt []byte, n int) []byte {
+		length = n
+		return bytes.Repeat([]byte{1}, n)
+	}})
+	if length != 20 || len(derived) != 20 {
+		t.Errorf("Error in DeriveSecret (expected = 20 bytes, got = %d)", length)
+	}
+}
+
+func TestDeriveSecretInvalid(t *testing.T) {
+	for i, params := range []KDFParams{{Length: 10}, {Length: 8, Func: func(passphrase, salt []byte, length int) []byte {
+		return make([]byte, length)
+	}}} {
+		if _, err := DeriveSecret([]byte("master"), []byte("alice"), params); !errors.Is(err, ErrInvalidSecret) {
+			t.Errorf("Error in DeriveSecretInvalid (i = %d, expected = %v, got = %v)", i, ErrInvalidSecret, err)
+		}
+	}
+
+	if secret, err := DeriveSecret([]byte("master"), []byte("alice"), KDFParams{Iterations: -1}); err == nil || secret != nil {
+		t.Errorf("Error in DeriveSecretInvalid (expected error, got = %x)", secret)
+	}
+}
@@ -0,0 +1,215 @@
+package otp
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"maps"
+)
+
+// Key types, as used by the type of Key URIs.
+const (
+	TypeHOTP = "hotp"
+	TypeTOTP = "totp"
+)
+
+// MinSecretLength is the minimum length in bytes of secrets required by section 4 of rfc 4226 (128 bits).
+const MinSecretLength = 16
+
+// Key holds the parameters of an OTP account, as provisioned to authenticator applications.
+type Key struct {
+	Type        string // TypeHOTP or TypeTOTP
+	Issuer      string // provider or service of the account
+	AccountName string // name of the account, e.g. an email address
+	Secret      []byte
+	Algorithm   string // algorithm name, as registered with RegisterAlgorithm (e.g. "SHA1")
+	Digits      uint
+	Period      int    // time period in seconds, TOTP only
+	Counter     uint64 // initial counter, HOTP only
+	Encoding    string // encoding of the secret in URIs: EncodingBase32 (default), EncodingHex or EncodingBase64
+
+	// Params holds the parameters of the URI the key was parsed from which aren't part of the Key URI format,
+	// such as image, so that they are written back by URI.
+	Params map[string]string
+}
+
+// Clone returns a copy of the key which doesn't share its secret with k.
+func (k Key) Clone() Key {
+	k.Secret = bytes.Clone(k.Secret)
+	k.Params = maps.Clone(k.Params)
+	return k
+}
+
+// Zero overwrites the secret of the key with zeros, as WipeBytes does, and removes it from the key, for
+// applications done with a key. Copies of the key sharing its secret, unlike the ones made by Clone, are wiped
+// as well.
+func (k *Key) Zero() {
+	WipeBytes(k.Secret)
+	k.Secret = nil
+}
+
+// Equal reports whether two keys have the same fields. Secrets are compared in constant time.
+func (k Key) Equal(other Key) bool {
+	return subtle.ConstantTimeCompare(k.Secret, other.Secret) == 1 &&
+		k.Type == other.Type &&
+		k.Issuer == other.Issuer &&
+		k.AccountName == other.AccountName &&
+		k.Algorithm == other.Algorithm &&
+		k.Digits == other.Digits &&
+		k.Period == other.Period &&
+		k.Counter == other.Counter &&
+		k.Encoding == other.Encoding &&
+		maps.Equal(k.Params, other.Params)
+}
+
+// HOTPOptions returns the options computing the codes of the key. Unset fields are left to their defaults.
+func (k Key) HOTPOptions() (HOTPOptions, error) {
+	opts := HOTPOptions{Digits: k.Digits}
+	if k.Algorithm != "" {
+		// hash functions with a crypto.Hash value are set by Hash, so that their hash instances are pooled
+		if h, ok := AlgorithmByName(k.Algorithm); ok {
+			opts.Hash = h
+		} else if fn, ok := AlgorithmFuncByName(k.Algorithm); ok {
+			opts.Algorithm = fn
+		} else {
+			return HOTPOptions{}, fmt.Errorf("%w: %s is not registered", ErrInvalidAlgorithm, k.Algorithm)
+		}
+	}
+	return opts, opts.Validate()
+}
+
+// TOTPOptions returns the options computing the codes of a totp key. Unset fields are left to their defaults.
+func (k Key) TOTPOptions() (TOTPOptions, error) {
+	hotpOpts, err := k.HOTPOptions()
+	if err != nil {
+		return TOTPOptions{}, err
+	}
+
+	opts := TOTPOptions{HOTPOptions: hotpOpts, Period: k.Period}
+	return opts, opts.Validate()
+}
+
+// Generator returns the Generator of the key, e.g. of a parsed otpauth URI. TOTP codes are computed by TOTPMAC with
+// the Generator and the options of TOTPOptions.
+func (k Key) Generator() (*Generator, error) {
+	opts, err := k.HOTPOptions()
+	if err != nil {
+		return nil, err
+	}
+	return NewGenerator(k.Secret, opts)
+}
+
+// Token returns the Token of a hotp key, whose next code is the one of the counter of the key.
+func (k Key) Token() (*Token, error) {
+	if k.Type != TypeHOTP {
+		return nil, fmt.Errorf("%w: %q is not %s", ErrInvalidType, k.Type, TypeHOTP)
+	}
+	opts, err := k.HOTPOptions()
+	if err != nil {
+		return nil, err
+	}
+	return NewToken(k.Secret, k.Counter, opts)
+}
+
+// String returns the fields of the key, with the secret masked so that logged keys don't leak it.
+func (k Key) String() string {
+	return fmt.Sprintf("{Type: %s, Issuer: %s, AccountName: %s, Secret: %s, Algorithm: %s, Digits: %d, Period: %d, Counter: %d, Encoding: %s, Params: %v}",
+		k.Type, k.Issuer, k.AccountName, maskSecret(k.Secret), k.Algorithm, k.Digits, k.Period, k.Counter, k.Encoding, k.Params)
+}
+
+// GoString returns the fields of the key as a Go literal, with the secret masked as with String.
+func (k Key) GoString() string {
+	return fmt.Sprintf("otp.Key{Type:%q, Issuer:%q, AccountName:%q, Secret:%q, Algorithm:%q, Digits:%d, Period:%d, Counter:%d, Encoding:%q, Params:%#v}",
+		k.Type, k.Issuer, k.AccountName, maskSecret(k.Secret), k.Algorithm, k.Digits, k.Period, k.Counter, k.Encoding, k.Params)
+}
+
+// Fingerprint identifies the secret of the key, e.g. in logs and audit trails, without revealing it: it is the
+// first 8 bytes of the sha256 of the secret, in hex.
+func (k Key) Fingerprint() string {
+	sum := sha256.Sum256(k.Secret)
+	return hex.EncodeToString(sum[:8])
+}
+
+// maskSecret returns the first 4 characters of the base32 encoding of a secret, followed by an ellipsis.
+func maskSecret(secret []byte) string {
+	if len(secret) == 0 {
+		return ""
+	}
+
+	s := base32NoPadding.EncodeToString(secret[:min(len(secret), 3)])
+	return s[:min(len(s), 4)] + "…"
+}
+
+// Validate checks the key, and reports all the problems found. It is stricter than ParseURI and
+// UnmarshalJSON: the secret must have at least MinSecretLength bytes, the hash function must be usable, and
+// Period and Counter must only be set for TOTP and HOTP keys respectively.
+func (k Key) Validate() error {
+	return k.ValidateMinSecretLength(MinSecretLength)
+}
+
+// ValidateMinSecretLength checks the key as Validate does, but requires minSecretLength bytes of secret instead of
+// MinSecretLength, for policies of their own. The length isn't checked when minSecretLength is 0, while an empty
+// secret is always refused.
+func (k Key) ValidateMinSecretLength(minSecretLength int) error {
+	errs := []error{k.validate()}
+
+	if len(k.Secret) != 0 && len(k.Secret) < minSecretLength {
+		errs = append(errs, fmt.Errorf("%w: %d bytes is shorter than %d bytes", ErrInvalidSecret, len(k.Secret), minSecretLength))
+	}
+
+	// hash functions with a crypto.Hash value may be registered without being linked in the binary, e.g. BLAKE2
+	if h, ok := AlgorithmByName(k.Algorithm); ok {
+		errs = append(errs, HOTPOptions{Hash: h}.Validate())
+	} else if fn, ok := AlgorithmFuncByName(k.Algorithm); ok {
+		errs = append(errs, HOTPOptions{Algorithm: fn}.Validate())
+	}
+
+	if k.Type == TypeHOTP && k.Period != 0 {
+		errs = append(errs, fmt.Errorf("%w: period is set on a %s key", ErrInvalidPeriod, TypeHOTP))
+	}
+
+	if k.Type == TypeTOTP && k.Counter != 0 {
+		errs = append(errs, fmt.Errorf("%w: counter is set on a %s key", ErrInvalidCounter, TypeTOTP))
+	}
+
+	return errors.Join(errs...)
+}
+
+// validate checks the fields of the key, and reports all the problems found.
+// Algorithm, Digits and Period are valid when unset, as they are defaulted.
+func (k Key) validate() error {
+	var errs []error
+
+	if k.Type != TypeHOTP && k.Type != TypeTOTP {
+		errs = append(errs, fmt.Errorf("%w: %q is neither %s nor %s", ErrInvalidType, k.Type, TypeHOTP, TypeTOTP))
+	}
+
+	if len(k.Secret) == 0 {
+		errs = append(errs, ErrInvalidSecret)
+	}
+
+	if k.Algorithm != "" {
+		if _, ok := AlgorithmFuncByName(k.Algorithm); !ok {
+			errs = append(errs, fmt.Errorf("%w: %s is not registered", ErrInvalidAlgorithm, k.Algorithm))
+		}
+	}
+
+	if k.Digits != 0 {
+		errs = append(errs, validateDigits(k.Digits))
+	}
+
+	if k.Period < 0 {
+		errs = append(errs, fmt.Errorf("%w: %d is negative", ErrInvalidPeriod, k.Period))
+	}
+
+	switch k.Encoding {
+	case "", EncodingBase32, EncodingHex, EncodingBase64:
+	default:
+		errs = append(errs, fmt.Errorf("%w: unknown encoding %q", ErrInvalidSecretEncoding, k.Encoding))
+	}
+
+	return errors.Join(errs...)
+}
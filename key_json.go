@@ -0,0 +1,72 @@
+//go:build !tinygo && !otpcore
+
+package otp
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// jsonKey is the JSON representation of a Key.
+type jsonKey struct {
+	Type        string            `json:"type"`
+	Issuer      string            `json:"issuer,omitempty"`
+	AccountName string            `json:"account_name,omitempty"`
+	Secret      string            `json:"secret"`
+	Algorithm   string            `json:"algorithm,omitempty"`
+	Digits      uint              `json:"digits,omitempty"`
+	Period      int               `json:"period,omitempty"`
+	Counter     uint64            `json:"counter,omitempty"`
+	Encoding    string            `json:"encoding,omitempty"`
+	Params      map[string]string `json:"params,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler. The secret is encoded in base32, without padding.
+func (k Key) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonKey{
+		Type:        k.Type,
+		Issuer:      k.Issuer,
+		AccountName: k.AccountName,
+		Secret:      base32NoPadding.EncodeToString(k.Secret),
+		Algorithm:   k.Algorithm,
+		Digits:      k.Digits,
+		Period:      k.Period,
+		Counter:     k.Counter,
+		Encoding:    k.Encoding,
+		Params:      k.Params,
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler. Unknown fields and invalid values are rejected.
+func (k *Key) UnmarshalJSON(data []byte) error {
+	var jk jsonKey
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&jk); err != nil {
+		return err
+	}
+
+	secret, err := ParseSecret(jk.Secret)
+	if err != nil {
+		return err
+	}
+
+	res := Key{
+		Type:        jk.Type,
+		Issuer:      jk.Issuer,
+		AccountName: jk.AccountName,
+		Secret:      secret,
+		Algorithm:   jk.Algorithm,
+		Digits:      jk.Digits,
+		Period:      jk.Period,
+		Counter:     jk.Counter,
+		Encoding:    jk.Encoding,
+		Params:      jk.Params,
+	}
+	if err := res.validate(); err != nil {
+		return err
+	}
+
+	*k = res
+	return nil
+}
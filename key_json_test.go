@@ -0,0 +1,55 @@
+//go:build !tinygo && !otpcore
+
+package otp
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestKeyJSON(t *testing.T) {
+	data, err := json.Marshal(testKey)
+	if err != nil {
+		t.Fatalf("Error in KeyJSON (err = %v)", err)
+	}
+
+	expected := `{"type":"totp","issuer":"Example","account_name":"alice@example.com","secret":"GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ","algorithm":"SHA1","digits":6,"period":30}`
+	if string(data) != expected {
+		t.Errorf("Error in KeyJSON (expected = %s, got = %s)", expected, data)
+	}
+
+	var res Key
+	if err := json.Unmarshal(data, &res); err != nil {
+		t.Fatalf("Error in KeyJSON (err = %v)", err)
+	}
+	if !res.Equal(testKey) {
+		t.Errorf("Error in KeyJSON (expected = %#v, got = %#v)", testKey, res)
+	}
+}
+
+func TestKeyJSONInvalid(t *testing.T) {
+	invalid := []struct {
+		data string
+		err  error
+	}{
+		{`{"type":"otp","secret":"GEZDGNBV"}`, ErrInvalidType},
+		{`{"type":"totp","secret":""}`, ErrInvalidSecret},
+		{`{"type":"totp","secret":"1234"}`, ErrInvalidSecretEncoding},
+		{`{"type":"totp","secret":"GEZDGNBV","algorithm":"MD5"}`, ErrInvalidAlgorithm},
+		{`{"type":"totp","secret":"GEZDGNBV","digits":12}`, ErrInvalidDigits},
+		{`{"type":"totp","secret":"GEZDGNBV","period":-30}`, ErrInvalidPeriod},
+	}
+
+	for i, testValue := range invalid {
+		var res Key
+		if err := json.Unmarshal([]byte(testValue.data), &res); !errors.Is(err, testValue.err) {
+			t.Errorf("Error in KeyJSONInvalid (i = %d, expected = %v, got = %v)", i, testValue.err, err)
+		}
+	}
+
+	var res Key
+	if err := json.Unmarshal([]byte(`{"type":"totp","secret":"GEZDGNBV","label":"Example"}`), &res); err == nil {
+		t.Errorf("Error in KeyJSONInvalid (unknown field accepted)")
+	}
+}
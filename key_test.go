@@ -0,0 +1,204 @@
+package otp
+
+import (
+	"bytes"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testKey = Key{
+	Type:        TypeTOTP,
+	Issuer:      "Example",
+	AccountName: "alice@example.com",
+	Secret:      hotpSecret,
+	Algorithm:   "SHA1",
+	Digits:      6,
+	Period:      30,
+}
+
+func TestKeyStringRedacted(t *testing.T) {
+	secret := base32.StdEncoding.EncodeToString(testKey.Secret)
+
+	for _, format := range []string{"%v", "%+v", "%s", "%#v"} {
+		res := fmt.Sprintf(format, testKey)
+		if strings.Contains(res, secret) || strings.Contains(res, string(testKey.Secret)) {
+			t.Errorf("Error in KeyStringRedacted (format = %s, secret leaked in %s)", format, res)
+		}
+		if !strings.Contains(res, "GEZD…") || !strings.Contains(res, "alice@example.com") {
+			t.Errorf("Error in KeyStringRedacted (format = %s, got = %s)", format, res)
+		}
+	}
+
+	// pointers are formatted with the same methods
+	if res := fmt.Sprintf("%v", &testKey); strings.Contains(res, secret) {
+		t.Errorf("Error in KeyStringRedacted (secret leaked in %s)", res)
+	}
+}
+
+func TestKeyFingerprint(t *testing.T) {
+	if res := testKey.Fingerprint(); res != "6ed645ef0e1abea1" {
+		t.Errorf("Error in KeyFingerprint (expected = 6ed645ef0e1abea1, got = %s)", res)
+	}
+
+	other := testKey.Clone()
+	other.Secret[0] ^= 1
+	if other.Fingerprint() == testKey.Fingerprint() {
+		t.Errorf("Error in KeyFingerprint (same fingerprint for different secrets)")
+	}
+}
+
+func TestKeyEqual(t *testing.T) {
+	other := testKey
+	other.Secret = []byte("12345678901234567890")
+	if !testKey.Equal(other) {
+		t.Errorf("Error in KeyEqual (equal keys reported as different)")
+	}
+
+	other.Secret = []byte("12345678901234567891")
+	if testKey.Equal(other) {
+		t.Errorf("Error in KeyEqual (different secrets reported as equal)")
+	}
+
+	other = testKey
+	other.Digits = 8
+	if testKey.Equal(other) {
+		t.Errorf("Error in KeyEqual (different digits reported as equal)")
+	}
+}
+
+func TestKeyValidate(t *testing.T) {
+	if err := testKey.Validate(); err != nil {
+		t.Errorf("Error in KeyValidate (err = %v)", err)
+	}
+
+	invalid := []struct {
+		key Key
+		err error
+	}{
+		{Key{Type: "otp", Secret: hotpSecret}, ErrInvalidType},
+		{Key{Type: TypeTOTP}, ErrInvalidSecret},
+		{Key{Type: TypeTOTP, Secret: []byte("1234567890")}, ErrInvalidSecret},
+		{Key{Type: TypeTOTP, Secret: hotpSecret, Algorithm: "MD5"}, ErrInvalidAlgorithm},
+		// registered, but golang.org/x/crypto/blake2b isn't imported
+		{Key{Type: TypeTOTP, Secret: hotpSecret, Algorithm: "BLAKE2B256"}, ErrInvalidAlgorithm},
+		{Key{Type: TypeTOTP, Secret: hotpSecret, Digits: 11}, ErrInvalidDigits},
+		{Key{Type: TypeTOTP, Secret: hotpSecret, Period: -1}, ErrInvalidPeriod},
+		{Key{Type: TypeHOTP, Secret: hotpSecret, Period: 30}, ErrInvalidPeriod},
+		{Key{Type: TypeTOTP, Secret: hotpSecret, Counter: 1}, ErrInvalidCounter},
+	}
+
+	for i, testValue := range invalid {
+		if err := testValue.key.Validate(); !errors.Is(err, testValue.err) {
+			t.Errorf("Error in KeyValidate (i = %d, expected = %v, got = %v)", i, testValue.err, err)
+		}
+	}
+
+	short := Key{Type: TypeTOTP, Secret: []byte("1234567890")}
+	if err := short.ValidateMinSecretLength(10); err != nil {
+		t.Errorf("Error in KeyValidate (expected = nil, got = %v)", err)
+	}
+	if err := short.ValidateMinSecretLength(11); !errors.Is(err, ErrInvalidSecret) {
+		t.Errorf("Error in KeyValidate (expected = %v, got = %v)", ErrInvalidSecret, err)
+	}
+	if err := (Key{Type: TypeTOTP}).ValidateMinSecretLength(0); !errors.Is(err, ErrInvalidSecret) {
+		t.Errorf("Error in KeyValidate (expected = %v, got = %v)", ErrInvalidSecret, err)
+	}
+
+	// all the problems are reported
+	err := Key{Type: "otp", Digits: 11}.Validate()
+	for _, expected := range []error{ErrInvalidType, ErrInvalidSecret, ErrInvalidDigits} {
+		if !errors.Is(err, expected) {
+			t.Errorf("Error in KeyValidate (expected = %v, got = %v)", expected, err)
+		}
+	}
+}
+
+func TestKeyClone(t *testing.T) {
+	key := testKey
+	key.Secret = []byte("12345678901234567890")
+
+	clone := key.Clone()
+	if !clone.Equal(key) {
+		t.Errorf("Error in KeyClone (expected = %#v, got = %#v)", key, clone)
+	}
+
+	WipeBytes(key.Secret)
+	if clone.Equal(key) {
+		t.Errorf("Error in KeyClone (secret is shared)")
+	}
+}
+
+func TestKeyZero(t *testing.T) {
+	key := testKey.Clone()
+	secret := key.Secret
+	shared := key
+
+	key.Zero()
+	if key.Secret != nil || !bytes.Equal(secret, make([]byte, len(secret))) {
+		t.Errorf("Error in KeyZero (secret not wiped, got = %x, %x)", key.Secret, secret)
+	}
+	if !bytes.Equal(shared.Secret, make([]byte, len(secret))) {
+		t.Errorf("Error in KeyZero (shared secret not wiped, got = %x)", shared.Secret)
+	}
+	if len(testKey.Secret) == 0 || bytes.Equal(testKey.Secret, make([]byte, len(testKey.Secret))) {
+		t.Errorf("Error in KeyZero (secret of the original key wiped)")
+	}
+
+	// zeroing a key without secret does nothing
+	key.Zero()
+}
+
+func TestKeyOptions(t *testing.T) {
+	k := Key{Type: TypeTOTP, Secret: totpSecretSha256, Algorithm: "SHA256", Digits: 8, Period: 30}
+	opts, err := k.TOTPOptions()
+	if err != nil {
+		t.Fatalf("Error in KeyOptions (err = %v)", err)
+	}
+
+	for i, testValue := range totpTestValues {
+		if string(testValue.Secret) != string(totpSecretSha256) {
+			continue
+		}
+		if res := TOTP(k.Secret, testValue.Time, opts); res != testValue.OTP {
+			t.Errorf("Error in KeyOptions (i = %d, expected = %d, got = %d)", i, testValue.OTP, res)
+		}
+	}
+
+	if _, err := (Key{Type: TypeTOTP, Algorithm: "MD5"}).TOTPOptions(); !errors.Is(err, ErrInvalidAlgorithm) {
+		t.Errorf("Error in KeyOptions (expected = %v, got = %v)", ErrInvalidAlgorithm, err)
+	}
+	if _, err := (Key{Type: TypeTOTP, Period: -1}).TOTPOptions(); !errors.Is(err, ErrInvalidPeriod) {
+		t.Errorf("Error in KeyOptions (expected = %v, got = %v)", ErrInvalidPeriod, err)
+	}
+}
+
+func TestKeyGenerator(t *testing.T) {
+	k := Key{Type: TypeTOTP, Issuer: "Example", AccountName: "alice", Secret: totpSecretSha256, Algorithm: "SHA256", Digits: 8}
+	g, err := k.Generator()
+	if err != nil {
+		t.Fatalf("Error in KeyGenerator (err = %v)", err)
+	}
+	opts, _ := k.TOTPOptions()
+	if code, err := TOTPMAC(g, time.Unix(59, 0), opts); err != nil || code.Value != "46119246" {
+		t.Errorf("Error in KeyGenerator (expected = 46119246, got = %v, err = %v)", code, err)
+	}
+	if _, err := k.Token(); !errors.Is(err, ErrInvalidType) {
+		t.Errorf("Error in KeyToken (expected = %v, got = %v)", ErrInvalidType, err)
+	}
+
+	k = Key{Type: TypeHOTP, Secret: hotpSecret, Counter: 3}
+	token, err := k.Token()
+	if err != nil {
+		t.Fatalf("Error in KeyToken (err = %v)", err)
+	}
+	if code, next := token.Next(); code.Value != "969429" || next != 4 {
+		t.Errorf("Error in KeyToken (expected = 969429, got = %v, next = %d)", code, next)
+	}
+	if _, err := (Key{Type: TypeHOTP, Algorithm: "UNKNOWN", Secret: hotpSecret}).Generator(); !errors.Is(err, ErrInvalidAlgorithm) {
+		t.Errorf("Error in KeyGenerator (expected = %v, got = %v)", ErrInvalidAlgorithm, err)
+	}
+}
@@ -0,0 +1,32 @@
+//go:build !windows
+
+package keyring
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// command runs a command of the credential store with an optional standard input, and returns its output. The
+// exit code of the command is returned along with its error.
+func command(stdin string, name string, args ...string) (string, int, error) {
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return "", 0, fmt.Errorf("%w: %w", ErrUnsupported, err)
+	}
+
+	cmd := exec.Command(path, args...)
+	cmd.Stdin = strings.NewReader(stdin)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	err = cmd.Run()
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return stdout.String(), exitErr.ExitCode(), fmt.Errorf("keyring: %s: %w: %s", name, err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), 0, err
+}
@@ -0,0 +1,125 @@
+// Package keyring stores named keys in the credential store of the operating system: the Keychain on macOS, the
+// Credential Manager on Windows, and the Secret Service (GNOME Keyring, KWallet) on Linux and the BSDs, so that
+// command line and desktop authenticators don't write secrets to files.
+//
+// Each key is a credential of a service, e.g. "otp", holding its otpauth URI, with the name of the key as account.
+// The credential store is driven by the security and secret-tool commands on macOS and Linux, without cgo, and by
+// the Credential Manager API on Windows.
+package keyring
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/xrjr/otp"
+)
+
+var (
+	// ErrNotFound is returned when no key has a name.
+	ErrNotFound = errors.New("keyring: key not found")
+	// ErrUnsupported is returned when the operating system has no supported credential store, or when its
+	// command isn't installed.
+	ErrUnsupported = errors.New("keyring: unsupported platform")
+)
+
+// indexName is the account of the credential holding the names of the keys of a service, as credential stores
+// can't list the credentials of a service portably.
+const indexName = "(index)"
+
+// backend is the credential store of an operating system.
+type backend interface {
+	// get returns the secret of the credential of an account of a service, or ErrNotFound.
+	get(service, account string) (string, error)
+	// set creates or replaces the secret of the credential of an account of a service.
+	set(service, account, secret string) error
+	// delete removes the credential of an account of a service, or returns ErrNotFound.
+	delete(service, account string) error
+}
+
+// Store is the set of keys of a service in the credential store. Its methods aren't atomic: a Store shouldn't be
+// changed by several processes at once.
+type Store struct {
+	service string
+	backend backend
+}
+
+// New returns the store of the keys of a service, e.g. "otp".
+func New(service string) *Store {
+	return &Store{service: service, backend: systemBackend{}}
+}
+
+// Get returns the key of a name, or ErrNotFound.
+func (s *Store) Get(name string) (otp.Key, error) {
+	uri, err := s.backend.get(s.service, name)
+	if err != nil {
+		return otp.Key{}, wrap(err, name)
+	}
+	return otp.ParseURI(uri)
+}
+
+// Set stores the key of a name, replacing the key of the same name if any.
+func (s *Store) Set(name string, key otp.Key) error {
+	if name == "" || name == indexName {
+		return fmt.Errorf("keyring: invalid name %q", name)
+	}
+	if err := s.backend.set(s.service, name, key.URI()); err != nil {
+		return err
+	}
+
+	names, err := s.Names()
+	if err != nil || slices.Contains(names, name) {
+		return err
+	}
+	return s.setNames(append(names, name))
+}
+
+// Delete removes the key of a name, or returns ErrNotFound.
+func (s *Store) Delete(name string) error {
+	if err := s.backend.delete(s.service, name); err != nil {
+		return wrap(err, name)
+	}
+
+	names, err := s.Names()
+	if err != nil {
+		return err
+	}
+	return s.setNames(slices.DeleteFunc(names, func(n string) bool {
+		return n == name
+	}))
+}
+
+// Names returns the names of the keys, in the order they were added.
+func (s *Store) Names() ([]string, error) {
+	data, err := s.backend.get(s.service, indexName)
+	if errors.Is(err, ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	if err := json.Unmarshal([]byte(data), &names); err != nil {
+		return nil, fmt.Errorf("keyring: invalid index: %w", err)
+	}
+	return names, nil
+}
+
+// setNames stores the names of the keys.
+func (s *Store) setNames(names []string) error {
+	data, err := json.Marshal(names)
+	if err != nil {
+		return err
+	}
+	return s.backend.set(s.service, indexName, string(data))
+}
+
+// wrap adds the name of a key to ErrNotFound.
+func wrap(err error, name string) error {
+	if errors.Is(err, ErrNotFound) {
+		return fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	return err
+}
@@ -0,0 +1,42 @@
+package keyring
+
+import (
+	"strings"
+)
+
+// errSecItemNotFound is the exit code of the security command when no credential matches.
+const errSecItemNotFound = 44
+
+// systemBackend is the login keychain, driven by the security command.
+type systemBackend struct{}
+
+func (systemBackend) get(service, account string) (string, error) {
+	out, code, err := command("", "security", "find-generic-password", "-s", service, "-a", account, "-w")
+	if code == errSecItemNotFound {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(out, "\n"), nil
+}
+
+func (systemBackend) set(service, account, secret string) error {
+	// the command is read by the interactive mode from the standard input, so that the secret doesn't appear in
+	// the arguments of a process
+	_, _, err := command("add-generic-password -U -s "+quote(service)+" -a "+quote(account)+" -w "+quote(secret)+"\n", "security", "-i")
+	return err
+}
+
+func (systemBackend) delete(service, account string) error {
+	_, code, err := command("", "security", "delete-generic-password", "-s", service, "-a", account)
+	if code == errSecItemNotFound {
+		return ErrNotFound
+	}
+	return err
+}
+
+// quote quotes an argument of the interactive mode of the security command, which splits its lines as a shell.
+func quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,18 @@
+//go:build !darwin && !windows && !linux && !freebsd && !openbsd && !netbsd && !dragonfly
+
+package keyring
+
+// systemBackend reports that the operating system has no supported credential store.
+type systemBackend struct{}
+
+func (systemBackend) get(service, account string) (string, error) {
+	return "", ErrUnsupported
+}
+
+func (systemBackend) set(service, account, secret string) error {
+	return ErrUnsupported
+}
+
+func (systemBackend) delete(service, account string) error {
+	return ErrUnsupported
+}
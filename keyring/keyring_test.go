@@ -0,0 +1,95 @@
+package keyring
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/xrjr/otp"
+)
+
+// memoryBackend is a credential store in memory.
+type memoryBackend map[string]string
+
+func (b memoryBackend) get(service, account string) (string, error) {
+	secret, ok := b[service+":"+account]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return secret, nil
+}
+
+func (b memoryBackend) set(service, account, secret string) error {
+	b[service+":"+account] = secret
+	return nil
+}
+
+func (b memoryBackend) delete(service, account string) error {
+	if _, ok := b[service+":"+account]; !ok {
+		return ErrNotFound
+	}
+	delete(b, service+":"+account)
+	return nil
+}
+
+func TestStore(t *testing.T) {
+	s := &Store{service: "otp", backend: memoryBackend{}}
+	keys := []otp.Key{
+		{Type: otp.TypeTOTP, Issuer: "Example", AccountName: "alice@example.com", Secret: []byte("12345678901234567890")},
+		{Type: otp.TypeHOTP, AccountName: "bob", Secret: []byte("abcdefghij"), Counter: 42},
+	}
+
+	for i, key := range keys {
+		if err := s.Set(key.AccountName, key); err != nil {
+			t.Fatalf("Error in Set (i = %d, expected = nil, got = %v)", i, err)
+		}
+	}
+	// replacing a key doesn't repeat its name
+	if err := s.Set(keys[0].AccountName, keys[0]); err != nil {
+		t.Fatalf("Error in Set (expected = nil, got = %v)", err)
+	}
+
+	names, err := s.Names()
+	if expected := []string{"alice@example.com", "bob"}; err != nil || !reflect.DeepEqual(names, expected) {
+		t.Errorf("Error in Names (expected = %v, got = %v, %v)", expected, names, err)
+	}
+
+	for i, key := range keys {
+		got, err := s.Get(key.AccountName)
+		if err != nil || !reflect.DeepEqual(got, key) {
+			t.Errorf("Error in Get (i = %d, expected = %v, got = %v, %v)", i, key, got, err)
+		}
+	}
+
+	if err := s.Delete("alice@example.com"); err != nil {
+		t.Errorf("Error in Delete (expected = nil, got = %v)", err)
+	}
+	if _, err := s.Get("alice@example.com"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Error in Get (expected = %v, got = %v)", ErrNotFound, err)
+	}
+	if err := s.Delete("alice@example.com"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Error in Delete (expected = %v, got = %v)", ErrNotFound, err)
+	}
+	names, err = s.Names()
+	if expected := []string{"bob"}; err != nil || !reflect.DeepEqual(names, expected) {
+		t.Errorf("Error in Names (expected = %v, got = %v, %v)", expected, names, err)
+	}
+}
+
+func TestStoreInvalidName(t *testing.T) {
+	s := &Store{service: "otp", backend: memoryBackend{}}
+	key := otp.Key{Type: otp.TypeTOTP, AccountName: "alice", Secret: []byte("12345678901234567890")}
+
+	for i, name := range []string{"", indexName} {
+		if err := s.Set(name, key); err == nil {
+			t.Errorf("Error in Set (i = %d, expected = error, got = nil)", i)
+		}
+	}
+}
+
+func TestStoreEmpty(t *testing.T) {
+	s := &Store{service: "otp", backend: memoryBackend{}}
+	if names, err := s.Names(); err != nil || names != nil {
+		t.Errorf("Error in Names (expected = [], got = %v, %v)", names, err)
+	}
+}
@@ -0,0 +1,37 @@
+//go:build linux || freebsd || openbsd || netbsd || dragonfly
+
+package keyring
+
+import (
+	"strings"
+)
+
+// systemBackend is the Secret Service, driven by the secret-tool command of libsecret.
+type systemBackend struct{}
+
+func (systemBackend) get(service, account string) (string, error) {
+	out, code, err := command("", "secret-tool", "lookup", "service", service, "account", account)
+	// secret-tool exits with 1 without output when no credential matches
+	if code == 1 && out == "" {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(out, "\n"), nil
+}
+
+func (systemBackend) set(service, account, secret string) error {
+	// the secret is read from the standard input, so that it doesn't appear in the arguments of a process
+	_, _, err := command(secret, "secret-tool", "store", "--label", service+": "+account, "service", service, "account", account)
+	return err
+}
+
+func (b systemBackend) delete(service, account string) error {
+	// secret-tool clear succeeds when no credential matches
+	if _, err := b.get(service, account); err != nil {
+		return err
+	}
+	_, _, err := command("", "secret-tool", "clear", "service", service, "account", account)
+	return err
+}
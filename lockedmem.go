@@ -0,0 +1,114 @@
+//go:build !tinygo && !otpcore
+
+package otp
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// LockedSecret holds a secret in locked memory, for high-assurance validators which must keep secrets out of swap
+// and core files. On Linux and macOS, the secret is stored in its own pages, mapped outside of the Go heap:
+//
+//   - they are locked with mlock, so that they are never written to swap;
+//   - they are excluded from core dumps with madvise(MADV_DONTDUMP) on Linux;
+//   - they are read-only, and surrounded by inaccessible guard pages, so that an overflow faults instead of
+//     reading or writing the secret.
+//
+// The platform may deny mlock, e.g. when RLIMIT_MEMLOCK is exceeded, in which case the secret is kept in pages which
+// aren't locked, and Locked reports false. Other platforms hold the secret on the heap, as a plain slice.
+//
+//	locked, err := otp.NewLockedSecret(key.Secret)
+//	otp.WipeBytes(key.Secret)
+//	key.Secret = nil
+//	defer locked.Destroy()
+//	...
+//	code, err := locked.TOTP(time.Now(), opts)
+//
+// A LockedSecret is safe for concurrent use.
+type LockedSecret struct {
+	mu        sync.RWMutex
+	mem       *lockedMemory
+	destroyed bool
+}
+
+// NewLockedSecret copies a secret in locked memory. The secret isn't modified, and should be wiped by the caller
+// with WipeBytes. Destroy should be called once the LockedSecret isn't used anymore, as its pages are otherwise
+// only released by the garbage collector.
+func NewLockedSecret(secret []byte) (*LockedSecret, error) {
+	if len(secret) == 0 {
+		return nil, ErrInvalidSecret
+	}
+
+	mem, err := allocLocked(len(secret))
+	if err != nil {
+		return nil, err
+	}
+	copy(mem.secret, secret)
+	if err := mem.protect(false); err != nil {
+		mem.free()
+		return nil, err
+	}
+
+	s := &LockedSecret{mem: mem}
+	runtime.SetFinalizer(s, (*LockedSecret).Destroy)
+	return s, nil
+}
+
+// Locked reports whether the secret is in memory locked by mlock.
+func (s *LockedSecret) Locked() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return !s.destroyed && s.mem.locked
+}
+
+// Use calls fn with the secret. fn must neither retain nor modify the secret, which may be read-only.
+func (s *LockedSecret) Use(fn func(secret []byte) error) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.destroyed {
+		return fmt.Errorf("%w: locked secret is destroyed", ErrInvalidSecret)
+	}
+	return fn(s.mem.secret)
+}
+
+// HOTP computes the OTP code of a given counter with the secret, as HOTPE does.
+func (s *LockedSecret) HOTP(counter uint64, opts HOTPOptions) (code Code, err error) {
+	err = s.Use(func(secret []byte) error {
+		code, err = HOTPE(secret, counter, opts)
+		return err
+	})
+	return code, err
+}
+
+// TOTP computes the OTP code of a given time with the secret, as TOTPE does.
+func (s *LockedSecret) TOTP(t time.Time, opts TOTPOptions) (code Code, err error) {
+	err = s.Use(func(secret []byte) error {
+		code, err = TOTPE(secret, t, opts)
+		return err
+	})
+	return code, err
+}
+
+// Destroy wipes the secret and releases its memory. The LockedSecret can't be used afterwards. Destroy may be called
+// several times.
+func (s *LockedSecret) Destroy() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.destroyed {
+		return nil
+	}
+	s.destroyed = true
+	runtime.SetFinalizer(s, nil)
+
+	if err := s.mem.protect(true); err != nil {
+		return err
+	}
+	WipeBytes(s.mem.secret)
+	return s.mem.free()
+}
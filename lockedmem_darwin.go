@@ -0,0 +1,6 @@
+//go:build !tinygo && !otpcore
+
+package otp
+
+// dontDump does nothing, as macOS can't exclude pages from core dumps.
+func dontDump(pages []byte) {}
@@ -0,0 +1,15 @@
+//go:build !tinygo && !otpcore
+
+package otp
+
+import (
+	"syscall"
+)
+
+// madvDontDump is MADV_DONTDUMP, which isn't defined by package syscall.
+const madvDontDump = 0x10
+
+// dontDump excludes pages from core dumps. Kernels older than 3.4 don't support it, and the error is ignored.
+func dontDump(pages []byte) {
+	syscall.Madvise(pages, madvDontDump)
+}
@@ -0,0 +1,61 @@
+//go:build (linux || darwin) && !tinygo && !otpcore
+
+package otp
+
+import (
+	"syscall"
+)
+
+// lockedMemory is a mapping of anonymous pages, whose first and last pages are guard pages. The secret is placed at
+// the end of the pages between them, so that reading or writing past it faults.
+type lockedMemory struct {
+	region []byte // whole mapping
+	pages  []byte // pages between the guard pages
+	secret []byte
+	locked bool
+}
+
+// allocLocked maps locked memory for a secret of size bytes.
+func allocLocked(size int) (*lockedMemory, error) {
+	pageSize := syscall.Getpagesize()
+	n := (size + pageSize - 1) / pageSize * pageSize
+
+	region, err := syscall.Mmap(-1, 0, n+2*pageSize, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_PRIVATE|syscall.MAP_ANON)
+	if err != nil {
+		return nil, err
+	}
+	mem := &lockedMemory{region: region, pages: region[pageSize : pageSize+n]}
+	mem.secret = mem.pages[n-size:]
+
+	if err := syscall.Mprotect(region[:pageSize], syscall.PROT_NONE); err != nil {
+		mem.free()
+		return nil, err
+	}
+	if err := syscall.Mprotect(region[pageSize+n:], syscall.PROT_NONE); err != nil {
+		mem.free()
+		return nil, err
+	}
+
+	// mlock is denied when RLIMIT_MEMLOCK is exceeded, or without the privilege on some systems, in which case
+	// the pages are used unlocked
+	mem.locked = syscall.Mlock(mem.pages) == nil
+	dontDump(mem.pages)
+	return mem, nil
+}
+
+// protect makes the secret read-only, or writable.
+func (m *lockedMemory) protect(writable bool) error {
+	prot := syscall.PROT_READ
+	if writable {
+		prot |= syscall.PROT_WRITE
+	}
+	return syscall.Mprotect(m.pages, prot)
+}
+
+// free unlocks and unmaps the memory.
+func (m *lockedMemory) free() error {
+	if m.locked {
+		syscall.Munlock(m.pages)
+	}
+	return syscall.Munmap(m.region)
+}
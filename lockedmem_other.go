@@ -0,0 +1,24 @@
+//go:build !linux && !darwin && !tinygo && !otpcore
+
+package otp
+
+// lockedMemory is a slice of the heap, on platforms where memory can't be locked.
+type lockedMemory struct {
+	secret []byte
+	locked bool
+}
+
+// allocLocked allocates a secret of size bytes on the heap.
+func allocLocked(size int) (*lockedMemory, error) {
+	return &lockedMemory{secret: make([]byte, size)}, nil
+}
+
+// protect does nothing, as the heap can't be protected.
+func (m *lockedMemory) protect(writable bool) error {
+	return nil
+}
+
+// free does nothing, the secret being released by the garbage collector.
+func (m *lockedMemory) free() error {
+	return nil
+}
@@ -0,0 +1,59 @@
+//go:build !tinygo && !otpcore
+
+package otp
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLockedSecret(t *testing.T) {
+	secret := bytes.Clone(totpSecretSha1)
+
+	locked, err := NewLockedSecret(secret)
+	if err != nil {
+		t.Fatalf("Error in LockedSecret (err = %v)", err)
+	}
+	// mlock may be denied by the limits of the test environment
+	t.Logf("LockedSecret locked = %v", locked.Locked())
+
+	now := time.Unix(1111111109, 0)
+	code, err := locked.TOTP(now, TOTPOptions{})
+	if expected := TOTPCode(totpSecretSha1, now, TOTPOptions{}); err != nil || code != expected {
+		t.Errorf("Error in LockedSecret (expected = %v, got = %v, err = %v)", expected, code, err)
+	}
+	code, err = locked.HOTP(1, HOTPOptions{})
+	if expected := HOTPCode(totpSecretSha1, 1, HOTPOptions{}); err != nil || code != expected {
+		t.Errorf("Error in LockedSecret (expected = %v, got = %v, err = %v)", expected, code, err)
+	}
+	if _, err := locked.HOTP(1, HOTPOptions{Digits: 11}); !errors.Is(err, ErrInvalidDigits) {
+		t.Errorf("Error in LockedSecret (expected = %v, got = %v)", ErrInvalidDigits, err)
+	}
+
+	// the secret is a copy, left unmodified
+	secret[0] ^= 1
+	locked.Use(func(s []byte) error {
+		if !bytes.Equal(s, totpSecretSha1) {
+			t.Errorf("Error in LockedSecret (expected = %x, got = %x)", totpSecretSha1, s)
+		}
+		return nil
+	})
+
+	for i := 0; i < 2; i++ {
+		if err := locked.Destroy(); err != nil {
+			t.Errorf("Error in LockedSecret (i = %d, expected = nil, got = %v)", i, err)
+		}
+	}
+	if locked.Locked() {
+		t.Errorf("Error in LockedSecret (destroyed secret is locked)")
+	}
+	if _, err := locked.TOTP(now, TOTPOptions{}); !errors.Is(err, ErrInvalidSecret) {
+		t.Errorf("Error in LockedSecret (expected = %v, got = %v)", ErrInvalidSecret, err)
+	}
+
+	if _, err := NewLockedSecret(nil); !errors.Is(err, ErrInvalidSecret) {
+		t.Errorf("Error in LockedSecret (expected = %v, got = %v)", ErrInvalidSecret, err)
+	}
+}
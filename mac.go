@@ -0,0 +1,56 @@
+package otp
+
+import (
+	"fmt"
+	"time"
+)
+
+// MACer computes the hmac of a counter, as done by HOTP with its secret. It allows the secret to be kept in an HSM, a
+// TPM or a cloud KMS, and never enter the memory of the process.
+type MACer interface {
+	MAC(counter uint64) ([]byte, error)
+}
+
+// HOTPMAC computes the OTP code of a given counter, using the MACer instead of a secret.
+// The hash function is chosen by the MACer, so opts.Algorithm and opts.Hash are ignored.
+func HOTPMAC(m MACer, counter uint64, opts HOTPOptions) (Code, error) {
+	opts, err := opts.checkedDefaults()
+	if err != nil {
+		return Code{}, err
+	}
+
+	hs, err := m.MAC(counter)
+	if err != nil {
+		return Code{}, err
+	}
+
+	// dynamic truncation reads 4 bytes at an offset up to 15
+	if opts.Truncate == nil && len(hs) < 20 {
+		return Code{}, fmt.Errorf("%w: mac is shorter than 20 bytes", ErrInvalidAlgorithm)
+	}
+
+	return Code{
+		Value:   opts.Encoder.Encode(opts.truncation(hs), opts.Digits),
+		Digits:  opts.Digits,
+		Counter: counter,
+	}, nil
+}
+
+// TOTPMAC computes the OTP code of a given time, using the MACer instead of a secret.
+// The hash function is chosen by the MACer, so opts.Algorithm and opts.Hash are ignored.
+func TOTPMAC(m MACer, t time.Time, opts TOTPOptions) (Code, error) {
+	opts, err := opts.checkedDefaults()
+	if err != nil {
+		return Code{}, err
+	}
+
+	counter := timePeriodCounter(t.Unix(), opts.TimeReference, opts.Period) + int64(opts.Step)
+	code, err := HOTPMAC(m, uint64(counter), opts.HOTPOptions)
+	if err != nil {
+		return Code{}, err
+	}
+
+	code.ValidFrom = periodStart(counter, opts)
+	code.ValidUntil = periodStart(counter+1, opts)
+	return code, nil
+}
@@ -0,0 +1,62 @@
+package otp
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"errors"
+	"hash"
+	"testing"
+)
+
+// testMACer computes the hmac in process, as an HSM would do.
+type testMACer struct {
+	algorithm func() hash.Hash
+	key       []byte
+	err       error
+}
+
+func (m testMACer) MAC(counter uint64) ([]byte, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	var sum [maxHashSize]byte
+	return hmacShaN(m.algorithm, m.key, counter, &sum), nil
+}
+
+func TestHOTPMAC(t *testing.T) {
+	for _, testValue := range hotpTestValues {
+		res, err := HOTPMAC(testMACer{algorithm: sha1.New, key: testValue.Secret}, testValue.Counter, HOTPOptions{})
+		expected := HOTPCode(testValue.Secret, testValue.Counter, HOTPOptions{})
+		if err != nil || res != expected {
+			t.Errorf("Error in HOTPMAC for Counter = %d (expected %+v, got %+v, err = %v)", testValue.Counter, expected, res, err)
+		}
+	}
+}
+
+func TestTOTPMAC(t *testing.T) {
+	for i, testValue := range totpTestValues {
+		opts := TOTPOptions{
+			HOTPOptions: HOTPOptions{
+				Digits: testValue.Digits,
+			},
+			Period: testValue.Period,
+		}
+		res, err := TOTPMAC(testMACer{algorithm: testValue.Mode, key: testValue.Secret}, testValue.Time, opts)
+		opts.Algorithm = testValue.Mode
+		expected := TOTPCode(testValue.Secret, testValue.Time, opts)
+		if err != nil || res != expected {
+			t.Errorf("Error in TOTPMAC (i = %d, expected = %+v, got = %+v, err = %v)", i, expected, res, err)
+		}
+	}
+}
+
+func TestHOTPMACErrors(t *testing.T) {
+	errMAC := errors.New("hsm unavailable")
+	if _, err := HOTPMAC(testMACer{err: errMAC}, 0, HOTPOptions{}); err != errMAC {
+		t.Errorf("Error in HOTPMACErrors (expected = %v, got = %v)", errMAC, err)
+	}
+
+	if _, err := HOTPMAC(testMACer{algorithm: md5.New, key: hotpSecret}, 0, HOTPOptions{}); !errors.Is(err, ErrInvalidAlgorithm) {
+		t.Errorf("Error in HOTPMACErrors (expected = %v, got = %v)", ErrInvalidAlgorithm, err)
+	}
+}
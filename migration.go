@@ -0,0 +1,286 @@
+//go:build !tinygo && !otpcore
+
+package otp
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// errInvalidMigration is reported when the payload of a migration URI can't be decoded.
+var errInvalidMigration = errors.New("invalid migration payload")
+
+// ParseMigrationURI parses an otpauth-migration URI, as exported by Google Authenticator, and returns the keys
+// of its payload.
+func ParseMigrationURI(uri string) ([]Key, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidURI, err)
+	}
+	if u.Scheme != "otpauth-migration" {
+		return nil, fmt.Errorf("%w: scheme %q is not otpauth-migration", ErrInvalidURI, u.Scheme)
+	}
+
+	// + is often left unescaped in the query, and then decoded as a space
+	data, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(u.Query().Get("data"), " ", "+"))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidURI, err)
+	}
+
+	// MigrationPayload message, whose field 1 holds the OtpParameters messages
+	var keys []Key
+	err = readProtobuf(data, func(field int, value []byte, _ uint64) error {
+		if field != 1 {
+			return nil
+		}
+		k, err := parseMigrationKey(value)
+		if err != nil {
+			return err
+		}
+		keys = append(keys, k)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidURI, err)
+	}
+	return keys, nil
+}
+
+// MigrationURIs returns the otpauth-migration URIs of keys, as imported by Google Authenticator, with at most
+// perURI keys per URI so that the QR codes of the URIs stay readable, or all the keys in one URI when perURI is
+// zero. The URIs are numbered as the batches of a single export.
+// Migration URIs only hold keys of 6 or 8 digits, with the SHA1, SHA256 or SHA512 algorithm and the default
+// period: the keys which can't be exported are reported by the returned error.
+func MigrationURIs(keys []Key, perURI int) ([]string, error) {
+	var params [][]byte
+	var errs []error
+	for i, k := range keys {
+		p, err := appendMigrationKey(nil, k)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("key %d (%s): %w", i, k.AccountName, err))
+			continue
+		}
+		params = append(params, p)
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	if perURI <= 0 {
+		perURI = max(len(params), 1)
+	}
+	batchSize := max((len(params)+perURI-1)/perURI, 1)
+	var id [4]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return nil, err
+	}
+
+	uris := make([]string, 0, batchSize)
+	for batch := range batchSize {
+		// MigrationPayload message, with the version 1 of the format
+		var payload []byte
+		for _, p := range params[batch*perURI : min((batch+1)*perURI, len(params))] {
+			payload = appendProtobuf(payload, 1, p, 0)
+		}
+		payload = appendProtobuf(payload, 2, nil, 1)
+		payload = appendProtobuf(payload, 3, nil, uint64(batchSize))
+		payload = appendProtobuf(payload, 4, nil, uint64(batch))
+		payload = appendProtobuf(payload, 5, nil, uint64(binary.BigEndian.Uint32(id[:])>>1))
+
+		uris = append(uris, "otpauth-migration://offline?data="+url.QueryEscape(base64.StdEncoding.EncodeToString(payload)))
+	}
+	return uris, nil
+}
+
+// appendMigrationKey appends the OtpParameters message of a key.
+func appendMigrationKey(b []byte, k Key) ([]byte, error) {
+	if err := k.validate(); err != nil {
+		return nil, err
+	}
+
+	var algorithm uint64
+	switch strings.ToUpper(k.Algorithm) {
+	case "", "SHA1":
+		algorithm = 1
+	case "SHA256":
+		algorithm = 2
+	case "SHA512":
+		algorithm = 3
+	default:
+		return nil, fmt.Errorf("%w: %s is not supported by migration URIs", ErrInvalidAlgorithm, k.Algorithm)
+	}
+
+	var digits uint64
+	switch k.Digits {
+	case 0, 6:
+		digits = 1
+	case 8:
+		digits = 2
+	default:
+		return nil, fmt.Errorf("%w: %d digits are not supported by migration URIs", ErrInvalidDigits, k.Digits)
+	}
+
+	if k.Type == TypeTOTP && k.Period != 0 && k.Period != 30 {
+		return nil, fmt.Errorf("%w: %d seconds is not supported by migration URIs", ErrInvalidPeriod, k.Period)
+	}
+
+	name := k.AccountName
+	if k.Issuer != "" {
+		name = k.Issuer + ":" + k.AccountName
+	}
+
+	b = appendProtobuf(b, 1, k.Secret, 0)
+	b = appendProtobuf(b, 2, []byte(name), 0)
+	if k.Issuer != "" {
+		b = appendProtobuf(b, 3, []byte(k.Issuer), 0)
+	}
+	b = appendProtobuf(b, 4, nil, algorithm)
+	b = appendProtobuf(b, 5, nil, digits)
+	if k.Type == TypeHOTP {
+		b = appendProtobuf(b, 6, nil, 1)
+		b = appendProtobuf(b, 7, nil, k.Counter)
+	} else {
+		b = appendProtobuf(b, 6, nil, 2)
+	}
+	return b, nil
+}
+
+// parseMigrationKey decodes an OtpParameters message of a migration payload.
+func parseMigrationKey(data []byte) (Key, error) {
+	var k Key
+	var name, issuer string
+	err := readProtobuf(data, func(field int, value []byte, n uint64) error {
+		switch field {
+		case 1:
+			k.Secret = append([]byte{}, value...)
+		case 2:
+			name = string(value)
+		case 3:
+			issuer = string(value)
+		case 4:
+			switch n {
+			case 0, 1:
+				k.Algorithm = "SHA1"
+			case 2:
+				k.Algorithm = "SHA256"
+			case 3:
+				k.Algorithm = "SHA512"
+			default:
+				return fmt.Errorf("%w: unsupported algorithm %d", errInvalidMigration, n)
+			}
+		case 5:
+			switch n {
+			case 0, 1:
+				k.Digits = 6
+			case 2:
+				k.Digits = 8
+			default:
+				return fmt.Errorf("%w: unsupported digits %d", errInvalidMigration, n)
+			}
+		case 6:
+			switch n {
+			case 1:
+				k.Type = TypeHOTP
+			case 0, 2:
+				k.Type = TypeTOTP
+			default:
+				return fmt.Errorf("%w: unsupported type %d", errInvalidMigration, n)
+			}
+		case 7:
+			k.Counter = n
+		}
+		return nil
+	})
+	if err != nil {
+		return Key{}, err
+	}
+
+	// the type field is omitted when unspecified
+	if k.Type == "" {
+		k.Type = TypeTOTP
+	}
+
+	k.Issuer, k.AccountName = parseLabel(name)
+	if k.Issuer == "" {
+		k.Issuer = issuer
+	}
+
+	if err := k.validate(); err != nil {
+		return Key{}, err
+	}
+	return k, nil
+}
+
+// readProtobuf decodes the fields of a protocol buffers message, calling fn with the field number and either the
+// value of length-delimited fields or the value of varint fields.
+func readProtobuf(data []byte, fn func(field int, value []byte, n uint64) error) error {
+	for len(data) > 0 {
+		tag, size := readVarint(data)
+		if size == 0 {
+			return errInvalidMigration
+		}
+		data = data[size:]
+
+		field, wireType := int(tag>>3), tag&0x7
+		switch wireType {
+		case 0:
+			n, size := readVarint(data)
+			if size == 0 {
+				return errInvalidMigration
+			}
+			data = data[size:]
+			if err := fn(field, nil, n); err != nil {
+				return err
+			}
+		case 2:
+			length, size := readVarint(data)
+			if size == 0 || uint64(len(data)-size) < length {
+				return errInvalidMigration
+			}
+			value := data[size : size+int(length)]
+			data = data[size+int(length):]
+			if err := fn(field, value, 0); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("%w: unsupported wire type %d", errInvalidMigration, wireType)
+		}
+	}
+	return nil
+}
+
+// appendProtobuf appends a field of a protocol buffers message, as a varint when value is nil.
+func appendProtobuf(b []byte, field int, value []byte, n uint64) []byte {
+	if value == nil {
+		return appendVarint(appendVarint(b, uint64(field)<<3), n)
+	}
+	b = appendVarint(b, uint64(field)<<3|2)
+	b = appendVarint(b, uint64(len(value)))
+	return append(b, value...)
+}
+
+// appendVarint appends a varint.
+func appendVarint(b []byte, n uint64) []byte {
+	for n >= 0x80 {
+		b = append(b, byte(n)|0x80)
+		n >>= 7
+	}
+	return append(b, byte(n))
+}
+
+// readVarint decodes a varint, and returns its value and size. The size is 0 when the varint is invalid.
+func readVarint(data []byte) (uint64, int) {
+	var n uint64
+	for i := 0; i < len(data) && i < 10; i++ {
+		n |= uint64(data[i]&0x7f) << (7 * i)
+		if data[i] < 0x80 {
+			return n, i + 1
+		}
+	}
+	return 0, 0
+}
@@ -0,0 +1,121 @@
+//go:build !tinygo && !otpcore
+
+package otp
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"testing"
+)
+
+// migrationURI builds an otpauth-migration URI of two keys.
+func migrationURI() string {
+	var totpKey, hotpKey []byte
+	totpKey = appendProtobuf(totpKey, 1, hotpSecret, 0)
+	totpKey = appendProtobuf(totpKey, 2, []byte("Example:alice@example.com"), 0)
+	totpKey = appendProtobuf(totpKey, 3, []byte("Example"), 0)
+	totpKey = appendProtobuf(totpKey, 4, nil, 1)
+	totpKey = appendProtobuf(totpKey, 5, nil, 1)
+	totpKey = appendProtobuf(totpKey, 6, nil, 2)
+
+	hotpKey = appendProtobuf(hotpKey, 1, hotpSecret, 0)
+	hotpKey = appendProtobuf(hotpKey, 2, []byte("bob"), 0)
+	hotpKey = appendProtobuf(hotpKey, 3, []byte("Other"), 0)
+	hotpKey = appendProtobuf(hotpKey, 4, nil, 2)
+	hotpKey = appendProtobuf(hotpKey, 5, nil, 2)
+	hotpKey = appendProtobuf(hotpKey, 6, nil, 1)
+	hotpKey = appendProtobuf(hotpKey, 7, nil, 300)
+
+	var payload []byte
+	payload = appendProtobuf(payload, 1, totpKey, 0)
+	payload = appendProtobuf(payload, 1, hotpKey, 0)
+	payload = appendProtobuf(payload, 2, nil, 1)
+	payload = appendProtobuf(payload, 3, nil, 1)
+
+	return "otpauth-migration://offline?data=" + url.QueryEscape(base64.StdEncoding.EncodeToString(payload))
+}
+
+var migrationKeys = []Key{
+	{Type: TypeTOTP, Issuer: "Example", AccountName: "alice@example.com", Secret: hotpSecret, Algorithm: "SHA1", Digits: 6},
+	{Type: TypeHOTP, Issuer: "Other", AccountName: "bob", Secret: hotpSecret, Algorithm: "SHA256", Digits: 8, Counter: 300},
+}
+
+func TestParseMigrationURI(t *testing.T) {
+	keys, err := ParseMigrationURI(migrationURI())
+	if err != nil {
+		t.Fatalf("Error in ParseMigrationURI (err = %v)", err)
+	}
+
+	if len(keys) != len(migrationKeys) {
+		t.Fatalf("Error in ParseMigrationURI (expected = %d keys, got = %d)", len(migrationKeys), len(keys))
+	}
+	for i := range keys {
+		if !keys[i].Equal(migrationKeys[i]) {
+			t.Errorf("Error in ParseMigrationURI (i = %d, expected = %#v, got = %#v)", i, migrationKeys[i], keys[i])
+		}
+	}
+}
+
+func TestParseMigrationURIInvalid(t *testing.T) {
+	for _, uri := range []string{
+		"otpauth://totp/alice?secret=GEZDGNBV",
+		"otpauth-migration://offline?data=not%20base64!",
+		"otpauth-migration://offline?data=CgU",
+	} {
+		if _, err := ParseMigrationURI(uri); !errors.Is(err, ErrInvalidURI) {
+			t.Errorf("Error in ParseMigrationURIInvalid (uri = %s, expected = %v, got = %v)", uri, ErrInvalidURI, err)
+		}
+	}
+}
+
+func TestMigrationURIs(t *testing.T) {
+	for _, perURI := range []int{0, 1, 2, 3} {
+		uris, err := MigrationURIs(migrationKeys, perURI)
+		if err != nil {
+			t.Fatalf("Error in MigrationURIs (perURI = %d, err = %v)", perURI, err)
+		}
+		expected := 1
+		if perURI == 1 {
+			expected = 2
+		}
+		if len(uris) != expected {
+			t.Errorf("Error in MigrationURIs (perURI = %d, expected = %d URIs, got = %d)", perURI, expected, len(uris))
+		}
+
+		var keys []Key
+		for _, uri := range uris {
+			k, err := ParseMigrationURI(uri)
+			if err != nil {
+				t.Fatalf("Error in MigrationURIs (perURI = %d, err = %v)", perURI, err)
+			}
+			keys = append(keys, k...)
+		}
+		if len(keys) != len(migrationKeys) {
+			t.Fatalf("Error in MigrationURIs (perURI = %d, expected = %d keys, got = %d)", perURI, len(migrationKeys), len(keys))
+		}
+		for i := range keys {
+			if !keys[i].Equal(migrationKeys[i]) {
+				t.Errorf("Error in MigrationURIs (perURI = %d, i = %d, expected = %#v, got = %#v)", perURI, i, migrationKeys[i], keys[i])
+			}
+		}
+	}
+}
+
+func TestMigrationURIsUnsupported(t *testing.T) {
+	tests := []struct {
+		key      Key
+		expected error
+	}{
+		{Key{Type: TypeTOTP, AccountName: "alice", Secret: hotpSecret, Digits: 7}, ErrInvalidDigits},
+		{Key{Type: TypeTOTP, AccountName: "alice", Secret: hotpSecret, Period: 60}, ErrInvalidPeriod},
+		{Key{Type: TypeTOTP, AccountName: "alice", Secret: hotpSecret, Algorithm: "MD5"}, ErrInvalidAlgorithm},
+		{Key{Type: TypeTOTP, AccountName: "alice"}, ErrInvalidSecret},
+	}
+
+	for i, test := range tests {
+		if _, err := MigrationURIs([]Key{migrationKeys[0], test.key}, 0); !errors.Is(err, test.expected) {
+			t.Errorf("Error in MigrationURIsUnsupported (i = %d, expected = %v, got = %v)", i, test.expected, err)
+		}
+	}
+}
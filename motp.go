@@ -0,0 +1,16 @@
+package otp
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"strconv"
+	"time"
+)
+
+// MOTP computes the Mobile-OTP code of a given time.
+// The code is the first 6 hexadecimal characters of the MD5 of the time in tens of seconds, the secret and the PIN.
+// MD5 is broken, mOTP should only be used for compatibility with existing deployments.
+func MOTP(secret string, pin string, t time.Time) string {
+	sum := md5.Sum([]byte(strconv.FormatInt(t.Unix()/10, 10) + secret + pin))
+	return hex.EncodeToString(sum[:])[:6]
+}
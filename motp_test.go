@@ -0,0 +1,30 @@
+package otp
+
+import (
+	"testing"
+	"time"
+)
+
+type MOTPTestValue struct {
+	Time time.Time
+	Code string
+}
+
+var motpSecret = "e3152afee62599c8"
+var motpPin = "1234"
+
+var motpTestValues = []MOTPTestValue{
+	{Time: time.Unix(59, 0), Code: "0c1ac3"},
+	{Time: time.Unix(1111111109, 0), Code: "6664a2"},
+	{Time: time.Unix(1234567890, 0), Code: "49c5b4"},
+	{Time: time.Unix(2000000000, 0), Code: "eb6eb2"},
+}
+
+func TestMOTP(t *testing.T) {
+	for i, testValue := range motpTestValues {
+		res := MOTP(motpSecret, motpPin, testValue.Time)
+		if res != testValue.Code {
+			t.Errorf("Error in MOTP (i = %d, expected = %s, got = %s)", i, testValue.Code, res)
+		}
+	}
+}
@@ -0,0 +1,261 @@
+// Package ntptime implements a Clock of the otp package correcting the system clock with the offset measured from
+// NTP servers, for embedded devices and virtual machines whose clock drifts or is reset at boot, which would compute
+// TOTP codes of the wrong time period:
+//
+//	clock := &ntptime.Clock{Servers: []string{"time.cloudflare.com", "pool.ntp.org"}}
+//	code := otp.TOTPCode(key, clock.Now(), otp.TOTPOptions{})
+//
+// Servers are queried with SNTP (rfc 4330), and the offset is cached for MaxAge. SNTP responses aren't
+// authenticated, so that offsets larger than MaxOffset are refused: an attacker on the path to the servers would
+// otherwise move the clock far enough to make old codes valid again.
+package ntptime
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Errors returned by Query and Clock.Sync.
+var (
+	ErrInvalidResponse = errors.New("ntptime: invalid response")
+	ErrUnsynchronized  = errors.New("ntptime: server is unsynchronized")
+	ErrNoServer        = errors.New("ntptime: no server")
+	ErrOffsetTooLarge  = errors.New("ntptime: offset too large")
+)
+
+// DefaultServers are the servers queried by a Clock without Servers.
+var DefaultServers = []string{"pool.ntp.org"}
+
+const (
+	defaultTimeout = 5 * time.Second
+	defaultMaxAge  = time.Hour
+
+	// defaultMaxOffset is the largest offset accepted by a Clock without MaxOffset.
+	defaultMaxOffset = time.Hour
+
+	// retryInterval is the delay before a Clock whose servers failed queries them again.
+	retryInterval = time.Minute
+
+	// ntpEpochOffset is the number of seconds from the NTP epoch (1900-01-01) to the Unix epoch (1970-01-01).
+	ntpEpochOffset = 2208988800
+)
+
+// Response is the result of the query of an NTP server.
+type Response struct {
+	Offset  time.Duration // offset of the server's clock from the system clock
+	RTT     time.Duration // round trip time of the query
+	Stratum uint8         // stratum of the server, 1 for primary servers
+}
+
+// Query queries an NTP server, given as host or host:port (port 123 by default), and returns the offset of its clock
+// from the system clock. The query is cancelled with the context.
+func Query(ctx context.Context, server string) (Response, error) {
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, "123")
+	}
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "udp", server)
+	if err != nil {
+		return Response{}, err
+	}
+	defer conn.Close()
+
+	// the reads are interrupted once the context is done, so that its error is returned
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	// leap indicator 0, version 4, mode 3 (client), with the transmit time which the server returns as originate
+	// time, so that responses to other requests are discarded
+	var req [48]byte
+	req[0] = 0x23
+	t1 := time.Now()
+	binary.BigEndian.PutUint64(req[40:], toNTP(t1))
+	if _, err := conn.Write(req[:]); err != nil {
+		return Response{}, err
+	}
+
+	var resp [48]byte
+	for {
+		n, err := conn.Read(resp[:])
+		if err != nil {
+			if ctx.Err() != nil {
+				return Response{}, ctx.Err()
+			}
+			return Response{}, err
+		}
+		if n == len(resp) && resp[0]&0x7 == 4 && binary.BigEndian.Uint64(resp[24:]) == binary.BigEndian.Uint64(req[40:]) {
+			break
+		}
+	}
+	t4 := time.Now()
+
+	// leap indicator 3 is an unsynchronized clock, and stratum 0 a kiss-o'-death message
+	if resp[0]>>6 == 3 || resp[1] == 0 {
+		return Response{}, fmt.Errorf("%w: %s", ErrUnsynchronized, server)
+	}
+	t2 := fromNTP(binary.BigEndian.Uint64(resp[32:]))
+	t3 := fromNTP(binary.BigEndian.Uint64(resp[40:]))
+	if t3.Before(t2) {
+		return Response{}, fmt.Errorf("%w: transmit time is before receive time", ErrInvalidResponse)
+	}
+
+	// section 5 of rfc 4330
+	return Response{
+		Offset:  (t2.Sub(t1) + t3.Sub(t4)) / 2,
+		RTT:     t4.Sub(t1) - t3.Sub(t2),
+		Stratum: resp[1],
+	}, nil
+}
+
+// toNTP returns the NTP timestamp of a time: seconds since 1900 and their fraction, in 32 bits each.
+func toNTP(t time.Time) uint64 {
+	secs := uint64(t.Unix() + ntpEpochOffset)
+	frac := uint64(t.Nanosecond()) << 32 / 1e9
+	return secs<<32 | frac
+}
+
+// fromNTP returns the time of an NTP timestamp. Timestamps whose most significant bit is not set are after 2036, as
+// specified by section 3 of rfc 4330.
+func fromNTP(ts uint64) time.Time {
+	secs := int64(ts >> 32)
+	if secs&0x80000000 == 0 {
+		secs += 1 << 32
+	}
+	nsecs := int64((ts & 0xffffffff) * 1e9 >> 32)
+	return time.Unix(secs-ntpEpochOffset, nsecs)
+}
+
+// Clock is an otp.Clock giving the system time corrected with the median of the offsets measured from its servers.
+// The offset is measured when it is older than MaxAge, by the call of Now or Sync: the call of Now measuring it waits
+// for the servers, up to Timeout, while the other calls keep the last offset measured meanwhile. Servers whose offset
+// is larger than MaxOffset are ignored. If every server fails, Now keeps the last offset measured, which is 0 until
+// one is, and retries a minute later.
+// A Clock is safe for concurrent use.
+type Clock struct {
+	Servers []string      // servers queried, as host or host:port, defaults to DefaultServers
+	Timeout time.Duration // timeout of the queries, defaults to 5 seconds
+	MaxAge  time.Duration // lifetime of the offset measured, defaults to 1 hour
+
+	// MaxOffset is the largest offset accepted, either way, defaulting to 1 hour. Devices whose clock is reset at
+	// boot need a larger one, and should then get their time from authenticated sources instead when they can.
+	MaxOffset time.Duration
+
+	mu      sync.Mutex
+	offset  time.Duration
+	synced  time.Time // time of the last successful sync
+	tried   time.Time // time of the last sync
+	err     error     // error of the last sync
+	syncing bool      // whether a call of Now is measuring the offset
+}
+
+// Now returns the system time corrected with the offset, measuring it first if it is older than MaxAge, unless
+// another call is already measuring it. Measuring it blocks the call for up to Timeout.
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	now := time.Now()
+	stale := !c.syncing && (c.synced.IsZero() || now.Sub(c.synced) >= c.maxAge()) &&
+		(c.tried.IsZero() || now.Sub(c.tried) >= min(retryInterval, c.maxAge()))
+	c.syncing = stale
+	offset := c.offset
+	c.mu.Unlock()
+
+	if !stale {
+		return now.Add(offset)
+	}
+
+	c.sync(context.Background())
+	c.mu.Lock()
+	c.syncing = false
+	offset = c.offset
+	c.mu.Unlock()
+	return time.Now().Add(offset)
+}
+
+// Sync measures the offset from the servers, e.g. at startup so that the first call of Now doesn't wait for it.
+// It returns the errors of the servers if all of them fail, the offset being kept.
+func (c *Clock) Sync(ctx context.Context) error {
+	return c.sync(ctx)
+}
+
+// Offset returns the offset of the clock from the system clock, along with the error of the last sync.
+func (c *Clock) Offset() (time.Duration, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.offset, c.err
+}
+
+// sync queries the servers concurrently, without the lock held, and sets the offset to the median of their offsets.
+func (c *Clock) sync(ctx context.Context) error {
+	servers := c.Servers
+	if len(servers) == 0 {
+		servers = DefaultServers
+	}
+	if len(servers) == 0 {
+		return ErrNoServer
+	}
+
+	timeout := c.Timeout
+	if timeout == 0 {
+		timeout = defaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	maxOffset := c.MaxOffset
+	if maxOffset == 0 {
+		maxOffset = defaultMaxOffset
+	}
+
+	offsets := make([]time.Duration, len(servers))
+	errs := make([]error, len(servers))
+	var wg sync.WaitGroup
+	for i, server := range servers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := Query(ctx, server)
+			if err == nil && (resp.Offset > maxOffset || resp.Offset < -maxOffset) {
+				err = fmt.Errorf("%w: %s from %s", ErrOffsetTooLarge, resp.Offset, server)
+			}
+			offsets[i], errs[i] = resp.Offset, err
+		}()
+	}
+	wg.Wait()
+
+	var valid []time.Duration
+	for i, err := range errs {
+		if err == nil {
+			valid = append(valid, offsets[i])
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.tried = time.Now()
+	if len(valid) == 0 {
+		c.err = errors.Join(errs...)
+		return c.err
+	}
+
+	slices.Sort(valid)
+	c.offset = valid[len(valid)/2]
+	c.synced = c.tried
+	c.err = nil
+	return nil
+}
+
+// maxAge returns the lifetime of the offset.
+func (c *Clock) maxAge() time.Duration {
+	if c.MaxAge == 0 {
+		return defaultMaxAge
+	}
+	return c.MaxAge
+}
@@ -0,0 +1,179 @@
+package ntptime
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+// serve runs an NTP server on the loopback interface whose clock is ahead of the system clock by offset, with a
+// given first byte (leap indicator, version and mode) and stratum, and returns its address.
+func serve(t *testing.T, offset time.Duration, first, stratum byte) string {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("no loopback udp: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		var req [48]byte
+		for {
+			n, addr, err := conn.ReadFrom(req[:])
+			if err != nil {
+				return
+			}
+			if n != len(req) {
+				continue
+			}
+
+			var resp [48]byte
+			resp[0] = first
+			resp[1] = stratum
+			copy(resp[24:32], req[40:48])
+			binary.BigEndian.PutUint64(resp[32:], toNTP(time.Now().Add(offset)))
+			binary.BigEndian.PutUint64(resp[40:], toNTP(time.Now().Add(offset)))
+			conn.WriteTo(resp[:], addr)
+		}
+	}()
+	return conn.LocalAddr().String()
+}
+
+func TestNTPTimestamp(t *testing.T) {
+	times := []time.Time{
+		time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, 2, 29, 12, 30, 15, 500_000_000, time.UTC),
+		time.Date(2040, 1, 1, 0, 0, 0, 250_000_000, time.UTC),
+	}
+
+	for i, v := range times {
+		res := fromNTP(toNTP(v))
+		if d := res.Sub(v); d < -time.Microsecond || d > time.Microsecond {
+			t.Errorf("Error in NTPTimestamp (i = %d, expected = %v, got = %v)", i, v, res)
+		}
+	}
+}
+
+func TestQuery(t *testing.T) {
+	server := serve(t, time.Hour, 0x24, 2)
+
+	resp, err := Query(context.Background(), server)
+	if err != nil {
+		t.Fatalf("Error in Query (expected = nil, got = %v)", err)
+	}
+	if d := resp.Offset - time.Hour; d < -time.Second || d > time.Second {
+		t.Errorf("Error in Query (expected = %v, got = %v)", time.Hour, resp.Offset)
+	}
+	if resp.Stratum != 2 {
+		t.Errorf("Error in Query (expected = 2, got = %d)", resp.Stratum)
+	}
+
+	invalid := []string{
+		serve(t, 0, 0xe4, 2), // leap indicator 3
+		serve(t, 0, 0x24, 0), // kiss-o'-death
+	}
+	for i, v := range invalid {
+		if _, err := Query(context.Background(), v); !errors.Is(err, ErrUnsynchronized) {
+			t.Errorf("Error in Query (i = %d, expected = %v, got = %v)", i, ErrUnsynchronized, err)
+		}
+	}
+}
+
+func TestQueryTimeout(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("no loopback udp: %v", err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := Query(ctx, conn.LocalAddr().String()); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Error in QueryTimeout (expected = %v, got = %v)", context.DeadlineExceeded, err)
+	}
+}
+
+func TestClock(t *testing.T) {
+	clock := &Clock{
+		Servers:   []string{serve(t, time.Hour, 0x24, 2), serve(t, time.Hour+time.Second, 0x24, 2), serve(t, 0, 0xe4, 2)},
+		MaxOffset: 2 * time.Hour,
+	}
+
+	now := clock.Now()
+	if d := now.Sub(time.Now()) - time.Hour; d < 0 || d > 2*time.Second {
+		t.Errorf("Error in Clock (expected = %v, got = %v)", time.Now().Add(time.Hour), now)
+	}
+
+	offset, err := clock.Offset()
+	if err != nil || offset < time.Hour {
+		t.Errorf("Error in Clock (expected = %v, got = %v, %v)", time.Hour, offset, err)
+	}
+}
+
+func TestClockUnreachable(t *testing.T) {
+	clock := &Clock{Servers: []string{serve(t, 0, 0xe4, 2)}, Timeout: time.Second}
+
+	if err := clock.Sync(context.Background()); !errors.Is(err, ErrUnsynchronized) {
+		t.Errorf("Error in ClockUnreachable (expected = %v, got = %v)", ErrUnsynchronized, err)
+	}
+	if d := clock.Now().Sub(time.Now()); d < -time.Second || d > time.Second {
+		t.Errorf("Error in ClockUnreachable (expected = 0, got = %v)", d)
+	}
+}
+
+func TestClockMaxOffset(t *testing.T) {
+	clock := &Clock{Servers: []string{serve(t, 2*time.Hour, 0x24, 2), serve(t, -2*time.Hour, 0x24, 2)}}
+	if err := clock.Sync(context.Background()); !errors.Is(err, ErrOffsetTooLarge) {
+		t.Errorf("Error in ClockMaxOffset (expected = %v, got = %v)", ErrOffsetTooLarge, err)
+	}
+	if offset, _ := clock.Offset(); offset != 0 {
+		t.Errorf("Error in ClockMaxOffset (expected = 0, got = %v)", offset)
+	}
+
+	// the servers within MaxOffset are kept
+	clock.Servers = append(clock.Servers, serve(t, time.Minute, 0x24, 2))
+	if err := clock.Sync(context.Background()); err != nil {
+		t.Errorf("Error in ClockMaxOffset (expected = nil, got = %v)", err)
+	}
+	if offset, _ := clock.Offset(); offset < 59*time.Second || offset > 61*time.Second {
+		t.Errorf("Error in ClockMaxOffset (expected = %v, got = %v)", time.Minute, offset)
+	}
+}
+
+func TestClockNowSyncing(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("no loopback udp: %v", err)
+	}
+	defer conn.Close()
+	clock := &Clock{Servers: []string{conn.LocalAddr().String()}, Timeout: time.Second}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		clock.Now()
+	}()
+	for syncing := false; !syncing; {
+		time.Sleep(time.Millisecond)
+		clock.mu.Lock()
+		syncing = clock.syncing
+		clock.mu.Unlock()
+	}
+
+	// the other calls don't wait for the server, which never responds
+	start := time.Now()
+	clock.Now()
+	if d := time.Since(start); d > 100*time.Millisecond {
+		t.Errorf("Error in ClockNowSyncing (expected < 100ms, got = %v)", d)
+	}
+	if _, err := clock.Offset(); err != nil {
+		t.Errorf("Error in ClockNowSyncing (expected = nil, got = %v)", err)
+	}
+
+	<-done
+	if _, err := clock.Offset(); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Error in ClockNowSyncing (expected = %v, got = %v)", context.DeadlineExceeded, err)
+	}
+}
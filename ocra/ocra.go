@@ -0,0 +1,182 @@
+// Package ocra implements the OATH Challenge-Response Algorithm, as described in rfc 6287.
+package ocra
+
+import (
+	"crypto/hmac"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"time"
+
+	"github.com/xrjr/otp"
+)
+
+var (
+	// ErrInvalidQuestion is returned when the question doesn't match the format or length of the suite.
+	ErrInvalidQuestion = errors.New("ocra: invalid question")
+	// ErrInvalidSession is returned when the session information is longer than allowed by the suite.
+	ErrInvalidSession = errors.New("ocra: invalid session information")
+)
+
+// Input holds the values of the data input (section 5.1 of the rfc).
+// Fields not used by the suite are ignored.
+type Input struct {
+	Counter      uint64    // counter C
+	Question     string    // challenge question Q, formatted as described by the suite
+	Password     []byte    // PIN, hashed with the suite password hash function
+	PasswordHash []byte    // already hashed PIN, used instead of Password when set
+	Session      []byte    // session information S
+	Time         time.Time // time used to compute the timestamp T
+}
+
+// Compute computes the OCRA response of the given input.
+func Compute(key []byte, suite Suite, in Input) (uint, error) {
+	msg, err := dataInput(suite, in)
+	if err != nil {
+		return 0, err
+	}
+
+	hasher := hmac.New(suite.Algorithm, key)
+	hasher.Write(msg)
+	hs := hasher.Sum(nil)
+	// the message holds the hashed PIN
+	clear(msg)
+
+	// dynamic truncation, as in section 5.4 of rfc 4226
+	code := uint64(otp.DynamicTruncation(hs))
+	return uint(code % pow10(suite.Digits)), nil
+}
+
+// Verify checks that response is the OCRA response of the given input.
+// For suites using a counter, the window following counters are also tried. For suites using a
+// timestamp, the window time steps before and after the input time are also tried.
+// The input that matched is returned, so that the caller can update its counter.
+func Verify(key []byte, suite Suite, in Input, response uint, window int) (Input, bool) {
+	candidates := []Input{in}
+	for i := 1; i <= window; i++ {
+		if suite.Counter {
+			next := in
+			next.Counter += uint64(i)
+			candidates = append(candidates, next)
+		}
+		if suite.TimeStep != 0 {
+			before, after := in, in
+			before.Time = in.Time.Add(-time.Duration(i) * suite.TimeStep)
+			after.Time = in.Time.Add(time.Duration(i) * suite.TimeStep)
+			candidates = append(candidates, before, after)
+		}
+	}
+
+	for _, candidate := range candidates {
+		code, err := Compute(key, suite, candidate)
+		if err != nil {
+			return Input{}, false
+		}
+		if code == response {
+			return candidate, true
+		}
+	}
+	return Input{}, false
+}
+
+// dataInput builds the message given to the hmac (section 5.1 of the rfc).
+func dataInput(suite Suite, in Input) ([]byte, error) {
+	msg := append([]byte(suite.String()), 0)
+
+	if suite.Counter {
+		msg = appendUint64(msg, in.Counter)
+	}
+
+	question, err := encodeQuestion(suite, in.Question)
+	if err != nil {
+		return nil, err
+	}
+	msg = append(msg, question...)
+
+	if suite.Password != nil {
+		p := in.PasswordHash
+		if p == nil {
+			hasher := suite.Password()
+			hasher.Write(in.Password)
+			p = hasher.Sum(nil)
+		}
+		msg = append(msg, p...)
+	}
+
+	if suite.SessionLength != 0 {
+		if len(in.Session) > suite.SessionLength {
+			return nil, ErrInvalidSession
+		}
+		msg = append(msg, make([]byte, suite.SessionLength-len(in.Session))...)
+		msg = append(msg, in.Session...)
+	}
+
+	if suite.TimeStep != 0 {
+		msg = appendUint64(msg, uint64(in.Time.Unix()/int64(suite.TimeStep/time.Second)))
+	}
+
+	return msg, nil
+}
+
+// encodeQuestion encodes the question on 128 bytes, as done by the reference implementation
+// of the rfc.
+// The question length of the suite isn't enforced, as the mutual challenge-response mode
+// (section 7.3 of the rfc) concatenates the client and server challenges.
+func encodeQuestion(suite Suite, q string) ([]byte, error) {
+	if len(q) == 0 {
+		return nil, ErrInvalidQuestion
+	}
+
+	var b []byte
+	switch suite.QuestionFormat {
+	case QuestionAlphanumeric:
+		b = []byte(q)
+	case QuestionNumeric:
+		n, ok := new(big.Int).SetString(q, 10)
+		if !ok || n.Sign() < 0 {
+			return nil, ErrInvalidQuestion
+		}
+		// the hexadecimal representation is left aligned, so an odd number of nibbles
+		// is completed with a trailing zero
+		h := n.Text(16)
+		if len(h)%2 == 1 {
+			h += "0"
+		}
+		b, _ = hex.DecodeString(h)
+	case QuestionHex:
+		h := q
+		if len(h)%2 == 1 {
+			h += "0"
+		}
+		var err error
+		b, err = hex.DecodeString(h)
+		if err != nil {
+			return nil, ErrInvalidQuestion
+		}
+	}
+
+	if len(b) > 128 {
+		return nil, ErrInvalidQuestion
+	}
+
+	res := make([]byte, 128)
+	copy(res, b)
+	return res, nil
+}
+
+// appendUint64 appends the big endian representation of n to b.
+func appendUint64(b []byte, n uint64) []byte {
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, n)
+	return append(b, buf...)
+}
+
+// pow10 computes the n-th power of 10.
+func pow10(n uint) uint64 {
+	res := uint64(1)
+	for i := uint(0); i < n; i++ {
+		res *= 10
+	}
+	return res
+}
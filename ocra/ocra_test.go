@@ -0,0 +1,107 @@
+package ocra
+
+import (
+	"testing"
+	"time"
+)
+
+type OCRATestValue struct {
+	Suite    string
+	Secret   []byte
+	Input    Input
+	Response uint
+}
+
+var ocraSecret20 = []byte("12345678901234567890")
+var ocraSecret32 = []byte("12345678901234567890123456789012")
+var ocraSecret64 = []byte("1234567890123456789012345678901234567890123456789012345678901234")
+
+var ocraPin = []byte("1234")
+
+// timestamp 0x132d0b6 of the rfc, in minutes
+var ocraTime = time.Unix(0x132d0b6*60, 0)
+
+// test values from appendix C of the rfc
+var ocraTestValues = []OCRATestValue{
+	{"OCRA-1:HOTP-SHA1-6:QN08", ocraSecret20, Input{Question: "00000000"}, 237653},
+	{"OCRA-1:HOTP-SHA1-6:QN08", ocraSecret20, Input{Question: "11111111"}, 243178},
+	{"OCRA-1:HOTP-SHA1-6:QN08", ocraSecret20, Input{Question: "22222222"}, 653583},
+	{"OCRA-1:HOTP-SHA1-6:QN08", ocraSecret20, Input{Question: "33333333"}, 740991},
+	{"OCRA-1:HOTP-SHA1-6:QN08", ocraSecret20, Input{Question: "44444444"}, 608993},
+	{"OCRA-1:HOTP-SHA1-6:QN08", ocraSecret20, Input{Question: "55555555"}, 388898},
+	{"OCRA-1:HOTP-SHA256-8:C-QN08-PSHA1", ocraSecret32, Input{Counter: 0, Question: "12345678", Password: ocraPin}, 65347737},
+	{"OCRA-1:HOTP-SHA256-8:C-QN08-PSHA1", ocraSecret32, Input{Counter: 1, Question: "12345678", Password: ocraPin}, 86775851},
+	{"OCRA-1:HOTP-SHA256-8:C-QN08-PSHA1", ocraSecret32, Input{Counter: 2, Question: "12345678", Password: ocraPin}, 78192410},
+	{"OCRA-1:HOTP-SHA256-8:C-QN08-PSHA1", ocraSecret32, Input{Counter: 3, Question: "12345678", Password: ocraPin}, 71565254},
+	{"OCRA-1:HOTP-SHA256-8:C-QN08-PSHA1", ocraSecret32, Input{Counter: 4, Question: "12345678", Password: ocraPin}, 10104329},
+	{"OCRA-1:HOTP-SHA256-8:C-QN08-PSHA1", ocraSecret32, Input{Counter: 9, Question: "12345678", Password: ocraPin}, 8522129},
+	{"OCRA-1:HOTP-SHA256-8:QN08-PSHA1", ocraSecret32, Input{Question: "00000000", Password: ocraPin}, 83238735},
+	{"OCRA-1:HOTP-SHA256-8:QN08-PSHA1", ocraSecret32, Input{Question: "11111111", Password: ocraPin}, 1501458},
+	{"OCRA-1:HOTP-SHA256-8:QN08-PSHA1", ocraSecret32, Input{Question: "44444444", Password: ocraPin}, 86807031},
+	{"OCRA-1:HOTP-SHA512-8:C-QN08", ocraSecret64, Input{Counter: 0, Question: "00000000"}, 7016083},
+	{"OCRA-1:HOTP-SHA512-8:C-QN08", ocraSecret64, Input{Counter: 1, Question: "11111111"}, 63947962},
+	{"OCRA-1:HOTP-SHA512-8:C-QN08", ocraSecret64, Input{Counter: 9, Question: "99999999"}, 31409299},
+	{"OCRA-1:HOTP-SHA512-8:QN08-T1M", ocraSecret64, Input{Question: "00000000", Time: ocraTime}, 95209754},
+	{"OCRA-1:HOTP-SHA512-8:QN08-T1M", ocraSecret64, Input{Question: "11111111", Time: ocraTime}, 55907591},
+	{"OCRA-1:HOTP-SHA512-8:QN08-T1M", ocraSecret64, Input{Question: "44444444", Time: ocraTime}, 36209546},
+	{"OCRA-1:HOTP-SHA256-8:QA08", ocraSecret32, Input{Question: "CLI22220SRV11110"}, 28247970},
+	{"OCRA-1:HOTP-SHA256-8:QA08", ocraSecret32, Input{Question: "SRV11110CLI22220"}, 15510767},
+}
+
+func TestCompute(t *testing.T) {
+	for i, testValue := range ocraTestValues {
+		suite, err := ParseSuite(testValue.Suite)
+		if err != nil {
+			t.Fatalf("Error in ParseSuite (i = %d, err = %v)", i, err)
+		}
+		res, err := Compute(testValue.Secret, suite, testValue.Input)
+		if err != nil {
+			t.Errorf("Error in Compute (i = %d, err = %v)", i, err)
+		} else if res != testValue.Response {
+			t.Errorf("Error in Compute (i = %d, expected = %d, got = %d)", i, testValue.Response, res)
+		}
+	}
+}
+
+func TestComputeInvalidQuestion(t *testing.T) {
+	suite, _ := ParseSuite("OCRA-1:HOTP-SHA1-6:QN08")
+	for _, q := range []string{"", "1234abcd", "-1234"} {
+		if _, err := Compute(ocraSecret20, suite, Input{Question: q}); err != ErrInvalidQuestion {
+			t.Errorf("Error in ComputeInvalidQuestion (q = %q, expected = %v, got = %v)", q, ErrInvalidQuestion, err)
+		}
+	}
+}
+
+func TestVerifyCounter(t *testing.T) {
+	suite, _ := ParseSuite("OCRA-1:HOTP-SHA256-8:C-QN08-PSHA1")
+	in := Input{Counter: 2, Question: "12345678", Password: ocraPin}
+
+	matched, ok := Verify(ocraSecret32, suite, in, 10104329, 2)
+	if !ok || matched.Counter != 4 {
+		t.Errorf("Error in VerifyCounter (expected counter = 4, got = %d, ok = %t)", matched.Counter, ok)
+	}
+
+	if _, ok := Verify(ocraSecret32, suite, in, 10104329, 1); ok {
+		t.Errorf("Error in VerifyCounter (response outside of window accepted)")
+	}
+
+	if _, ok := Verify(ocraSecret32, suite, in, 65347737, 2); ok {
+		t.Errorf("Error in VerifyCounter (past counter accepted)")
+	}
+}
+
+func TestVerifyTime(t *testing.T) {
+	suite, _ := ParseSuite("OCRA-1:HOTP-SHA512-8:QN08-T1M")
+	for _, offset := range []time.Duration{-time.Minute, 0, time.Minute} {
+		in := Input{Question: "00000000", Time: ocraTime.Add(offset)}
+		matched, ok := Verify(ocraSecret64, suite, in, 95209754, 1)
+		if !ok || !matched.Time.Equal(ocraTime) {
+			t.Errorf("Error in VerifyTime (offset = %s, ok = %t)", offset, ok)
+		}
+	}
+
+	in := Input{Question: "00000000", Time: ocraTime.Add(2 * time.Minute)}
+	if _, ok := Verify(ocraSecret64, suite, in, 95209754, 1); ok {
+		t.Errorf("Error in VerifyTime (response outside of window accepted)")
+	}
+}
@@ -0,0 +1,161 @@
+package ocra
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+	"hash"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidSuite is returned when an OCRA suite string can't be parsed.
+var ErrInvalidSuite = errors.New("ocra: invalid suite")
+
+// Question formats, as defined in section 6.3 of the rfc.
+const (
+	QuestionAlphanumeric = 'A'
+	QuestionNumeric      = 'N'
+	QuestionHex          = 'H'
+)
+
+// Suite is a parsed OCRA suite (section 6 of the rfc).
+type Suite struct {
+	Algorithm      func() hash.Hash // hash function of the HOTP-SHA-x crypto function
+	Digits         uint             // number of digits of the response (4 to 10)
+	Counter        bool             // whether the counter C is part of the data input
+	QuestionFormat byte             // one of QuestionAlphanumeric, QuestionNumeric or QuestionHex
+	QuestionLength int              // maximum length of the question (4 to 64)
+	Password       func() hash.Hash // hash function of the PIN, nil if no password is used
+	SessionLength  int              // length in bytes of the session information, 0 if not used
+	TimeStep       time.Duration    // time step of the timestamp, 0 if not used
+
+	raw string
+}
+
+// ParseSuite parses an OCRA suite string such as "OCRA-1:HOTP-SHA1-6:QN08".
+// Suites with truncation disabled (0 digits) are not supported.
+func ParseSuite(s string) (Suite, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 3 || parts[0] != "OCRA-1" {
+		return Suite{}, ErrInvalidSuite
+	}
+
+	suite := Suite{raw: s}
+
+	// crypto function
+	fn := strings.Split(parts[1], "-")
+	if len(fn) != 3 || fn[0] != "HOTP" {
+		return Suite{}, ErrInvalidSuite
+	}
+	suite.Algorithm = hashFunc(fn[1])
+	if suite.Algorithm == nil {
+		return Suite{}, ErrInvalidSuite
+	}
+	digits, err := strconv.ParseUint(fn[2], 10, 8)
+	if err != nil || digits < 4 || digits > 10 {
+		return Suite{}, ErrInvalidSuite
+	}
+	suite.Digits = uint(digits)
+
+	// data input
+	inputs := strings.Split(parts[2], "-")
+	if len(inputs) > 0 && inputs[0] == "C" {
+		suite.Counter = true
+		inputs = inputs[1:]
+	}
+
+	if len(inputs) == 0 || len(inputs[0]) != 4 || inputs[0][0] != 'Q' {
+		return Suite{}, ErrInvalidSuite
+	}
+	suite.QuestionFormat = inputs[0][1]
+	if suite.QuestionFormat != QuestionAlphanumeric && suite.QuestionFormat != QuestionNumeric && suite.QuestionFormat != QuestionHex {
+		return Suite{}, ErrInvalidSuite
+	}
+	suite.QuestionLength, err = strconv.Atoi(inputs[0][2:])
+	if err != nil || suite.QuestionLength < 4 || suite.QuestionLength > 64 {
+		return Suite{}, ErrInvalidSuite
+	}
+	inputs = inputs[1:]
+
+	if len(inputs) > 0 && strings.HasPrefix(inputs[0], "P") {
+		suite.Password = hashFunc(inputs[0][1:])
+		if suite.Password == nil {
+			return Suite{}, ErrInvalidSuite
+		}
+		inputs = inputs[1:]
+	}
+
+	if len(inputs) > 0 && strings.HasPrefix(inputs[0], "S") {
+		if len(inputs[0]) != 4 {
+			return Suite{}, ErrInvalidSuite
+		}
+		suite.SessionLength, err = strconv.Atoi(inputs[0][1:])
+		if err != nil || suite.SessionLength <= 0 {
+			return Suite{}, ErrInvalidSuite
+		}
+		inputs = inputs[1:]
+	}
+
+	if len(inputs) > 0 && strings.HasPrefix(inputs[0], "T") {
+		suite.TimeStep, err = parseTimeStep(inputs[0][1:])
+		if err != nil {
+			return Suite{}, err
+		}
+		inputs = inputs[1:]
+	}
+
+	if len(inputs) != 0 {
+		return Suite{}, ErrInvalidSuite
+	}
+
+	return suite, nil
+}
+
+// String returns the suite string the suite was parsed from.
+func (s Suite) String() string {
+	return s.raw
+}
+
+// hashFunc returns the hash function of the given OCRA hash name, or nil if it isn't supported.
+func hashFunc(name string) func() hash.Hash {
+	switch name {
+	case "SHA1":
+		return sha1.New
+	case "SHA256":
+		return sha256.New
+	case "SHA512":
+		return sha512.New
+	}
+	return nil
+}
+
+// parseTimeStep parses the G part of a Tg timestamp input (section 6.3 of the rfc).
+func parseTimeStep(g string) (time.Duration, error) {
+	if len(g) < 2 {
+		return 0, ErrInvalidSuite
+	}
+
+	n, err := strconv.Atoi(g[:len(g)-1])
+	if err != nil {
+		return 0, ErrInvalidSuite
+	}
+
+	switch g[len(g)-1] {
+	case 'S':
+		if n >= 1 && n <= 59 {
+			return time.Duration(n) * time.Second, nil
+		}
+	case 'M':
+		if n >= 1 && n <= 59 {
+			return time.Duration(n) * time.Minute, nil
+		}
+	case 'H':
+		if n >= 1 && n <= 48 {
+			return time.Duration(n) * time.Hour, nil
+		}
+	}
+	return 0, ErrInvalidSuite
+}
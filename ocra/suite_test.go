@@ -0,0 +1,47 @@
+package ocra
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSuite(t *testing.T) {
+	suite, err := ParseSuite("OCRA-1:HOTP-SHA256-8:C-QH40-PSHA1-S128-T30S")
+	if err != nil {
+		t.Fatalf("Error in ParseSuite (err = %v)", err)
+	}
+
+	if suite.Digits != 8 || !suite.Counter || suite.QuestionFormat != QuestionHex || suite.QuestionLength != 40 ||
+		suite.Password == nil || suite.SessionLength != 128 || suite.TimeStep != 30*time.Second {
+		t.Errorf("Error in ParseSuite (got = %+v)", suite)
+	}
+
+	if suite.String() != "OCRA-1:HOTP-SHA256-8:C-QH40-PSHA1-S128-T30S" {
+		t.Errorf("Error in ParseSuite (String() = %s)", suite.String())
+	}
+}
+
+func TestParseSuiteInvalid(t *testing.T) {
+	invalid := []string{
+		"",
+		"OCRA-2:HOTP-SHA1-6:QN08",
+		"OCRA-1:HOTP-MD5-6:QN08",
+		"OCRA-1:HOTP-SHA1-3:QN08",
+		"OCRA-1:HOTP-SHA1-0:QN08",
+		"OCRA-1:HOTP-SHA1-11:QN08",
+		"OCRA-1:HOTP-SHA1-6:C",
+		"OCRA-1:HOTP-SHA1-6:QX08",
+		"OCRA-1:HOTP-SHA1-6:QN65",
+		"OCRA-1:HOTP-SHA1-6:QN08-PMD5",
+		"OCRA-1:HOTP-SHA1-6:QN08-S12",
+		"OCRA-1:HOTP-SHA1-6:QN08-T60S",
+		"OCRA-1:HOTP-SHA1-6:QN08-T1D",
+		"OCRA-1:HOTP-SHA1-6:QN08-T1M-C",
+	}
+
+	for _, s := range invalid {
+		if _, err := ParseSuite(s); err != ErrInvalidSuite {
+			t.Errorf("Error in ParseSuiteInvalid (suite = %s, expected = %v, got = %v)", s, ErrInvalidSuite, err)
+		}
+	}
+}
@@ -1,3 +1,7 @@
 // OTP implements HOTP and TOTP algorithms, as described in their respective RFCs.
 // It also provides helpers when working with Google Authenticator Key URIs.
+//
+// The code generation core compiles with TinyGo. URI parsing and building, otpauth-migration URIs, JSON keys,
+// SecretBox and LockedSecret are left out of TinyGo builds, and of builds with the otpcore tag, which checks the
+// core with the standard toolchain.
 package otp
@@ -0,0 +1,83 @@
+package otptest
+
+import (
+	"time"
+
+	"github.com/xrjr/otp"
+)
+
+// Vector is a test vector of an rfc: the code of a key at a counter, or at a time.
+type Vector struct {
+	Source  string    // rfc and appendix of the vector, e.g. "RFC 4226 Appendix D"
+	Key     otp.Key   // key of the vector, whose Counter is the counter of HOTP vectors
+	Time    time.Time // time of TOTP vectors, zero for HOTP vectors
+	Counter uint64    // counter of HOTP vectors, or time step (called T in rfc) of TOTP vectors
+	Code    string
+}
+
+// rfc secrets, of the size of the output of their hash function
+var (
+	secretSHA1   = []byte("12345678901234567890")
+	secretSHA256 = []byte("12345678901234567890123456789012")
+	secretSHA512 = []byte("1234567890123456789012345678901234567890123456789012345678901234")
+)
+
+// Vectors are the test vectors of appendix D of rfc 4226 and appendix B of rfc 6238, so that libraries and services
+// can check their compatibility:
+//
+//	for _, v := range otptest.Vectors {
+//		if v.Key.Type == otp.TypeTOTP {
+//			opts, _ := v.Key.TOTPOptions()
+//			if code := myTOTP(v.Key.Secret, v.Time, opts); code != v.Code {
+//				t.Errorf("%s: expected %s at %d, got %s", v.Source, v.Code, v.Time.Unix(), code)
+//			}
+//		}
+//	}
+//
+// The vectors, and their keys, must not be modified.
+var Vectors = append(rfc4226Vectors(), rfc6238Vectors()...)
+
+// rfc4226Vectors returns the vectors of appendix D of rfc 4226.
+func rfc4226Vectors() []Vector {
+	codes := []string{"755224", "287082", "359152", "969429", "338314", "254676", "287922", "162583", "399871", "520489"}
+
+	vectors := make([]Vector, len(codes))
+	for i, code := range codes {
+		vectors[i] = Vector{
+			Source:  "RFC 4226 Appendix D",
+			Key:     otp.Key{Type: otp.TypeHOTP, Secret: secretSHA1, Algorithm: "SHA1", Digits: 6, Counter: uint64(i)},
+			Counter: uint64(i),
+			Code:    code,
+		}
+	}
+	return vectors
+}
+
+// rfc6238Vectors returns the vectors of appendix B of rfc 6238.
+func rfc6238Vectors() []Vector {
+	steps := []uint64{0x1, 0x23523EC, 0x23523ED, 0x273EF07, 0x3F940AA, 0x27BC86AA}
+	modes := []struct {
+		algorithm string
+		secret    []byte
+		codes     []string
+	}{
+		{"SHA1", secretSHA1, []string{"94287082", "07081804", "14050471", "89005924", "69279037", "65353130"}},
+		{"SHA256", secretSHA256, []string{"46119246", "68084774", "67062674", "91819424", "90698825", "77737706"}},
+		{"SHA512", secretSHA512, []string{"90693936", "25091201", "99943326", "93441116", "38618901", "47863826"}},
+	}
+
+	// the rfc lists the modes of each time together
+	var vectors []Vector
+	for i, t := range RFC6238Times {
+		for _, mode := range modes {
+			vectors = append(vectors, Vector{
+				Source:  "RFC 6238 Appendix B",
+				Key:     otp.Key{Type: otp.TypeTOTP, Secret: mode.secret, Algorithm: mode.algorithm, Digits: 8, Period: 30},
+				Time:    t,
+				Counter: steps[i],
+				Code:    mode.codes[i],
+			})
+		}
+	}
+	return vectors
+}
@@ -0,0 +1,32 @@
+package otptest
+
+import (
+	"testing"
+
+	"github.com/xrjr/otp"
+)
+
+func TestVectors(t *testing.T) {
+	if len(Vectors) != 10+18 {
+		t.Fatalf("Error in Vectors (expected = 28 vectors, got = %d)", len(Vectors))
+	}
+
+	for i, v := range Vectors {
+		var code otp.Code
+		var err error
+		switch v.Key.Type {
+		case otp.TypeHOTP:
+			opts, _ := v.Key.HOTPOptions()
+			code, err = otp.HOTPE(v.Key.Secret, v.Key.Counter, opts)
+		case otp.TypeTOTP:
+			opts, _ := v.Key.TOTPOptions()
+			code, err = otp.TOTPE(v.Key.Secret, v.Time, opts)
+		}
+		if err != nil || code.Value != v.Code || code.Counter != v.Counter {
+			t.Errorf("Error in Vectors (i = %d, expected = %s at %d, got = %s at %d, err = %v)", i, v.Code, v.Counter, code.Value, code.Counter, err)
+		}
+		if err := v.Key.Validate(); err != nil {
+			t.Errorf("Error in Vectors (i = %d, err = %v)", i, err)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+// Package otptest helps testing code built on otp: it generates test vectors in the format of the appendices of rfc
+// 4226 and rfc 6238, so that downstream projects and hardware vendors can produce interoperability fixtures for
+// any secret and algorithm, exposes the vectors of the rfcs as Vectors, and provides a Verifier with scripted
+// outcomes, standing in for a server.Validator in the tests of applications.
+package otptest
+
+import (
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/xrjr/otp"
+)
+
+// RFC6238Times are the times of the test vectors of appendix B of rfc 6238.
+var RFC6238Times = []time.Time{
+	time.Unix(59, 0),
+	time.Unix(1111111109, 0),
+	time.Unix(1111111111, 0),
+	time.Unix(1234567890, 0),
+	time.Unix(2000000000, 0),
+	time.Unix(20000000000, 0),
+}
+
+// HOTPVector is a test vector of HOTP, as in appendix D of rfc 4226.
+type HOTPVector struct {
+	Counter   uint64 `json:"counter"`
+	HMAC      string `json:"hmac"`      // hmac of the counter, in hex
+	Truncated uint32 `json:"truncated"` // dynamic truncation of the hmac, before its reduction to the digits of the code
+	Code      string `json:"code"`
+}
+
+// TOTPVector is a test vector of TOTP, as in appendix B of rfc 6238.
+type TOTPVector struct {
+	Time time.Time `json:"time"`
+	T    uint64    `json:"t"`    // time step of the time
+	Mode string    `json:"mode"` // name of the algorithm, e.g. "SHA1"
+	Code string    `json:"code"`
+}
+
+// HOTPVectors returns the test vectors of count counters of a key, starting at its counter. Keys with a custom
+// truncation aren't supported: the truncated value is always the dynamic truncation of the hmac.
+func HOTPVectors(key otp.Key, count uint64) ([]HOTPVector, error) {
+	opts, err := key.HOTPOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	vectors := make([]HOTPVector, 0, count)
+	for counter := key.Counter; counter < key.Counter+count; counter++ {
+		code, err := otp.HOTPE(key.Secret, counter, opts)
+		if err != nil {
+			return nil, err
+		}
+		mac := otp.HMACCounter(hashFunc(opts), key.Secret, counter)
+		vectors = append(vectors, HOTPVector{
+			Counter:   counter,
+			HMAC:      hex.EncodeToString(mac),
+			Truncated: otp.DynamicTruncation(mac),
+			Code:      code.Value,
+		})
+	}
+	return vectors, nil
+}
+
+// TOTPVectors returns the test vectors of a key at given times, e.g. RFC6238Times.
+func TOTPVectors(key otp.Key, times []time.Time) ([]TOTPVector, error) {
+	opts, err := key.TOTPOptions()
+	if err != nil {
+		return nil, err
+	}
+	mode := key.Algorithm
+	if mode == "" {
+		mode = "SHA1"
+	}
+
+	vectors := make([]TOTPVector, 0, len(times))
+	for _, t := range times {
+		code, err := otp.TOTPE(key.Secret, t, opts)
+		if err != nil {
+			return nil, err
+		}
+		vectors = append(vectors, TOTPVector{Time: t, T: code.Counter, Mode: mode, Code: code.Value})
+	}
+	return vectors, nil
+}
+
+// WriteHOTPTable writes test vectors as a table, in the layout of appendix D of rfc 4226.
+func WriteHOTPTable(w io.Writer, vectors []HOTPVector) error {
+	rows := make([][]string, len(vectors))
+	for i, v := range vectors {
+		var truncated [4]byte
+		binary.BigEndian.PutUint32(truncated[:], v.Truncated)
+		rows[i] = []string{fmt.Sprint(v.Counter), v.HMAC, hex.EncodeToString(truncated[:]), fmt.Sprint(v.Truncated), v.Code}
+	}
+	return writeTable(w, []string{"Count", "Hexadecimal HMAC", "Truncated (hex)", "Truncated (dec)", "HOTP"}, rows)
+}
+
+// WriteTOTPTable writes test vectors as a table, in the layout of appendix B of rfc 6238.
+func WriteTOTPTable(w io.Writer, vectors []TOTPVector) error {
+	rows := make([][]string, len(vectors))
+	for i, v := range vectors {
+		rows[i] = []string{
+			fmt.Sprint(v.Time.Unix()),
+			v.Time.UTC().Format(time.DateTime),
+			fmt.Sprintf("%016X", v.T),
+			v.Code,
+			v.Mode,
+		}
+	}
+	return writeTable(w, []string{"Time (sec)", "UTC Time", "Value of T (hex)", "TOTP", "Mode"}, rows)
+}
+
+// writeTable writes a table with borders, its cells centered in their column.
+func writeTable(w io.Writer, header []string, rows [][]string) error {
+	widths := make([]int, len(header))
+	for _, row := range append([][]string{header}, rows...) {
+		for i, cell := range row {
+			widths[i] = max(widths[i], len(cell))
+		}
+	}
+
+	var b strings.Builder
+	border := func() {
+		for _, width := range widths {
+			b.WriteString("+" + strings.Repeat("-", width+4))
+		}
+		b.WriteString("+\n")
+	}
+	line := func(row []string) {
+		for i, cell := range row {
+			left := (widths[i] + 4 - len(cell)) / 2
+			right := widths[i] + 4 - len(cell) - left
+			b.WriteString("|" + strings.Repeat(" ", left) + cell + strings.Repeat(" ", right))
+		}
+		b.WriteString("|\n")
+	}
+
+	border()
+	line(header)
+	border()
+	for _, row := range rows {
+		line(row)
+	}
+	border()
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// hashFunc returns the hash function of options, SHA1 by default.
+func hashFunc(opts otp.HOTPOptions) func() hash.Hash {
+	switch {
+	case opts.Algorithm != nil:
+		return opts.Algorithm
+	case opts.Hash != 0:
+		return opts.Hash.New
+	default:
+		return sha1.New
+	}
+}
@@ -0,0 +1,113 @@
+package otptest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/xrjr/otp"
+)
+
+func TestHOTPVectors(t *testing.T) {
+	// appendix D of rfc 4226
+	expected := []HOTPVector{
+		{0, "cc93cf18508d94934c64b65d8ba7667fb7cde4b0", 1284755224, "755224"},
+		{1, "75a48a19d4cbe100644e8ac1397eea747a2d33ab", 1094287082, "287082"},
+		{9, "1637409809a679dc698207310c8c7fc07290d9e5", 645520489, "520489"},
+	}
+	key := otp.Key{Type: otp.TypeHOTP, Secret: []byte("12345678901234567890")}
+
+	vectors, err := HOTPVectors(key, 10)
+	if err != nil || len(vectors) != 10 {
+		t.Fatalf("Error in HOTPVectors (expected = 10 vectors, got = %d, err = %v)", len(vectors), err)
+	}
+	for i, v := range expected {
+		if got := vectors[v.Counter]; got != v {
+			t.Errorf("Error in HOTPVectors (i = %d, expected = %v, got = %v)", i, v, got)
+		}
+	}
+
+	key.Counter = 9
+	vectors, err = HOTPVectors(key, 1)
+	if err != nil || len(vectors) != 1 || vectors[0] != expected[2] {
+		t.Errorf("Error in HOTPVectors (expected = %v, got = %v, err = %v)", expected[2:], vectors, err)
+	}
+
+	key.Algorithm = "MD4"
+	if _, err := HOTPVectors(key, 1); err == nil {
+		t.Errorf("Error in HOTPVectors (unknown algorithm accepted)")
+	}
+}
+
+func TestTOTPVectors(t *testing.T) {
+	// appendix B of rfc 6238
+	tests := []struct {
+		key      otp.Key
+		expected []string
+	}{
+		{
+			key:      otp.Key{Type: otp.TypeTOTP, Secret: []byte("12345678901234567890"), Digits: 8},
+			expected: []string{"94287082", "07081804", "14050471", "89005924", "69279037", "65353130"},
+		},
+		{
+			key:      otp.Key{Type: otp.TypeTOTP, Secret: []byte("12345678901234567890123456789012"), Algorithm: "SHA256", Digits: 8},
+			expected: []string{"46119246", "68084774", "67062674", "91819424", "90698825", "77737706"},
+		},
+		{
+			key:      otp.Key{Type: otp.TypeTOTP, Secret: []byte("1234567890123456789012345678901234567890123456789012345678901234"), Algorithm: "SHA512", Digits: 8},
+			expected: []string{"90693936", "25091201", "99943326", "93441116", "38618901", "47863826"},
+		},
+	}
+
+	for i, test := range tests {
+		vectors, err := TOTPVectors(test.key, RFC6238Times)
+		if err != nil {
+			t.Fatalf("Error in TOTPVectors (i = %d, err = %v)", i, err)
+		}
+		for j, v := range vectors {
+			if v.Code != test.expected[j] {
+				t.Errorf("Error in TOTPVectors (i = %d, j = %d, expected = %s, got = %s)", i, j, test.expected[j], v.Code)
+			}
+		}
+		if vectors[0].T != 1 || vectors[5].T != 0x27BC86AA {
+			t.Errorf("Error in TOTPVectors (i = %d, expected = 1 and 27BC86AA, got = %X and %X)", i, vectors[0].T, vectors[5].T)
+		}
+	}
+}
+
+func TestWriteTOTPTable(t *testing.T) {
+	key := otp.Key{Type: otp.TypeTOTP, Secret: []byte("12345678901234567890"), Digits: 8}
+	vectors, err := TOTPVectors(key, RFC6238Times[:1])
+	if err != nil {
+		t.Fatalf("Error in TOTPVectors (err = %v)", err)
+	}
+
+	var b strings.Builder
+	if err := WriteTOTPTable(&b, vectors); err != nil {
+		t.Fatalf("Error in WriteTOTPTable (err = %v)", err)
+	}
+	expected := `+--------------+-----------------------+--------------------+------------+--------+
+|  Time (sec)  |       UTC Time        |  Value of T (hex)  |    TOTP    |  Mode  |
++--------------+-----------------------+--------------------+------------+--------+
+|      59      |  1970-01-01 00:00:59  |  0000000000000001  |  94287082  |  SHA1  |
++--------------+-----------------------+--------------------+------------+--------+
+`
+	if b.String() != expected {
+		t.Errorf("Error in WriteTOTPTable (expected = \n%s, got = \n%s)", expected, b.String())
+	}
+}
+
+func TestWriteHOTPTable(t *testing.T) {
+	key := otp.Key{Type: otp.TypeHOTP, Secret: []byte("12345678901234567890")}
+	vectors, err := HOTPVectors(key, 1)
+	if err != nil {
+		t.Fatalf("Error in HOTPVectors (err = %v)", err)
+	}
+
+	var b strings.Builder
+	if err := WriteHOTPTable(&b, vectors); err != nil {
+		t.Fatalf("Error in WriteHOTPTable (err = %v)", err)
+	}
+	if row := "|    0    |  cc93cf18508d94934c64b65d8ba7667fb7cde4b0  |     4c93cf18      |    1284755224     |  755224  |\n"; !strings.Contains(b.String(), row) {
+		t.Errorf("Error in WriteHOTPTable (expected row = \n%s, got = \n%s)", row, b.String())
+	}
+}
@@ -0,0 +1,115 @@
+package otptest
+
+import (
+	"context"
+	"sync"
+
+	"github.com/xrjr/otp"
+	"github.com/xrjr/otp/server"
+)
+
+// Call is a call recorded by a Verifier.
+type Call struct {
+	ID   string
+	Key  otp.Key
+	Code string
+}
+
+// outcome is a scripted outcome of a Verifier.
+type outcome struct {
+	res server.Result
+	err error
+}
+
+// Verifier is a server.Verifier with scripted outcomes, recording its calls, so that the authentication flows of
+// applications can be unit tested without real keys:
+//
+//	v := new(otptest.Verifier).Reject(nil).Accept(server.Result{})
+//	mw := httpmw.Require(httpmw.Options{Validator: v, Key: keyOf})
+//	...
+//	if calls := v.Calls(); len(calls) != 2 || calls[1].Code != "123456" {
+//		t.Errorf(...)
+//	}
+//
+// The scripted outcomes are returned once each, in order. Once they are exhausted, the calls are answered by Func,
+// or rejected with server.ErrInvalidCode when Func is nil. The zero value is ready to use, and is safe for
+// concurrent use.
+type Verifier struct {
+	// Func answers the calls once the scripted outcomes are exhausted.
+	Func func(ctx context.Context, id string, key otp.Key, code string) (server.Result, error)
+
+	mu       sync.Mutex
+	outcomes []outcome
+	calls    []Call
+}
+
+// Accept scripts the acceptance of a call, returning res. The ID of res defaults to the id of the call.
+func (v *Verifier) Accept(res server.Result) *Verifier {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.outcomes = append(v.outcomes, outcome{res: res})
+	return v
+}
+
+// Reject scripts the rejection of a call with err, server.ErrInvalidCode when nil.
+func (v *Verifier) Reject(err error) *Verifier {
+	if err == nil {
+		err = server.ErrInvalidCode
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.outcomes = append(v.outcomes, outcome{err: err})
+	return v
+}
+
+// Calls returns the calls recorded, in order.
+func (v *Verifier) Calls() []Call {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	calls := make([]Call, len(v.calls))
+	copy(calls, v.calls)
+	return calls
+}
+
+// Reset forgets the scripted outcomes not returned yet and the calls recorded.
+func (v *Verifier) Reset() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.outcomes, v.calls = nil, nil
+}
+
+// Verify records the call and returns its outcome.
+func (v *Verifier) Verify(id string, key otp.Key, code string) (server.Result, error) {
+	return v.VerifyContext(context.Background(), id, key, code)
+}
+
+// VerifyContext records the call and returns its outcome.
+func (v *Verifier) VerifyContext(ctx context.Context, id string, key otp.Key, code string) (server.Result, error) {
+	v.mu.Lock()
+	v.calls = append(v.calls, Call{ID: id, Key: key, Code: code})
+	if len(v.outcomes) == 0 {
+		v.mu.Unlock()
+		if v.Func == nil {
+			return server.Result{}, server.ErrInvalidCode
+		}
+		return v.Func(ctx, id, key, code)
+	}
+	o := v.outcomes[0]
+	v.outcomes = v.outcomes[1:]
+	v.mu.Unlock()
+
+	if o.err != nil {
+		return server.Result{}, o.err
+	}
+	if o.res.ID == "" {
+		o.res.ID = id
+	}
+	return o.res, nil
+}
+
+var _ server.Verifier = (*Verifier)(nil)
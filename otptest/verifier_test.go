@@ -0,0 +1,66 @@
+package otptest
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/xrjr/otp"
+	"github.com/xrjr/otp/server"
+)
+
+func TestVerifier(t *testing.T) {
+	key := otp.Key{Type: otp.TypeTOTP, Secret: []byte("12345678901234567890")}
+	v := new(Verifier).
+		Accept(server.Result{Counter: 42}).
+		Reject(nil).
+		Reject(server.ErrReplayedCode)
+
+	tests := []struct {
+		res server.Result
+		err error
+	}{
+		{res: server.Result{ID: "alice", Counter: 42}},
+		{err: server.ErrInvalidCode},
+		{err: server.ErrReplayedCode},
+		// scripted outcomes are exhausted
+		{err: server.ErrInvalidCode},
+	}
+	for i, test := range tests {
+		res, err := v.Verify("alice", key, "123456")
+		if res != test.res || !errors.Is(err, test.err) {
+			t.Errorf("Error in Verifier (i = %d, expected = %v, %v, got = %v, %v)", i, test.res, test.err, res, err)
+		}
+	}
+
+	v.Func = func(ctx context.Context, id string, key otp.Key, code string) (server.Result, error) {
+		if code == "000000" {
+			return server.Result{ID: id}, nil
+		}
+		return server.Result{}, server.ErrInvalidCode
+	}
+	if res, err := v.VerifyContext(context.Background(), "bob", key, "000000"); err != nil || res.ID != "bob" {
+		t.Errorf("Error in Verifier (expected = bob, got = %v, %v)", res, err)
+	}
+
+	calls := v.Calls()
+	expected := []string{"alice", "alice", "alice", "alice", "bob"}
+	if len(calls) != len(expected) {
+		t.Fatalf("Error in Verifier (expected = %d calls, got = %d)", len(expected), len(calls))
+	}
+	for i, call := range calls {
+		if call.ID != expected[i] || !reflect.DeepEqual(call.Key, key) {
+			t.Errorf("Error in Verifier (i = %d, expected = %s, got = %v)", i, expected[i], call)
+		}
+	}
+
+	v.Accept(server.Result{})
+	v.Reset()
+	if calls := v.Calls(); len(calls) != 0 {
+		t.Errorf("Error in Verifier (expected = no calls, got = %v)", calls)
+	}
+	if _, err := v.Verify("carol", key, "111111"); !errors.Is(err, server.ErrInvalidCode) {
+		t.Errorf("Error in Verifier (expected = %v, got = %v)", server.ErrInvalidCode, err)
+	}
+}
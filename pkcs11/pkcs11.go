@@ -0,0 +1,71 @@
+// Package pkcs11 computes the hmacs of HOTP and TOTP on a PKCS#11 token, so that secrets never leave the token.
+//
+// The package doesn't depend on a PKCS#11 binding: a Session is a thin adapter over one, e.g. with
+// github.com/miekg/pkcs11:
+//
+//	type session struct {
+//		ctx    *pkcs11.Ctx
+//		handle pkcs11.SessionHandle
+//	}
+//
+//	func (s session) SignInit(mechanism uint, key uint) error {
+//		return s.ctx.SignInit(s.handle, []*pkcs11.Mechanism{pkcs11.NewMechanism(mechanism, nil)}, pkcs11.ObjectHandle(key))
+//	}
+//
+//	func (s session) Sign(message []byte) ([]byte, error) {
+//		return s.ctx.Sign(s.handle, message)
+//	}
+package pkcs11
+
+import (
+	"encoding/binary"
+	"sync"
+)
+
+// Hmac mechanisms of the PKCS#11 specification.
+const (
+	MechanismSHA1HMAC   uint = 0x00000221 // CKM_SHA_1_HMAC
+	MechanismSHA224HMAC uint = 0x00000256 // CKM_SHA224_HMAC
+	MechanismSHA256HMAC uint = 0x00000251 // CKM_SHA256_HMAC
+	MechanismSHA384HMAC uint = 0x00000261 // CKM_SHA384_HMAC
+	MechanismSHA512HMAC uint = 0x00000271 // CKM_SHA512_HMAC
+)
+
+// Session is the part of an open PKCS#11 session used to compute hmacs (C_SignInit and C_Sign).
+type Session interface {
+	SignInit(mechanism uint, key uint) error
+	Sign(message []byte) ([]byte, error)
+}
+
+// MACer computes hmacs of counters with a secret key object of a PKCS#11 token. It implements otp.MACer.
+type MACer struct {
+	session   Session
+	key       uint
+	mechanism uint
+
+	// a PKCS#11 session runs one operation at a time
+	mu sync.Mutex
+}
+
+// New returns a MACer computing hmacs with the given mechanism and secret key object handle.
+func New(session Session, key uint, mechanism uint) *MACer {
+	return &MACer{
+		session:   session,
+		key:       key,
+		mechanism: mechanism,
+	}
+}
+
+// MAC computes the hmac of a counter on the token.
+func (m *MACer) MAC(counter uint64) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.session.SignInit(m.mechanism, m.key); err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, counter)
+	return m.session.Sign(buf)
+}
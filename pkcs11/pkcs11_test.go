@@ -0,0 +1,71 @@
+package pkcs11
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+	"hash"
+	"testing"
+	"time"
+
+	"github.com/xrjr/otp"
+)
+
+// testSession is a software token holding a single key object.
+type testSession struct {
+	key       []byte
+	algorithm func() hash.Hash
+	err       error
+}
+
+var testMechanisms = map[uint]func() hash.Hash{
+	MechanismSHA1HMAC:   sha1.New,
+	MechanismSHA256HMAC: sha256.New,
+	MechanismSHA512HMAC: sha512.New,
+}
+
+func (s *testSession) SignInit(mechanism uint, key uint) error {
+	if key != 1 {
+		return errors.New("CKR_KEY_HANDLE_INVALID")
+	}
+	s.algorithm = testMechanisms[mechanism]
+	return s.err
+}
+
+func (s *testSession) Sign(message []byte) ([]byte, error) {
+	hasher := hmac.New(s.algorithm, s.key)
+	hasher.Write(message)
+	return hasher.Sum(nil), nil
+}
+
+var testSecret = []byte("12345678901234567890123456789012")
+
+func TestMACer(t *testing.T) {
+	for mechanism, algorithm := range testMechanisms {
+		m := New(&testSession{key: testSecret}, 1, mechanism)
+		opts := otp.TOTPOptions{HOTPOptions: otp.HOTPOptions{Digits: 8}}
+		now := time.Unix(1111111109, 0)
+
+		res, err := otp.TOTPMAC(m, now, opts)
+		opts.Algorithm = algorithm
+		expected := otp.TOTPCode(testSecret, now, opts)
+		if err != nil || res != expected {
+			t.Errorf("Error in MACer (mechanism = %x, expected = %+v, got = %+v, err = %v)", mechanism, expected, res, err)
+		}
+	}
+}
+
+func TestMACerError(t *testing.T) {
+	errSession := errors.New("CKR_SESSION_CLOSED")
+	m := New(&testSession{key: testSecret, err: errSession}, 1, MechanismSHA1HMAC)
+	if _, err := otp.HOTPMAC(m, 0, otp.HOTPOptions{}); err != errSession {
+		t.Errorf("Error in MACerError (expected = %v, got = %v)", errSession, err)
+	}
+
+	m = New(&testSession{key: testSecret}, 2, MechanismSHA1HMAC)
+	if _, err := otp.HOTPMAC(m, 0, otp.HOTPOptions{}); err == nil {
+		t.Errorf("Error in MACerError (invalid key handle accepted)")
+	}
+}
@@ -0,0 +1,578 @@
+package qr
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"strings"
+)
+
+// Level is the error correction level of a QR code, recovering about 7%, 15%, 25% and 30% of its codewords
+// respectively.
+type Level int
+
+const (
+	LevelL Level = iota
+	LevelM
+	LevelQ
+	LevelH
+)
+
+// ErrTooLong is returned when a text doesn't fit in the largest QR code of an error correction level.
+var ErrTooLong = errors.New("qr: text too long")
+
+// eccCodewordsPerBlock and numBlocks are the error correction codewords per block and the number of blocks of each
+// level and version (index 0 is unused), from table 9 of ISO/IEC 18004.
+var (
+	eccCodewordsPerBlock = [4][41]int{
+		{0, 7, 10, 15, 20, 26, 18, 20, 24, 30, 18, 20, 24, 26, 30, 22, 24, 28, 30, 28, 28, 28, 28, 30, 30, 26, 28, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+		{0, 10, 16, 26, 18, 24, 16, 18, 22, 22, 26, 30, 22, 22, 24, 24, 28, 28, 26, 26, 26, 26, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28},
+		{0, 13, 22, 18, 26, 18, 24, 18, 22, 20, 24, 28, 26, 24, 20, 30, 24, 28, 28, 26, 30, 28, 30, 30, 30, 30, 28, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+		{0, 17, 28, 22, 16, 22, 28, 26, 26, 24, 28, 24, 28, 22, 24, 24, 30, 28, 28, 26, 28, 30, 24, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+	}
+	numBlocks = [4][41]int{
+		{0, 1, 1, 1, 1, 1, 2, 2, 2, 2, 4, 4, 4, 4, 4, 6, 6, 6, 6, 7, 8, 8, 9, 9, 10, 12, 12, 12, 13, 14, 15, 16, 17, 18, 19, 19, 20, 21, 22, 24, 25},
+		{0, 1, 1, 1, 2, 2, 4, 4, 4, 5, 5, 5, 8, 9, 9, 10, 10, 11, 13, 14, 16, 17, 17, 18, 20, 21, 23, 25, 26, 28, 29, 31, 33, 35, 37, 38, 40, 43, 45, 47, 49},
+		{0, 1, 1, 2, 2, 4, 4, 6, 6, 8, 8, 8, 10, 12, 16, 12, 17, 16, 18, 21, 20, 23, 23, 25, 27, 29, 34, 34, 35, 38, 40, 43, 45, 48, 51, 53, 56, 59, 62, 65, 68},
+		{0, 1, 1, 2, 4, 4, 4, 5, 6, 8, 8, 11, 11, 16, 16, 18, 16, 19, 21, 25, 25, 25, 34, 30, 32, 35, 37, 40, 42, 45, 48, 51, 54, 57, 60, 63, 66, 70, 74, 77, 81},
+	}
+)
+
+// formatLevel is the value of each level in the format information, which doesn't follow their order.
+var formatLevel = [4]int{1, 0, 3, 2}
+
+// Code is a QR code, as a square of dark and light modules. Texts are encoded in byte mode, which holds any text
+// such as otpauth URIs, in the smallest version fitting them.
+type Code struct {
+	Size    int // number of modules of a side, 17 + 4 × version
+	Version int
+	Level   Level
+
+	modules []bool
+}
+
+// quietZone is the number of light modules around the images and texts of codes.
+const quietZone = 4
+
+// New returns the QR code of a text, with an error correction level.
+func New(text string, level Level) (*Code, error) {
+	data := []byte(text)
+	version := 1
+	for ; version <= 40; version++ {
+		if 4+countBits(version)+8*len(data) <= 8*numDataCodewords(version, level) {
+			break
+		}
+	}
+	if version > 40 {
+		return nil, ErrTooLong
+	}
+
+	// segment in byte mode, terminator and padding
+	var bits bitBuffer
+	bits.append(0b0100, 4)
+	bits.append(len(data), countBits(version))
+	for _, b := range data {
+		bits.append(int(b), 8)
+	}
+	capacity := 8 * numDataCodewords(version, level)
+	bits.append(0, min(4, capacity-len(bits)))
+	bits.append(0, (8-len(bits)%8)%8)
+	for pad := 0xEC; len(bits) < capacity; pad ^= 0xEC ^ 0x11 {
+		bits.append(pad, 8)
+	}
+
+	b := newBuilder(version, level)
+	b.drawFunctionPatterns()
+	b.drawCodewords(addECCAndInterleave(bits.bytes(), version, level))
+
+	// the mask with the lowest penalty is kept
+	best, bestPenalty := 0, -1
+	for mask := range 8 {
+		b.applyMask(mask)
+		b.drawFormatBits(mask)
+		if penalty := b.penalty(); bestPenalty < 0 || penalty < bestPenalty {
+			best, bestPenalty = mask, penalty
+		}
+		b.applyMask(mask)
+	}
+	b.applyMask(best)
+	b.drawFormatBits(best)
+
+	return &Code{Size: b.size, Version: version, Level: level, modules: b.modules}, nil
+}
+
+// Dark reports whether the module of column x and row y is dark. Modules outside the code are light.
+func (c *Code) Dark(x, y int) bool {
+	return x >= 0 && y >= 0 && x < c.Size && y < c.Size && c.modules[y*c.Size+x]
+}
+
+// Image returns the image of the code, each module being a square of scale pixels, surrounded by a quiet zone of
+// 4 modules.
+func (c *Code) Image(scale int) *image.Gray {
+	scale = max(scale, 1)
+	side := (c.Size + 2*quietZone) * scale
+	img := image.NewGray(image.Rect(0, 0, side, side))
+	for y := range side {
+		for x := range side {
+			v := color.Gray{Y: 255}
+			if c.Dark(x/scale-quietZone, y/scale-quietZone) {
+				v = color.Gray{}
+			}
+			img.SetGray(x, y, v)
+		}
+	}
+	return img
+}
+
+// Text returns the code drawn with Unicode block elements, two rows of modules per line, surrounded by a quiet zone
+// of 4 modules. Dark modules are drawn as blocks, or light ones when inverse is set, as needed by terminals writing
+// light text on a dark background.
+func (c *Code) Text(inverse bool) string {
+	on := func(x, y int) bool {
+		return c.Dark(x, y) != inverse
+	}
+
+	var sb strings.Builder
+	for y := -quietZone; y < c.Size+quietZone; y += 2 {
+		for x := -quietZone; x < c.Size+quietZone; x++ {
+			top, bottom := on(x, y), on(x, y+1) && y+1 < c.Size+quietZone
+			switch {
+			case top && bottom:
+				sb.WriteRune('█')
+			case top:
+				sb.WriteRune('▀')
+			case bottom:
+				sb.WriteRune('▄')
+			default:
+				sb.WriteRune(' ')
+			}
+		}
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+// ImageEncoder is an Encoder drawing QR codes with New, without external QR code library.
+type ImageEncoder struct {
+	Level Level // error correction level, defaults to LevelL
+	Scale int   // pixels per module, defaults to 8
+}
+
+// Encode implements Encoder.
+func (e ImageEncoder) Encode(text string) (image.Image, error) {
+	c, err := New(text, e.Level)
+	if err != nil {
+		return nil, err
+	}
+	if e.Scale == 0 {
+		e.Scale = 8
+	}
+	return c.Image(e.Scale), nil
+}
+
+// countBits returns the length of the character count of byte mode segments.
+func countBits(version int) int {
+	if version <= 9 {
+		return 8
+	}
+	return 16
+}
+
+// numRawDataModules returns the number of modules of a version which hold data or error correction codewords,
+// remainder bits included.
+func numRawDataModules(version int) int {
+	n := (16*version+128)*version + 64
+	if version >= 2 {
+		numAlign := version/7 + 2
+		n -= (25*numAlign-10)*numAlign - 55
+		if version >= 7 {
+			n -= 36
+		}
+	}
+	return n
+}
+
+// numDataCodewords returns the number of data codewords of a version and level.
+func numDataCodewords(version int, level Level) int {
+	return numRawDataModules(version)/8 - eccCodewordsPerBlock[level][version]*numBlocks[level][version]
+}
+
+// alignmentPositions returns the coordinates of the centers of the alignment patterns of a version, on both axes.
+func alignmentPositions(version int) []int {
+	if version == 1 {
+		return nil
+	}
+	numAlign := version/7 + 2
+	step := (version*8 + numAlign*3 + 5) / (numAlign*4 - 4) * 2
+	positions := make([]int, numAlign)
+	positions[0] = 6
+	for i, pos := numAlign-1, version*4+17-7; i >= 1; i, pos = i-1, pos-step {
+		positions[i] = pos
+	}
+	return positions
+}
+
+// addECCAndInterleave splits the data codewords in blocks, appends the error correction codewords of each block,
+// and interleaves the blocks.
+func addECCAndInterleave(data []byte, version int, level Level) []byte {
+	blocks, eccLen := numBlocks[level][version], eccCodewordsPerBlock[level][version]
+	raw := numRawDataModules(version) / 8
+	numShort := blocks - raw%blocks
+	shortLen := raw / blocks
+
+	divisor := rsDivisor(eccLen)
+	var all [][]byte
+	for i, k := 0, 0; i < blocks; i++ {
+		n := shortLen - eccLen
+		if i >= numShort {
+			n++
+		}
+		block := append([]byte(nil), data[k:k+n]...)
+		k += n
+		ecc := rsRemainder(block, divisor)
+		if i < numShort {
+			// placeholder, so that all the blocks have the same length
+			block = append(block, 0)
+		}
+		all = append(all, append(block, ecc...))
+	}
+
+	var res []byte
+	for i := range all[0] {
+		for j, block := range all {
+			// skips the placeholders of the short blocks
+			if i != shortLen-eccLen || j >= numShort {
+				res = append(res, block[i])
+			}
+		}
+	}
+	return res
+}
+
+// rsDivisor returns the generator polynomial of Reed-Solomon codes of a degree, with its coefficients from the
+// highest to the lowest power, without the leading 1.
+func rsDivisor(degree int) []byte {
+	res := make([]byte, degree)
+	res[degree-1] = 1
+	root := byte(1)
+	for range degree {
+		for j := range res {
+			res[j] = gfMultiply(res[j], root)
+			if j+1 < len(res) {
+				res[j] ^= res[j+1]
+			}
+		}
+		root = gfMultiply(root, 0x02)
+	}
+	return res
+}
+
+// rsRemainder returns the Reed-Solomon error correction codewords of data.
+func rsRemainder(data []byte, divisor []byte) []byte {
+	res := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ res[0]
+		copy(res, res[1:])
+		res[len(res)-1] = 0
+		for i, coef := range divisor {
+			res[i] ^= gfMultiply(coef, factor)
+		}
+	}
+	return res
+}
+
+// gfMultiply multiplies two elements of GF(2^8), modulo x^8 + x^4 + x^3 + x^2 + 1.
+func gfMultiply(x, y byte) byte {
+	var z int
+	for i := 7; i >= 0; i-- {
+		z = (z << 1) ^ ((z >> 7) * 0x11D)
+		z ^= int((y>>i)&1) * int(x)
+	}
+	return byte(z)
+}
+
+// bitBuffer is a sequence of bits.
+type bitBuffer []bool
+
+// append appends the n lowest bits of v, from the highest one.
+func (b *bitBuffer) append(v int, n int) {
+	for i := n - 1; i >= 0; i-- {
+		*b = append(*b, (v>>i)&1 == 1)
+	}
+}
+
+// bytes returns the bits packed in bytes.
+func (b bitBuffer) bytes() []byte {
+	res := make([]byte, (len(b)+7)/8)
+	for i, bit := range b {
+		if bit {
+			res[i/8] |= 0x80 >> (i % 8)
+		}
+	}
+	return res
+}
+
+// builder draws the modules of a code.
+type builder struct {
+	version  int
+	level    Level
+	size     int
+	modules  []bool
+	function []bool // modules of the function patterns, which aren't masked
+}
+
+func newBuilder(version int, level Level) *builder {
+	size := version*4 + 17
+	return &builder{version: version, level: level, size: size, modules: make([]bool, size*size), function: make([]bool, size*size)}
+}
+
+// setFunction sets a module of a function pattern.
+func (b *builder) setFunction(x, y int, dark bool) {
+	b.modules[y*b.size+x] = dark
+	b.function[y*b.size+x] = true
+}
+
+// drawFunctionPatterns draws the finder, timing and alignment patterns, and the version information, and reserves
+// the modules of the format information.
+func (b *builder) drawFunctionPatterns() {
+	for i := range b.size {
+		b.setFunction(6, i, i%2 == 0)
+		b.setFunction(i, 6, i%2 == 0)
+	}
+
+	b.drawFinder(3, 3)
+	b.drawFinder(b.size-4, 3)
+	b.drawFinder(3, b.size-4)
+
+	positions := alignmentPositions(b.version)
+	n := len(positions)
+	for i := range n {
+		for j := range n {
+			// the corners of the finder patterns
+			if (i == 0 && j == 0) || (i == 0 && j == n-1) || (i == n-1 && j == 0) {
+				continue
+			}
+			b.drawAlignment(positions[i], positions[j])
+		}
+	}
+
+	b.drawFormatBits(0)
+	b.drawVersion()
+}
+
+// drawFinder draws a finder pattern centered on x and y, with its separator.
+func (b *builder) drawFinder(x, y int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			xx, yy := x+dx, y+dy
+			if xx < 0 || yy < 0 || xx >= b.size || yy >= b.size {
+				continue
+			}
+			dist := max(abs(dx), abs(dy))
+			b.setFunction(xx, yy, dist != 2 && dist != 4)
+		}
+	}
+}
+
+// drawAlignment draws an alignment pattern centered on x and y.
+func (b *builder) drawAlignment(x, y int) {
+	for dy := -2; dy <= 2; dy++ {
+		for dx := -2; dx <= 2; dx++ {
+			b.setFunction(x+dx, y+dy, max(abs(dx), abs(dy)) != 1)
+		}
+	}
+}
+
+// drawFormatBits draws both copies of the format information of a mask, and the dark module.
+func (b *builder) drawFormatBits(mask int) {
+	data := formatLevel[b.level]<<3 | mask
+	rem := data
+	for range 10 {
+		rem = (rem << 1) ^ ((rem >> 9) * 0x537)
+	}
+	bits := (data<<10 | rem) ^ 0x5412
+	bit := func(i int) bool {
+		return (bits>>i)&1 == 1
+	}
+
+	for i := 0; i <= 5; i++ {
+		b.setFunction(8, i, bit(i))
+	}
+	b.setFunction(8, 7, bit(6))
+	b.setFunction(8, 8, bit(7))
+	b.setFunction(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		b.setFunction(14-i, 8, bit(i))
+	}
+
+	for i := range 8 {
+		b.setFunction(b.size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		b.setFunction(8, b.size-15+i, bit(i))
+	}
+	b.setFunction(8, b.size-8, true)
+}
+
+// drawVersion draws both copies of the version information, from version 7.
+func (b *builder) drawVersion() {
+	if b.version < 7 {
+		return
+	}
+	rem := b.version
+	for range 12 {
+		rem = (rem << 1) ^ ((rem >> 11) * 0x1F25)
+	}
+	bits := b.version<<12 | rem
+
+	for i := range 18 {
+		dark := (bits>>i)&1 == 1
+		x, y := b.size-11+i%3, i/3
+		b.setFunction(x, y, dark)
+		b.setFunction(y, x, dark)
+	}
+}
+
+// drawCodewords draws the codewords in the modules which aren't part of function patterns, in the zigzag order of
+// the columns pairs from the bottom right corner.
+func (b *builder) drawCodewords(data []byte) {
+	i := 0
+	for right := b.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			// the vertical timing pattern
+			right = 5
+		}
+		upward := (right+1)&2 == 0
+		for vert := range b.size {
+			y := vert
+			if upward {
+				y = b.size - 1 - vert
+			}
+			for j := range 2 {
+				x := right - j
+				if b.function[y*b.size+x] || i >= len(data)*8 {
+					continue
+				}
+				b.modules[y*b.size+x] = data[i/8]>>(7-i%8)&1 == 1
+				i++
+			}
+		}
+	}
+}
+
+// applyMask inverts the modules selected by a mask, except the ones of function patterns. Applying a mask twice
+// restores the modules.
+func (b *builder) applyMask(mask int) {
+	for y := range b.size {
+		for x := range b.size {
+			var invert bool
+			switch mask {
+			case 0:
+				invert = (x+y)%2 == 0
+			case 1:
+				invert = y%2 == 0
+			case 2:
+				invert = x%3 == 0
+			case 3:
+				invert = (x+y)%3 == 0
+			case 4:
+				invert = (x/3+y/2)%2 == 0
+			case 5:
+				invert = x*y%2+x*y%3 == 0
+			case 6:
+				invert = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				invert = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if invert && !b.function[y*b.size+x] {
+				b.modules[y*b.size+x] = !b.modules[y*b.size+x]
+			}
+		}
+	}
+}
+
+// finderLike are the patterns penalized by the third rule, 1:1:3:1:1 patterns preceded or followed by 4 light
+// modules.
+var finderLike = [2][11]bool{
+	{true, false, true, true, true, false, true, false, false, false, false},
+	{false, false, false, false, true, false, true, true, true, false, true},
+}
+
+// penalty returns the penalty of the modules, as defined by section 7.8.3 of ISO/IEC 18004.
+func (b *builder) penalty() int {
+	penalty := 0
+	dark := func(x, y int) bool {
+		return b.modules[y*b.size+x]
+	}
+
+	for _, transposed := range []bool{false, true} {
+		at := dark
+		if transposed {
+			at = func(x, y int) bool {
+				return dark(y, x)
+			}
+		}
+		for y := range b.size {
+			// runs of 5 or more modules of the same color
+			run := 1
+			for x := 1; x < b.size; x++ {
+				if at(x, y) == at(x-1, y) {
+					run++
+					continue
+				}
+				if run >= 5 {
+					penalty += 3 + run - 5
+				}
+				run = 1
+			}
+			if run >= 5 {
+				penalty += 3 + run - 5
+			}
+
+			// patterns similar to the finder patterns
+			for x := 0; x+11 <= b.size; x++ {
+				for _, pattern := range finderLike {
+					match := true
+					for i, v := range pattern {
+						if at(x+i, y) != v {
+							match = false
+							break
+						}
+					}
+					if match {
+						penalty += 40
+					}
+				}
+			}
+		}
+	}
+
+	// blocks of 2×2 modules of the same color
+	for y := 0; y+1 < b.size; y++ {
+		for x := 0; x+1 < b.size; x++ {
+			c := dark(x, y)
+			if c == dark(x+1, y) && c == dark(x, y+1) && c == dark(x+1, y+1) {
+				penalty += 3
+			}
+		}
+	}
+
+	// balance of dark and light modules
+	numDark := 0
+	for _, m := range b.modules {
+		if m {
+			numDark++
+		}
+	}
+	total := len(b.modules)
+	k := (abs(numDark*20-total*10)+total-1)/total - 1
+	penalty += max(k, 0) * 10
+	return penalty
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
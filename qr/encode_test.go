@@ -0,0 +1,274 @@
+package qr
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestByteCapacity(t *testing.T) {
+	// capacities in bytes of the versions 1 to 10 and 40, from table 7 of ISO/IEC 18004
+	expected := [4][]int{
+		{17, 32, 53, 78, 106, 134, 154, 192, 230, 271, 2953},
+		{14, 26, 42, 62, 84, 106, 122, 152, 180, 213, 2331},
+		{11, 20, 32, 46, 60, 74, 86, 108, 130, 151, 1663},
+		{7, 14, 24, 34, 44, 58, 64, 84, 98, 119, 1273},
+	}
+
+	for level := range expected {
+		for i, capacity := range expected[level] {
+			version := i + 1
+			if i == 10 {
+				version = 40
+			}
+			got := (8*numDataCodewords(version, Level(level)) - 4 - countBits(version)) / 8
+			if got != capacity {
+				t.Errorf("Error in numDataCodewords (level = %d, version = %d, expected = %d, got = %d)", level, version, capacity, got)
+			}
+		}
+	}
+}
+
+func TestAlignmentPositions(t *testing.T) {
+	tests := []struct {
+		version  int
+		expected []int
+	}{
+		{1, nil},
+		{2, []int{6, 18}},
+		{7, []int{6, 22, 38}},
+		{14, []int{6, 26, 46, 66}},
+		{32, []int{6, 34, 60, 86, 112, 138}},
+		{36, []int{6, 24, 50, 76, 102, 128, 154}},
+		{40, []int{6, 30, 58, 86, 114, 142, 170}},
+	}
+
+	for i, test := range tests {
+		if got := alignmentPositions(test.version); !slices.Equal(got, test.expected) {
+			t.Errorf("Error in alignmentPositions (i = %d, expected = %v, got = %v)", i, test.expected, got)
+		}
+	}
+}
+
+func TestRSRemainder(t *testing.T) {
+	// "HELLO WORLD" in alphanumeric mode, version 1-M
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	expected := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+	if got := rsRemainder(data, rsDivisor(10)); !bytes.Equal(got, expected) {
+		t.Errorf("Error in rsRemainder (expected = %v, got = %v)", expected, got)
+	}
+}
+
+func TestFormatAndVersionBits(t *testing.T) {
+	formats := []struct {
+		level    Level
+		mask     int
+		expected string
+	}{
+		{LevelL, 0, "111011111000100"},
+		{LevelM, 0, "101010000010010"},
+		{LevelQ, 0, "011010101011111"},
+		{LevelH, 0, "001011010001001"},
+	}
+	for i, test := range formats {
+		b := newBuilder(1, test.level)
+		b.drawFormatBits(test.mask)
+		if got := readFormat(b.modules, b.size); got != test.expected {
+			t.Errorf("Error in drawFormatBits (i = %d, expected = %s, got = %s)", i, test.expected, got)
+		}
+	}
+
+	b := newBuilder(7, LevelL)
+	b.drawVersion()
+	var got strings.Builder
+	for i := 17; i >= 0; i-- {
+		got.WriteString(fmt.Sprint(btoi(b.modules[(i/3)*b.size+b.size-11+i%3])))
+	}
+	if got.String() != "000111110010010100" {
+		t.Errorf("Error in drawVersion (expected = 000111110010010100, got = %s)", got.String())
+	}
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		text    string
+		level   Level
+		version int
+	}{
+		{"", LevelL, 1},
+		{"otpauth://totp/Example:alice@example.com?secret=JBSWY3DPEHPK3PXP&issuer=Example", LevelM, 5},
+		{strings.Repeat("a", 271), LevelL, 10},
+		{strings.Repeat("b", 272), LevelL, 11},
+		{strings.Repeat("c", 300), LevelQ, 16},
+		{strings.Repeat("d", 1273), LevelH, 40},
+	}
+
+	for i, test := range tests {
+		c, err := New(test.text, test.level)
+		if err != nil {
+			t.Fatalf("Error in New (i = %d, err = %v)", i, err)
+		}
+		if c.Version != test.version || c.Size != 17+4*test.version {
+			t.Errorf("Error in New (i = %d, expected version = %d, got = %d)", i, test.version, c.Version)
+		}
+		if got := decode(t, c); got != test.text {
+			t.Errorf("Error in New (i = %d, expected = %q, got = %q)", i, test.text, got)
+		}
+	}
+
+	if _, err := New(strings.Repeat("e", 1274), LevelH); err != ErrTooLong {
+		t.Errorf("Error in New (expected = %v, got = %v)", ErrTooLong, err)
+	}
+}
+
+func TestCodeText(t *testing.T) {
+	c, _ := New("otpauth://totp/alice?secret=JBSWY3DPEHPK3PXP", LevelL)
+	lines := strings.Split(strings.TrimSuffix(c.Text(false), "\n"), "\n")
+	side := c.Size + 2*quietZone
+	if len(lines) != (side+1)/2 || len([]rune(lines[0])) != side {
+		t.Errorf("Error in Text (expected = %d lines of %d, got = %d lines of %d)", (side+1)/2, side, len(lines), len([]rune(lines[0])))
+	}
+	// top of the top left finder pattern, after the quiet zone
+	if got := string([]rune(lines[2])[quietZone : quietZone+7]); got != "█▀▀▀▀▀█" {
+		t.Errorf("Error in Text (expected = %q, got = %q)", "█▀▀▀▀▀█", got)
+	}
+	if inverse := c.Text(true); strings.TrimSpace(inverse) == "" || []rune(inverse)[0] != '█' {
+		t.Errorf("Error in Text (quiet zone not drawn in inverse)")
+	}
+
+	img := c.Image(2)
+	if img.Bounds().Dx() != side*2 || img.GrayAt(quietZone*2, quietZone*2).Y != 0 || img.GrayAt(0, 0).Y != 255 {
+		t.Errorf("Error in Image (unexpected image of %v)", img.Bounds())
+	}
+}
+
+func btoi(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// readFormat returns the bits of the first copy of the format information, from the highest one.
+func readFormat(modules []bool, size int) string {
+	at := func(x, y int) bool {
+		return modules[y*size+x]
+	}
+	var bits [15]bool
+	for i := 0; i <= 5; i++ {
+		bits[i] = at(8, i)
+	}
+	bits[6], bits[7], bits[8] = at(8, 7), at(8, 8), at(7, 8)
+	for i := 9; i < 15; i++ {
+		bits[i] = at(14-i, 8)
+	}
+
+	var sb strings.Builder
+	for i := 14; i >= 0; i-- {
+		sb.WriteString(fmt.Sprint(btoi(bits[i])))
+	}
+	return sb.String()
+}
+
+// decode decodes the text of a code, checking its format information and its error correction codewords.
+func decode(t *testing.T, c *Code) string {
+	t.Helper()
+
+	format := readFormat(c.modules, c.Size)
+	var mask, level int
+	found := false
+	for l := range 4 {
+		for m := range 8 {
+			b := newBuilder(c.Version, Level(l))
+			b.drawFormatBits(m)
+			if readFormat(b.modules, b.size) == format {
+				level, mask, found = l, m, true
+			}
+		}
+	}
+	if !found || Level(level) != c.Level {
+		t.Fatalf("Error in decode (invalid format %s)", format)
+	}
+
+	// the modules are unmasked, and read as they are drawn
+	b := newBuilder(c.Version, c.Level)
+	b.drawFunctionPatterns()
+	function := b.function
+	b.modules = slices.Clone(c.modules)
+	b.applyMask(mask)
+
+	var codewords []byte
+	var current byte
+	n := 0
+	for right := c.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		upward := (right+1)&2 == 0
+		for vert := range c.Size {
+			y := vert
+			if upward {
+				y = c.Size - 1 - vert
+			}
+			for j := range 2 {
+				x := right - j
+				if function[y*c.Size+x] {
+					continue
+				}
+				current = current<<1 | byte(btoi(b.modules[y*c.Size+x]))
+				if n++; n%8 == 0 {
+					codewords = append(codewords, current)
+				}
+			}
+		}
+	}
+
+	// the blocks are deinterleaved, and their error correction codewords checked
+	blocks, eccLen := numBlocks[c.Level][c.Version], eccCodewordsPerBlock[c.Level][c.Version]
+	raw := numRawDataModules(c.Version) / 8
+	numShort := blocks - raw%blocks
+	shortLen := raw / blocks
+	deinterleaved := make([][]byte, blocks)
+	k := 0
+	for i := range shortLen + 1 {
+		for j := range blocks {
+			// the short blocks have one data codeword less
+			if i == shortLen-eccLen && j < numShort {
+				continue
+			}
+			deinterleaved[j] = append(deinterleaved[j], codewords[k])
+			k++
+		}
+	}
+	var data []byte
+	for j, block := range deinterleaved {
+		dataLen := len(block) - eccLen
+		if !bytes.Equal(rsRemainder(block[:dataLen], rsDivisor(eccLen)), block[dataLen:]) {
+			t.Fatalf("Error in decode (invalid error correction of block %d)", j)
+		}
+		data = append(data, block[:dataLen]...)
+	}
+
+	// byte mode segment
+	bit := func(i int) int {
+		return int(data[i/8]>>(7-i%8)) & 1
+	}
+	read := func(pos, n int) int {
+		v := 0
+		for i := range n {
+			v = v<<1 | bit(pos+i)
+		}
+		return v
+	}
+	if read(0, 4) != 0b0100 {
+		t.Fatalf("Error in decode (mode %04b isn't byte mode)", read(0, 4))
+	}
+	length := read(4, countBits(c.Version))
+	text := make([]byte, length)
+	for i := range text {
+		text[i] = byte(read(4+countBits(c.Version)+8*i, 8))
+	}
+	return string(text)
+}
@@ -0,0 +1,109 @@
+// Package qr decodes the keys of provisioning QR codes, such as screenshots of the QR codes shown by services or
+// exported by Google Authenticator, and encodes the QR codes provisioning keys.
+//
+// The package doesn't depend on a QR code library: a Decoder is a thin adapter over one, e.g. with
+// github.com/makiuchi-d/gozxing:
+//
+//	type decoder struct{}
+//
+//	func (decoder) Decode(img image.Image) (string, error) {
+//		bmp, err := gozxing.NewBinaryBitmapFromImage(img)
+//		if err != nil {
+//			return "", err
+//		}
+//		res, err := qrcode.NewQRCodeReader().Decode(bmp, nil)
+//		if err != nil {
+//			return "", err
+//		}
+//		return res.GetText(), nil
+//	}
+//
+// QR codes are encoded by ImageEncoder, or by New for other renderings such as terminals. An Encoder may also be an
+// adapter over another library, e.g. with github.com/skip2/go-qrcode:
+//
+//	type encoder struct{}
+//
+//	func (encoder) Encode(text string) (image.Image, error) {
+//		code, err := qrcode.New(text, qrcode.Medium)
+//		if err != nil {
+//			return nil, err
+//		}
+//		return code.Image(256), nil
+//	}
+package qr
+
+import (
+	"errors"
+	"image"
+	"strings"
+
+	"github.com/xrjr/otp"
+)
+
+// ErrNoURI is returned when the QR code doesn't hold an otpauth or otpauth-migration URI.
+var ErrNoURI = errors.New("qr: no otpauth uri")
+
+// Decoder decodes the text of the QR code of an image, e.g. decoded with image/png or image/jpeg.
+type Decoder interface {
+	Decode(img image.Image) (string, error)
+}
+
+// DecoderFunc is an adapter to use a function as a Decoder.
+type DecoderFunc func(img image.Image) (string, error)
+
+// Decode implements Decoder.
+func (f DecoderFunc) Decode(img image.Image) (string, error) {
+	return f(img)
+}
+
+// Encoder encodes a text in the QR code of an image, e.g. to be encoded with image/png.
+type Encoder interface {
+	Encode(text string) (image.Image, error)
+}
+
+// EncoderFunc is an adapter to use a function as an Encoder.
+type EncoderFunc func(text string) (image.Image, error)
+
+// Encode implements Encoder.
+func (f EncoderFunc) Encode(text string) (image.Image, error) {
+	return f(text)
+}
+
+// EncodeKey returns the QR code of the otpauth URI of a key, as scanned by authenticator applications.
+func EncodeKey(e Encoder, k otp.Key) (image.Image, error) {
+	return e.Encode(k.URI())
+}
+
+// DecodeKey returns the key of the otpauth URI held by the QR code of an image.
+func DecodeKey(d Decoder, img image.Image) (otp.Key, error) {
+	text, err := d.Decode(img)
+	if err != nil {
+		return otp.Key{}, err
+	}
+
+	if !strings.HasPrefix(text, "otpauth://") {
+		return otp.Key{}, ErrNoURI
+	}
+	return otp.ParseURI(text)
+}
+
+// DecodeKeys returns the keys of the otpauth or otpauth-migration URI held by the QR code of an image.
+func DecodeKeys(d Decoder, img image.Image) ([]otp.Key, error) {
+	text, err := d.Decode(img)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case strings.HasPrefix(text, "otpauth-migration://"):
+		return otp.ParseMigrationURI(text)
+	case strings.HasPrefix(text, "otpauth://"):
+		k, err := otp.ParseURI(text)
+		if err != nil {
+			return nil, err
+		}
+		return []otp.Key{k}, nil
+	default:
+		return nil, ErrNoURI
+	}
+}
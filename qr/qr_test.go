@@ -0,0 +1,80 @@
+package qr
+
+import (
+	"errors"
+	"image"
+	"testing"
+
+	"github.com/xrjr/otp"
+)
+
+// textDecoder decodes every image as a given text.
+func textDecoder(text string) Decoder {
+	return DecoderFunc(func(image.Image) (string, error) {
+		return text, nil
+	})
+}
+
+var img = image.NewGray(image.Rect(0, 0, 1, 1))
+
+func TestDecodeKey(t *testing.T) {
+	k, err := DecodeKey(textDecoder("otpauth://totp/Example:alice@example.com?secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"), img)
+	if err != nil {
+		t.Fatalf("Error in DecodeKey (err = %v)", err)
+	}
+
+	expected := otp.Key{Type: otp.TypeTOTP, Issuer: "Example", AccountName: "alice@example.com", Secret: []byte("12345678901234567890")}
+	if !k.Equal(expected) {
+		t.Errorf("Error in DecodeKey (expected = %#v, got = %#v)", expected, k)
+	}
+
+	if _, err := DecodeKey(textDecoder("https://example.com"), img); !errors.Is(err, ErrNoURI) {
+		t.Errorf("Error in DecodeKey (expected = %v, got = %v)", ErrNoURI, err)
+	}
+
+	decodeErr := errors.New("no qr code found")
+	failing := DecoderFunc(func(image.Image) (string, error) {
+		return "", decodeErr
+	})
+	if _, err := DecodeKey(failing, img); !errors.Is(err, decodeErr) {
+		t.Errorf("Error in DecodeKey (expected = %v, got = %v)", decodeErr, err)
+	}
+}
+
+func TestDecodeKeys(t *testing.T) {
+	// migration payload of a single key, whose secret is "12345678901234567890" and name "alice"
+	migration := "otpauth-migration://offline?data=Ch8KFDEyMzQ1Njc4OTAxMjM0NTY3ODkwEgVhbGljZTACEAEYAQ%3D%3D"
+
+	keys, err := DecodeKeys(textDecoder(migration), img)
+	if err != nil {
+		t.Fatalf("Error in DecodeKeys (err = %v)", err)
+	}
+	if len(keys) != 1 || keys[0].AccountName != "alice" || keys[0].Type != otp.TypeTOTP {
+		t.Errorf("Error in DecodeKeys (got = %v)", keys)
+	}
+
+	keys, err = DecodeKeys(textDecoder("otpauth://hotp/bob?secret=GEZDGNBV&counter=1"), img)
+	if err != nil || len(keys) != 1 || keys[0].AccountName != "bob" {
+		t.Errorf("Error in DecodeKeys (got = %v, err = %v)", keys, err)
+	}
+}
+
+func TestEncodeKey(t *testing.T) {
+	k := otp.Key{Type: otp.TypeTOTP, Issuer: "Example", AccountName: "alice@example.com", Secret: []byte("12345678901234567890")}
+
+	// the encoder and decoder of the test pass the text along with the image
+	var encoded string
+	e := EncoderFunc(func(text string) (image.Image, error) {
+		encoded = text
+		return img, nil
+	})
+	res, err := EncodeKey(e, k)
+	if err != nil || res != img {
+		t.Fatalf("Error in EncodeKey (err = %v)", err)
+	}
+
+	decoded, err := DecodeKey(textDecoder(encoded), res)
+	if err != nil || !decoded.Equal(k) {
+		t.Errorf("Error in EncodeKey (expected = %#v, got = %#v, err = %v)", k, decoded, err)
+	}
+}
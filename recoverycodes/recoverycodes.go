@@ -0,0 +1,208 @@
+// Package recoverycodes generates single-use recovery codes, given to users along with their OTP key to regain
+// access to their account when they lose it.
+//
+// Only salted hashes of the codes are stored, so that a leak of the store doesn't reveal them.
+package recoverycodes
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+var (
+	// ErrInvalidCode is returned when the code isn't an unused recovery code.
+	ErrInvalidCode = errors.New("recoverycodes: invalid code")
+	// ErrInvalidHash is returned when a hash can't be parsed.
+	ErrInvalidHash = errors.New("recoverycodes: invalid hash")
+)
+
+// alphabet is the alphabet of the codes, without the characters easily mistaken for others (0, 1, i, l, o).
+const alphabet = "23456789abcdefghjkmnpqrstuvwxyz"
+
+// codeLength is the number of characters of a code, written in two groups of 5. A code holds about 50 bits.
+const codeLength = 10
+
+// saltLength is the length in bytes of the salt of a hash.
+const saltLength = 16
+
+// Hash is a salted hash of a recovery code.
+type Hash struct {
+	Salt []byte
+	Sum  []byte // sha256 of the salt followed by the normalized code
+}
+
+// Store holds the hashes of the unused recovery codes of users. Its methods are given the context of the
+// verification.
+type Store interface {
+	// Hashes returns the hashes of the unused codes of the user id.
+	Hashes(ctx context.Context, id string) ([]Hash, error)
+	// Replace replaces the codes of the user id.
+	Replace(ctx context.Context, id string, hashes []Hash) error
+	// Consume removes a hash of the user id, and reports whether it was still there. It must be atomic, as
+	// concurrent verifications may use the same code.
+	Consume(ctx context.Context, id string, hash Hash) (bool, error)
+}
+
+// Generate returns n new recovery codes, formatted as "xxxxx-xxxxx", along with their hashes.
+func Generate(n int) ([]string, []Hash, error) {
+	codes := make([]string, n)
+	hashes := make([]Hash, n)
+
+	buf := make([]byte, codeLength)
+	for i := range codes {
+		if _, err := rand.Read(buf); err != nil {
+			return nil, nil, err
+		}
+		for j, b := range buf {
+			// 256 isn't a multiple of the length of the alphabet, which makes some characters a bit more likely
+			// without weakening the codes noticeably
+			buf[j] = alphabet[int(b)%len(alphabet)]
+		}
+		codes[i] = string(buf[:codeLength/2]) + "-" + string(buf[codeLength/2:])
+
+		hash, err := NewHash(codes[i])
+		if err != nil {
+			return nil, nil, err
+		}
+		hashes[i] = hash
+	}
+	return codes, hashes, nil
+}
+
+// NewHash returns a hash of a code, with a new salt.
+func NewHash(code string) (Hash, error) {
+	salt := make([]byte, saltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return Hash{}, err
+	}
+	return Hash{Salt: salt, Sum: sum(salt, normalize(code))}, nil
+}
+
+// Match reports whether h is a hash of a code. The sums are compared in constant time.
+func (h Hash) Match(code string) bool {
+	return subtle.ConstantTimeCompare(h.Sum, sum(h.Salt, normalize(code))) == 1
+}
+
+// MarshalText implements encoding.TextMarshaler, with the salt and the sum in hex, separated by a colon.
+func (h Hash) MarshalText() ([]byte, error) {
+	return []byte(hex.EncodeToString(h.Salt) + ":" + hex.EncodeToString(h.Sum)), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (h *Hash) UnmarshalText(text []byte) error {
+	salt, sum, found := strings.Cut(string(text), ":")
+	if !found {
+		return fmt.Errorf("%w: missing colon", ErrInvalidHash)
+	}
+
+	var err error
+	if h.Salt, err = hex.DecodeString(salt); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidHash, err)
+	}
+	if h.Sum, err = hex.DecodeString(sum); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidHash, err)
+	}
+	return nil
+}
+
+// Verify checks a recovery code of the user id, and consumes it, so that it is only accepted once. It returns
+// ErrInvalidCode when the code isn't an unused code of the user.
+func Verify(s Store, id string, code string) error {
+	return VerifyContext(context.Background(), s, id, code)
+}
+
+// VerifyContext checks a recovery code of the user id as Verify does, the store being called with ctx.
+func VerifyContext(ctx context.Context, s Store, id string, code string) error {
+	hashes, err := s.Hashes(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	// every hash is compared, so that the time taken doesn't depend on which one matched
+	matched := -1
+	for i, h := range hashes {
+		if h.Match(code) && matched == -1 {
+			matched = i
+		}
+	}
+	if matched == -1 {
+		return ErrInvalidCode
+	}
+
+	consumed, err := s.Consume(ctx, id, hashes[matched])
+	if err != nil {
+		return err
+	}
+	if !consumed {
+		return ErrInvalidCode
+	}
+	return nil
+}
+
+// normalize removes the separators and spaces of a code typed by a user, and converts it to lower case.
+func normalize(code string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || unicode.IsSpace(r) {
+			return -1
+		}
+		return unicode.ToLower(r)
+	}, code)
+}
+
+// sum returns the sha256 of salt followed by code.
+func sum(salt []byte, code string) []byte {
+	h := sha256.New()
+	h.Write(salt)
+	h.Write([]byte(code))
+	return h.Sum(nil)
+}
+
+// MemoryStore is a Store keeping the hashes in memory. It is safe for concurrent use, and its zero value is ready
+// to use.
+type MemoryStore struct {
+	mu     sync.Mutex
+	hashes map[string][]Hash
+}
+
+// Hashes implements Store.
+func (s *MemoryStore) Hashes(ctx context.Context, id string) ([]Hash, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return slices.Clone(s.hashes[id]), nil
+}
+
+// Replace implements Store.
+func (s *MemoryStore) Replace(ctx context.Context, id string, hashes []Hash) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.hashes == nil {
+		s.hashes = map[string][]Hash{}
+	}
+	s.hashes[id] = slices.Clone(hashes)
+	return nil
+}
+
+// Consume implements Store.
+func (s *MemoryStore) Consume(ctx context.Context, id string, hash Hash) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, h := range s.hashes[id] {
+		if subtle.ConstantTimeCompare(h.Sum, hash.Sum) == 1 {
+			s.hashes[id] = slices.Delete(s.hashes[id], i, i+1)
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,89 @@
+package recoverycodes
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	codes, hashes, err := Generate(10)
+	if err != nil || len(codes) != 10 || len(hashes) != 10 {
+		t.Fatalf("Error in Generate (expected = 10 codes, got = %d, err = %v)", len(codes), err)
+	}
+
+	format := regexp.MustCompile("^[" + alphabet + "]{5}-[" + alphabet + "]{5}$")
+	for i, code := range codes {
+		if !format.MatchString(code) {
+			t.Errorf("Error in Generate (i = %d, unexpected format %q)", i, code)
+		}
+		if !hashes[i].Match(code) || !hashes[i].Match(" "+strings.ToUpper(strings.ReplaceAll(code, "-", ""))) {
+			t.Errorf("Error in Generate (i = %d, hash doesn't match %q)", i, code)
+		}
+		if i > 0 && (codes[i] == codes[0] || string(hashes[i].Salt) == string(hashes[0].Salt)) {
+			t.Errorf("Error in Generate (i = %d, code or salt repeated)", i)
+		}
+	}
+}
+
+func TestHashText(t *testing.T) {
+	h, _ := NewHash("abcde-fghjk")
+	text, _ := h.MarshalText()
+
+	var parsed Hash
+	if err := parsed.UnmarshalText(text); err != nil || !parsed.Match("abcde-fghjk") {
+		t.Errorf("Error in HashText (text = %s, err = %v)", text, err)
+	}
+
+	for _, text := range []string{"", "00", "zz:00", "00:zz"} {
+		if err := parsed.UnmarshalText([]byte(text)); !errors.Is(err, ErrInvalidHash) {
+			t.Errorf("Error in HashText (text = %q, expected = %v, got = %v)", text, ErrInvalidHash, err)
+		}
+	}
+}
+
+func TestVerify(t *testing.T) {
+	s := &MemoryStore{}
+	codes, hashes, _ := Generate(3)
+	s.Replace(context.Background(), "alice", hashes)
+
+	if err := Verify(s, "alice", codes[1]); err != nil {
+		t.Errorf("Error in Verify (err = %v)", err)
+	}
+	if err := Verify(s, "alice", codes[1]); !errors.Is(err, ErrInvalidCode) {
+		t.Errorf("Error in Verify (expected = %v, got = %v)", ErrInvalidCode, err)
+	}
+	if err := Verify(s, "bob", codes[0]); !errors.Is(err, ErrInvalidCode) {
+		t.Errorf("Error in Verify (expected = %v, got = %v)", ErrInvalidCode, err)
+	}
+	if remaining, _ := s.Hashes(context.Background(), "alice"); len(remaining) != 2 {
+		t.Errorf("Error in Verify (expected = 2 codes left, got = %d)", len(remaining))
+	}
+}
+
+func TestVerifyConcurrent(t *testing.T) {
+	s := &MemoryStore{}
+	codes, hashes, _ := Generate(1)
+	s.Replace(context.Background(), "alice", hashes)
+
+	var accepted atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if Verify(s, "alice", codes[0]) == nil {
+				accepted.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if accepted.Load() != 1 {
+		t.Errorf("Error in VerifyConcurrent (expected = 1 accepted, got = %d)", accepted.Load())
+	}
+}
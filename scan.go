@@ -0,0 +1,53 @@
+//go:build !tinygo && !otpcore
+
+package otp
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// uriPattern matches the otpauth and otpauth-migration URIs of a text.
+var uriPattern = regexp.MustCompile(`otpauth(?:-migration)?://[^\s"'<>]+`)
+
+// ScanURIs extracts every otpauth and otpauth-migration URI from a text, such as exported notes or configuration
+// dumps, and parses them. URIs which can't be parsed are reported by the returned errors, prefixed by their line
+// number, without stopping the scan.
+func ScanURIs(r io.Reader) ([]Key, []error) {
+	var keys []Key
+	var errs []error
+
+	scanner := bufio.NewScanner(r)
+	// migration URIs of many keys are long
+	scanner.Buffer(nil, 1<<20)
+	for line := 1; scanner.Scan(); line++ {
+		for _, uri := range uriPattern.FindAllString(scanner.Text(), -1) {
+			// punctuation following a URI in a sentence
+			uri = strings.TrimRight(uri, ".,;:!?)")
+
+			if strings.HasPrefix(uri, "otpauth-migration:") {
+				migrated, err := ParseMigrationURI(uri)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("line %d: %w", line, err))
+				}
+				keys = append(keys, migrated...)
+				continue
+			}
+
+			k, err := ParseURI(uri)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("line %d: %w", line, err))
+				continue
+			}
+			keys = append(keys, k)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		errs = append(errs, err)
+	}
+	return keys, errs
+}
@@ -0,0 +1,35 @@
+//go:build !tinygo && !otpcore
+
+package otp
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestScanURIs(t *testing.T) {
+	text := `Accounts exported on 2024-01-01
+
+- work: <otpauth://totp/Example:alice@example.com?secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ&issuer=Example>
+- broken: "otpauth://totp/broken?secret=12345678"
+- personal: otpauth://hotp/bob?secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ&counter=3, then otpauth://totp/carol?secret=GEZDGNBV
+- phone: ` + migrationURI() + `
+`
+
+	keys, errs := ScanURIs(strings.NewReader(text))
+
+	expected := []string{"alice@example.com", "bob", "carol", "alice@example.com", "bob"}
+	if len(keys) != len(expected) {
+		t.Fatalf("Error in ScanURIs (expected = %d keys, got = %d)", len(expected), len(keys))
+	}
+	for i, k := range keys {
+		if k.AccountName != expected[i] {
+			t.Errorf("Error in ScanURIs (i = %d, expected = %s, got = %s)", i, expected[i], k.AccountName)
+		}
+	}
+
+	if len(errs) != 1 || !errors.Is(errs[0], ErrInvalidSecretEncoding) || !strings.HasPrefix(errs[0].Error(), "line 4: ") {
+		t.Errorf("Error in ScanURIs (expected = one error on line 4, got = %v)", errs)
+	}
+}
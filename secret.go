@@ -0,0 +1,135 @@
+package otp
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"runtime"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Secret encodings, as used by the encoding parameter of Key URIs. Secrets are encoded in base32 by default.
+const (
+	EncodingBase32 = "base32"
+	EncodingHex    = "hex"
+	EncodingBase64 = "base64"
+)
+
+// base32NoPadding is the base32 encoding used for secrets, which are usually written without padding.
+var base32NoPadding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// WipeBytes overwrites b with zeros, so that secret material doesn't stay in memory after use.
+// The garbage collector may have moved or copied b before, so it only reduces the exposure of the secret.
+func WipeBytes(b []byte) {
+	clear(b)
+	// keep b alive until cleared, so that the writes aren't considered dead
+	runtime.KeepAlive(b)
+}
+
+// GenerateSecret returns a random secret of size bytes, read from crypto/rand. Secrets shorter than
+// MinSecretLength are refused, and 20 bytes (160 bits) are recommended by section 4 of rfc 4226.
+func GenerateSecret(size int) ([]byte, error) {
+	if size < MinSecretLength {
+		return nil, fmt.Errorf("%w: %d bytes is shorter than %d", ErrInvalidSecret, size, MinSecretLength)
+	}
+
+	secret := make([]byte, size)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	return secret, nil
+}
+
+// ParseSecret decodes a base32 encoded secret. It tolerates padding, lower case letters and whitespaces, as found in
+// secrets pasted by users (e.g. "gezd gnbv gy3t qojq").
+func ParseSecret(s string) ([]byte, error) {
+	// the secret is normalized in a buffer wiped once decoded, rather than in intermediate strings
+	buf := make([]byte, 0, len(s))
+	defer func() { WipeBytes(buf) }()
+	for _, r := range s {
+		if !unicode.IsSpace(r) {
+			buf = utf8.AppendRune(buf, unicode.ToUpper(r))
+		}
+	}
+
+	return decodeBuffer(base32NoPadding, bytes.TrimRight(buf, "="))
+}
+
+// DecodeSecret decodes a secret, detecting its encoding: base32 is tried first, as with ParseSecret, then hex and
+// base64 (standard or URL alphabet, with or without padding).
+// Some strings are valid in several encodings, such as "ABCDEF23" which is valid base32 and hex, so secrets
+// whose encoding is known should be decoded with it.
+func DecodeSecret(s string) ([]byte, error) {
+	for _, encoding := range []string{EncodingBase32, EncodingHex, EncodingBase64} {
+		if secret, err := decodeSecret(s, encoding); err == nil {
+			return secret, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: unknown encoding", ErrInvalidSecretEncoding)
+}
+
+// decoder decodes a buffer, as the encodings of encoding/base32, encoding/base64 and encoding/hex do.
+type decoder interface {
+	DecodedLen(n int) int
+	Decode(dst, src []byte) (int, error)
+}
+
+// hexEncoding is the decoder of encoding/hex.
+type hexEncoding struct{}
+
+func (hexEncoding) DecodedLen(n int) int                { return hex.DecodedLen(n) }
+func (hexEncoding) Decode(dst, src []byte) (int, error) { return hex.Decode(dst, src) }
+
+// decodeBuffer decodes an encoded secret, wiping the secret decoded so far on failure.
+func decodeBuffer(d decoder, buf []byte) ([]byte, error) {
+	secret := make([]byte, d.DecodedLen(len(buf)))
+	n, err := d.Decode(secret, buf)
+	if err != nil {
+		WipeBytes(secret)
+		return nil, fmt.Errorf("%w: %w", ErrInvalidSecretEncoding, err)
+	}
+	return secret[:n], nil
+}
+
+// decodeSecret decodes a secret with a given encoding. The copies of the encoded secret are wiped once decoded.
+func decodeSecret(s string, encoding string) ([]byte, error) {
+	switch encoding {
+	case "", EncodingBase32:
+		return ParseSecret(s)
+	case EncodingHex:
+		buf := []byte(strings.TrimSpace(s))
+		defer WipeBytes(buf)
+		return decodeBuffer(hexEncoding{}, buf)
+	case EncodingBase64:
+		buf := []byte(strings.TrimSpace(s))
+		defer WipeBytes(buf)
+		secret, err := decodeBuffer(base64.StdEncoding, buf)
+		for _, e := range []*base64.Encoding{base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+			if err == nil {
+				break
+			}
+			secret, err = decodeBuffer(e, buf)
+		}
+		return secret, err
+	default:
+		return nil, fmt.Errorf("%w: unknown encoding %q", ErrInvalidSecretEncoding, encoding)
+	}
+}
+
+// appendSecret appends a secret encoded with a given encoding to b. The result should be wiped by the caller once
+// used.
+func appendSecret(b []byte, secret []byte, encoding string) []byte {
+	switch encoding {
+	case EncodingHex:
+		return hex.AppendEncode(b, secret)
+	case EncodingBase64:
+		return base64.StdEncoding.AppendEncode(b, secret)
+	default:
+		return base32NoPadding.AppendEncode(b, secret)
+	}
+}
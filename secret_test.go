@@ -0,0 +1,72 @@
+package otp
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestWipeBytes(t *testing.T) {
+	secret := []byte("12345678901234567890")
+	WipeBytes(secret)
+
+	for i, b := range secret {
+		if b != 0 {
+			t.Errorf("Error in WipeBytes (i = %d, expected = 0, got = %d)", i, b)
+		}
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	a, err := GenerateSecret(20)
+	if err != nil || len(a) != 20 {
+		t.Fatalf("Error in GenerateSecret (expected = 20 bytes, got = %d, err = %v)", len(a), err)
+	}
+	if b, _ := GenerateSecret(20); string(a) == string(b) {
+		t.Errorf("Error in GenerateSecret (same secret generated twice)")
+	}
+
+	if _, err := GenerateSecret(MinSecretLength - 1); !errors.Is(err, ErrInvalidSecret) {
+		t.Errorf("Error in GenerateSecret (expected = %v, got = %v)", ErrInvalidSecret, err)
+	}
+}
+
+func TestParseSecret(t *testing.T) {
+	for _, s := range []string{
+		"GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ",
+		"gezdgnbvgy3tqojqgezdgnbvgy3tqojq",
+		"GEZD GNBV GY3T QOJQ GEZD GNBV GY3T QOJQ",
+		" gezdgnbvgy3tqojq\ngezdgnbvgy3tqojq\t",
+		"GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ====",
+	} {
+		secret, err := ParseSecret(s)
+		if err != nil {
+			t.Errorf("Error in ParseSecret (s = %q, err = %v)", s, err)
+		} else if string(secret) != string(hotpSecret) {
+			t.Errorf("Error in ParseSecret (s = %q, expected = %s, got = %s)", s, hotpSecret, secret)
+		}
+	}
+
+	if _, err := ParseSecret("GEZDGNB1"); !errors.Is(err, ErrInvalidSecretEncoding) {
+		t.Errorf("Error in ParseSecret (expected = %v, got = %v)", ErrInvalidSecretEncoding, err)
+	}
+}
+
+func TestDecodeSecret(t *testing.T) {
+	for _, s := range []string{
+		"GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ",
+		"3132333435363738393031323334353637383930",
+		"MTIzNDU2Nzg5MDEyMzQ1Njc4OTA=",
+		"MTIzNDU2Nzg5MDEyMzQ1Njc4OTA",
+	} {
+		secret, err := DecodeSecret(s)
+		if err != nil {
+			t.Errorf("Error in DecodeSecret (s = %q, err = %v)", s, err)
+		} else if string(secret) != string(hotpSecret) {
+			t.Errorf("Error in DecodeSecret (s = %q, expected = %s, got = %s)", s, hotpSecret, secret)
+		}
+	}
+
+	if _, err := DecodeSecret("not a secret!"); !errors.Is(err, ErrInvalidSecretEncoding) {
+		t.Errorf("Error in DecodeSecret (expected = %v, got = %v)", ErrInvalidSecretEncoding, err)
+	}
+}
@@ -0,0 +1,91 @@
+//go:build !tinygo && !otpcore
+
+package otp
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"fmt"
+	"time"
+)
+
+// SecretBox holds a secret encrypted with AES-GCM under a key encryption key (KEK), so that applications keeping
+// keys in memory, e.g. in a cache, don't hold their secret in plaintext in heap dumps and core files. The secret
+// is only decrypted while a code is computed, then wiped:
+//
+//	box, err := otp.NewSecretBox(kek, key.Secret)
+//	otp.WipeBytes(key.Secret)
+//	key.Secret = nil
+//	...
+//	code, err := box.TOTP(time.Now(), opts)
+//
+// The KEK should come from a KMS or a secret manager: the expanded AES key stays in memory, so a dump still holds
+// what decrypts the secrets, but never the secrets themselves.
+// A SecretBox is safe for concurrent use.
+type SecretBox struct {
+	aead   cipher.AEAD
+	nonce  []byte
+	sealed []byte
+}
+
+// NewSecretBox encrypts a secret under a KEK of 16, 24 or 32 bytes (AES-128, AES-192 or AES-256). The secret isn't
+// modified, and should be wiped by the caller with WipeBytes.
+func NewSecretBox(kek, secret []byte) (*SecretBox, error) {
+	if len(secret) == 0 {
+		return nil, ErrInvalidSecret
+	}
+
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return &SecretBox{aead: aead, nonce: nonce, sealed: aead.Seal(nil, nonce, secret, nil)}, nil
+}
+
+// Open decrypts the secret, which should be wiped with WipeBytes once used. Use is preferred, as it wipes it.
+func (b *SecretBox) Open() ([]byte, error) {
+	secret, err := b.aead.Open(nil, b.nonce, b.sealed, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: secret box can't be opened: %w", ErrInvalidSecret, err)
+	}
+	return secret, nil
+}
+
+// Use decrypts the secret, calls fn with it, and wipes it once fn returns. fn must not retain the secret.
+func (b *SecretBox) Use(fn func(secret []byte) error) error {
+	secret, err := b.Open()
+	if err != nil {
+		return err
+	}
+	defer WipeBytes(secret)
+
+	return fn(secret)
+}
+
+// HOTP computes the OTP code of a given counter with the secret, as HOTPE does.
+func (b *SecretBox) HOTP(counter uint64, opts HOTPOptions) (code Code, err error) {
+	err = b.Use(func(secret []byte) error {
+		code, err = HOTPE(secret, counter, opts)
+		return err
+	})
+	return code, err
+}
+
+// TOTP computes the OTP code of a given time with the secret, as TOTPE does.
+func (b *SecretBox) TOTP(t time.Time, opts TOTPOptions) (code Code, err error) {
+	err = b.Use(func(secret []byte) error {
+		code, err = TOTPE(secret, t, opts)
+		return err
+	})
+	return code, err
+}
@@ -0,0 +1,63 @@
+//go:build !tinygo && !otpcore
+
+package otp
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSecretBox(t *testing.T) {
+	kek := bytes.Repeat([]byte{0x42}, 32)
+	secret := bytes.Clone(totpSecretSha1)
+
+	box, err := NewSecretBox(kek, secret)
+	if err != nil {
+		t.Fatalf("Error in SecretBox (err = %v)", err)
+	}
+	if bytes.Contains(box.sealed, totpSecretSha1) {
+		t.Errorf("Error in SecretBox (secret stored in plaintext)")
+	}
+
+	opened, err := box.Open()
+	if err != nil || !bytes.Equal(opened, totpSecretSha1) {
+		t.Errorf("Error in SecretBox (expected = %x, got = %x, err = %v)", totpSecretSha1, opened, err)
+	}
+
+	now := time.Unix(1111111109, 0)
+	code, err := box.TOTP(now, TOTPOptions{})
+	if expected := TOTPCode(totpSecretSha1, now, TOTPOptions{}); err != nil || code != expected {
+		t.Errorf("Error in SecretBox (expected = %v, got = %v, err = %v)", expected, code, err)
+	}
+	code, err = box.HOTP(1, HOTPOptions{})
+	if expected := HOTPCode(totpSecretSha1, 1, HOTPOptions{}); err != nil || code != expected {
+		t.Errorf("Error in SecretBox (expected = %v, got = %v, err = %v)", expected, code, err)
+	}
+	if _, err := box.HOTP(1, HOTPOptions{Digits: 11}); !errors.Is(err, ErrInvalidDigits) {
+		t.Errorf("Error in SecretBox (expected = %v, got = %v)", ErrInvalidDigits, err)
+	}
+
+	// the secret given to Use is wiped once it returns
+	var used []byte
+	box.Use(func(secret []byte) error {
+		used = secret
+		return nil
+	})
+	if !bytes.Equal(used, make([]byte, len(totpSecretSha1))) {
+		t.Errorf("Error in SecretBox (secret not wiped, got = %x)", used)
+	}
+
+	box.sealed[0] ^= 1
+	if _, err := box.Open(); !errors.Is(err, ErrInvalidSecret) {
+		t.Errorf("Error in SecretBox (expected = %v, got = %v)", ErrInvalidSecret, err)
+	}
+
+	if _, err := NewSecretBox(kek[:10], secret); err == nil {
+		t.Errorf("Error in SecretBox (invalid kek accepted)")
+	}
+	if _, err := NewSecretBox(kek, nil); !errors.Is(err, ErrInvalidSecret) {
+		t.Errorf("Error in SecretBox (expected = %v, got = %v)", ErrInvalidSecret, err)
+	}
+}
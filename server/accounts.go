@@ -0,0 +1,245 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/xrjr/otp"
+)
+
+// KeyStore holds the keys of users, and their pending enrollments.
+type KeyStore interface {
+	// Key returns the key of the user id, or ErrNoKey.
+	Key(ctx context.Context, id string) (otp.Key, error)
+	// SetKey sets the key of the user id.
+	SetKey(ctx context.Context, id string, key otp.Key) error
+	// Enrollment returns the pending enrollment of the user id, or ErrNoKey.
+	Enrollment(ctx context.Context, id string) (*Enrollment, error)
+	// SetEnrollment sets the pending enrollment of the user id, or removes it when nil.
+	SetEnrollment(ctx context.Context, id string, e *Enrollment) error
+}
+
+// Accounts enrolls the keys of users and verifies their codes, the keys and the pending enrollments being kept by
+// a KeyStore. It is the flow served by the HTTP handlers and the gRPC service of the package httpmw and grpcotp.
+//
+// Each key has its own id in the stores of the validator, the id of the user followed by the fingerprint of the
+// key, so that the codes used and the counter of a key enrolled again don't collide with the previous one, while
+// the codes used during the enrollment stay used.
+type Accounts struct {
+	Validator *Validator
+	Store     KeyStore
+
+	Template otp.Key       // parameters of the keys enrolled, such as their issuer, defaults to a TOTP key
+	Required int           // consecutive codes activating an enrollment, defaults to 1
+	Expiry   time.Duration // duration of enrollments, defaults to 10 minutes
+}
+
+// Enroll starts the enrollment of a new key of the user id, replacing a pending one. A user who already has a key
+// must prove it holds it with a code of that key, so that a stolen session can't replace it: ErrCodeRequired is
+// returned when code is empty, and the error of VerifyKey when the code is refused. The code is ignored for users
+// without key. The user keeps its current key until the new one is confirmed by Verify.
+func (a *Accounts) Enroll(id string, accountName string, code string) (*Enrollment, error) {
+	return a.EnrollContext(context.Background(), id, accountName, code)
+}
+
+// EnrollContext starts the enrollment of a new key of the user id as Enroll does, the stores being called with ctx.
+func (a *Accounts) EnrollContext(ctx context.Context, id string, accountName string, code string) (*Enrollment, error) {
+	_, err := a.Store.Key(ctx, id)
+	switch {
+	case err == nil && code == "":
+		return nil, ErrCodeRequired
+	case err == nil:
+		if _, err := a.VerifyKeyContext(ctx, id, code); err != nil {
+			return nil, err
+		}
+	case !errors.Is(err, ErrNoKey):
+		return nil, err
+	}
+
+	template := a.Template.Clone()
+	if template.Type == "" {
+		template.Type = otp.TypeTOTP
+	}
+	template.AccountName = accountName
+
+	expiry := a.Expiry
+	if expiry == 0 {
+		expiry = 10 * time.Minute
+	}
+
+	e, err := NewEnrollment("", template, a.Validator.now().Add(expiry))
+	if err != nil {
+		return nil, err
+	}
+	e.Device.ID = deviceID(id, e.Device.Key)
+	e.Required = a.Required
+
+	if err := a.Store.SetEnrollment(ctx, id, e); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// Register sets the key of the user id, once checked by Validator.CheckKey, e.g. the key of a hardware token
+// provisioned by its vendor. It replaces the current key of the user, while a pending enrollment is kept.
+func (a *Accounts) Register(id string, key otp.Key) error {
+	return a.RegisterContext(context.Background(), id, key)
+}
+
+// RegisterContext sets the key of the user id as Register does, the KeyStore being called with ctx.
+func (a *Accounts) RegisterContext(ctx context.Context, id string, key otp.Key) error {
+	if err := a.Validator.CheckKey(key); err != nil {
+		return err
+	}
+	return a.Store.SetKey(ctx, id, key.Clone())
+}
+
+// Verify checks a code of the user id: a code of its pending enrollment if any, which activates the key once
+// confirmed, or a code of its key. The code of the key is still accepted during an enrollment, leaving the
+// enrollment as is. It returns the code accepted, and reports whether the code activated the key of an enrollment.
+// An expired enrollment is removed, and ErrEnrollmentExpired returned.
+func (a *Accounts) Verify(id string, code string) (res Result, enrolled bool, err error) {
+	return a.VerifyContext(context.Background(), id, code)
+}
+
+// VerifyContext checks a code of the user id as Verify does, the stores being called with ctx.
+func (a *Accounts) VerifyContext(ctx context.Context, id string, code string) (res Result, enrolled bool, err error) {
+	e, err := a.Store.Enrollment(ctx, id)
+	if errors.Is(err, ErrNoKey) {
+		res, err := a.VerifyKeyContext(ctx, id, code)
+		return res, false, err
+	}
+	if err != nil {
+		return Result{}, false, err
+	}
+	if !a.Validator.now().Before(e.Expiry) {
+		return Result{}, false, errors.Join(ErrEnrollmentExpired, a.Store.SetEnrollment(ctx, id, nil))
+	}
+
+	// the code is checked against the pending key and the current key in a single attempt, so that a code of the
+	// current key isn't recorded as a failure
+	devices := []Device{e.Device}
+	key, err := a.Store.Key(ctx, id)
+	switch {
+	case err == nil:
+		devices = append(devices, Device{ID: deviceID(id, key), Key: key})
+	case !errors.Is(err, ErrNoKey):
+		return Result{}, false, err
+	}
+
+	res, err = a.Validator.VerifyAnyContext(ctx, id, devices, code)
+	if err == nil && res.ID != e.Device.ID {
+		return res, false, nil
+	}
+	e.record(res, err)
+	if err != nil || !e.Active {
+		// the codes accepted so far are kept, or reset by an invalid code
+		return res, false, errors.Join(err, a.Store.SetEnrollment(ctx, id, e))
+	}
+
+	if err := a.Store.SetKey(ctx, id, e.Device.Key); err != nil {
+		return Result{}, false, err
+	}
+	return res, true, a.Store.SetEnrollment(ctx, id, nil)
+}
+
+// VerifyKey checks a code of the key of the user id, ignoring its pending enrollment if any, e.g. to protect
+// operations while the user enrolls a new key. It returns the code accepted.
+func (a *Accounts) VerifyKey(id string, code string) (Result, error) {
+	return a.VerifyKeyContext(context.Background(), id, code)
+}
+
+// VerifyKeyContext checks a code of the key of the user id as VerifyKey does, the stores being called with ctx.
+func (a *Accounts) VerifyKeyContext(ctx context.Context, id string, code string) (Result, error) {
+	key, err := a.Store.Key(ctx, id)
+	if err != nil {
+		return Result{}, err
+	}
+	return a.Validator.VerifyAnyContext(ctx, id, []Device{{ID: deviceID(id, key), Key: key}}, code)
+}
+
+// Resync resynchronizes the counter of the HOTP key of the user id with two consecutive codes, as
+// Validator.Resync does with the default look-ahead.
+func (a *Accounts) Resync(id string, first, second string) (Result, error) {
+	return a.ResyncContext(context.Background(), id, first, second)
+}
+
+// ResyncContext resynchronizes the counter of the HOTP key of the user id as Resync does, the stores being called
+// with ctx.
+func (a *Accounts) ResyncContext(ctx context.Context, id string, first, second string) (Result, error) {
+	key, err := a.Store.Key(ctx, id)
+	if err != nil {
+		return Result{}, err
+	}
+	return a.Validator.ResyncContext(ctx, deviceID(id, key), key, first, second, 0)
+}
+
+// deviceID returns the id of the key of the user id in the stores of the validator.
+func deviceID(id string, key otp.Key) string {
+	return id + "/" + key.Fingerprint()
+}
+
+// MemoryKeyStore is a KeyStore keeping the keys in memory. It is safe for concurrent use, and its zero value is
+// ready to use.
+type MemoryKeyStore struct {
+	mu          sync.Mutex
+	keys        map[string]otp.Key
+	enrollments map[string]Enrollment
+}
+
+// Key implements KeyStore.
+func (s *MemoryKeyStore) Key(ctx context.Context, id string) (otp.Key, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, ok := s.keys[id]
+	if !ok {
+		return otp.Key{}, ErrNoKey
+	}
+	return key.Clone(), nil
+}
+
+// SetKey implements KeyStore.
+func (s *MemoryKeyStore) SetKey(ctx context.Context, id string, key otp.Key) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.keys == nil {
+		s.keys = map[string]otp.Key{}
+	}
+	s.keys[id] = key.Clone()
+	return nil
+}
+
+// Enrollment implements KeyStore.
+func (s *MemoryKeyStore) Enrollment(ctx context.Context, id string) (*Enrollment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.enrollments[id]
+	if !ok {
+		return nil, ErrNoKey
+	}
+	e.Device.Key = e.Device.Key.Clone()
+	return &e, nil
+}
+
+// SetEnrollment implements KeyStore.
+func (s *MemoryKeyStore) SetEnrollment(ctx context.Context, id string, e *Enrollment) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e == nil {
+		delete(s.enrollments, id)
+		return nil
+	}
+	if s.enrollments == nil {
+		s.enrollments = map[string]Enrollment{}
+	}
+	stored := *e
+	stored.Device.Key = e.Device.Key.Clone()
+	s.enrollments[id] = stored
+	return nil
+}
@@ -0,0 +1,132 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/xrjr/otp"
+)
+
+func TestAccounts(t *testing.T) {
+	a := &Accounts{
+		Validator: &Validator{Counters: mapCounterStore{}, Clock: clock},
+		Store:     &MemoryKeyStore{},
+		Template:  otp.Key{Type: otp.TypeHOTP, Issuer: "Example"},
+	}
+
+	if _, _, err := a.Verify("alice", "755224"); !errors.Is(err, ErrNoKey) {
+		t.Errorf("Error in Accounts (expected = %v, got = %v)", ErrNoKey, err)
+	}
+
+	e, err := a.Enroll("alice", "alice@example.com", "")
+	if err != nil {
+		t.Fatalf("Error in Accounts (err = %v)", err)
+	}
+	if e.Device.ID != "alice/"+e.Device.Key.Fingerprint() || e.Device.Key.Issuer != "Example" || e.Device.Key.AccountName != "alice@example.com" {
+		t.Errorf("Error in Accounts (unexpected enrollment %+v)", e)
+	}
+
+	code := func(counter uint64) string {
+		return otp.HOTPString(e.Device.Key.Secret, counter, otp.HOTPOptions{})
+	}
+
+	if res, enrolled, err := a.Verify("alice", code(0)); err != nil || !enrolled || res.Counter != 0 {
+		t.Errorf("Error in Accounts (expected enrolled, got = %t, %v, err = %v)", enrolled, res, err)
+	}
+	if _, err := a.Store.Enrollment(context.Background(), "alice"); !errors.Is(err, ErrNoKey) {
+		t.Errorf("Error in Accounts (enrollment kept, err = %v)", err)
+	}
+
+	// the counter advanced by the enrollment is kept by the key
+	if _, _, err := a.Verify("alice", code(0)); !errors.Is(err, ErrInvalidCode) {
+		t.Errorf("Error in Accounts (expected = %v, got = %v)", ErrInvalidCode, err)
+	}
+	if res, enrolled, err := a.Verify("alice", code(1)); err != nil || enrolled || res.Counter != 1 {
+		t.Errorf("Error in Accounts (expected verified, got = %t, %v, err = %v)", enrolled, res, err)
+	}
+
+	if res, err := a.Resync("alice", code(20), code(21)); err != nil || res.Counter != 21 {
+		t.Errorf("Error in Accounts (expected counter = 21, got = %v, err = %v)", res, err)
+	}
+}
+
+func TestAccountsRegister(t *testing.T) {
+	a := &Accounts{
+		Validator: &Validator{Counters: mapCounterStore{}, Clock: clock, MinSecretLength: 20},
+		Store:     &MemoryKeyStore{},
+	}
+
+	weak := otp.Key{Type: otp.TypeHOTP, AccountName: "alice", Secret: secret[:16]}
+	var weakErr *WeakSecretError
+	if err := a.Register("alice", weak); !errors.Is(err, ErrWeakSecret) || !errors.As(err, &weakErr) || weakErr.Length != 16 || weakErr.MinLength != 20 {
+		t.Errorf("Error in Register (expected = %v, got = %v)", ErrWeakSecret, err)
+	}
+	if _, err := a.Store.Key(context.Background(), "alice"); !errors.Is(err, ErrNoKey) {
+		t.Errorf("Error in Register (weak key stored, err = %v)", err)
+	}
+	if err := a.Register("alice", otp.Key{Type: "unknown", Secret: secret}); !errors.Is(err, otp.ErrInvalidType) {
+		t.Errorf("Error in Register (expected = %v, got = %v)", otp.ErrInvalidType, err)
+	}
+
+	if err := a.Register("alice", otp.Key{Type: otp.TypeHOTP, AccountName: "alice", Secret: secret}); err != nil {
+		t.Fatalf("Error in Register (err = %v)", err)
+	}
+	if res, err := a.VerifyKey("alice", "755224"); err != nil || res.Counter != 0 {
+		t.Errorf("Error in Register (got = %v, err = %v)", res, err)
+	}
+}
+
+func TestAccountsEnrollReplace(t *testing.T) {
+	a := &Accounts{
+		Validator: &Validator{Counters: mapCounterStore{}, Clock: clock},
+		Store:     &MemoryKeyStore{},
+		Template:  otp.Key{Type: otp.TypeHOTP},
+		Required:  2,
+	}
+	key := otp.Key{Type: otp.TypeHOTP, AccountName: "alice", Secret: secret}
+	if err := a.Register("alice", key); err != nil {
+		t.Fatalf("Error in Register (err = %v)", err)
+	}
+	current := func(counter uint64) string {
+		return otp.HOTPString(secret, counter, otp.HOTPOptions{})
+	}
+
+	// a new key is only enrolled with a code of the current key
+	if _, err := a.Enroll("alice", "alice", ""); !errors.Is(err, ErrCodeRequired) {
+		t.Errorf("Error in Enroll (expected = %v, got = %v)", ErrCodeRequired, err)
+	}
+	if _, err := a.Enroll("alice", "alice", "000000"); !errors.Is(err, ErrInvalidCode) {
+		t.Errorf("Error in Enroll (expected = %v, got = %v)", ErrInvalidCode, err)
+	}
+	if _, err := a.Store.Enrollment(context.Background(), "alice"); !errors.Is(err, ErrNoKey) {
+		t.Errorf("Error in Enroll (enrollment started without code, err = %v)", err)
+	}
+
+	e, err := a.Enroll("alice", "alice", current(0))
+	if err != nil {
+		t.Fatalf("Error in Enroll (err = %v)", err)
+	}
+	pending := func(counter uint64) string {
+		return otp.HOTPString(e.Device.Key.Secret, counter, otp.HOTPOptions{})
+	}
+
+	if _, enrolled, err := a.Verify("alice", pending(0)); err != nil || enrolled {
+		t.Errorf("Error in Verify (expected pending, got = %t, err = %v)", enrolled, err)
+	}
+
+	// the current key is still accepted during the enrollment, which is left as is
+	if res, enrolled, err := a.Verify("alice", current(1)); err != nil || enrolled || res.ID != deviceID("alice", key) {
+		t.Errorf("Error in Verify (expected current key, got = %t, %v, err = %v)", enrolled, res, err)
+	}
+	if stored, _ := a.Store.Enrollment(context.Background(), "alice"); stored == nil || stored.Accepted != 1 {
+		t.Errorf("Error in Verify (expected = 1 code accepted, got = %+v)", stored)
+	}
+
+	if _, enrolled, err := a.Verify("alice", pending(1)); err != nil || !enrolled {
+		t.Errorf("Error in Verify (expected enrolled, got = %t, err = %v)", enrolled, err)
+	}
+	if stored, _ := a.Store.Key(context.Background(), "alice"); !stored.Equal(e.Device.Key) {
+		t.Errorf("Error in Verify (expected the new key, got = %v)", stored)
+	}
+}
@@ -0,0 +1,124 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+// EventType is the outcome of a verification reported by an Event.
+type EventType int
+
+const (
+	EventSuccess     EventType = iota // the code was accepted
+	EventFailure                      // the code was invalid
+	EventReplay                       // the code was already used
+	EventLockout                      // the identity was locked out by the Throttler
+	EventRateLimited                  // the attempt was refused by the RateLimiter
+)
+
+// String returns the name of the event type, e.g. "success".
+func (t EventType) String() string {
+	switch t {
+	case EventSuccess:
+		return "success"
+	case EventFailure:
+		return "failure"
+	case EventReplay:
+		return "replay"
+	case EventLockout:
+		return "lockout"
+	case EventRateLimited:
+		return "rate_limited"
+	default:
+		return "unknown"
+	}
+}
+
+// Event describes a verification, as reported to the AuditFunc of a Validator.
+type Event struct {
+	Type        EventType
+	ID          string    // id of the identity, as given to Verify
+	Device      string    // id of the key accepting the code, or of the only key verified
+	Fingerprint string    // fingerprint of the key of Device, as returned by otp.Key.Fingerprint
+	Counter     uint64    // counter or time step of the code accepted or replayed
+	Skew        int       // skew of the code accepted
+	Time        time.Time // time of the verification, from the clock of the validator
+	Err         error     // error returned by the verification, nil on success
+}
+
+// AuditFunc receives the events of the verifications of a Validator, e.g. to feed a security monitoring pipeline.
+// It is called synchronously, and must be safe for concurrent use when the Validator is.
+type AuditFunc func(event Event)
+
+// report reports the outcome of a verification of the identity id against devices to the AuditFunc and the
+// Logger. Errors which don't come from the verification itself, such as errors of the stores, aren't audited, and
+// are logged at the error level.
+func (v *Validator) report(id string, devices []Device, res Result, err error) {
+	event := Event{ID: id, Device: res.ID, Counter: res.Counter, Skew: res.Skew, Time: v.now(), Err: err}
+
+	audited := true
+	switch {
+	case err == nil:
+		event.Type = EventSuccess
+	case errors.Is(err, ErrReplayedCode):
+		event.Type = EventReplay
+	case errors.Is(err, ErrInvalidCode):
+		event.Type = EventFailure
+	case errors.Is(err, ErrLockedOut):
+		event.Type = EventLockout
+	case errors.Is(err, ErrRateLimited):
+		event.Type = EventRateLimited
+	default:
+		audited = false
+	}
+
+	if event.Device == "" && len(devices) == 1 {
+		event.Device = devices[0].ID
+	}
+	for _, d := range devices {
+		if d.ID == event.Device {
+			event.Fingerprint = d.Key.Fingerprint()
+			break
+		}
+	}
+
+	if v.Audit != nil && audited {
+		v.Audit(event)
+	}
+	if v.Logger != nil {
+		v.log(event, audited)
+	}
+}
+
+// logLevel returns the level of the events of a type, as set by LogLevels or by default.
+func (v *Validator) logLevel(t EventType) slog.Level {
+	if level, ok := v.LogLevels[t]; ok {
+		return level
+	}
+	if t == EventSuccess {
+		return slog.LevelInfo
+	}
+	return slog.LevelWarn
+}
+
+// log logs an event, without the code verified. Events which aren't audited only report their error.
+func (v *Validator) log(event Event, audited bool) {
+	level := slog.LevelError
+	attrs := []slog.Attr{slog.String("id", event.ID)}
+	if event.Device != "" {
+		attrs = append(attrs, slog.String("device", event.Device), slog.String("fingerprint", event.Fingerprint))
+	}
+	if audited {
+		level = v.logLevel(event.Type)
+		attrs = append(attrs, slog.String("event", event.Type.String()))
+		if event.Type == EventSuccess || event.Type == EventReplay {
+			attrs = append(attrs, slog.Uint64("counter", event.Counter), slog.Int("skew", event.Skew))
+		}
+	}
+	if event.Err != nil {
+		attrs = append(attrs, slog.String("error", event.Err.Error()))
+	}
+	v.Logger.LogAttrs(context.Background(), level, "otp verification", attrs...)
+}
@@ -0,0 +1,102 @@
+package server
+
+import (
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestAudit(t *testing.T) {
+	var events []Event
+	v := &Validator{
+		Replay:   mapReplayStore{},
+		Throttle: &MemoryThrottler{MaxAttempts: 2, Clock: clock},
+		Audit: func(event Event) {
+			events = append(events, event)
+		},
+		Clock: clock,
+	}
+
+	v.Verify("alice", totpKey, "07081804")
+	v.Verify("alice", totpKey, "07081804")
+	v.Verify("alice", totpKey, "00000000")
+	v.Verify("alice", totpKey, "07081804")
+
+	expected := []EventType{EventSuccess, EventReplay, EventFailure, EventLockout}
+	if len(events) != len(expected) {
+		t.Fatalf("Error in Audit (expected = %d events, got = %d)", len(expected), len(events))
+	}
+	for i, event := range events {
+		if event.Type != expected[i] || event.ID != "alice" || event.Device != "alice" || event.Fingerprint != totpKey.Fingerprint() || !event.Time.Equal(now) {
+			t.Errorf("Error in Audit (i = %d, expected = %s, got = %+v)", i, expected[i], event)
+		}
+	}
+
+	if events[0].Counter != 37037036 || events[0].Err != nil || events[1].Counter != 37037036 {
+		t.Errorf("Error in Audit (unexpected counters or error, got = %+v)", events[:2])
+	}
+	if !errors.Is(events[3].Err, ErrLockedOut) {
+		t.Errorf("Error in Audit (expected = %v, got = %v)", ErrLockedOut, events[3].Err)
+	}
+}
+
+func TestAuditDevices(t *testing.T) {
+	var events []Event
+	v := &Validator{
+		Counters: mapCounterStore{},
+		Audit: func(event Event) {
+			events = append(events, event)
+		},
+		Clock: clock,
+	}
+	devices := []Device{{ID: "alice/phone", Key: totpKey}, {ID: "alice/token", Key: hotpKey}}
+
+	v.VerifyAny("alice", devices, "755224")
+	v.VerifyAny("alice", devices, "00000000")
+	// errors of the stores aren't reported
+	(&Validator{Audit: v.Audit}).Verify("alice", hotpKey, "755224")
+
+	if len(events) != 2 {
+		t.Fatalf("Error in AuditDevices (expected = 2 events, got = %d)", len(events))
+	}
+	if events[0].Type != EventSuccess || events[0].Device != "alice/token" || events[0].Fingerprint != hotpKey.Fingerprint() {
+		t.Errorf("Error in AuditDevices (unexpected event %+v)", events[0])
+	}
+	if events[1].Type != EventFailure || events[1].Device != "" || events[1].Fingerprint != "" {
+		t.Errorf("Error in AuditDevices (unexpected event %+v)", events[1])
+	}
+}
+
+func TestLogger(t *testing.T) {
+	var buf strings.Builder
+	v := &Validator{
+		Replay:    mapReplayStore{},
+		Logger:    slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})),
+		LogLevels: map[EventType]slog.Level{EventSuccess: slog.LevelDebug},
+		Clock:     clock,
+	}
+
+	v.Verify("alice", totpKey, "07081804")
+	v.Verify("alice", totpKey, "00000000")
+	v.Verify("alice", hotpKey, "755224")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	expected := []string{
+		"level=DEBUG msg=\"otp verification\" id=alice device=alice fingerprint=" + totpKey.Fingerprint() + " event=success counter=37037036 skew=0",
+		"level=WARN msg=\"otp verification\" id=alice device=alice fingerprint=" + totpKey.Fingerprint() + " event=failure error=\"server: invalid code\"",
+		"level=ERROR msg=\"otp verification\" id=alice device=alice fingerprint=" + hotpKey.Fingerprint() + " error=\"server: no counter store\"",
+	}
+	if len(lines) != len(expected) {
+		t.Fatalf("Error in Logger (expected = %d lines, got = %q)", len(expected), lines)
+	}
+	for i, line := range lines {
+		// the time of the record is removed
+		if _, line, _ = strings.Cut(line, " "); line != expected[i] {
+			t.Errorf("Error in Logger (i = %d, expected = %s, got = %s)", i, expected[i], line)
+		}
+		if strings.Contains(line, "07081804") || strings.Contains(line, "00000000") {
+			t.Errorf("Error in Logger (i = %d, code leaked in %s)", i, line)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package server
+
+import (
+	"context"
+	"runtime"
+	"sync"
+
+	"github.com/xrjr/otp"
+)
+
+// VerifyRequest is a verification of a batch, as done by Verify.
+type VerifyRequest struct {
+	ID   string // id of the key in the stores
+	Key  otp.Key
+	Code string
+}
+
+// VerifyResult is the outcome of a VerifyRequest.
+type VerifyResult struct {
+	Result Result
+	Err    error
+}
+
+// VerifyBatch verifies the codes of requests as Verify does, fanned out over workers goroutines (GOMAXPROCS when
+// workers isn't positive), for bulk operations such as validating queued offline transactions. The results are
+// returned in the order of the requests. The requests not started once ctx is done fail with the error of ctx.
+// Requests of the same key may be verified concurrently, the stores then accepting a code only once.
+func (v *Validator) VerifyBatch(ctx context.Context, requests []VerifyRequest, workers int) []VerifyResult {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	workers = min(workers, len(requests))
+
+	results := make([]VerifyResult, len(requests))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				if err := ctx.Err(); err != nil {
+					results[i].Err = err
+					continue
+				}
+				r := requests[i]
+				results[i].Result, results[i].Err = v.VerifyAnyContext(ctx, r.ID, []Device{{ID: r.ID, Key: r.Key}}, r.Code)
+			}
+		}()
+	}
+
+	for i := range requests {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+	return results
+}
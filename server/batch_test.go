@@ -0,0 +1,54 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestVerifyBatch(t *testing.T) {
+	v := &Validator{Replay: &MemoryReplayStore{Clock: clock}, Counters: &MemoryCounterStore{}, Window: 1, Clock: clock}
+
+	var requests []VerifyRequest
+	for i := range 20 {
+		requests = append(requests, VerifyRequest{ID: fmt.Sprint("totp", i), Key: totpKey, Code: "07081804"})
+	}
+	requests = append(requests,
+		VerifyRequest{ID: "hotp", Key: hotpKey, Code: "287082"},
+		VerifyRequest{ID: "invalid", Key: totpKey, Code: "12345678"},
+		// the same code twice, accepted once
+		VerifyRequest{ID: "replay", Key: totpKey, Code: "07081804"},
+		VerifyRequest{ID: "replay", Key: totpKey, Code: "07081804"},
+	)
+
+	results := v.VerifyBatch(context.Background(), requests, 4)
+	if len(results) != len(requests) {
+		t.Fatalf("Error in VerifyBatch (expected = %d results, got = %d)", len(requests), len(results))
+	}
+	for i := range 20 {
+		if results[i].Err != nil || results[i].Result.Counter != 37037036 {
+			t.Errorf("Error in VerifyBatch (i = %d, got = %+v)", i, results[i])
+		}
+	}
+	if res := results[20]; res.Err != nil || res.Result.Counter != 1 {
+		t.Errorf("Error in VerifyBatch (expected counter = 1, got = %+v)", res)
+	}
+	if res := results[21]; !errors.Is(res.Err, ErrInvalidCode) {
+		t.Errorf("Error in VerifyBatch (expected = %v, got = %v)", ErrInvalidCode, res.Err)
+	}
+	if a, b := results[22].Err, results[23].Err; (a == nil) == (b == nil) || !errors.Is(errors.Join(a, b), ErrReplayedCode) {
+		t.Errorf("Error in VerifyBatch (expected one %v, got = %v and %v)", ErrReplayedCode, a, b)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for i, res := range v.VerifyBatch(ctx, requests[:3], 0) {
+		if !errors.Is(res.Err, context.Canceled) {
+			t.Errorf("Error in VerifyBatch (i = %d, expected = %v, got = %v)", i, context.Canceled, res.Err)
+		}
+	}
+	if results := v.VerifyBatch(context.Background(), nil, 2); len(results) != 0 {
+		t.Errorf("Error in VerifyBatch (expected no results, got = %v)", results)
+	}
+}
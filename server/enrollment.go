@@ -0,0 +1,84 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/xrjr/otp"
+)
+
+// Enrollment provisions a new key to a user: the key is pending until the user proves it was provisioned by
+// submitting codes of it, and the enrollment is abandoned after its expiry.
+//
+// An Enrollment is a value: its changes, by ConfirmEnrollment, must be persisted by the application until it is
+// active, the key of its device then being the key of the user.
+type Enrollment struct {
+	Device   Device
+	Expiry   time.Time // end of the enrollment, if it isn't active by then
+	Required int       // consecutive codes required to activate the key, defaults to 1
+	Accepted int       // consecutive codes accepted
+	Last     uint64    // counter or time step of the last code accepted
+	Active   bool      // whether the key was activated
+}
+
+// NewEnrollment starts the enrollment of a key with a new secret, stored as id. The parameters of the key, such
+// as its issuer, account name or type, are given by key, whose secret is replaced by a new one of 20 bytes, as
+// recommended by section 4 of rfc 4226.
+func NewEnrollment(id string, key otp.Key, expiry time.Time) (*Enrollment, error) {
+	secret, err := otp.GenerateSecret(20)
+	if err != nil {
+		return nil, err
+	}
+
+	key = key.Clone()
+	key.Secret = secret
+	if err := key.Validate(); err != nil {
+		return nil, err
+	}
+	return &Enrollment{Device: Device{ID: id, Key: key}, Expiry: expiry}, nil
+}
+
+// URI returns the otpauth URI provisioning the key, to be shown to the user, e.g. as a QR code.
+func (e *Enrollment) URI() string {
+	return e.Device.Key.URI()
+}
+
+// ConfirmEnrollment checks a code of the pending key of an enrollment, identified by the ID of its device in the
+// stores, the RateLimiter and the Throttler. The key is activated once Required consecutive codes are accepted:
+// codes of consecutive time steps for TOTP keys, or consecutive counters for HOTP keys. An invalid code, or a code
+// which doesn't follow the last one, starts over the sequence. ErrEnrollmentExpired is returned after the expiry.
+func (v *Validator) ConfirmEnrollment(e *Enrollment, code string) (Result, error) {
+	return v.ConfirmEnrollmentContext(context.Background(), e, code)
+}
+
+// ConfirmEnrollmentContext checks a code of the pending key of an enrollment as ConfirmEnrollment does, the stores
+// being called with ctx.
+func (v *Validator) ConfirmEnrollmentContext(ctx context.Context, e *Enrollment, code string) (Result, error) {
+	if e.Active {
+		return v.VerifyContext(ctx, e.Device.ID, e.Device.Key, code)
+	}
+	if !v.now().Before(e.Expiry) {
+		return Result{}, ErrEnrollmentExpired
+	}
+
+	res, err := v.VerifyContext(ctx, e.Device.ID, e.Device.Key, code)
+	e.record(res, err)
+	return res, err
+}
+
+// record updates the sequence of consecutive codes accepted with the outcome of the verification of a code of the
+// pending key, and activates the key once Required codes are accepted.
+func (e *Enrollment) record(res Result, err error) {
+	if err != nil {
+		e.Accepted = 0
+		return
+	}
+
+	if e.Accepted > 0 && res.Counter == e.Last+1 {
+		e.Accepted++
+	} else {
+		e.Accepted = 1
+	}
+	e.Last = res.Counter
+	e.Active = e.Accepted >= max(e.Required, 1)
+}
@@ -0,0 +1,56 @@
+package server
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/xrjr/otp"
+)
+
+func TestEnrollment(t *testing.T) {
+	current := now
+	v := &Validator{Replay: mapReplayStore{}, Window: 1, Clock: otp.ClockFunc(func() time.Time {
+		return current
+	})}
+
+	e, err := NewEnrollment("alice/1", otp.Key{Type: otp.TypeTOTP, Issuer: "Example", AccountName: "alice"}, now.Add(10*time.Minute))
+	if err != nil {
+		t.Fatalf("Error in Enrollment (err = %v)", err)
+	}
+	e.Required = 2
+	if key, err := otp.ParseURI(e.URI()); err != nil || !key.Equal(e.Device.Key) {
+		t.Errorf("Error in Enrollment (uri = %s, err = %v)", e.URI(), err)
+	}
+
+	codeAt := func(t time.Time) string {
+		return otp.TOTPString(e.Device.Key.Secret, t, otp.TOTPOptions{})
+	}
+
+	if _, err := v.ConfirmEnrollment(e, codeAt(current)); err != nil || e.Active || e.Accepted != 1 {
+		t.Errorf("Error in Enrollment (expected = 1 accepted, got = %d, err = %v)", e.Accepted, err)
+	}
+
+	// a code which isn't the next one starts over the sequence
+	current = current.Add(time.Minute)
+	if _, err := v.ConfirmEnrollment(e, codeAt(current)); err != nil || e.Active || e.Accepted != 1 {
+		t.Errorf("Error in Enrollment (expected = 1 accepted, got = %d, err = %v)", e.Accepted, err)
+	}
+
+	if _, err := v.ConfirmEnrollment(e, codeAt(current.Add(30*time.Second))); err != nil || !e.Active {
+		t.Errorf("Error in Enrollment (expected active, got = %d accepted, err = %v)", e.Accepted, err)
+	}
+}
+
+func TestEnrollmentExpired(t *testing.T) {
+	v := &Validator{Clock: clock}
+
+	e, _ := NewEnrollment("alice/1", otp.Key{Type: otp.TypeTOTP}, now)
+	if _, err := v.ConfirmEnrollment(e, otp.TOTPString(e.Device.Key.Secret, now, otp.TOTPOptions{})); !errors.Is(err, ErrEnrollmentExpired) {
+		t.Errorf("Error in EnrollmentExpired (expected = %v, got = %v)", ErrEnrollmentExpired, err)
+	}
+
+	if _, err := NewEnrollment("alice/1", otp.Key{Type: "motp"}, now); !errors.Is(err, otp.ErrInvalidType) {
+		t.Errorf("Error in EnrollmentExpired (expected = %v, got = %v)", otp.ErrInvalidType, err)
+	}
+}
@@ -0,0 +1,108 @@
+package server
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/xrjr/otp"
+)
+
+// defaultLRUCapacity is the capacity of a LRUReplayStore without Capacity.
+const defaultLRUCapacity = 100_000
+
+// LRUReplayStore is a ReplayStore keeping the used time steps in memory, as MemoryReplayStore does, but bounding
+// their number: once Capacity time steps are kept, the least recently used one is evicted, expired time steps being
+// removed first. Its memory doesn't grow with the traffic of high-traffic validators, at the cost of accepting again
+// the codes of the time steps evicted before they expire. Capacity should be sized from the number of keys
+// verifying codes within the lifetime of a time step, (2*Window+1) time periods, so that Evictions stays at 0.
+// It is safe for concurrent use, and its zero value is ready to use.
+type LRUReplayStore struct {
+	Capacity int       // maximum number of time steps kept, defaults to 100000
+	Clock    otp.Clock // clock giving the current time, defaults to the system clock
+
+	mu          sync.Mutex
+	entries     map[replayKey]*list.Element
+	order       list.List // lruEntry values, the most recently used first
+	evictions   uint64
+	expirations uint64
+}
+
+// lruEntry is a time step kept by a LRUReplayStore.
+type lruEntry struct {
+	key    replayKey
+	expiry time.Time
+}
+
+// LRUStats are the metrics of a LRUReplayStore.
+type LRUStats struct {
+	Len         int    // number of time steps kept
+	Evictions   uint64 // number of time steps evicted before they expired, whose codes could be replayed
+	Expirations uint64 // number of expired time steps removed
+}
+
+// Use implements ReplayStore.
+func (s *LRUReplayStore) Use(ctx context.Context, id string, step uint64, expiry time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if s.entries == nil {
+		s.entries = map[replayKey]*list.Element{}
+	}
+
+	k := replayKey{id: id, step: step}
+	if el, ok := s.entries[k]; ok {
+		e := el.Value.(*lruEntry)
+		if now.Before(e.expiry) {
+			s.order.MoveToFront(el)
+			return false, nil
+		}
+		s.remove(el)
+		s.expirations++
+	}
+
+	// the least recently used time steps are mostly the oldest ones, and the first to expire
+	for el := s.order.Back(); el != nil && !now.Before(el.Value.(*lruEntry).expiry); el = s.order.Back() {
+		s.remove(el)
+		s.expirations++
+	}
+	for s.order.Len() >= s.capacity() {
+		s.remove(s.order.Back())
+		s.evictions++
+	}
+
+	s.entries[k] = s.order.PushFront(&lruEntry{key: k, expiry: expiry})
+	return true, nil
+}
+
+// Stats returns the metrics of the store, e.g. to be exported to a monitoring system.
+func (s *LRUReplayStore) Stats() LRUStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return LRUStats{Len: s.order.Len(), Evictions: s.evictions, Expirations: s.expirations}
+}
+
+// remove removes a time step from the store.
+func (s *LRUReplayStore) remove(el *list.Element) {
+	delete(s.entries, el.Value.(*lruEntry).key)
+	s.order.Remove(el)
+}
+
+// capacity returns the maximum number of time steps kept.
+func (s *LRUReplayStore) capacity() int {
+	if s.Capacity <= 0 {
+		return defaultLRUCapacity
+	}
+	return s.Capacity
+}
+
+// now returns the current time of the clock of the store.
+func (s *LRUReplayStore) now() time.Time {
+	if s.Clock == nil {
+		return time.Now()
+	}
+	return s.Clock.Now()
+}
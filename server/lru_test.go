@@ -0,0 +1,49 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/xrjr/otp"
+)
+
+func TestLRUReplayStore(t *testing.T) {
+	current := now
+	s := &LRUReplayStore{Capacity: 3, Clock: otp.ClockFunc(func() time.Time {
+		return current
+	})}
+
+	expiry := now.Add(time.Minute)
+	if ok, _ := s.Use(context.Background(), "alice", 1, expiry); !ok {
+		t.Errorf("Error in LRUReplayStore (first use refused)")
+	}
+	if ok, _ := s.Use(context.Background(), "alice", 1, expiry); ok {
+		t.Errorf("Error in LRUReplayStore (second use accepted)")
+	}
+	s.Use(context.Background(), "bob", 1, expiry)
+	s.Use(context.Background(), "carol", 1, expiry)
+
+	// alice was used more recently than bob, which is evicted
+	s.Use(context.Background(), "alice", 1, expiry)
+	if ok, _ := s.Use(context.Background(), "dave", 1, expiry); !ok {
+		t.Errorf("Error in LRUReplayStore (first use refused)")
+	}
+	if ok, _ := s.Use(context.Background(), "alice", 1, expiry); ok {
+		t.Errorf("Error in LRUReplayStore (second use accepted after eviction of another step)")
+	}
+	expected := LRUStats{Len: 3, Evictions: 1}
+	if stats := s.Stats(); stats != expected {
+		t.Errorf("Error in LRUReplayStore (expected = %+v, got = %+v)", expected, stats)
+	}
+
+	// expired time steps are removed before any eviction
+	current = expiry
+	if ok, _ := s.Use(context.Background(), "alice", 1, current.Add(time.Minute)); !ok {
+		t.Errorf("Error in LRUReplayStore (expired step refused)")
+	}
+	expected = LRUStats{Len: 1, Evictions: 1, Expirations: 3}
+	if stats := s.Stats(); stats != expected {
+		t.Errorf("Error in LRUReplayStore (expected = %+v, got = %+v)", expected, stats)
+	}
+}
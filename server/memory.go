@@ -0,0 +1,176 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/xrjr/otp"
+)
+
+// MemoryReplayStore is a ReplayStore keeping the used time steps in memory, until they expire. It is safe for
+// concurrent use, and its zero value is ready to use.
+type MemoryReplayStore struct {
+	Clock otp.Clock // clock giving the current time, defaults to the system clock
+
+	mu        sync.Mutex
+	used      map[replayKey]time.Time
+	nextSweep time.Time
+}
+
+// replayKey identifies a time step of a key.
+type replayKey struct {
+	id   string
+	step uint64
+}
+
+// sweepInterval is the interval between the removals of expired time steps.
+const sweepInterval = time.Minute
+
+// Use implements ReplayStore.
+func (s *MemoryReplayStore) Use(ctx context.Context, id string, step uint64, expiry time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if s.used == nil {
+		s.used = map[replayKey]time.Time{}
+	}
+
+	// expired time steps are removed at most once per interval, so that Use doesn't scan the whole map each time
+	if !now.Before(s.nextSweep) {
+		for k, e := range s.used {
+			if !now.Before(e) {
+				delete(s.used, k)
+			}
+		}
+		s.nextSweep = now.Add(sweepInterval)
+	}
+
+	k := replayKey{id: id, step: step}
+	if e, ok := s.used[k]; ok && now.Before(e) {
+		return false, nil
+	}
+	s.used[k] = expiry
+	return true, nil
+}
+
+// now returns the current time of the clock of the store.
+func (s *MemoryReplayStore) now() time.Time {
+	if s.Clock == nil {
+		return time.Now()
+	}
+	return s.Clock.Now()
+}
+
+// MemoryCounterStore is a CounterStore keeping the counters of HOTP keys in memory. It is safe for concurrent use,
+// and its zero value is ready to use.
+type MemoryCounterStore struct {
+	mu       sync.Mutex
+	counters map[string]uint64
+}
+
+// Get implements CounterStore.
+func (s *MemoryCounterStore) Get(ctx context.Context, id string) (uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.counters[id], nil
+}
+
+// CompareAndSwap implements CounterStore.
+func (s *MemoryCounterStore) CompareAndSwap(ctx context.Context, id string, old, new uint64) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.counters[id] != old {
+		return false, nil
+	}
+	if s.counters == nil {
+		s.counters = map[string]uint64{}
+	}
+	s.counters[id] = new
+	return true, nil
+}
+
+// MemoryDriftStore is a DriftStore keeping the drifts in memory. It is safe for concurrent use, and its zero value
+// is ready to use.
+type MemoryDriftStore struct {
+	mu     sync.Mutex
+	drifts map[string]int
+}
+
+// Get implements DriftStore.
+func (s *MemoryDriftStore) Get(ctx context.Context, id string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.drifts[id], nil
+}
+
+// Set implements DriftStore.
+func (s *MemoryDriftStore) Set(ctx context.Context, id string, drift int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.drifts == nil {
+		s.drifts = map[string]int{}
+	}
+	s.drifts[id] = drift
+	return nil
+}
+
+// MemoryLocker is a Locker of the keys verified by a single process, e.g. for tests. It is safe for concurrent use,
+// and its zero value is ready to use.
+type MemoryLocker struct {
+	mu    sync.Mutex
+	locks map[string]*memoryLock
+}
+
+// memoryLock is the lock of a key, held while its channel holds a value, along with the number of its holders and
+// waiters.
+type memoryLock struct {
+	held chan struct{}
+	refs int
+}
+
+// Lock implements Locker. It waits for the lock as long as it is held, until ctx is done. The lock is released by
+// the first call of the returned function, and later calls do nothing.
+func (l *MemoryLocker) Lock(ctx context.Context, id string) (func() error, error) {
+	l.mu.Lock()
+	if l.locks == nil {
+		l.locks = map[string]*memoryLock{}
+	}
+	lock, ok := l.locks[id]
+	if !ok {
+		lock = &memoryLock{held: make(chan struct{}, 1)}
+		l.locks[id] = lock
+	}
+	lock.refs++
+	l.mu.Unlock()
+
+	select {
+	case lock.held <- struct{}{}:
+	case <-ctx.Done():
+		l.release(id, lock)
+		return nil, ctx.Err()
+	}
+	var once sync.Once
+	return func() error {
+		once.Do(func() {
+			<-lock.held
+			l.release(id, lock)
+		})
+		return nil
+	}, nil
+}
+
+// release removes a holder or a waiter of the lock of the key id, and the lock once it has none.
+func (l *MemoryLocker) release(id string, lock *memoryLock) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if lock.refs--; lock.refs == 0 {
+		delete(l.locks, id)
+	}
+}
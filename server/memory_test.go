@@ -0,0 +1,206 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/xrjr/otp"
+)
+
+func TestMemoryReplayStore(t *testing.T) {
+	current := now
+	s := &MemoryReplayStore{Clock: otp.ClockFunc(func() time.Time {
+		return current
+	})}
+
+	expiry := now.Add(time.Minute)
+	if ok, _ := s.Use(context.Background(), "alice", 1, expiry); !ok {
+		t.Errorf("Error in MemoryReplayStore (first use refused)")
+	}
+	if ok, _ := s.Use(context.Background(), "alice", 1, expiry); ok {
+		t.Errorf("Error in MemoryReplayStore (second use accepted)")
+	}
+	if ok, _ := s.Use(context.Background(), "alice", 2, expiry); !ok {
+		t.Errorf("Error in MemoryReplayStore (other time step refused)")
+	}
+	if ok, _ := s.Use(context.Background(), "bob", 1, expiry); !ok {
+		t.Errorf("Error in MemoryReplayStore (other key refused)")
+	}
+
+	// expired time steps are evicted
+	current = expiry.Add(sweepInterval)
+	if ok, _ := s.Use(context.Background(), "carol", 1, current.Add(time.Minute)); !ok {
+		t.Errorf("Error in MemoryReplayStore (first use refused)")
+	}
+	if len(s.used) != 1 {
+		t.Errorf("Error in MemoryReplayStore (expected = 1 time step, got = %d)", len(s.used))
+	}
+}
+
+func TestMemoryReplayStoreConcurrent(t *testing.T) {
+	v := &Validator{Replay: &MemoryReplayStore{Clock: clock}, Window: 1, Clock: clock}
+
+	var accepted atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := v.Verify("alice", totpKey, "07081804"); err == nil {
+				accepted.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if accepted.Load() != 1 {
+		t.Errorf("Error in MemoryReplayStoreConcurrent (expected = 1 accepted code, got = %d)", accepted.Load())
+	}
+}
+
+func TestMemoryCounterStore(t *testing.T) {
+	s := &MemoryCounterStore{}
+
+	if n, _ := s.Get(context.Background(), "alice"); n != 0 {
+		t.Errorf("Error in MemoryCounterStore (expected = 0, got = %d)", n)
+	}
+	if ok, _ := s.CompareAndSwap(context.Background(), "alice", 1, 2); ok {
+		t.Errorf("Error in MemoryCounterStore (swap of a different counter accepted)")
+	}
+	if ok, _ := s.CompareAndSwap(context.Background(), "alice", 0, 2); !ok {
+		t.Errorf("Error in MemoryCounterStore (swap refused)")
+	}
+	if n, _ := s.Get(context.Background(), "alice"); n != 2 {
+		t.Errorf("Error in MemoryCounterStore (expected = 2, got = %d)", n)
+	}
+}
+
+func TestMemoryCounterStoreConcurrent(t *testing.T) {
+	v := &Validator{Counters: &MemoryCounterStore{}, Window: 3}
+
+	// codes of counters 0 to 3 of rfc 4226, each submitted by 25 goroutines
+	codes := []string{"755224", "287082", "359152", "969429"}
+
+	var accepted [4]atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if res, err := v.Verify("alice", hotpKey, codes[i%4]); err == nil {
+				accepted[res.Counter].Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// each code is accepted at most once, and the last one always is
+	for i := range accepted {
+		if accepted[i].Load() > 1 {
+			t.Errorf("Error in MemoryCounterStoreConcurrent (counter = %d accepted %d times)", i, accepted[i].Load())
+		}
+	}
+	if accepted[3].Load() != 1 {
+		t.Errorf("Error in MemoryCounterStoreConcurrent (last code not accepted)")
+	}
+}
+
+func TestMemoryLocker(t *testing.T) {
+	l := &MemoryLocker{}
+	v := &Validator{Counters: &MemoryCounterStore{}, Locker: l, Window: 3}
+
+	// with the lock, the codes are accepted in the order of the verifications
+	codes := []string{"755224", "287082", "359152", "969429"}
+	var accepted atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := v.Verify("alice", hotpKey, codes[i%4]); err == nil {
+				accepted.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := accepted.Load(); n < 1 || n > 4 {
+		t.Errorf("Error in MemoryLocker (expected between 1 and 4 codes accepted, got = %d)", n)
+	}
+	if len(l.locks) != 0 {
+		t.Errorf("Error in MemoryLocker (expected = 0 locks, got = %d)", len(l.locks))
+	}
+
+	// a verification waiting for the lock gives up once its context is done
+	unlock, _ := l.Lock(context.Background(), "alice")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := v.VerifyContext(ctx, "alice", hotpKey, "755224"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Error in MemoryLocker (expected = %v, got = %v)", context.DeadlineExceeded, err)
+	}
+	unlock()
+	if len(l.locks) != 0 {
+		t.Errorf("Error in MemoryLocker (expected = 0 locks, got = %d)", len(l.locks))
+	}
+}
+
+func TestMemoryLockerUnlockTwice(t *testing.T) {
+	l := &MemoryLocker{}
+	unlock, _ := l.Lock(context.Background(), "alice")
+	unlock()
+
+	// unlocking again neither blocks nor releases the lock of another caller
+	other, err := l.Lock(context.Background(), "alice")
+	if err != nil {
+		t.Fatalf("Error in MemoryLockerUnlockTwice (err = %v)", err)
+	}
+	unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := l.Lock(ctx, "alice"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Error in MemoryLockerUnlockTwice (expected = %v, got = %v)", context.DeadlineExceeded, err)
+	}
+	if lock := l.locks["alice"]; lock == nil || lock.refs != 1 {
+		t.Errorf("Error in MemoryLockerUnlockTwice (expected = 1 holder, got = %+v)", lock)
+	}
+
+	other()
+	other()
+	if len(l.locks) != 0 {
+		t.Errorf("Error in MemoryLockerUnlockTwice (expected = 0 locks, got = %d)", len(l.locks))
+	}
+}
+
+// lockerFunc is an adapter to use a function as a Locker.
+type lockerFunc func(ctx context.Context, id string) (func() error, error)
+
+func (f lockerFunc) Lock(ctx context.Context, id string) (func() error, error) {
+	return f(ctx, id)
+}
+
+func TestValidatorLocker(t *testing.T) {
+	v := &Validator{Counters: &MemoryCounterStore{}, Locker: lockerFunc(func(ctx context.Context, id string) (func() error, error) {
+		return nil, ErrNotLocked
+	})}
+	if _, err := v.Verify("alice", hotpKey, "755224"); !errors.Is(err, ErrNotLocked) {
+		t.Errorf("Error in ValidatorLocker (expected = %v, got = %v)", ErrNotLocked, err)
+	}
+	if n, _ := v.Counters.Get(context.Background(), "alice"); n != 0 {
+		t.Errorf("Error in ValidatorLocker (counter advanced without lock, got = %d)", n)
+	}
+
+	unlocked := false
+	v.Locker = lockerFunc(func(ctx context.Context, id string) (func() error, error) {
+		return func() error {
+			unlocked = true
+			return nil
+		}, nil
+	})
+	if _, err := v.Verify("alice", hotpKey, "755224"); err != nil || !unlocked {
+		t.Errorf("Error in ValidatorLocker (unlocked = %t, err = %v)", unlocked, err)
+	}
+}
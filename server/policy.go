@@ -0,0 +1,39 @@
+package server
+
+import (
+	"fmt"
+
+	"github.com/xrjr/otp"
+)
+
+// WeakSecretError is returned when the secret of a key is shorter than the MinSecretLength of a Validator. It
+// matches ErrWeakSecret with errors.Is.
+type WeakSecretError struct {
+	Length    int // length of the secret in bytes
+	MinLength int // minimum length required by the policy
+}
+
+// Error implements error.
+func (e *WeakSecretError) Error() string {
+	return fmt.Sprintf("%s: %d bytes is shorter than %d", ErrWeakSecret, e.Length, e.MinLength)
+}
+
+// Is reports whether target is ErrWeakSecret.
+func (e *WeakSecretError) Is(target error) bool {
+	return target == ErrWeakSecret
+}
+
+// CheckKey checks that a key can be registered, e.g. a key provisioned by a third party such as the seed of a
+// hardware token: its secret must have at least MinSecretLength bytes, and the key must be valid as checked by
+// otp.Key.Validate, whose own minimum length is replaced by MinSecretLength.
+func (v *Validator) CheckKey(key otp.Key) error {
+	minLength := v.MinSecretLength
+	if minLength == 0 {
+		minLength = otp.MinSecretLength
+	}
+
+	if len(key.Secret) < minLength {
+		return &WeakSecretError{Length: len(key.Secret), MinLength: minLength}
+	}
+	return key.ValidateMinSecretLength(0)
+}
@@ -0,0 +1,43 @@
+package server
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/xrjr/otp"
+)
+
+func TestCheckKey(t *testing.T) {
+	tests := []struct {
+		minLength int
+		length    int
+		expected  int // minimum length of the WeakSecretError, 0 when the key is accepted
+	}{
+		{0, 16, 0},
+		{0, 15, otp.MinSecretLength},
+		{0, 0, otp.MinSecretLength},
+		{20, 16, 20},
+		{20, 20, 0},
+		// a policy below otp.MinSecretLength accepts legacy seeds
+		{10, 10, 0},
+		{10, 9, 10},
+	}
+
+	for i, test := range tests {
+		v := &Validator{MinSecretLength: test.minLength}
+		err := v.CheckKey(otp.Key{Type: otp.TypeHOTP, Secret: make([]byte, test.length)})
+
+		var weakErr *WeakSecretError
+		switch {
+		case test.expected == 0 && err != nil:
+			t.Errorf("Error in CheckKey (i = %d, expected = nil, got = %v)", i, err)
+		case test.expected != 0 && (!errors.Is(err, ErrWeakSecret) || !errors.As(err, &weakErr) || weakErr.MinLength != test.expected):
+			t.Errorf("Error in CheckKey (i = %d, expected = %v with %d bytes, got = %v)", i, ErrWeakSecret, test.expected, err)
+		}
+	}
+
+	v := &Validator{MinSecretLength: 10}
+	if err := v.CheckKey(otp.Key{Type: "unknown", Secret: make([]byte, 10)}); !errors.Is(err, otp.ErrInvalidType) {
+		t.Errorf("Error in CheckKey (expected = %v, got = %v)", otp.ErrInvalidType, err)
+	}
+}
@@ -0,0 +1,120 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/xrjr/otp"
+)
+
+// RateLimitedError is returned when an attempt is refused by a TokenBucket. It matches ErrRateLimited with
+// errors.Is.
+type RateLimitedError struct {
+	RetryAfter time.Duration // time left before an attempt is allowed
+}
+
+// Error implements error.
+func (e *RateLimitedError) Error() string {
+	return fmt.Sprintf("%s: retry after %s", ErrRateLimited, e.RetryAfter)
+}
+
+// Is reports whether target is ErrRateLimited.
+func (e *RateLimitedError) Is(target error) bool {
+	return target == ErrRateLimited
+}
+
+// BucketStore holds the token buckets of a TokenBucket, so that they can be shared by several instances of a
+// service.
+type BucketStore interface {
+	// Take removes a token from the bucket key at time now, the bucket holding up to burst tokens and gaining one
+	// every interval. It returns 0 if it did, or the time left before a token is available. It must be atomic, as
+	// concurrent attempts may take the last token.
+	Take(ctx context.Context, key string, now time.Time, interval time.Duration, burst int) (time.Duration, error)
+}
+
+// TokenBucket is a RateLimiter allowing bursts of Burst attempts, then an attempt every Interval. Refused attempts
+// are reported by a *RateLimitedError.
+//
+// Its keys are free: a Validator limits the attempts of each key id, and a service can also limit the attempts
+// of each client by calling Allow with its IP address before Verify.
+type TokenBucket struct {
+	Store    BucketStore   // buckets of the keys, required
+	Interval time.Duration // time to gain a token, defaults to 30 seconds
+	Burst    int           // maximum number of tokens, defaults to 5
+	Clock    otp.Clock     // clock giving the current time, defaults to the system clock
+}
+
+// Allow implements RateLimiter.
+func (b *TokenBucket) Allow(ctx context.Context, key string) (bool, error) {
+	interval, burst := b.Interval, b.Burst
+	if interval == 0 {
+		interval = 30 * time.Second
+	}
+	if burst == 0 {
+		burst = 5
+	}
+
+	now := time.Now()
+	if b.Clock != nil {
+		now = b.Clock.Now()
+	}
+
+	retryAfter, err := b.Store.Take(ctx, key, now, interval, burst)
+	if err != nil {
+		return false, err
+	}
+	if retryAfter > 0 {
+		return false, &RateLimitedError{RetryAfter: retryAfter}
+	}
+	return true, nil
+}
+
+// takeToken takes a token from a bucket stored as the time at which it is full again. It returns the new time at
+// which the bucket is full, and the time left before a token is available, in which case full is unchanged.
+func takeToken(full, now time.Time, interval time.Duration, burst int) (time.Time, time.Duration) {
+	// a bucket full in the past holds burst tokens, and each token taken pushes the time it is full by interval
+	next := full
+	if next.Before(now) {
+		next = now
+	}
+	next = next.Add(interval)
+
+	if exceeded := next.Sub(now) - time.Duration(burst)*interval; exceeded > 0 {
+		return full, exceeded
+	}
+	return next, 0
+}
+
+// MemoryBucketStore is a BucketStore keeping the buckets in memory. It is safe for concurrent use, and its zero
+// value is ready to use.
+type MemoryBucketStore struct {
+	mu        sync.Mutex
+	full      map[string]time.Time
+	nextSweep time.Time
+}
+
+// Take implements BucketStore.
+func (s *MemoryBucketStore) Take(ctx context.Context, key string, now time.Time, interval time.Duration, burst int) (time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.full == nil {
+		s.full = map[string]time.Time{}
+	}
+
+	// full buckets are removed at most once per interval, as a missing bucket is full
+	if !now.Before(s.nextSweep) {
+		for k, full := range s.full {
+			if !now.Before(full) {
+				delete(s.full, k)
+			}
+		}
+		s.nextSweep = now.Add(sweepInterval)
+	}
+
+	full, retryAfter := takeToken(s.full[key], now, interval, burst)
+	s.full[key] = full
+	return retryAfter, nil
+}
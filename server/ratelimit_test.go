@@ -0,0 +1,54 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/xrjr/otp"
+)
+
+func TestTokenBucket(t *testing.T) {
+	current := now
+	b := &TokenBucket{Store: &MemoryBucketStore{}, Interval: time.Minute, Burst: 3, Clock: otp.ClockFunc(func() time.Time {
+		return current
+	})}
+
+	// time left before an attempt, after each attempt
+	expected := []time.Duration{0, 0, 0, time.Minute, time.Minute}
+	for i, retryAfter := range expected {
+		_, err := b.Allow(context.Background(), "alice")
+		var rateLimited *RateLimitedError
+		if retryAfter == 0 && err != nil || retryAfter != 0 && (!errors.As(err, &rateLimited) || rateLimited.RetryAfter != retryAfter) {
+			t.Errorf("Error in TokenBucket (i = %d, expected = %s, got = %v)", i, retryAfter, err)
+		}
+	}
+
+	if ok, err := b.Allow(context.Background(), "bob"); !ok || err != nil {
+		t.Errorf("Error in TokenBucket (attempt of another key refused, err = %v)", err)
+	}
+
+	// a token is gained every interval
+	current = current.Add(90 * time.Second)
+	if ok, err := b.Allow(context.Background(), "alice"); !ok || err != nil {
+		t.Errorf("Error in TokenBucket (attempt refused after an interval, err = %v)", err)
+	}
+	if _, err := b.Allow(context.Background(), "alice"); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("Error in TokenBucket (expected = %v, got = %v)", ErrRateLimited, err)
+	}
+}
+
+func TestVerifyTokenBucket(t *testing.T) {
+	v := &Validator{Limiter: &TokenBucket{Store: &MemoryBucketStore{}, Burst: 1, Clock: clock}, Clock: clock}
+
+	if _, err := v.Verify("alice", totpKey, "07081804"); err != nil {
+		t.Errorf("Error in VerifyTokenBucket (err = %v)", err)
+	}
+
+	_, err := v.Verify("alice", totpKey, "07081804")
+	var rateLimited *RateLimitedError
+	if !errors.As(err, &rateLimited) || rateLimited.RetryAfter != 30*time.Second {
+		t.Errorf("Error in VerifyTokenBucket (expected = retry after 30s, got = %v)", err)
+	}
+}
@@ -0,0 +1,69 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+
+	"github.com/xrjr/otp"
+)
+
+// DefaultResyncWindow is the number of counters searched by Resync when its look-ahead is 0.
+const DefaultResyncWindow = 1000
+
+// Resync resynchronizes the counter of a HOTP key whose device went past the window, e.g. a hardware token pressed
+// many times, with two consecutive codes of the device (section 7.4 of rfc 4226). The codes are searched in the
+// lookAhead counters following the expected one, and the counter is advanced past the second code, whose counter
+// is returned in Result. As a single code, the pair is only accepted once.
+func (v *Validator) Resync(id string, key otp.Key, first, second string, lookAhead int) (Result, error) {
+	return v.ResyncContext(context.Background(), id, key, first, second, lookAhead)
+}
+
+// ResyncContext resynchronizes the counter of a HOTP key as Resync does, the stores being called with ctx.
+func (v *Validator) ResyncContext(ctx context.Context, id string, key otp.Key, first, second string, lookAhead int) (Result, error) {
+	if v.Counters == nil {
+		return Result{}, ErrNoCounterStore
+	}
+	if lookAhead <= 0 {
+		lookAhead = DefaultResyncWindow
+	}
+
+	opts, err := key.HOTPOptions()
+	if err != nil {
+		return Result{}, err
+	}
+
+	return v.attempt(ctx, "otp.Resync", id, []Device{{ID: id, Key: key}}, func(ctx context.Context) (Result, error) {
+		unlock, err := v.lock(ctx, id)
+		if err != nil {
+			return Result{}, err
+		}
+		defer unlock()
+
+		stored, err := v.getCounter(ctx, id)
+		if err != nil {
+			return Result{}, err
+		}
+		expected := max(stored, key.Counter)
+
+		// the codes are computed with a single hmac, as the window is large
+		codes := otp.HOTPRange(key.Secret, expected, uint64(lookAhead)+1, opts)
+
+		var matched uint64
+		skew, found := 0, false
+		for i := 0; i < lookAhead; i++ {
+			pair := subtle.ConstantTimeCompare([]byte(codes[i].Value), []byte(first)) &
+				subtle.ConstantTimeCompare([]byte(codes[i+1].Value), []byte(second))
+			if pair == 1 && !found {
+				matched, skew, found = codes[i+1].Counter, i+1, true
+			}
+		}
+		if !found {
+			return Result{}, ErrInvalidCode
+		}
+
+		if err := v.advance(ctx, id, stored, matched); err != nil {
+			return Result{ID: id, Counter: matched}, err
+		}
+		return Result{ID: id, Counter: matched, Skew: skew}, nil
+	})
+}
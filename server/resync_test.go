@@ -0,0 +1,38 @@
+package server
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestResync(t *testing.T) {
+	counters := mapCounterStore{}
+	v := &Validator{Counters: counters}
+
+	// codes of counters 3 and 4 of rfc 4226, out of the window
+	if _, err := v.Verify("alice", hotpKey, "969429"); !errors.Is(err, ErrInvalidCode) {
+		t.Errorf("Error in Resync (expected = %v, got = %v)", ErrInvalidCode, err)
+	}
+
+	if _, err := v.Resync("alice", hotpKey, "287082", "969429", 10); !errors.Is(err, ErrInvalidCode) {
+		t.Errorf("Error in Resync (expected = %v for codes not consecutive, got = %v)", ErrInvalidCode, err)
+	}
+	if _, err := v.Resync("alice", hotpKey, "969429", "338314", 3); !errors.Is(err, ErrInvalidCode) {
+		t.Errorf("Error in Resync (expected = %v out of the look-ahead, got = %v)", ErrInvalidCode, err)
+	}
+
+	res, err := v.Resync("alice", hotpKey, "969429", "338314", 0)
+	if err != nil || res.Counter != 4 || res.Skew != 4 {
+		t.Errorf("Error in Resync (expected = {4 4}, got = %v, err = %v)", res, err)
+	}
+	if counters["alice"] != 5 {
+		t.Errorf("Error in Resync (expected counter = 5, got = %d)", counters["alice"])
+	}
+
+	if _, err := v.Resync("alice", hotpKey, "969429", "338314", 0); !errors.Is(err, ErrInvalidCode) {
+		t.Errorf("Error in Resync (expected = %v, got = %v)", ErrInvalidCode, err)
+	}
+	if _, err := v.Verify("alice", hotpKey, "254676"); err != nil {
+		t.Errorf("Error in Resync (code following the resync refused, err = %v)", err)
+	}
+}
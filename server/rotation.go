@@ -0,0 +1,114 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/xrjr/otp"
+)
+
+// RotationState is the state of a Rotation.
+type RotationState int
+
+const (
+	RotationPending    RotationState = iota // codes of both keys are accepted
+	RotationFinalized                       // the new key replaced the old one
+	RotationRolledBack                      // the old key is kept
+)
+
+// Rotation replaces the key of an identity by a new key. Codes of both keys are accepted until the deadline, so
+// that the new key can be provisioned while the old one is still in use. The rotation is then finalized if a code
+// of the new key was accepted, and rolled back otherwise.
+//
+// A Rotation is a value: its changes, by VerifyRotation, Finalize and Rollback, must be persisted by the
+// application along with the keys of the identity.
+type Rotation struct {
+	Old       Device
+	New       Device
+	Deadline  time.Time // end of the grace period accepting both keys
+	Confirmed bool      // whether a code of the new key was accepted
+	State     RotationState
+}
+
+// NewRotation starts the rotation of the key of the device old until deadline. The new key, stored as newID,
+// has the parameters of the old one and a new secret of the same length, at least otp.MinSecretLength bytes.
+func NewRotation(old Device, newID string, deadline time.Time) (*Rotation, error) {
+	secret, err := otp.GenerateSecret(max(len(old.Key.Secret), otp.MinSecretLength))
+	if err != nil {
+		return nil, err
+	}
+
+	key := old.Key.Clone()
+	key.Secret = secret
+	key.Counter = 0
+	return &Rotation{Old: old, New: Device{ID: newID, Key: key}, Deadline: deadline}, nil
+}
+
+// Resolve ends a pending rotation whose deadline is past at time now: it is finalized if the new key was
+// confirmed, and rolled back otherwise.
+func (r *Rotation) Resolve(now time.Time) {
+	if r.State != RotationPending || now.Before(r.Deadline) {
+		return
+	}
+	if r.Confirmed {
+		r.State = RotationFinalized
+	} else {
+		r.State = RotationRolledBack
+	}
+}
+
+// Finalize ends the rotation with the new key. It fails with ErrRotationNotConfirmed until a code of the new key
+// is accepted, and with ErrRotationEnded once the rotation is rolled back.
+func (r *Rotation) Finalize() error {
+	switch {
+	case r.State == RotationFinalized:
+		return nil
+	case r.State == RotationRolledBack:
+		return ErrRotationEnded
+	case !r.Confirmed:
+		return ErrRotationNotConfirmed
+	}
+	r.State = RotationFinalized
+	return nil
+}
+
+// Rollback ends the rotation with the old key. It fails with ErrRotationEnded once the rotation is finalized.
+func (r *Rotation) Rollback() error {
+	if r.State == RotationFinalized {
+		return ErrRotationEnded
+	}
+	r.State = RotationRolledBack
+	return nil
+}
+
+// Current returns the device of the identity: the old one until the rotation is finalized, the new one after.
+func (r *Rotation) Current() Device {
+	if r.State == RotationFinalized {
+		return r.New
+	}
+	return r.Old
+}
+
+// VerifyRotation checks a code of an identity, identified by id in the RateLimiter and the Throttler, whose key
+// is rotated. The rotation is first resolved with the time of the clock of the validator. The codes of the new
+// key are tried first while it is pending, and accepting one confirms the new key.
+func (v *Validator) VerifyRotation(id string, r *Rotation, code string) (Result, error) {
+	return v.VerifyRotationContext(context.Background(), id, r, code)
+}
+
+// VerifyRotationContext checks a code of an identity whose key is rotated as VerifyRotation does, the stores being
+// called with ctx.
+func (v *Validator) VerifyRotationContext(ctx context.Context, id string, r *Rotation, code string) (Result, error) {
+	r.Resolve(v.now())
+
+	devices := []Device{r.Current()}
+	if r.State == RotationPending {
+		devices = []Device{r.New, r.Old}
+	}
+
+	res, err := v.VerifyAnyContext(ctx, id, devices, code)
+	if err == nil && res.ID == r.New.ID {
+		r.Confirmed = true
+	}
+	return res, err
+}
@@ -0,0 +1,57 @@
+package server
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/xrjr/otp"
+)
+
+func TestRotation(t *testing.T) {
+	v := &Validator{Replay: mapReplayStore{}, Clock: clock}
+	r, err := NewRotation(Device{ID: "alice/1", Key: totpKey}, "alice/2", now.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("Error in Rotation (err = %v)", err)
+	}
+	if len(r.New.Key.Secret) != len(secret) || string(r.New.Key.Secret) == string(secret) || r.New.Key.Digits != 8 {
+		t.Errorf("Error in Rotation (unexpected new key %v)", r.New.Key)
+	}
+
+	if err := r.Finalize(); !errors.Is(err, ErrRotationNotConfirmed) {
+		t.Errorf("Error in Rotation (expected = %v, got = %v)", ErrRotationNotConfirmed, err)
+	}
+
+	// codes of both keys are accepted while pending
+	if res, err := v.VerifyRotation("alice", r, "07081804"); err != nil || res.ID != "alice/1" || r.Confirmed {
+		t.Errorf("Error in Rotation (expected = alice/1, got = %v, err = %v)", res, err)
+	}
+	code := otp.TOTPString(r.New.Key.Secret, now, otp.TOTPOptions{HOTPOptions: otp.HOTPOptions{Digits: 8}})
+	if res, err := v.VerifyRotation("alice", r, code); err != nil || res.ID != "alice/2" || !r.Confirmed {
+		t.Errorf("Error in Rotation (expected = alice/2, got = %v, err = %v)", res, err)
+	}
+
+	if err := r.Finalize(); err != nil || r.Current().ID != "alice/2" {
+		t.Errorf("Error in Rotation (expected = alice/2, got = %s, err = %v)", r.Current().ID, err)
+	}
+	if err := r.Rollback(); !errors.Is(err, ErrRotationEnded) {
+		t.Errorf("Error in Rotation (expected = %v, got = %v)", ErrRotationEnded, err)
+	}
+}
+
+func TestRotationDeadline(t *testing.T) {
+	v := &Validator{Replay: mapReplayStore{}, Clock: clock}
+
+	// an unconfirmed rotation is rolled back at the deadline
+	r, _ := NewRotation(Device{ID: "alice/1", Key: totpKey}, "alice/2", now)
+	if _, err := v.VerifyRotation("alice", r, "07081804"); err != nil || r.State != RotationRolledBack {
+		t.Errorf("Error in RotationDeadline (expected = %d, got = %d, err = %v)", RotationRolledBack, r.State, err)
+	}
+
+	// a confirmed rotation is finalized, and the old key refused
+	r, _ = NewRotation(Device{ID: "bob/1", Key: totpKey}, "bob/2", now)
+	r.Confirmed = true
+	if _, err := v.VerifyRotation("bob", r, "07081804"); !errors.Is(err, ErrInvalidCode) || r.State != RotationFinalized {
+		t.Errorf("Error in RotationDeadline (expected = %d, got = %d, err = %v)", RotationFinalized, r.State, err)
+	}
+}
@@ -0,0 +1,83 @@
+// Package server verifies the codes submitted to a service, along with the state verification needs: the time
+// steps already used by TOTP codes, the counters of HOTP keys, and the limitation of attempts.
+//
+// The state is kept by small interfaces, so that it can be shared by several instances of a service. Their methods
+// are given the context of the verification, so that the calls to remote stores honor its deadline and cancellation.
+package server
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrInvalidCode is returned when the code doesn't match the key.
+	ErrInvalidCode = errors.New("server: invalid code")
+	// ErrReplayedCode is returned when the code matches the key, but was already used.
+	ErrReplayedCode = errors.New("server: replayed code")
+	// ErrRateLimited is returned, or matched by the *RateLimitedError returned, when the attempt is refused by the
+	// RateLimiter.
+	ErrRateLimited = errors.New("server: rate limited")
+	// ErrLockedOut is matched by the *LockedOutError returned when the key is locked out by the Throttler.
+	ErrLockedOut = errors.New("server: locked out")
+	// ErrRotationNotConfirmed is returned when a rotation is finalized before a code of the new key is accepted.
+	ErrRotationNotConfirmed = errors.New("server: rotation not confirmed")
+	// ErrRotationEnded is returned when a rotation already ended the other way is finalized or rolled back.
+	ErrRotationEnded = errors.New("server: rotation ended")
+	// ErrNoKey is returned by a KeyStore when the user has no key, or no pending enrollment.
+	ErrNoKey = errors.New("server: no key")
+	// ErrEnrollmentExpired is returned when an enrollment is confirmed after its expiry.
+	ErrEnrollmentExpired = errors.New("server: enrollment expired")
+	// ErrNoCounterStore is returned when a HOTP key is verified by a Validator without CounterStore.
+	ErrNoCounterStore = errors.New("server: no counter store")
+	// ErrWeakSecret is matched by the *WeakSecretError returned when a key is refused by the policy of a Validator.
+	ErrWeakSecret = errors.New("server: weak secret")
+	// ErrNotLocked is returned by a Locker when the lock of a key isn't acquired in time.
+	ErrNotLocked = errors.New("server: lock not acquired")
+	// ErrCodeRequired is returned when a new key is enrolled without a code of the current key of the user.
+	ErrCodeRequired = errors.New("server: code of the current key required")
+)
+
+// ReplayStore records the time steps of the TOTP codes already used, so that a code is accepted only once
+// (section 5.2 of rfc 6238).
+type ReplayStore interface {
+	// Use records that the code of a time step of the key id was used, until expiry at least, and reports whether
+	// it wasn't used before. It must be atomic, as concurrent verifications may use the same code.
+	Use(ctx context.Context, id string, step uint64, expiry time.Time) (bool, error)
+}
+
+// CounterStore holds the counters of HOTP keys, so that the counter of a key is advanced once a code is accepted
+// (section 7.2 of rfc 4226).
+type CounterStore interface {
+	// Get returns the counter of the next code expected for the key id, or 0 if none is stored.
+	Get(ctx context.Context, id string) (uint64, error)
+	// CompareAndSwap sets the counter of the key id to new if it is still old (0 if none is stored), and reports
+	// whether it did. It must be atomic, as concurrent verifications may accept the same code.
+	CompareAndSwap(ctx context.Context, id string, old, new uint64) (bool, error)
+}
+
+// Locker serializes the verifications of the codes of a HOTP key, across the replicas of a service. The counter is
+// advanced by CompareAndSwap, which never accepts a code twice, but stores replicated asynchronously may accept it
+// on both sides of a partition: a lock held from reading the counter to advancing it prevents it.
+type Locker interface {
+	// Lock acquires the lock of the key id, waiting for it while it is held, and returns the function releasing
+	// it. It returns ErrNotLocked if the lock isn't acquired in time.
+	Lock(ctx context.Context, id string) (unlock func() error, err error)
+}
+
+// DriftStore holds the clock drift of the devices of TOTP keys, in time steps, so that the codes of a device whose
+// clock is consistently off are still accepted (section 6 of rfc 6238).
+type DriftStore interface {
+	// Get returns the drift of the key id, or 0 if none is stored.
+	Get(ctx context.Context, id string) (int, error)
+	// Set records the drift of the key id.
+	Set(ctx context.Context, id string, drift int) error
+}
+
+// RateLimiter limits the verification attempts of a key, to prevent brute force attacks.
+type RateLimiter interface {
+	// Allow reports whether an attempt to verify a code of the key id is allowed now. A refused attempt may also
+	// be reported by a *RateLimitedError, carrying the time left before an attempt is allowed.
+	Allow(ctx context.Context, id string) (bool, error)
+}
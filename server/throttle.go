@@ -0,0 +1,135 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/xrjr/otp"
+)
+
+// LockedOutError is returned when the key is locked out by the Throttler. It matches ErrLockedOut with errors.Is.
+type LockedOutError struct {
+	RetryAfter time.Duration // time left before the key is unlocked
+}
+
+// Error implements error.
+func (e *LockedOutError) Error() string {
+	return fmt.Sprintf("%s: retry after %s", ErrLockedOut, e.RetryAfter)
+}
+
+// Is reports whether target is ErrLockedOut.
+func (e *LockedOutError) Is(target error) bool {
+	return target == ErrLockedOut
+}
+
+// Throttler locks out keys after repeated failures, as recommended by section 7.3 of rfc 4226.
+type Throttler interface {
+	// Delay returns how long the key id stays locked out, 0 if it isn't.
+	Delay(ctx context.Context, id string) (time.Duration, error)
+	// Failure records a failed verification of the key id.
+	Failure(ctx context.Context, id string) error
+	// Success records a successful verification of the key id, which resets its failures.
+	Success(ctx context.Context, id string) error
+}
+
+// MemoryThrottler is a Throttler keeping the failures of keys in memory. Once a key reaches MaxAttempts
+// consecutive failures, it is locked out for Lockout, and the lockout doubles on each following failure, up to
+// MaxLockout. The failures of a key are forgotten once it isn't locked out and hasn't failed for MaxLockout, so
+// that the failures of many keys don't stay in memory. It is safe for concurrent use.
+type MemoryThrottler struct {
+	MaxAttempts int           // consecutive failures before the first lockout, defaults to 3
+	Lockout     time.Duration // first lockout, defaults to 30 seconds
+	MaxLockout  time.Duration // longest lockout, defaults to 1 hour
+	Clock       otp.Clock     // clock giving the current time, defaults to the system clock
+
+	mu        sync.Mutex
+	state     map[string]throttleState
+	nextSweep time.Time
+}
+
+// throttleState holds the failures of a key.
+type throttleState struct {
+	failures    int
+	lastFailure time.Time
+	lockedUntil time.Time
+}
+
+// stale reports whether the failures of a key are forgotten at a given time.
+func (s throttleState) stale(now time.Time, maxLockout time.Duration) bool {
+	return !now.Before(s.lockedUntil) && !now.Before(s.lastFailure.Add(maxLockout))
+}
+
+// Delay implements Throttler.
+func (t *MemoryThrottler) Delay(ctx context.Context, id string) (time.Duration, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return max(t.state[id].lockedUntil.Sub(t.now()), 0), nil
+}
+
+// Failure implements Throttler.
+func (t *MemoryThrottler) Failure(ctx context.Context, id string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.state == nil {
+		t.state = map[string]throttleState{}
+	}
+
+	maxAttempts, lockout, maxLockout := t.MaxAttempts, t.Lockout, t.MaxLockout
+	if maxAttempts == 0 {
+		maxAttempts = 3
+	}
+	if lockout == 0 {
+		lockout = 30 * time.Second
+	}
+	if maxLockout == 0 {
+		maxLockout = time.Hour
+	}
+
+	// stale failures are removed at most once per interval, so that Failure doesn't scan the whole map each time
+	now := t.now()
+	if !now.Before(t.nextSweep) {
+		for k, state := range t.state {
+			if state.stale(now, maxLockout) {
+				delete(t.state, k)
+			}
+		}
+		t.nextSweep = now.Add(sweepInterval)
+	}
+
+	state := t.state[id]
+	if state.stale(now, maxLockout) {
+		state = throttleState{}
+	}
+	state.failures++
+	state.lastFailure = now
+	if exceeded := state.failures - maxAttempts; exceeded >= 0 {
+		// doubling stops at maxLockout, so that the lockout doesn't overflow
+		for i := 0; i < exceeded && lockout < maxLockout; i++ {
+			lockout *= 2
+		}
+		state.lockedUntil = now.Add(min(lockout, maxLockout))
+	}
+	t.state[id] = state
+	return nil
+}
+
+// Success implements Throttler.
+func (t *MemoryThrottler) Success(ctx context.Context, id string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.state, id)
+	return nil
+}
+
+// now returns the current time of the clock of the throttler.
+func (t *MemoryThrottler) now() time.Time {
+	if t.Clock == nil {
+		return time.Now()
+	}
+	return t.Clock.Now()
+}
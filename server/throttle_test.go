@@ -0,0 +1,91 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/xrjr/otp"
+)
+
+func TestMemoryThrottler(t *testing.T) {
+	current := now
+	throttler := &MemoryThrottler{MaxAttempts: 2, Lockout: time.Minute, MaxLockout: 3 * time.Minute, Clock: otp.ClockFunc(func() time.Time {
+		return current
+	})}
+
+	// lockouts after each failure
+	expected := []time.Duration{0, time.Minute, 2 * time.Minute, 3 * time.Minute, 3 * time.Minute}
+	for i, lockout := range expected {
+		throttler.Failure(context.Background(), "alice")
+		if delay, _ := throttler.Delay(context.Background(), "alice"); delay != lockout {
+			t.Errorf("Error in MemoryThrottler (i = %d, expected = %s, got = %s)", i, lockout, delay)
+		}
+	}
+
+	current = current.Add(3 * time.Minute)
+	if delay, _ := throttler.Delay(context.Background(), "alice"); delay != 0 {
+		t.Errorf("Error in MemoryThrottler (expected = 0, got = %s)", delay)
+	}
+
+	throttler.Success(context.Background(), "alice")
+	throttler.Failure(context.Background(), "alice")
+	if delay, _ := throttler.Delay(context.Background(), "alice"); delay != 0 {
+		t.Errorf("Error in MemoryThrottler (failures not reset, delay = %s)", delay)
+	}
+}
+
+func TestMemoryThrottlerSweep(t *testing.T) {
+	current := now
+	throttler := &MemoryThrottler{MaxAttempts: 2, Lockout: time.Minute, MaxLockout: 3 * time.Minute, Clock: otp.ClockFunc(func() time.Time {
+		return current
+	})}
+
+	for i := range 100 {
+		throttler.Failure(context.Background(), fmt.Sprintf("user%d", i))
+	}
+	throttler.Failure(context.Background(), "alice")
+	throttler.Failure(context.Background(), "alice")
+
+	// the failures of the users which haven't failed since MaxLockout are removed, unless they are locked out
+	current = current.Add(3 * time.Minute)
+	throttler.Failure(context.Background(), "bob")
+	if len(throttler.state) != 1 {
+		t.Errorf("Error in MemoryThrottlerSweep (expected = 1 key, got = %d)", len(throttler.state))
+	}
+
+	// stale failures are forgotten even if they weren't removed yet
+	throttler.MaxAttempts, throttler.Lockout, throttler.MaxLockout = 1, 10*time.Second, 20*time.Second
+	throttler.Failure(context.Background(), "carol")
+	current = current.Add(20 * time.Second)
+	throttler.Failure(context.Background(), "carol")
+	if delay, _ := throttler.Delay(context.Background(), "carol"); delay != 10*time.Second {
+		t.Errorf("Error in MemoryThrottlerSweep (stale failures counted, expected = 10s, got = %s)", delay)
+	}
+}
+
+func TestVerifyThrottled(t *testing.T) {
+	v := &Validator{Throttle: &MemoryThrottler{MaxAttempts: 2, Clock: clock}, Replay: &MemoryReplayStore{Clock: clock}, Clock: clock}
+
+	if _, err := v.Verify("alice", totpKey, "00000000"); !errors.Is(err, ErrInvalidCode) {
+		t.Errorf("Error in VerifyThrottled (expected = %v, got = %v)", ErrInvalidCode, err)
+	}
+	if _, err := v.Verify("alice", totpKey, "07081804"); err != nil {
+		t.Errorf("Error in VerifyThrottled (err = %v)", err)
+	}
+
+	// a replayed code is a failure
+	for i := 0; i < 2; i++ {
+		if _, err := v.Verify("alice", totpKey, "07081804"); !errors.Is(err, ErrReplayedCode) {
+			t.Errorf("Error in VerifyThrottled (expected = %v, got = %v)", ErrReplayedCode, err)
+		}
+	}
+
+	_, err := v.Verify("alice", totpKey, "07081804")
+	var lockedOut *LockedOutError
+	if !errors.Is(err, ErrLockedOut) || !errors.As(err, &lockedOut) || lockedOut.RetryAfter != 30*time.Second {
+		t.Errorf("Error in VerifyThrottled (expected = %v for 30s, got = %v)", ErrLockedOut, err)
+	}
+}
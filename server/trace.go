@@ -0,0 +1,82 @@
+package server
+
+import "context"
+
+// Tracer starts the spans of verifications and of their round trips to the stores, so that verifications can be
+// traced across the services of a deployment.
+//
+// The package doesn't depend on a tracing library: a Tracer is a thin adapter over one, e.g. with
+// go.opentelemetry.io/otel:
+//
+//	type tracer struct {
+//		tracer trace.Tracer
+//	}
+//
+//	func (t tracer) Start(ctx context.Context, name string) (context.Context, server.Span) {
+//		ctx, s := t.tracer.Start(ctx, name)
+//		return ctx, span{s}
+//	}
+//
+//	type span struct {
+//		span trace.Span
+//	}
+//
+//	func (s span) SetAttribute(key string, value any) {
+//		s.span.SetAttributes(attribute.String(key, fmt.Sprint(value)))
+//	}
+//
+//	func (s span) RecordError(err error) {
+//		s.span.RecordError(err)
+//		s.span.SetStatus(codes.Error, err.Error())
+//	}
+//
+//	func (s span) End() {
+//		s.span.End()
+//	}
+type Tracer interface {
+	// Start starts a span, child of the span of ctx if any, and returns a context holding it.
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is a span started by a Tracer.
+type Span interface {
+	// SetAttribute sets an attribute of the span, such as the id of the key verified. Secrets and codes are never
+	// set as attributes.
+	SetAttribute(key string, value any)
+	// RecordError records the error ending the span, including ErrInvalidCode and the other errors of
+	// verifications.
+	RecordError(err error)
+	// End ends the span.
+	End()
+}
+
+// noopSpan is the span of a Validator without Tracer.
+type noopSpan struct{}
+
+func (noopSpan) SetAttribute(key string, value any) {}
+func (noopSpan) RecordError(err error)              {}
+func (noopSpan) End()                               {}
+
+// startSpan starts a span with the Tracer of the validator, or a span doing nothing without Tracer.
+func (v *Validator) startSpan(ctx context.Context, name string) (context.Context, Span) {
+	if v.Tracer == nil {
+		return ctx, noopSpan{}
+	}
+	return v.Tracer.Start(ctx, name)
+}
+
+// trace runs fn, a round trip to a store, in a span of the given name.
+func (v *Validator) trace(ctx context.Context, name string, fn func() error) error {
+	_, span := v.startSpan(ctx, name)
+	err := fn()
+	endSpan(span, err)
+	return err
+}
+
+// endSpan records the error of a span, if any, and ends it.
+func endSpan(span Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+	}
+	span.End()
+}
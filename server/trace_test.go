@@ -0,0 +1,87 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// recorder records the spans of a Validator, as "parent>name" with the error ending them.
+type recorder struct {
+	spans []string
+}
+
+type spanKey struct{}
+
+type recordedSpan struct {
+	r     *recorder
+	name  string
+	err   error
+	attrs map[string]any
+}
+
+func (r *recorder) Start(ctx context.Context, name string) (context.Context, Span) {
+	if parent, ok := ctx.Value(spanKey{}).(*recordedSpan); ok {
+		name = parent.name + ">" + name
+	}
+	s := &recordedSpan{r: r, name: name, attrs: map[string]any{}}
+	return context.WithValue(ctx, spanKey{}, s), s
+}
+
+func (s *recordedSpan) SetAttribute(key string, value any) { s.attrs[key] = value }
+func (s *recordedSpan) RecordError(err error)              { s.err = err }
+func (s *recordedSpan) End() {
+	res := s.name
+	if s.err != nil {
+		res += " (" + s.err.Error() + ")"
+	}
+	if id, ok := s.attrs["otp.id"]; ok {
+		res += " " + id.(string)
+	}
+	s.r.spans = append(s.r.spans, res)
+}
+
+func TestTracer(t *testing.T) {
+	r := &recorder{}
+	v := &Validator{Replay: mapReplayStore{}, Counters: mapCounterStore{}, Throttle: &MemoryThrottler{}, Tracer: r, Clock: clock}
+
+	v.Verify("alice", totpKey, "07081804")
+	v.Verify("bob", hotpKey, "000000")
+
+	expected := []string{
+		"otp.Verify>otp.Throttler.Delay",
+		"otp.Verify>otp.ReplayStore.Use",
+		"otp.Verify>otp.Throttler.Success",
+		"otp.Verify alice",
+		"otp.Verify>otp.Throttler.Delay",
+		"otp.Verify>otp.CounterStore.Get",
+		"otp.Verify>otp.Throttler.Failure",
+		"otp.Verify (" + ErrInvalidCode.Error() + ") bob",
+	}
+	if strings.Join(r.spans, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Error in Tracer (expected = %q, got = %q)", expected, r.spans)
+	}
+
+	// errors of the stores are recorded by their span
+	r.spans = nil
+	storeErr := errors.New("unavailable")
+	v = &Validator{Counters: failingCounterStore{storeErr}, Tracer: r}
+	v.Verify("alice", hotpKey, "755224")
+	if len(r.spans) != 2 || r.spans[0] != "otp.Verify>otp.CounterStore.Get (unavailable)" {
+		t.Errorf("Error in Tracer (unexpected spans %q)", r.spans)
+	}
+}
+
+// failingCounterStore is a CounterStore failing with err.
+type failingCounterStore struct {
+	err error
+}
+
+func (s failingCounterStore) Get(ctx context.Context, id string) (uint64, error) {
+	return 0, s.err
+}
+
+func (s failingCounterStore) CompareAndSwap(ctx context.Context, id string, old, new uint64) (bool, error) {
+	return false, s.err
+}
@@ -0,0 +1,337 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/xrjr/otp"
+)
+
+// Validator verifies the codes of keys, identified by an id in the stores.
+type Validator struct {
+	Replay   ReplayStore  // time steps already used, TOTP codes can be replayed within the window when nil
+	Counters CounterStore // counters of HOTP keys, required to verify them
+	Locker   Locker       // locks of HOTP keys around the advancement of their counter, not locked when nil
+	Drift    DriftStore   // clock drift of the devices of TOTP keys, not compensated when nil
+	Limiter  RateLimiter  // limitation of attempts, unlimited when nil
+	Throttle Throttler    // lockout of keys after repeated failures, never locked out when nil
+	Audit    AuditFunc    // receiver of the events of verifications, not reported when nil
+	Tracer   Tracer       // tracer of verifications and round trips to the stores, not traced when nil
+
+	// Logger logs the outcome of verifications, never their codes. It is silent when nil.
+	Logger *slog.Logger
+	// LogLevels overrides the levels of the events logged, which are info for successes and warn otherwise.
+	// Errors of the stores are logged at the error level.
+	LogLevels map[EventType]slog.Level
+
+	// Window is the number of time steps accepted before and after the current one for TOTP keys, shifted by
+	// the drift of the key, and the number of counters accepted after the expected one for HOTP keys. 0 only
+	// accepts the expected code.
+	Window int
+	Clock  otp.Clock // clock giving the current time, defaults to the system clock
+
+	// MinSecretLength is the minimum length in bytes of the secrets of the keys registered, checked by CheckKey.
+	// It defaults to otp.MinSecretLength, and may be lowered for legacy tokens with shorter seeds.
+	MinSecretLength int
+}
+
+// Verifier verifies the codes of keys. It is implemented by Validator, and lets applications substitute it in
+// their tests, e.g. with otptest.Verifier.
+type Verifier interface {
+	// Verify checks a code of a key, identified by id in the stores.
+	Verify(id string, key otp.Key, code string) (Result, error)
+	// VerifyContext checks a code as Verify does, honoring ctx.
+	VerifyContext(ctx context.Context, id string, key otp.Key, code string) (Result, error)
+}
+
+var _ Verifier = (*Validator)(nil)
+
+// Result describes the code accepted by a Validator.
+type Result struct {
+	ID      string // id of the key accepting the code in the stores
+	Counter uint64 // counter of HOTP codes, or time step of TOTP codes
+	Skew    int    // difference between the counter of the code and the expected one, or the time step and the current one
+}
+
+// Verify checks a code of a key, identified by id in the stores. The code is only accepted once: the time step
+// of TOTP codes is recorded by the ReplayStore, and the counter of HOTP keys is advanced past the code.
+// Invalid and replayed codes are recorded as failures by the Throttler, which may then lock out the key.
+// Errors other than ErrInvalidCode, ErrReplayedCode, ErrRateLimited, *RateLimitedError and *LockedOutError come
+// from the stores or the key.
+func (v *Validator) Verify(id string, key otp.Key, code string) (Result, error) {
+	return v.VerifyContext(context.Background(), id, key, code)
+}
+
+// VerifyContext checks a code as Verify does, the stores being called with ctx and the verification traced as a
+// child span of ctx.
+func (v *Validator) VerifyContext(ctx context.Context, id string, key otp.Key, code string) (Result, error) {
+	return v.VerifyAnyContext(ctx, id, []Device{{ID: id, Key: key}}, code)
+}
+
+// Device is one of the keys registered to an identity, e.g. an authenticator application or a hardware token.
+type Device struct {
+	ID  string // id of the key in the ReplayStore and the CounterStore
+	Key otp.Key
+}
+
+// VerifyAny checks a code against the keys of the devices of an identity, identified by id in the RateLimiter and
+// the Throttler, and returns the ID of the device accepting it in Result.ID. The devices are tried in order, and a
+// code matching a device is only accepted once, as with Verify.
+func (v *Validator) VerifyAny(id string, devices []Device, code string) (Result, error) {
+	return v.VerifyAnyContext(context.Background(), id, devices, code)
+}
+
+// VerifyAnyContext checks a code as VerifyAny does, the stores being called with ctx and the verification traced
+// as a child span of ctx.
+func (v *Validator) VerifyAnyContext(ctx context.Context, id string, devices []Device, code string) (Result, error) {
+	return v.attempt(ctx, "otp.Verify", id, devices, func(ctx context.Context) (Result, error) {
+		res, err := Result{}, ErrInvalidCode
+		for _, d := range devices {
+			if d.Key.Type == otp.TypeHOTP {
+				res, err = v.verifyHOTP(ctx, d.ID, d.Key, code)
+			} else {
+				res, err = v.verifyTOTP(ctx, d.ID, d.Key, code)
+			}
+			// the code matched this device, even if it was replayed
+			if !errors.Is(err, ErrInvalidCode) {
+				break
+			}
+		}
+		return res, err
+	})
+}
+
+// attempt runs a verification of the identity id against devices when allowed by the RateLimiter and the
+// Throttler, and records its outcome with the Throttler and the AuditFunc. The verification is traced as a span
+// of the given name.
+func (v *Validator) attempt(ctx context.Context, name string, id string, devices []Device, verify func(ctx context.Context) (Result, error)) (res Result, err error) {
+	ctx, span := v.startSpan(ctx, name)
+	span.SetAttribute("otp.id", id)
+	defer func() {
+		if res.ID != "" {
+			span.SetAttribute("otp.device", res.ID)
+		}
+		endSpan(span, err)
+	}()
+
+	if v.Audit != nil || v.Logger != nil {
+		defer func() {
+			v.report(id, devices, res, err)
+		}()
+	}
+
+	if v.Limiter != nil {
+		var allowed bool
+		err := v.trace(ctx, "otp.RateLimiter.Allow", func() (err error) {
+			allowed, err = v.Limiter.Allow(ctx, id)
+			return err
+		})
+		if err != nil {
+			return Result{}, err
+		}
+		if !allowed {
+			return Result{}, ErrRateLimited
+		}
+	}
+
+	if v.Throttle != nil {
+		var delay time.Duration
+		err := v.trace(ctx, "otp.Throttler.Delay", func() (err error) {
+			delay, err = v.Throttle.Delay(ctx, id)
+			return err
+		})
+		if err != nil {
+			return Result{}, err
+		}
+		if delay > 0 {
+			return Result{}, &LockedOutError{RetryAfter: delay}
+		}
+	}
+
+	res, err = verify(ctx)
+
+	if v.Throttle != nil {
+		switch {
+		case err == nil:
+			err = v.trace(ctx, "otp.Throttler.Success", func() error {
+				return v.Throttle.Success(ctx, id)
+			})
+		case errors.Is(err, ErrInvalidCode) || errors.Is(err, ErrReplayedCode):
+			err = errors.Join(err, v.trace(ctx, "otp.Throttler.Failure", func() error {
+				return v.Throttle.Failure(ctx, id)
+			}))
+		}
+	}
+	return res, err
+}
+
+// verifyTOTP checks a TOTP code against the time steps of the window.
+func (v *Validator) verifyTOTP(ctx context.Context, id string, key otp.Key, code string) (Result, error) {
+	opts, err := key.TOTPOptions()
+	if err != nil {
+		return Result{}, err
+	}
+
+	drift := 0
+	if v.Drift != nil {
+		err := v.trace(ctx, "otp.DriftStore.Get", func() (err error) {
+			drift, err = v.Drift.Get(ctx, id)
+			return err
+		})
+		if err != nil {
+			return Result{}, err
+		}
+	}
+
+	now := v.now()
+	var matched otp.Code
+	skew, found := 0, false
+	for i := drift - v.Window; i <= drift+v.Window; i++ {
+		opts.Step = i
+		candidate := otp.TOTPCode(key.Secret, now, opts)
+		// every code is compared, so that the time taken doesn't depend on which one matched
+		if subtle.ConstantTimeCompare([]byte(candidate.Value), []byte(code)) == 1 && !found {
+			matched, skew, found = candidate, i, true
+		}
+	}
+	if !found {
+		return Result{}, ErrInvalidCode
+	}
+
+	if v.Replay != nil {
+		// the code stays acceptable until its time step leaves the window, which lags behind with a negative drift
+		lag := v.Window + max(-drift, 0)
+		expiry := matched.ValidUntil.Add(time.Duration(lag) * matched.ValidUntil.Sub(matched.ValidFrom))
+		var unused bool
+		err := v.trace(ctx, "otp.ReplayStore.Use", func() (err error) {
+			unused, err = v.Replay.Use(ctx, id, matched.Counter, expiry)
+			return err
+		})
+		if err != nil {
+			return Result{}, err
+		}
+		if !unused {
+			return Result{ID: id, Counter: matched.Counter}, ErrReplayedCode
+		}
+	}
+
+	// the window follows the drift observed, so that it stays centered on the clock of the device
+	if v.Drift != nil && skew != drift {
+		err := v.trace(ctx, "otp.DriftStore.Set", func() error {
+			return v.Drift.Set(ctx, id, skew)
+		})
+		if err != nil {
+			return Result{}, err
+		}
+	}
+
+	return Result{ID: id, Counter: matched.Counter, Skew: skew}, nil
+}
+
+// verifyHOTP checks a HOTP code against the counters of the window, and advances the counter of the key.
+func (v *Validator) verifyHOTP(ctx context.Context, id string, key otp.Key, code string) (Result, error) {
+	if v.Counters == nil {
+		return Result{}, ErrNoCounterStore
+	}
+
+	opts, err := key.HOTPOptions()
+	if err != nil {
+		return Result{}, err
+	}
+
+	unlock, err := v.lock(ctx, id)
+	if err != nil {
+		return Result{}, err
+	}
+	defer unlock()
+
+	stored, err := v.getCounter(ctx, id)
+	if err != nil {
+		return Result{}, err
+	}
+	expected := max(stored, key.Counter)
+
+	var matched uint64
+	skew, found := 0, false
+	for i := 0; i <= v.Window; i++ {
+		candidate := otp.HOTPString(key.Secret, expected+uint64(i), opts)
+		if subtle.ConstantTimeCompare([]byte(candidate), []byte(code)) == 1 && !found {
+			matched, skew, found = expected+uint64(i), i, true
+		}
+	}
+	if !found {
+		return Result{}, ErrInvalidCode
+	}
+
+	if err := v.advance(ctx, id, stored, matched); err != nil {
+		return Result{ID: id, Counter: matched}, err
+	}
+	return Result{ID: id, Counter: matched, Skew: skew}, nil
+}
+
+// lock acquires the lock of the key id with the Locker, if any, and returns the function releasing it.
+func (v *Validator) lock(ctx context.Context, id string) (func(), error) {
+	if v.Locker == nil {
+		return func() {}, nil
+	}
+
+	var unlock func() error
+	err := v.trace(ctx, "otp.Locker.Lock", func() (err error) {
+		unlock, err = v.Locker.Lock(ctx, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return func() {
+		// a lock which isn't released expires, so the error is only traced
+		v.trace(ctx, "otp.Locker.Unlock", unlock)
+	}, nil
+}
+
+// getCounter returns the counter of the key id in the CounterStore.
+func (v *Validator) getCounter(ctx context.Context, id string) (counter uint64, err error) {
+	err = v.trace(ctx, "otp.CounterStore.Get", func() (err error) {
+		counter, err = v.Counters.Get(ctx, id)
+		return err
+	})
+	return counter, err
+}
+
+// advance sets the counter of the key id, read as stored, past the accepted counter matched.
+func (v *Validator) advance(ctx context.Context, id string, stored, matched uint64) error {
+	// a concurrent verification accepting a code first changes the counter: the code is then still accepted if
+	// the new counter doesn't exceed it
+	for {
+		var swapped bool
+		err := v.trace(ctx, "otp.CounterStore.CompareAndSwap", func() (err error) {
+			swapped, err = v.Counters.CompareAndSwap(ctx, id, stored, matched+1)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		if swapped {
+			return nil
+		}
+
+		stored, err = v.getCounter(ctx, id)
+		if err != nil {
+			return err
+		}
+		if stored > matched {
+			return ErrReplayedCode
+		}
+	}
+}
+
+// now returns the current time of the clock of the validator.
+func (v *Validator) now() time.Time {
+	if v.Clock == nil {
+		return time.Now()
+	}
+	return v.Clock.Now()
+}
@@ -0,0 +1,193 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/xrjr/otp"
+)
+
+var secret = []byte("12345678901234567890")
+
+var totpKey = otp.Key{Type: otp.TypeTOTP, Secret: secret, Digits: 8}
+var hotpKey = otp.Key{Type: otp.TypeHOTP, Secret: secret}
+
+// time of a test value of rfc 6238, whose code is 07081804
+var now = time.Unix(1111111109, 0)
+
+var clock = otp.ClockFunc(func() time.Time {
+	return now
+})
+
+// mapReplayStore is a ReplayStore without expiry.
+type mapReplayStore map[string]bool
+
+func (s mapReplayStore) Use(ctx context.Context, id string, step uint64, expiry time.Time) (bool, error) {
+	key := fmt.Sprintf("%s/%d", id, step)
+	if s[key] {
+		return false, nil
+	}
+	s[key] = true
+	return true, nil
+}
+
+// mapCounterStore is a CounterStore of a single goroutine.
+type mapCounterStore map[string]uint64
+
+func (s mapCounterStore) Get(ctx context.Context, id string) (uint64, error) {
+	return s[id], nil
+}
+
+func (s mapCounterStore) CompareAndSwap(ctx context.Context, id string, old, new uint64) (bool, error) {
+	if s[id] != old {
+		return false, nil
+	}
+	s[id] = new
+	return true, nil
+}
+
+// limiter allows a given number of attempts.
+type limiter struct {
+	attempts int
+}
+
+func (l *limiter) Allow(ctx context.Context, id string) (bool, error) {
+	l.attempts--
+	return l.attempts >= 0, nil
+}
+
+func TestVerifyTOTP(t *testing.T) {
+	v := &Validator{Replay: mapReplayStore{}, Window: 1, Clock: clock}
+
+	res, err := v.Verify("alice", totpKey, "07081804")
+	if err != nil {
+		t.Fatalf("Error in VerifyTOTP (err = %v)", err)
+	}
+	if res.Counter != 37037036 || res.Skew != 0 {
+		t.Errorf("Error in VerifyTOTP (expected = {37037036 0}, got = %v)", res)
+	}
+
+	if _, err := v.Verify("alice", totpKey, "07081804"); !errors.Is(err, ErrReplayedCode) {
+		t.Errorf("Error in VerifyTOTP (expected = %v, got = %v)", ErrReplayedCode, err)
+	}
+
+	// the code of the next time step, within the window
+	next := otp.TOTPCode(secret, now.Add(30*time.Second), otp.TOTPOptions{HOTPOptions: otp.HOTPOptions{Digits: 8}})
+	res, err = v.Verify("alice", totpKey, next.Value)
+	if err != nil || res.Skew != 1 {
+		t.Errorf("Error in VerifyTOTP (expected skew = 1, got = %v, err = %v)", res, err)
+	}
+
+	// another key may use the same time step
+	if _, err := v.Verify("bob", totpKey, "07081804"); err != nil {
+		t.Errorf("Error in VerifyTOTP (err = %v)", err)
+	}
+
+	if _, err := v.Verify("alice", totpKey, "12345678"); !errors.Is(err, ErrInvalidCode) {
+		t.Errorf("Error in VerifyTOTP (expected = %v, got = %v)", ErrInvalidCode, err)
+	}
+}
+
+func TestVerifyHOTP(t *testing.T) {
+	counters := mapCounterStore{}
+	v := &Validator{Counters: counters, Window: 2}
+
+	// codes of counters 0 to 3 of rfc 4226
+	if _, err := v.Verify("alice", hotpKey, "755224"); err != nil {
+		t.Errorf("Error in VerifyHOTP (err = %v)", err)
+	}
+	if _, err := v.Verify("alice", hotpKey, "755224"); !errors.Is(err, ErrInvalidCode) {
+		t.Errorf("Error in VerifyHOTP (expected = %v, got = %v)", ErrInvalidCode, err)
+	}
+
+	res, err := v.Verify("alice", hotpKey, "969429")
+	if err != nil || res.Counter != 3 || res.Skew != 2 {
+		t.Errorf("Error in VerifyHOTP (expected = {3 2}, got = %v, err = %v)", res, err)
+	}
+	if counters["alice"] != 4 {
+		t.Errorf("Error in VerifyHOTP (expected counter = 4, got = %d)", counters["alice"])
+	}
+
+	// the initial counter of the key is used until a counter is stored
+	k := hotpKey
+	k.Counter = 5
+	if _, err := v.Verify("bob", k, "254676"); err != nil {
+		t.Errorf("Error in VerifyHOTP (err = %v)", err)
+	}
+
+	if _, err := (&Validator{}).Verify("alice", hotpKey, "287082"); !errors.Is(err, ErrNoCounterStore) {
+		t.Errorf("Error in VerifyHOTP (expected = %v, got = %v)", ErrNoCounterStore, err)
+	}
+}
+
+func TestVerifyRateLimited(t *testing.T) {
+	v := &Validator{Limiter: &limiter{attempts: 1}, Clock: clock}
+
+	if _, err := v.Verify("alice", totpKey, "00000000"); !errors.Is(err, ErrInvalidCode) {
+		t.Errorf("Error in VerifyRateLimited (expected = %v, got = %v)", ErrInvalidCode, err)
+	}
+	if _, err := v.Verify("alice", totpKey, "07081804"); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("Error in VerifyRateLimited (expected = %v, got = %v)", ErrRateLimited, err)
+	}
+}
+
+func TestVerifyAny(t *testing.T) {
+	replay, counters := mapReplayStore{}, mapCounterStore{}
+	v := &Validator{Replay: replay, Counters: counters, Clock: clock}
+	devices := []Device{{ID: "alice/phone", Key: totpKey}, {ID: "alice/token", Key: hotpKey}}
+
+	res, err := v.VerifyAny("alice", devices, "755224")
+	if err != nil || res.ID != "alice/token" || res.Counter != 0 {
+		t.Errorf("Error in VerifyAny (expected = alice/token, got = %v, err = %v)", res, err)
+	}
+
+	res, err = v.VerifyAny("alice", devices, "07081804")
+	if err != nil || res.ID != "alice/phone" || res.Counter != 37037036 {
+		t.Errorf("Error in VerifyAny (expected = alice/phone, got = %v, err = %v)", res, err)
+	}
+	if !replay["alice/phone/37037036"] {
+		t.Errorf("Error in VerifyAny (time step not recorded for the device)")
+	}
+
+	if _, err := v.VerifyAny("alice", devices, "07081804"); !errors.Is(err, ErrReplayedCode) {
+		t.Errorf("Error in VerifyAny (expected = %v, got = %v)", ErrReplayedCode, err)
+	}
+	if _, err := v.VerifyAny("alice", devices, "00000000"); !errors.Is(err, ErrInvalidCode) {
+		t.Errorf("Error in VerifyAny (expected = %v, got = %v)", ErrInvalidCode, err)
+	}
+	if _, err := v.VerifyAny("alice", nil, "07081804"); !errors.Is(err, ErrInvalidCode) {
+		t.Errorf("Error in VerifyAny (expected = %v, got = %v)", ErrInvalidCode, err)
+	}
+}
+
+func TestVerifyDrift(t *testing.T) {
+	drift := &MemoryDriftStore{}
+	v := &Validator{Replay: mapReplayStore{}, Drift: drift, Window: 1, Clock: clock}
+	opts := otp.TOTPOptions{HOTPOptions: otp.HOTPOptions{Digits: 8}}
+
+	// a device whose clock is 2 minutes late, out of the window until its drift is known
+	late := func(steps int) string {
+		return otp.TOTPString(secret, now.Add(time.Duration(steps)*30*time.Second), opts)
+	}
+	if _, err := v.Verify("alice", totpKey, late(-4)); !errors.Is(err, ErrInvalidCode) {
+		t.Errorf("Error in VerifyDrift (expected = %v, got = %v)", ErrInvalidCode, err)
+	}
+
+	for i, steps := range []int{-1, -2, -3, -4} {
+		res, err := v.Verify("alice", totpKey, late(steps))
+		if err != nil || res.Skew != steps {
+			t.Errorf("Error in VerifyDrift (i = %d, expected skew = %d, got = %v, err = %v)", i, steps, res, err)
+		}
+		if d, _ := drift.Get(context.Background(), "alice"); d != steps {
+			t.Errorf("Error in VerifyDrift (i = %d, expected drift = %d, got = %d)", i, steps, d)
+		}
+	}
+
+	// the current code is out of the window once the drift is known
+	if _, err := v.Verify("alice", totpKey, "07081804"); !errors.Is(err, ErrInvalidCode) {
+		t.Errorf("Error in VerifyDrift (expected = %v, got = %v)", ErrInvalidCode, err)
+	}
+}
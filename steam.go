@@ -0,0 +1,16 @@
+package otp
+
+import (
+	"time"
+)
+
+// SteamTOTP computes the Steam Guard code of a given time.
+// Steam Guard codes are TOTP codes (SHA1, 30 seconds time period) written as 5 characters of a custom alphabet.
+func SteamTOTP(key []byte, t time.Time) string {
+	return TOTPString(key, t, TOTPOptions{
+		HOTPOptions: HOTPOptions{
+			Digits:  5,
+			Encoder: SteamEncoder,
+		},
+	})
+}
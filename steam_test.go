@@ -0,0 +1,27 @@
+package otp
+
+import (
+	"testing"
+	"time"
+)
+
+type SteamTestValue struct {
+	Time time.Time
+	Code string
+}
+
+var steamTestValues = []SteamTestValue{
+	{Time: time.Unix(59, 0), Code: "PV9M4"},
+	{Time: time.Unix(1111111109, 0), Code: "PY4YB"},
+	{Time: time.Unix(1234567890, 0), Code: "VHHQY"},
+	{Time: time.Unix(2000000000, 0), Code: "9N776"},
+}
+
+func TestSteamTOTP(t *testing.T) {
+	for i, testValue := range steamTestValues {
+		res := SteamTOTP(totpSecretSha1, testValue.Time)
+		if res != testValue.Code {
+			t.Errorf("Error in SteamTOTP (i = %d, expected = %s, got = %s)", i, testValue.Code, res)
+		}
+	}
+}
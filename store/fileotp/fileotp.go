@@ -0,0 +1,271 @@
+// Package fileotp implements the replay and counter stores of the server package on a single file, for command
+// line tools and small self-hosted services which don't have a database.
+//
+// Changes are appended to the file and synced before they are acknowledged, and the file is read back when
+// opened. Store.Compact rewrites the file with the current state only.
+package fileotp
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xrjr/otp"
+	"github.com/xrjr/otp/server"
+)
+
+// Store implements server.ReplayStore and server.CounterStore on a file. It is safe for concurrent use, but the
+// file must not be opened by several stores.
+type Store struct {
+	Clock  otp.Clock    // clock giving the current time, defaults to the system clock
+	Logger *slog.Logger // logger of compactions, silent when nil
+
+	mu       sync.Mutex
+	path     string
+	file     storeFile
+	size     int64 // size of the complete records of the file
+	broken   error // error of a record which couldn't be removed from the file, refusing further records
+	replay   map[replayKey]int64
+	counters map[string]uint64
+}
+
+// storeFile is the file of a store, an *os.File except in tests.
+type storeFile interface {
+	WriteString(s string) (int, error)
+	Sync() error
+	Truncate(size int64) error
+	Close() error
+}
+
+var (
+	_ server.ReplayStore  = (*Store)(nil)
+	_ server.CounterStore = (*Store)(nil)
+)
+
+// replayKey identifies a time step of a key.
+type replayKey struct {
+	id   string
+	step uint64
+}
+
+// Open opens the store of a file, creating the file if it doesn't exist.
+func Open(path string) (*Store, error) {
+	s := &Store{path: path, replay: map[replayKey]int64{}, counters: map[string]uint64{}}
+
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	size, err := s.load(file)
+	if err == nil {
+		// removes a record interrupted while being written, so that the next record starts on its own line
+		err = file.Truncate(size)
+	}
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	s.file = file
+	s.size = size
+	return s, nil
+}
+
+// load reads the records of the file, and returns the size of the complete records. A last record without
+// newline was interrupted while being written, and is ignored.
+func (s *Store) load(r io.Reader) (int64, error) {
+	reader := bufio.NewReader(r)
+	size := int64(0)
+	for n := 1; ; n++ {
+		line, err := reader.ReadString('\n')
+		if errors.Is(err, io.EOF) {
+			return size, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		if err := s.apply(strings.TrimSuffix(line, "\n")); err != nil {
+			return 0, fmt.Errorf("fileotp: line %d: %w", n, err)
+		}
+		size += int64(len(line))
+	}
+}
+
+// apply applies a record to the state of the store.
+func (s *Store) apply(record string) error {
+	var id string
+	switch {
+	case strings.HasPrefix(record, "R "):
+		var step uint64
+		var expiry int64
+		if _, err := fmt.Sscanf(record, "R %q %d %d", &id, &step, &expiry); err != nil {
+			return err
+		}
+		s.replay[replayKey{id: id, step: step}] = expiry
+	case strings.HasPrefix(record, "C "):
+		var counter uint64
+		if _, err := fmt.Sscanf(record, "C %q %d", &id, &counter); err != nil {
+			return err
+		}
+		s.counters[id] = counter
+	default:
+		return fmt.Errorf("invalid record %q", record)
+	}
+	return nil
+}
+
+// append writes a record to the file and syncs it, then applies it. A record which fails to be written is removed
+// from the file, so that the next records don't follow a partial one, or else the store refuses further records.
+func (s *Store) append(record string) error {
+	if s.broken != nil {
+		return s.broken
+	}
+
+	n, err := s.file.WriteString(record + "\n")
+	if err == nil {
+		err = s.file.Sync()
+	}
+	if err != nil {
+		if terr := s.file.Truncate(s.size); terr != nil {
+			s.broken = fmt.Errorf("fileotp: partial record: %w", errors.Join(err, terr))
+			return s.broken
+		}
+		return err
+	}
+	s.size += int64(n)
+	return s.apply(record)
+}
+
+// Use implements server.ReplayStore.
+func (s *Store) Use(ctx context.Context, id string, step uint64, expiry time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	k := replayKey{id: id, step: step}
+	if e, ok := s.replay[k]; ok && s.now().Unix() < e {
+		return false, nil
+	}
+	return true, s.append(fmt.Sprintf("R %q %d %d", id, step, expiry.Unix()))
+}
+
+// Get implements server.CounterStore.
+func (s *Store) Get(ctx context.Context, id string) (uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.counters[id], nil
+}
+
+// CompareAndSwap implements server.CounterStore.
+func (s *Store) CompareAndSwap(ctx context.Context, id string, old, new uint64) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.counters[id] != old {
+		return false, nil
+	}
+	return true, s.append(fmt.Sprintf("C %q %d", id, new))
+}
+
+// Compact rewrites the file with the current counters and the time steps which haven't expired. The new file
+// replaces the previous one atomically.
+func (s *Store) Compact() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".fileotp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+	now := s.now().Unix()
+	var expired []replayKey
+	for k, expiry := range s.replay {
+		if now < expiry {
+			fmt.Fprintf(w, "R %q %d %d\n", k.id, k.step, expiry)
+		} else {
+			expired = append(expired, k)
+		}
+	}
+	for id, counter := range s.counters {
+		fmt.Fprintf(w, "C %q %d\n", id, counter)
+	}
+
+	if err := errors.Join(w.Flush(), tmp.Sync(), tmp.Close()); err != nil {
+		return err
+	}
+	// the file is opened before being renamed, so that the store isn't left without file once it is
+	file, err := os.OpenFile(tmp.Name(), os.O_RDWR|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	size := info.Size()
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		file.Close()
+		return err
+	}
+
+	// the state is only changed once the file is replaced
+	s.file.Close()
+	s.file = file
+	s.size = size
+	s.broken = nil
+	for _, k := range expired {
+		delete(s.replay, k)
+	}
+	if err := syncDir(filepath.Dir(s.path)); err != nil {
+		return err
+	}
+
+	if s.Logger != nil {
+		s.Logger.Info("otp file store compacted", slog.String("path", s.path),
+			slog.Int("time_steps", len(s.replay)), slog.Int("counters", len(s.counters)), slog.Int("expired", len(expired)))
+	}
+	return nil
+}
+
+// syncDir syncs a directory, so that the renaming of one of its files is durable. Directories can't be synced on
+// Windows, where renamings are durable once done.
+func syncDir(path string) error {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+
+	dir, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	return errors.Join(dir.Sync(), dir.Close())
+}
+
+// Close closes the file of the store.
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.file.Close()
+}
+
+// now returns the current time of the clock of the store.
+func (s *Store) now() time.Time {
+	if s.Clock == nil {
+		return time.Now()
+	}
+	return s.Clock.Now()
+}
@@ -0,0 +1,194 @@
+package fileotp
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xrjr/otp"
+	"github.com/xrjr/otp/server"
+)
+
+var now = time.Unix(1111111109, 0)
+
+var clock = otp.ClockFunc(func() time.Time {
+	return now
+})
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "otp.log")
+	s, err := Open(path)
+	if err != nil {
+		t.Fatalf("Error in Open (err = %v)", err)
+	}
+	s.Clock = clock
+
+	v := &server.Validator{Replay: s, Counters: s, Window: 2, Clock: clock}
+	totpKey := otp.Key{Type: otp.TypeTOTP, Secret: []byte("12345678901234567890"), Digits: 8}
+	hotpKey := otp.Key{Type: otp.TypeHOTP, Secret: []byte("12345678901234567890")}
+
+	if _, err := v.Verify("alice smith", totpKey, "07081804"); err != nil {
+		t.Errorf("Error in Store (err = %v)", err)
+	}
+	// code of counter 2 of rfc 4226
+	if _, err := v.Verify("alice smith", hotpKey, "359152"); err != nil {
+		t.Errorf("Error in Store (err = %v)", err)
+	}
+	s.Close()
+
+	// the state is read back, and an interrupted record is ignored
+	f, _ := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	f.WriteString(`C "alice smith" 12`)
+	f.Close()
+
+	s, err = Open(path)
+	if err != nil {
+		t.Fatalf("Error in Open (err = %v)", err)
+	}
+	defer s.Close()
+	s.Clock = clock
+	v.Replay, v.Counters = s, s
+
+	if _, err := v.Verify("alice smith", totpKey, "07081804"); err != server.ErrReplayedCode {
+		t.Errorf("Error in Store (expected = %v, got = %v)", server.ErrReplayedCode, err)
+	}
+	if counter, _ := s.Get(context.Background(), "alice smith"); counter != 3 {
+		t.Errorf("Error in Store (expected counter = 3, got = %d)", counter)
+	}
+
+	if ok, err := s.CompareAndSwap(context.Background(), "bob", 0, 1); !ok || err != nil {
+		t.Errorf("Error in Store (swap refused, err = %v)", err)
+	}
+}
+
+func TestStoreCompact(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "otp.log")
+	s, err := Open(path)
+	if err != nil {
+		t.Fatalf("Error in Open (err = %v)", err)
+	}
+	defer s.Close()
+	s.Clock = clock
+	var buf strings.Builder
+	s.Logger = slog.New(slog.NewTextHandler(&buf, nil))
+
+	for i := uint64(0); i < 10; i++ {
+		s.CompareAndSwap(context.Background(), "alice", i, i+1)
+		s.Use(context.Background(), "alice", i, now.Add(time.Duration(i)*time.Minute))
+	}
+	if err := s.Compact(); err != nil {
+		t.Fatalf("Error in Compact (err = %v)", err)
+	}
+
+	// one counter and the 9 time steps which haven't expired
+	data, _ := os.ReadFile(path)
+	if lines := len(strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")); lines != 10 {
+		t.Errorf("Error in Compact (expected = 10 records, got = %d)", lines)
+	}
+	if log := buf.String(); !strings.Contains(log, "time_steps=9 counters=1 expired=1") {
+		t.Errorf("Error in Compact (unexpected log %s)", log)
+	}
+
+	// the store is still writable
+	if ok, err := s.CompareAndSwap(context.Background(), "alice", 10, 11); !ok || err != nil {
+		t.Errorf("Error in Compact (swap refused, err = %v)", err)
+	}
+}
+
+func TestStoreCompactFailed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "otp.log")
+	s, err := Open(path)
+	if err != nil {
+		t.Fatalf("Error in Open (err = %v)", err)
+	}
+	defer s.Close()
+	s.Clock = clock
+
+	s.Use(context.Background(), "alice", 1, now)
+	s.Use(context.Background(), "alice", 2, now.Add(time.Minute))
+
+	// the file can't be replaced by a non empty directory
+	os.Remove(path)
+	os.MkdirAll(filepath.Join(path, "dir"), 0o700)
+	if err := s.Compact(); err == nil {
+		t.Fatalf("Error in CompactFailed (expected error)")
+	}
+
+	// the expired time step is kept until a compaction succeeds
+	if len(s.replay) != 2 {
+		t.Errorf("Error in CompactFailed (expected = 2 time steps, got = %d)", len(s.replay))
+	}
+	if ok, err := s.Use(context.Background(), "alice", 3, now.Add(time.Minute)); !ok || err != nil {
+		t.Errorf("Error in CompactFailed (store not writable, err = %v)", err)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(path)); len(entries) != 1 {
+		t.Errorf("Error in CompactFailed (temporary file kept, got = %v)", entries)
+	}
+}
+
+// shortFile is a file whose writes stop halfway once failing is set, and whose truncations fail with truncateErr.
+type shortFile struct {
+	*os.File
+	failing     bool
+	truncateErr error
+}
+
+func (f *shortFile) WriteString(s string) (int, error) {
+	if f.failing {
+		n, _ := f.File.WriteString(s[:len(s)/2])
+		return n, errors.New("short write")
+	}
+	return f.File.WriteString(s)
+}
+
+func (f *shortFile) Truncate(size int64) error {
+	if f.truncateErr != nil {
+		return f.truncateErr
+	}
+	return f.File.Truncate(size)
+}
+
+func TestStoreShortWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "otp.log")
+	s, err := Open(path)
+	if err != nil {
+		t.Fatalf("Error in Open (err = %v)", err)
+	}
+	s.Clock = clock
+	f := &shortFile{File: s.file.(*os.File)}
+	s.file = f
+
+	s.CompareAndSwap(context.Background(), "alice", 0, 1)
+	f.failing = true
+	if _, err := s.CompareAndSwap(context.Background(), "alice", 1, 2); err == nil {
+		t.Errorf("Error in StoreShortWrite (expected error)")
+	}
+	f.failing = false
+	if ok, err := s.CompareAndSwap(context.Background(), "alice", 1, 3); !ok || err != nil {
+		t.Errorf("Error in StoreShortWrite (swap refused, err = %v)", err)
+	}
+
+	// a partial record which can't be removed stops the store
+	f.failing, f.truncateErr = true, errors.New("read-only file system")
+	s.Use(context.Background(), "alice", 1, now.Add(time.Minute))
+	f.failing = false
+	if _, err := s.Use(context.Background(), "alice", 2, now.Add(time.Minute)); !errors.Is(err, f.truncateErr) {
+		t.Errorf("Error in StoreShortWrite (expected = %v, got = %v)", f.truncateErr, err)
+	}
+	s.Close()
+
+	// the partial record removed is left out, the other one being dropped as interrupted
+	s, err = Open(path)
+	if err != nil {
+		t.Fatalf("Error in StoreShortWrite (err = %v)", err)
+	}
+	defer s.Close()
+	if counter, _ := s.Get(context.Background(), "alice"); counter != 3 {
+		t.Errorf("Error in StoreShortWrite (expected = 3, got = %d)", counter)
+	}
+}
@@ -0,0 +1,112 @@
+package redisotp
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	mathrand "math/rand/v2"
+	"time"
+
+	"github.com/xrjr/otp/server"
+)
+
+// unlockScript deletes the lock KEYS[1] if it is still held with the token ARGV[1], so that a lock which expired
+// and was acquired by another replica isn't released.
+const unlockScript = `
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`
+
+// Locker implements server.Locker with the Redlock algorithm: the lock of a key is acquired on a majority of
+// independent Redis instances, so that it survives the failure of a minority of them. A single instance, e.g. the
+// one of a Store, may be given when its failure is tolerated.
+type Locker struct {
+	clients []Client
+	prefix  string
+
+	TTL     time.Duration // time after which a lock which isn't released expires, defaults to 5 seconds
+	Timeout time.Duration // time waited for a lock before ErrNotLocked is returned, defaults to 1 second
+	Retry   time.Duration // mean delay between the attempts to acquire a lock, defaults to 50 milliseconds
+}
+
+var _ server.Locker = (*Locker)(nil)
+
+// NewLocker returns a Locker keeping its locks under a given prefix, e.g. "otp:", on the given instances.
+func NewLocker(prefix string, clients ...Client) *Locker {
+	return &Locker{clients: clients, prefix: prefix}
+}
+
+// Lock implements server.Locker. The lock is acquired when it is set on a majority of the instances within its
+// validity: its TTL minus the time taken and an allowance for the drift of their clocks. Waiting for the lock stops
+// once ctx is done.
+func (l *Locker) Lock(ctx context.Context, id string) (func() error, error) {
+	ttl := cmpOr(l.TTL, 5*time.Second)
+	timeout := cmpOr(l.Timeout, time.Second)
+	retry := cmpOr(l.Retry, 50*time.Millisecond)
+
+	var b [16]byte
+	rand.Read(b[:])
+	key, token := l.prefix+"lock:"+id, hex.EncodeToString(b[:])
+	// the lock is released even when ctx is done, e.g. by the deadline of the verification
+	unlockCtx := context.WithoutCancel(ctx)
+	unlock := func() error {
+		var errs []error
+		for _, c := range l.clients {
+			if _, err := c.Eval(unlockCtx, unlockScript, []string{key}, token); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		return errors.Join(errs...)
+	}
+
+	deadline := time.Now().Add(timeout)
+	var errs []error
+	for {
+		start := time.Now()
+		acquired := 0
+		errs = errs[:0]
+		for _, c := range l.clients {
+			ok, err := c.SetNX(ctx, key, token, ttl)
+			if err != nil {
+				errs = append(errs, err)
+			} else if ok {
+				acquired++
+			}
+		}
+
+		drift := ttl/100 + 2*time.Millisecond
+		if acquired > len(l.clients)/2 && time.Since(start)+drift < ttl {
+			return unlock, nil
+		}
+		// the instances which were locked are released, so that another replica can acquire the lock
+		unlock()
+
+		// random delays desynchronize the replicas contending for the lock
+		delay := retry/2 + mathrand.N(retry)
+		if time.Now().Add(delay).After(deadline) {
+			if len(errs) != 0 {
+				return nil, fmt.Errorf("%w: %s: %w", server.ErrNotLocked, id, errors.Join(errs...))
+			}
+			return nil, fmt.Errorf("%w: %s", server.ErrNotLocked, id)
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// cmpOr returns d, or def if d isn't positive.
+func cmpOr(d, def time.Duration) time.Duration {
+	if d <= 0 {
+		return def
+	}
+	return d
+}
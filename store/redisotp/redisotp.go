@@ -0,0 +1,148 @@
+// Package redisotp implements the replay, counter and bucket stores of the server package on Redis, so that several
+// instances of a service share the time steps used, the counters of HOTP keys and the limitation of attempts. Its
+// Locker locks the HOTP keys verified, on one or several Redis instances.
+//
+// The package doesn't depend on a Redis client: a Client is a thin adapter over one, e.g. with
+// github.com/redis/go-redis/v9:
+//
+//	type client struct {
+//		rdb *redis.Client
+//	}
+//
+//	func (c client) SetNX(ctx context.Context, key, value string, ttl time.Duration) (bool, error) {
+//		return c.rdb.SetNX(ctx, key, value, ttl).Result()
+//	}
+//
+//	func (c client) Get(ctx context.Context, key string) (string, bool, error) {
+//		value, err := c.rdb.Get(ctx, key).Result()
+//		if err == redis.Nil {
+//			return "", false, nil
+//		}
+//		return value, err == nil, err
+//	}
+//
+//	func (c client) Eval(ctx context.Context, script string, keys []string, args ...any) (any, error) {
+//		return c.rdb.Eval(ctx, script, keys, args...).Result()
+//	}
+package redisotp
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/xrjr/otp"
+	"github.com/xrjr/otp/server"
+)
+
+// Client is the part of a Redis client used by the stores. Its commands are given the context of the verification.
+type Client interface {
+	// SetNX sets a key which doesn't exist, with a time to live, and reports whether it did (SET key value NX PX ttl).
+	SetNX(ctx context.Context, key, value string, ttl time.Duration) (bool, error)
+	// Get returns the value of a key, and whether it exists (GET key).
+	Get(ctx context.Context, key string) (string, bool, error)
+	// Eval runs a Lua script (EVAL script numkeys keys... args...).
+	Eval(ctx context.Context, script string, keys []string, args ...any) (any, error)
+}
+
+// compareAndSwapScript sets the counter of KEYS[1] to ARGV[2] if it is ARGV[1], a missing counter being 0.
+const compareAndSwapScript = `
+local current = redis.call('GET', KEYS[1]) or '0'
+if current == ARGV[1] then
+	redis.call('SET', KEYS[1], ARGV[2])
+	return 1
+end
+return 0
+`
+
+// takeTokenScript takes a token from the bucket of KEYS[1], stored as the time in milliseconds at which it is full
+// again, at time ARGV[1], the bucket gaining a token every ARGV[2] milliseconds up to ARGV[3] tokens. It returns 0
+// if it did, or the milliseconds left before a token is available.
+const takeTokenScript = `
+local now = tonumber(ARGV[1])
+local interval = tonumber(ARGV[2])
+local burst = tonumber(ARGV[3])
+local full = math.max(tonumber(redis.call('GET', KEYS[1]) or '0'), now) + interval
+if full - now > burst * interval then
+	return full - now - burst * interval
+end
+redis.call('SET', KEYS[1], full, 'PX', full - now)
+return 0
+`
+
+// Store implements server.ReplayStore, server.CounterStore and server.BucketStore on Redis.
+type Store struct {
+	client Client
+	prefix string
+
+	Clock otp.Clock // clock giving the current time, used to compute the time to live of used time steps
+}
+
+var (
+	_ server.ReplayStore  = (*Store)(nil)
+	_ server.CounterStore = (*Store)(nil)
+	_ server.BucketStore  = (*Store)(nil)
+)
+
+// New returns a store keeping its keys under a given prefix, e.g. "otp:".
+func New(client Client, prefix string) *Store {
+	return &Store{client: client, prefix: prefix}
+}
+
+// Use implements server.ReplayStore, with a key expiring with the time step.
+func (s *Store) Use(ctx context.Context, id string, step uint64, expiry time.Time) (bool, error) {
+	now := time.Now()
+	if s.Clock != nil {
+		now = s.Clock.Now()
+	}
+
+	// Redis refuses non positive times to live, and expires keys with a millisecond precision
+	ttl := max(expiry.Sub(now), time.Millisecond)
+	return s.client.SetNX(ctx, s.prefix+"replay:"+id+":"+strconv.FormatUint(step, 10), "1", ttl)
+}
+
+// Get implements server.CounterStore.
+func (s *Store) Get(ctx context.Context, id string) (uint64, error) {
+	value, ok, err := s.client.Get(ctx, s.prefix+"counter:"+id)
+	if err != nil || !ok {
+		return 0, err
+	}
+
+	counter, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("redisotp: invalid counter of %s: %w", id, err)
+	}
+	return counter, nil
+}
+
+// CompareAndSwap implements server.CounterStore, with a Lua script so that it is atomic.
+func (s *Store) CompareAndSwap(ctx context.Context, id string, old, new uint64) (bool, error) {
+	res, err := s.client.Eval(ctx, compareAndSwapScript, []string{s.prefix + "counter:" + id},
+		strconv.FormatUint(old, 10), strconv.FormatUint(new, 10))
+	if err != nil {
+		return false, err
+	}
+
+	swapped, ok := res.(int64)
+	if !ok {
+		return false, fmt.Errorf("redisotp: unexpected result %v", res)
+	}
+	return swapped == 1, nil
+}
+
+// Take implements server.BucketStore, with a Lua script so that it is atomic. The bucket expires once full, and
+// times are rounded to the millisecond.
+func (s *Store) Take(ctx context.Context, key string, now time.Time, interval time.Duration, burst int) (time.Duration, error) {
+	res, err := s.client.Eval(ctx, takeTokenScript, []string{s.prefix + "bucket:" + key},
+		strconv.FormatInt(now.UnixMilli(), 10), strconv.FormatInt(max(interval.Milliseconds(), 1), 10), strconv.Itoa(burst))
+	if err != nil {
+		return 0, err
+	}
+
+	retryAfter, ok := res.(int64)
+	if !ok {
+		return 0, fmt.Errorf("redisotp: unexpected result %v", res)
+	}
+	return time.Duration(retryAfter) * time.Millisecond, nil
+}
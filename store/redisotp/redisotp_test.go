@@ -0,0 +1,204 @@
+package redisotp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/xrjr/otp"
+	"github.com/xrjr/otp/server"
+)
+
+// fakeClient emulates the commands of a Redis server used by the stores.
+type fakeClient struct {
+	mu     sync.Mutex
+	values map[string]string
+	ttls   map[string]time.Duration
+}
+
+func newFakeClient() *fakeClient {
+	return &fakeClient{values: map[string]string{}, ttls: map[string]time.Duration{}}
+}
+
+func (c *fakeClient) SetNX(ctx context.Context, key, value string, ttl time.Duration) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.values[key]; ok {
+		return false, nil
+	}
+	c.values[key] = value
+	c.ttls[key] = ttl
+	return true, nil
+}
+
+func (c *fakeClient) Get(ctx context.Context, key string) (string, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	value, ok := c.values[key]
+	return value, ok, nil
+}
+
+func (c *fakeClient) Eval(ctx context.Context, script string, keys []string, args ...any) (any, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	current, ok := c.values[keys[0]]
+	if !ok {
+		current = "0"
+	}
+
+	switch script {
+	case compareAndSwapScript:
+		if current != args[0] {
+			return int64(0), nil
+		}
+		c.values[keys[0]] = args[1].(string)
+		return int64(1), nil
+	case takeTokenScript:
+		var now, interval, burst, full int64
+		fmt.Sscan(args[0].(string), &now)
+		fmt.Sscan(args[1].(string), &interval)
+		fmt.Sscan(args[2].(string), &burst)
+		fmt.Sscan(current, &full)
+		full = max(full, now) + interval
+		if full-now > burst*interval {
+			return full - now - burst*interval, nil
+		}
+		c.values[keys[0]] = strconv.FormatInt(full, 10)
+		c.ttls[keys[0]] = time.Duration(full-now) * time.Millisecond
+		return int64(0), nil
+	case unlockScript:
+		if value, ok := c.values[keys[0]]; !ok || value != args[0] {
+			return int64(0), nil
+		}
+		delete(c.values, keys[0])
+		return int64(1), nil
+	}
+	return nil, fmt.Errorf("unknown script")
+}
+
+var now = time.Unix(1111111109, 0)
+
+var clock = otp.ClockFunc(func() time.Time {
+	return now
+})
+
+func TestStoreUse(t *testing.T) {
+	client := newFakeClient()
+	s := New(client, "otp:")
+	s.Clock = clock
+
+	if ok, err := s.Use(context.Background(), "alice", 37037036, now.Add(time.Minute)); !ok || err != nil {
+		t.Errorf("Error in StoreUse (first use refused, err = %v)", err)
+	}
+	if ok, _ := s.Use(context.Background(), "alice", 37037036, now.Add(time.Minute)); ok {
+		t.Errorf("Error in StoreUse (second use accepted)")
+	}
+
+	if ttl := client.ttls["otp:replay:alice:37037036"]; ttl != time.Minute {
+		t.Errorf("Error in StoreUse (expected ttl = %s, got = %s)", time.Minute, ttl)
+	}
+
+	if _, err := s.Use(context.Background(), "alice", 1, now.Add(-time.Minute)); err != nil || client.ttls["otp:replay:alice:1"] <= 0 {
+		t.Errorf("Error in StoreUse (expected positive ttl, got = %s, err = %v)", client.ttls["otp:replay:alice:1"], err)
+	}
+}
+
+func TestStoreCounter(t *testing.T) {
+	s := New(newFakeClient(), "otp:")
+	v := &server.Validator{Counters: s, Window: 2}
+	k := otp.Key{Type: otp.TypeHOTP, Secret: []byte("12345678901234567890")}
+
+	// codes of counters 0 and 2 of rfc 4226
+	if _, err := v.Verify("alice", k, "755224"); err != nil {
+		t.Errorf("Error in StoreCounter (err = %v)", err)
+	}
+	if _, err := v.Verify("alice", k, "359152"); err != nil {
+		t.Errorf("Error in StoreCounter (err = %v)", err)
+	}
+
+	if counter, err := s.Get(context.Background(), "alice"); counter != 3 || err != nil {
+		t.Errorf("Error in StoreCounter (expected = 3, got = %d, err = %v)", counter, err)
+	}
+	if ok, _ := s.CompareAndSwap(context.Background(), "alice", 1, 5); ok {
+		t.Errorf("Error in StoreCounter (swap of a different counter accepted)")
+	}
+}
+
+func TestStoreTake(t *testing.T) {
+	client := newFakeClient()
+	b := &server.TokenBucket{Store: New(client, "otp:"), Interval: time.Second, Burst: 2, Clock: clock}
+
+	for i := 0; i < 2; i++ {
+		if ok, err := b.Allow(context.Background(), "alice"); !ok || err != nil {
+			t.Errorf("Error in StoreTake (i = %d, attempt refused, err = %v)", i, err)
+		}
+	}
+
+	_, err := b.Allow(context.Background(), "alice")
+	var rateLimited *server.RateLimitedError
+	if !errors.As(err, &rateLimited) || rateLimited.RetryAfter != time.Second {
+		t.Errorf("Error in StoreTake (expected = retry after 1s, got = %v)", err)
+	}
+
+	if ttl := client.ttls["otp:bucket:alice"]; ttl != 2*time.Second {
+		t.Errorf("Error in StoreTake (expected ttl = %s, got = %s)", 2*time.Second, ttl)
+	}
+}
+
+// failingClient is a Redis instance which is down.
+type failingClient struct{}
+
+func (failingClient) SetNX(ctx context.Context, key, value string, ttl time.Duration) (bool, error) {
+	return false, errors.New("connection refused")
+}
+
+func (failingClient) Get(ctx context.Context, key string) (string, bool, error) {
+	return "", false, errors.New("connection refused")
+}
+
+func (failingClient) Eval(ctx context.Context, script string, keys []string, args ...any) (any, error) {
+	return nil, errors.New("connection refused")
+}
+
+func TestLocker(t *testing.T) {
+	clients := []Client{newFakeClient(), newFakeClient(), failingClient{}}
+	l := NewLocker("otp:", clients...)
+	l.Timeout = 20 * time.Millisecond
+	l.Retry = 5 * time.Millisecond
+
+	unlock, err := l.Lock(context.Background(), "alice")
+	if err != nil {
+		t.Fatalf("Error in Locker (err = %v)", err)
+	}
+	if _, err := l.Lock(context.Background(), "alice"); !errors.Is(err, server.ErrNotLocked) {
+		t.Errorf("Error in Locker (expected = %v, got = %v)", server.ErrNotLocked, err)
+	}
+	if _, err := l.Lock(context.Background(), "bob"); err != nil {
+		t.Errorf("Error in Locker (other key not locked, err = %v)", err)
+	}
+
+	// the lock is released on the instances which are up
+	if err := unlock(); err == nil {
+		t.Errorf("Error in Locker (error of the failing instance not returned)")
+	}
+	if _, ok := clients[0].(*fakeClient).values["otp:lock:alice"]; ok {
+		t.Errorf("Error in Locker (lock not released)")
+	}
+	if _, err := l.Lock(context.Background(), "alice"); err != nil {
+		t.Errorf("Error in Locker (released lock not acquired, err = %v)", err)
+	}
+
+	// a lock is never acquired without a majority of instances
+	minority := NewLocker("otp:", newFakeClient(), failingClient{}, failingClient{})
+	minority.Timeout = time.Millisecond
+	if _, err := minority.Lock(context.Background(), "alice"); !errors.Is(err, server.ErrNotLocked) {
+		t.Errorf("Error in Locker (expected = %v, got = %v)", server.ErrNotLocked, err)
+	}
+}
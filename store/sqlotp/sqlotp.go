@@ -0,0 +1,222 @@
+// Package sqlotp implements the replay, counter and rate limiting stores of the server package over
+// database/sql, for PostgreSQL, MySQL and SQLite.
+//
+// The tables are created by Store.CreateTables. Used time steps aren't removed once expired: Store.DeleteExpired
+// should be called periodically.
+package sqlotp
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xrjr/otp"
+	"github.com/xrjr/otp/server"
+)
+
+// Dialect selects the SQL syntax of a database.
+type Dialect int
+
+// Supported dialects.
+const (
+	Postgres Dialect = iota
+	MySQL
+	SQLite
+)
+
+// Store implements server.ReplayStore, server.CounterStore and server.RateLimiter over a database.
+// Counters are stored as signed 64 bits integers, so counters above 2^63 are stored as negative numbers.
+type Store struct {
+	db      *sql.DB
+	dialect Dialect
+
+	Clock  otp.Clock     // clock giving the current time, defaults to the system clock
+	Limit  int           // number of attempts allowed per key and Window by Allow, unlimited when 0
+	Window time.Duration // window of the attempts limited by Allow, defaults to DefaultWindow
+	Logger *slog.Logger  // logger of the removals of expired time steps, silent when nil
+}
+
+// DefaultWindow is the window of the attempts limited by Allow of stores which don't set Window.
+const DefaultWindow = time.Minute
+
+var (
+	_ server.ReplayStore  = (*Store)(nil)
+	_ server.CounterStore = (*Store)(nil)
+	_ server.RateLimiter  = (*Store)(nil)
+)
+
+// New returns a store over a database of a given dialect.
+func New(db *sql.DB, dialect Dialect) *Store {
+	return &Store{db: db, dialect: dialect}
+}
+
+// CreateTables creates the tables of the store, unless they exist.
+func (s *Store) CreateTables(ctx context.Context) error {
+	for _, query := range []string{
+		`CREATE TABLE IF NOT EXISTS otp_replay (id VARCHAR(255) NOT NULL, step BIGINT NOT NULL, expires_at BIGINT NOT NULL, PRIMARY KEY (id, step))`,
+		`CREATE TABLE IF NOT EXISTS otp_counters (id VARCHAR(255) NOT NULL PRIMARY KEY, counter BIGINT NOT NULL)`,
+		`CREATE TABLE IF NOT EXISTS otp_attempts (id VARCHAR(255) NOT NULL PRIMARY KEY, window_start BIGINT NOT NULL, attempts INTEGER NOT NULL)`,
+	} {
+		if _, err := s.db.ExecContext(ctx, query); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Use implements server.ReplayStore.
+func (s *Store) Use(ctx context.Context, id string, step uint64, expiry time.Time) (bool, error) {
+	res, err := s.db.ExecContext(ctx, s.insertIgnore("otp_replay (id, step, expires_at) VALUES (?, ?, ?)"), id, int64(step), expiry.Unix())
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+// DeleteExpired removes the used time steps which have expired.
+func (s *Store) DeleteExpired(ctx context.Context) error {
+	res, err := s.db.ExecContext(ctx, s.rebind("DELETE FROM otp_replay WHERE expires_at <= ?"), s.now().Unix())
+	if err != nil {
+		return err
+	}
+
+	if s.Logger != nil {
+		// some drivers don't report the rows affected, which are then left out
+		if n, err := res.RowsAffected(); err == nil {
+			s.Logger.Info("otp expired time steps deleted", slog.Int64("deleted", n))
+		} else {
+			s.Logger.Info("otp expired time steps deleted")
+		}
+	}
+	return nil
+}
+
+// Get implements server.CounterStore.
+func (s *Store) Get(ctx context.Context, id string) (uint64, error) {
+	var counter int64
+	err := s.db.QueryRowContext(ctx, s.rebind("SELECT counter FROM otp_counters WHERE id = ?"), id).Scan(&counter)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	return uint64(counter), err
+}
+
+// CompareAndSwap implements server.CounterStore. The counter is updated by a conditional update, or inserted when
+// old is 0 and the key has no counter yet.
+func (s *Store) CompareAndSwap(ctx context.Context, id string, old, new uint64) (bool, error) {
+	res, err := s.db.ExecContext(ctx, s.rebind("UPDATE otp_counters SET counter = ? WHERE id = ? AND counter = ?"), int64(new), id, int64(old))
+	if err != nil {
+		return false, err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 1 {
+		return n == 1, err
+	}
+
+	if old != 0 {
+		return false, nil
+	}
+
+	// the primary key makes the insert fail if a concurrent swap inserted the counter first
+	res, err = s.db.ExecContext(ctx, s.insertIgnore("otp_counters (id, counter) VALUES (?, ?)"), id, int64(new))
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+// Allow implements server.RateLimiter, allowing Limit attempts per key in each fixed window of the Window
+// duration. The attempt is counted and read by a single statement with PostgreSQL and SQLite (3.35 or later), and in
+// a transaction with MySQL, which has no RETURNING clause.
+func (s *Store) Allow(ctx context.Context, id string) (bool, error) {
+	if s.Limit == 0 {
+		return true, nil
+	}
+
+	window := s.Window
+	if window <= 0 {
+		window = DefaultWindow
+	}
+	windowStart := s.now().Truncate(window).Unix()
+
+	var attempts int
+	var err error
+	switch s.dialect {
+	case MySQL:
+		attempts, err = s.allowTx(ctx, id, windowStart)
+	default:
+		query := `INSERT INTO otp_attempts (id, window_start, attempts) VALUES (?, ?, 1) ON CONFLICT (id) DO UPDATE SET ` +
+			`attempts = CASE WHEN otp_attempts.window_start = excluded.window_start THEN otp_attempts.attempts + 1 ELSE 1 END, ` +
+			`window_start = excluded.window_start RETURNING attempts`
+		err = s.db.QueryRowContext(ctx, s.rebind(query), id, windowStart).Scan(&attempts)
+	}
+	if err != nil {
+		return false, err
+	}
+	return attempts <= s.Limit, nil
+}
+
+// allowTx counts an attempt of the key id in the window starting at windowStart, and returns the attempts of the
+// window, in a transaction so that the attempts of concurrent calls aren't read instead.
+func (s *Store) allowTx(ctx context.Context, id string, windowStart int64) (int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	// assignments are evaluated from left to right, so attempts is computed with the previous window
+	query := `INSERT INTO otp_attempts (id, window_start, attempts) VALUES (?, ?, 1) ON DUPLICATE KEY UPDATE ` +
+		`attempts = IF(window_start = VALUES(window_start), attempts + 1, 1), window_start = VALUES(window_start)`
+	if _, err := tx.ExecContext(ctx, query, id, windowStart); err != nil {
+		return 0, err
+	}
+
+	// the row is locked by the insert until the commit
+	var attempts int
+	if err := tx.QueryRowContext(ctx, "SELECT attempts FROM otp_attempts WHERE id = ?", id).Scan(&attempts); err != nil {
+		return 0, err
+	}
+	return attempts, tx.Commit()
+}
+
+// insertIgnore returns an insert statement of the dialect which does nothing when the row already exists.
+func (s *Store) insertIgnore(into string) string {
+	if s.dialect == MySQL {
+		return "INSERT IGNORE INTO " + into
+	}
+	return s.rebind("INSERT INTO " + into + " ON CONFLICT DO NOTHING")
+}
+
+// rebind replaces the ? placeholders of a query by the placeholders of the dialect.
+func (s *Store) rebind(query string) string {
+	if s.dialect != Postgres {
+		return query
+	}
+
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// now returns the current time of the clock of the store.
+func (s *Store) now() time.Time {
+	if s.Clock == nil {
+		return time.Now()
+	}
+	return s.Clock.Now()
+}
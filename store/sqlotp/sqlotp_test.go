@@ -0,0 +1,246 @@
+package sqlotp
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/xrjr/otp"
+	"github.com/xrjr/otp/server"
+)
+
+// fakeDB emulates the statements of the store on in-memory tables, as a database/sql driver.
+type fakeDB struct {
+	mu       sync.Mutex
+	replay   map[string]int64
+	counters map[string]int64
+	attempts map[string][2]int64
+}
+
+func (db *fakeDB) Open(string) (driver.Conn, error) { return db, nil }
+func (db *fakeDB) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{db: db, query: query}, nil
+}
+func (db *fakeDB) Close() error              { return nil }
+func (db *fakeDB) Begin() (driver.Tx, error) { return fakeTx{}, nil }
+
+// fakeTx is a transaction of a fake database, whose statements are applied immediately.
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeStmt struct {
+	db    *fakeDB
+	query string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	db := s.db
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	switch q := s.query; {
+	case strings.HasPrefix(q, "CREATE TABLE"):
+		return driver.RowsAffected(0), nil
+	case strings.HasPrefix(q, "INSERT INTO otp_replay"):
+		k := fmt.Sprintf("%s/%d", args[0], args[1])
+		if _, ok := db.replay[k]; ok {
+			return driver.RowsAffected(0), nil
+		}
+		db.replay[k] = args[2].(int64)
+		return driver.RowsAffected(1), nil
+	case strings.HasPrefix(q, "DELETE FROM otp_replay"):
+		n := 0
+		for k, expiry := range db.replay {
+			if expiry <= args[0].(int64) {
+				delete(db.replay, k)
+				n++
+			}
+		}
+		return driver.RowsAffected(n), nil
+	case strings.HasPrefix(q, "UPDATE otp_counters"):
+		id := args[1].(string)
+		if counter, ok := db.counters[id]; !ok || counter != args[2].(int64) {
+			return driver.RowsAffected(0), nil
+		}
+		db.counters[id] = args[0].(int64)
+		return driver.RowsAffected(1), nil
+	case strings.HasPrefix(q, "INSERT INTO otp_counters"):
+		id := args[0].(string)
+		if _, ok := db.counters[id]; ok {
+			return driver.RowsAffected(0), nil
+		}
+		db.counters[id] = args[1].(int64)
+		return driver.RowsAffected(1), nil
+	case strings.HasPrefix(q, "INSERT INTO otp_attempts"):
+		db.attempt(args[0].(string), args[1].(int64))
+		return driver.RowsAffected(1), nil
+	}
+	return nil, fmt.Errorf("unexpected statement %s", s.query)
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	db := s.db
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	switch {
+	case strings.HasPrefix(s.query, "SELECT counter FROM otp_counters"):
+		if counter, ok := db.counters[args[0].(string)]; ok {
+			return &fakeRows{values: []driver.Value{counter}}, nil
+		}
+		return &fakeRows{}, nil
+	case strings.HasPrefix(s.query, "INSERT INTO otp_attempts") && strings.HasSuffix(s.query, "RETURNING attempts"):
+		return &fakeRows{values: []driver.Value{db.attempt(args[0].(string), args[1].(int64))}}, nil
+	case strings.HasPrefix(s.query, "SELECT attempts FROM otp_attempts"):
+		return &fakeRows{values: []driver.Value{db.attempts[args[0].(string)][1]}}, nil
+	}
+	return nil, fmt.Errorf("unexpected query %s", s.query)
+}
+
+// attempt counts an attempt of the key id in the window starting at windowStart, and returns the attempts of the
+// window, with db.mu held.
+func (db *fakeDB) attempt(id string, windowStart int64) int64 {
+	a, ok := db.attempts[id]
+	if !ok || a[0] != windowStart {
+		a = [2]int64{windowStart, 0}
+	}
+	a[1]++
+	db.attempts[id] = a
+	return a[1]
+}
+
+// fakeRows holds at most one row of one column.
+type fakeRows struct {
+	values []driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return []string{"value"} }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	dest[0], r.values = r.values[0], r.values[1:]
+	return nil
+}
+
+// openFake returns a store of a given dialect over a new fake database.
+func openFake(t *testing.T, dialect Dialect) (*Store, *fakeDB) {
+	fake := &fakeDB{replay: map[string]int64{}, counters: map[string]int64{}, attempts: map[string][2]int64{}}
+	db := sql.OpenDB(connector{fake})
+	t.Cleanup(func() { db.Close() })
+
+	s := New(db, dialect)
+	if err := s.CreateTables(context.Background()); err != nil {
+		t.Fatalf("Error in CreateTables (err = %v)", err)
+	}
+	return s, fake
+}
+
+// connector opens connections to a fake database.
+type connector struct {
+	db *fakeDB
+}
+
+func (c connector) Connect(context.Context) (driver.Conn, error) { return c.db, nil }
+func (c connector) Driver() driver.Driver                        { return c.db }
+
+var now = time.Unix(1111111109, 0)
+
+var clock = otp.ClockFunc(func() time.Time {
+	return now
+})
+
+func TestStoreReplay(t *testing.T) {
+	s, fake := openFake(t, SQLite)
+	s.Clock = clock
+	v := &server.Validator{Replay: s, Window: 1, Clock: clock}
+	k := otp.Key{Type: otp.TypeTOTP, Secret: []byte("12345678901234567890"), Digits: 8}
+
+	if _, err := v.Verify("alice", k, "07081804"); err != nil {
+		t.Errorf("Error in StoreReplay (err = %v)", err)
+	}
+	if _, err := v.Verify("alice", k, "07081804"); err != server.ErrReplayedCode {
+		t.Errorf("Error in StoreReplay (expected = %v, got = %v)", server.ErrReplayedCode, err)
+	}
+
+	now = now.Add(time.Hour)
+	defer func() { now = now.Add(-time.Hour) }()
+	if err := s.DeleteExpired(context.Background()); err != nil || len(fake.replay) != 0 {
+		t.Errorf("Error in StoreReplay (expected = 0 time steps, got = %d, err = %v)", len(fake.replay), err)
+	}
+}
+
+func TestStoreCounter(t *testing.T) {
+	s, _ := openFake(t, SQLite)
+	v := &server.Validator{Counters: s, Window: 2}
+	k := otp.Key{Type: otp.TypeHOTP, Secret: []byte("12345678901234567890")}
+
+	// codes of counters 0 and 2 of rfc 4226
+	for _, code := range []string{"755224", "359152"} {
+		if _, err := v.Verify("alice", k, code); err != nil {
+			t.Errorf("Error in StoreCounter (code = %s, err = %v)", code, err)
+		}
+	}
+
+	if counter, err := s.Get(context.Background(), "alice"); counter != 3 || err != nil {
+		t.Errorf("Error in StoreCounter (expected = 3, got = %d, err = %v)", counter, err)
+	}
+	if ok, _ := s.CompareAndSwap(context.Background(), "alice", 0, 5); ok {
+		t.Errorf("Error in StoreCounter (swap of a different counter accepted)")
+	}
+}
+
+func TestStoreAllow(t *testing.T) {
+	for _, dialect := range []Dialect{Postgres, MySQL, SQLite} {
+		s, _ := openFake(t, dialect)
+		s.Clock = clock
+		s.Limit = 2
+
+		for i, expected := range []bool{true, true, false} {
+			if ok, err := s.Allow(context.Background(), "alice"); ok != expected || err != nil {
+				t.Errorf("Error in StoreAllow (dialect = %d, i = %d, expected = %t, got = %t, err = %v)", dialect, i, expected, ok, err)
+			}
+		}
+
+		// the window defaults to DefaultWindow, and attempts are counted again in the next one
+		now = now.Add(time.Second)
+		if ok, _ := s.Allow(context.Background(), "alice"); ok {
+			t.Errorf("Error in StoreAllow (dialect = %d, attempt of the same window allowed)", dialect)
+		}
+		now = now.Add(DefaultWindow)
+		if ok, _ := s.Allow(context.Background(), "alice"); !ok {
+			t.Errorf("Error in StoreAllow (dialect = %d, attempt of the next window refused)", dialect)
+		}
+		now = now.Add(-DefaultWindow - time.Second)
+	}
+}
+
+func TestDialects(t *testing.T) {
+	testValues := []struct {
+		dialect Dialect
+		query   string
+	}{
+		{Postgres, "INSERT INTO otp_counters (id, counter) VALUES ($1, $2) ON CONFLICT DO NOTHING"},
+		{SQLite, "INSERT INTO otp_counters (id, counter) VALUES (?, ?) ON CONFLICT DO NOTHING"},
+		{MySQL, "INSERT IGNORE INTO otp_counters (id, counter) VALUES (?, ?)"},
+	}
+
+	for i, testValue := range testValues {
+		s := New(nil, testValue.dialect)
+		if res := s.insertIgnore("otp_counters (id, counter) VALUES (?, ?)"); res != testValue.query {
+			t.Errorf("Error in Dialects (i = %d, expected = %s, got = %s)", i, testValue.query, res)
+		}
+	}
+}
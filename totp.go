@@ -1,32 +1,145 @@
 package otp
 
 import (
+	"errors"
+	"fmt"
 	"time"
 )
 
 type TOTPOptions struct {
 	HOTPOptions
-	TimeReference int64 // time reference in seconds (called T0 in rfc)
-	Period        int   // time period in seconds (called X in rfc)
-	Step          int   // number of step before or after given time
+	TimeReference  int64         // time reference in seconds (called T0 in rfc)
+	Period         int           // time period in seconds (called X in rfc)
+	PeriodDuration time.Duration // time period, overrides Period when set (must be a whole number of seconds)
+	Step           int           // number of step before or after given time
+	Clock          Clock         // clock giving the current time, defaults to the system clock
 }
 
 // TOTP computes the OTP code of a given time.
+// The code is returned as an integer, so leading zeros are lost: use TOTPString to get exactly opts.Digits characters.
 func TOTP(key []byte, t time.Time, opts TOTPOptions) uint {
-	// defaults
-	// opts.TimeReference and opts.Step both default to 0
+	opts = opts.withDefaults()
+
+	// Compute
+	return HOTP(key, uint64(timePeriodCounter(t.Unix(), opts.TimeReference, opts.Period)+int64(opts.Step)), opts.HOTPOptions)
+}
+
+// TOTPString computes the OTP code of a given time, encoded with opts.Encoder.
+// With the default DecimalEncoder, the code is zero padded to opts.Digits characters.
+func TOTPString(key []byte, t time.Time, opts TOTPOptions) string {
+	opts = opts.withDefaults()
+
+	// Compute
+	return HOTPString(key, uint64(timePeriodCounter(t.Unix(), opts.TimeReference, opts.Period)+int64(opts.Step)), opts.HOTPOptions)
+}
+
+// TimeRemaining returns how long the code of a given time stays valid.
+func TimeRemaining(t time.Time, opts TOTPOptions) time.Duration {
+	opts = opts.withDefaults()
+
+	return periodStart(timePeriodCounter(t.Unix(), opts.TimeReference, opts.Period)+1, opts).Sub(t)
+}
+
+// TimePeriodCount returns the time step of a given time (called T in rfc), shifted by opts.Step, which is the
+// counter of its HOTP code as reported by Code.Counter. It is negative before opts.TimeReference.
+// Servers can record it to know which time step a code matched.
+func TimePeriodCount(t time.Time, opts TOTPOptions) int64 {
+	opts = opts.withDefaults()
+
+	return timePeriodCounter(t.Unix(), opts.TimeReference, opts.Period) + int64(opts.Step)
+}
+
+// TOTPE computes the OTP code of a given time, as TOTPCode does, but returns an error instead of panicking
+// when the options are invalid. An empty secret is also reported as an error.
+func TOTPE(key []byte, t time.Time, opts TOTPOptions) (Code, error) {
+	if len(key) == 0 {
+		return Code{}, ErrInvalidSecret
+	}
+
+	opts, err := opts.checkedDefaults()
+	if err != nil {
+		return Code{}, err
+	}
+
+	return TOTPCode(key, t, opts), nil
+}
+
+// MustTOTP is like TOTPE but panics if the secret or the options are invalid.
+// It simplifies the use of fixed configurations, such as in tests.
+func MustTOTP(key []byte, t time.Time, opts TOTPOptions) Code {
+	code, err := TOTPE(key, t, opts)
+	if err != nil {
+		panic(err)
+	}
+	return code
+}
+
+// withDefaults returns the options with defaults applied to unset fields.
+// It panics if the options are invalid.
+func (opts TOTPOptions) withDefaults() TOTPOptions {
+	opts, err := opts.checkedDefaults()
+	if err != nil {
+		panic(err)
+	}
+	return opts
+}
+
+// checkedDefaults returns the options with defaults applied to unset fields, or an error if they are invalid.
+// opts.TimeReference and opts.Step both default to 0.
+// opts.PeriodDuration must be a positive whole number of seconds, as the time period can't be represented
+// otherwise by the rfc.
+func (opts TOTPOptions) checkedDefaults() (TOTPOptions, error) {
+	if err := opts.Validate(); err != nil {
+		return opts, err
+	}
+
+	// the options are already validated
+	opts.HOTPOptions, _ = opts.HOTPOptions.checkedDefaults()
+
+	if opts.PeriodDuration != 0 {
+		opts.Period = int(opts.PeriodDuration / time.Second)
+	}
+
 	if opts.Period == 0 {
 		opts.Period = 30
 	}
 
-	// Compute
-	return HOTP(key, timePeriodCounter(t.Unix(), opts.TimeReference, opts.Period)+opts.Step, opts.HOTPOptions)
+	if opts.Clock == nil {
+		opts.Clock = systemClock{}
+	}
+
+	return opts, nil
+}
+
+// Validate checks the options, and reports all the problems found. Unset fields are valid, as they are defaulted.
+func (opts TOTPOptions) Validate() error {
+	var errs []error
+	if err := opts.HOTPOptions.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+
+	if opts.PeriodDuration != 0 && (opts.PeriodDuration < time.Second || opts.PeriodDuration%time.Second != 0) {
+		errs = append(errs, fmt.Errorf("%w: %s is not a positive whole number of seconds", ErrInvalidPeriod, opts.PeriodDuration))
+	}
+
+	if opts.Period < 0 {
+		errs = append(errs, fmt.Errorf("%w: %d is negative", ErrInvalidPeriod, opts.Period))
+	}
+
+	return errors.Join(errs...)
 }
 
 // timePeriodCounter returns T as defined in section 4.2 of the rfc.
-func timePeriodCounter(currentTime int64, t0 int64, x int) int {
+// T is negative before T0, and is then used by HOTP as its two's complement.
+func timePeriodCounter(currentTime int64, t0 int64, x int) int64 {
+	// integer division truncates toward zero, but T is the floor of the division
 	if currentTime < t0 {
-		return int((currentTime-t0)/int64(x)) - 1
+		return (currentTime-t0+1)/int64(x) - 1
 	}
-	return int((currentTime - t0) / int64(x))
+	return (currentTime - t0) / int64(x)
+}
+
+// periodStart returns the start of the time period of a given time step.
+func periodStart(counter int64, opts TOTPOptions) time.Time {
+	return time.Unix(opts.TimeReference+counter*int64(opts.Period), 0)
 }
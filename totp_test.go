@@ -1,9 +1,12 @@
 package otp
 
 import (
+	"crypto"
 	"crypto/sha1"
 	"crypto/sha256"
 	"crypto/sha512"
+	"errors"
+	"fmt"
 	"hash"
 	"testing"
 	"time"
@@ -16,7 +19,7 @@ type TOTPTestValue struct {
 	TimeReference int64
 	Period        int
 	Secret        []byte
-	T             int
+	T             int64
 	OTP           uint
 }
 
@@ -238,7 +241,7 @@ func TestTimePeriodCounterStep(t *testing.T) {
 	for i, testValue := range totpTestValues {
 		for _, step := range steps {
 			res := timePeriodCounter(testValue.Time.Add(time.Second*time.Duration(testValue.Period)*time.Duration(step)).Unix(), testValue.TimeReference, testValue.Period)
-			expected := testValue.T + step
+			expected := testValue.T + int64(step)
 			if res != expected {
 				t.Errorf("Error in TimePeriodCounterStep (i = %d, step = %d, expected = %d, got = %d)", i, step, expected, res)
 			}
@@ -290,3 +293,157 @@ func TestTOTPDefaults(t *testing.T) {
 		t.Errorf("Error in TOTPDefaults (expected = %d, got = %d)", resCustom, resDefaults)
 	}
 }
+
+func TestTOTPString(t *testing.T) {
+	for i, testValue := range totpTestValues {
+		res := TOTPString(testValue.Secret, testValue.Time, TOTPOptions{
+			HOTPOptions: HOTPOptions{
+				Digits:    testValue.Digits,
+				Algorithm: testValue.Mode,
+			},
+			TimeReference: testValue.TimeReference,
+			Period:        testValue.Period,
+		})
+		expected := fmt.Sprintf("%08d", testValue.OTP)
+		if res != expected {
+			t.Errorf("Error in TOTPString (i = %d, expected = %s, got = %s)", i, expected, res)
+		}
+	}
+}
+
+func TestTimePeriodCounterBeforeTimeReference(t *testing.T) {
+	expected := map[int64]int64{-1: -1, -29: -1, -30: -1, -31: -2, -60: -2, -61: -3}
+	for currentTime, T := range expected {
+		res := timePeriodCounter(currentTime, 0, 30)
+		if res != T {
+			t.Errorf("Error in TimePeriodCounterBeforeTimeReference (time = %d, expected = %d, got = %d)", currentTime, T, res)
+		}
+	}
+}
+
+func TestTimeRemaining(t *testing.T) {
+	expected := map[int64]time.Duration{0: 30 * time.Second, 1: 29 * time.Second, 29: time.Second, 30: 30 * time.Second, 59: time.Second, -1: time.Second}
+	for currentTime, remaining := range expected {
+		res := TimeRemaining(time.Unix(currentTime, 0), TOTPOptions{})
+		if res != remaining {
+			t.Errorf("Error in TimeRemaining (time = %d, expected = %s, got = %s)", currentTime, remaining, res)
+		}
+	}
+
+	res := TimeRemaining(time.Unix(100, int64(250*time.Millisecond)), TOTPOptions{Period: 60, TimeReference: 10})
+	if res != 29*time.Second+750*time.Millisecond {
+		t.Errorf("Error in TimeRemaining (expected = %s, got = %s)", 29*time.Second+750*time.Millisecond, res)
+	}
+}
+
+func TestTimePeriodCount(t *testing.T) {
+	tests := []struct {
+		time     int64
+		opts     TOTPOptions
+		expected int64
+	}{
+		{59, TOTPOptions{}, 1},
+		{1111111109, TOTPOptions{}, 37037036},
+		{-1, TOTPOptions{}, -1},
+		{100, TOTPOptions{Period: 60, TimeReference: 10}, 1},
+		{59, TOTPOptions{Step: -1}, 0},
+	}
+
+	for i, test := range tests {
+		if res := TimePeriodCount(time.Unix(test.time, 0), test.opts); res != test.expected {
+			t.Errorf("Error in TimePeriodCount (i = %d, expected = %d, got = %d)", i, test.expected, res)
+		}
+		if test.expected >= 0 {
+			if code := TOTPCode(totpSecretSha1, time.Unix(test.time, 0), test.opts); code.Counter != uint64(test.expected) {
+				t.Errorf("Error in TimePeriodCount (i = %d, expected = %d, got = %d)", i, code.Counter, test.expected)
+			}
+		}
+	}
+}
+
+func TestTOTPPeriodDuration(t *testing.T) {
+	testValue := totpTestValues[0]
+
+	resPeriod := TOTP(testValue.Secret, testValue.Time, TOTPOptions{Period: 60})
+	resDuration := TOTP(testValue.Secret, testValue.Time, TOTPOptions{Period: 30, PeriodDuration: time.Minute})
+	if resPeriod != resDuration {
+		t.Errorf("Error in TOTPPeriodDuration (expected = %d, got = %d)", resPeriod, resDuration)
+	}
+}
+
+func TestTOTPPeriodDurationSubSecond(t *testing.T) {
+	for _, period := range []time.Duration{time.Millisecond, 1500 * time.Millisecond, -time.Second} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Error in TOTPPeriodDurationSubSecond (period = %s accepted)", period)
+				}
+			}()
+			TOTP(totpSecretSha1, time.Unix(59, 0), TOTPOptions{PeriodDuration: period})
+		}()
+	}
+}
+
+func TestTOTPE(t *testing.T) {
+	testValue := totpTestValues[0]
+
+	res, err := TOTPE(testValue.Secret, testValue.Time, TOTPOptions{})
+	expected := TOTPCode(testValue.Secret, testValue.Time, TOTPOptions{})
+	if err != nil || res != expected {
+		t.Errorf("Error in TOTPE (expected = %+v, got = %+v, err = %v)", expected, res, err)
+	}
+
+	invalid := map[error]TOTPOptions{
+		ErrInvalidDigits: {HOTPOptions: HOTPOptions{Digits: 11}},
+		ErrInvalidPeriod: {Period: -30},
+	}
+	for expectedErr, opts := range invalid {
+		if _, err := TOTPE(testValue.Secret, testValue.Time, opts); !errors.Is(err, expectedErr) {
+			t.Errorf("Error in TOTPE (expected = %v, got = %v)", expectedErr, err)
+		}
+	}
+
+	if _, err := TOTPE(testValue.Secret, testValue.Time, TOTPOptions{PeriodDuration: 1500 * time.Millisecond}); !errors.Is(err, ErrInvalidPeriod) {
+		t.Errorf("Error in TOTPE (expected = %v, got = %v)", ErrInvalidPeriod, err)
+	}
+
+	if _, err := TOTPE([]byte{}, testValue.Time, TOTPOptions{}); !errors.Is(err, ErrInvalidSecret) {
+		t.Errorf("Error in TOTPE (expected = %v, got = %v)", ErrInvalidSecret, err)
+	}
+}
+
+func TestMustTOTP(t *testing.T) {
+	testValue := totpTestValues[0]
+
+	res := MustTOTP(testValue.Secret, testValue.Time, TOTPOptions{HOTPOptions: HOTPOptions{Digits: 8}})
+	if res.Value != "94287082" {
+		t.Errorf("Error in MustTOTP (expected = 94287082, got = %s)", res.Value)
+	}
+
+	defer func() {
+		if err, _ := recover().(error); !errors.Is(err, ErrInvalidPeriod) {
+			t.Errorf("Error in MustTOTP (expected = %v, got = %v)", ErrInvalidPeriod, err)
+		}
+	}()
+	MustTOTP(testValue.Secret, testValue.Time, TOTPOptions{Period: -1})
+}
+
+func TestTOTPOptionsValidate(t *testing.T) {
+	if err := (TOTPOptions{}).Validate(); err != nil {
+		t.Errorf("Error in TOTPOptionsValidate (defaults rejected, err = %v)", err)
+	}
+
+	err := TOTPOptions{HOTPOptions: HOTPOptions{Digits: 12}, Period: -1, PeriodDuration: time.Millisecond}.Validate()
+	if !errors.Is(err, ErrInvalidDigits) || !errors.Is(err, ErrInvalidPeriod) || len(err.(interface{ Unwrap() []error }).Unwrap()) != 3 {
+		t.Errorf("Error in TOTPOptionsValidate (expected = 3 errors, got = %v)", err)
+	}
+}
+
+func TestTOTPEHash(t *testing.T) {
+	testValue := totpTestValues[1]
+
+	res, err := TOTPE(testValue.Secret, testValue.Time, TOTPOptions{HOTPOptions: HOTPOptions{Digits: 8, Hash: crypto.SHA256}})
+	if err != nil || res.Value != "46119246" {
+		t.Errorf("Error in TOTPEHash (expected = 46119246, got = %s, err = %v)", res.Value, err)
+	}
+}
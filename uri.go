@@ -0,0 +1,314 @@
+//go:build !tinygo && !otpcore
+
+package otp
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// URI returns the otpauth URI of the key, as read by authenticator applications
+// (https://github.com/google/google-authenticator/wiki/Key-Uri-Format). The copies of the encoded secret made
+// while the URI is built are wiped, the returned URI being the only one left.
+func (k Key) URI() string {
+	label := k.AccountName
+	if k.Issuer != "" {
+		label = k.Issuer + ":" + k.AccountName
+	}
+
+	query := url.Values{}
+	if k.Encoding != "" && k.Encoding != EncodingBase32 {
+		query.Set("encoding", k.Encoding)
+	}
+	if k.Issuer != "" {
+		query.Set("issuer", k.Issuer)
+	}
+	if k.Algorithm != "" {
+		query.Set("algorithm", k.Algorithm)
+	}
+	if k.Digits != 0 {
+		query.Set("digits", strconv.FormatUint(uint64(k.Digits), 10))
+	}
+	if k.Period != 0 {
+		query.Set("period", strconv.Itoa(k.Period))
+	}
+	if k.Type == TypeHOTP {
+		query.Set("counter", strconv.FormatUint(k.Counter, 10))
+	}
+	for name, value := range k.Params {
+		if !query.Has(name) && name != "secret" {
+			query.Set(name, value)
+		}
+	}
+
+	// the parameters are sorted by name, as by url.Values.Encode, the secret being written between them in a
+	// buffer instead of a string, so that it can be wiped
+	before, after := url.Values{}, url.Values{}
+	for name, values := range query {
+		if name < "secret" {
+			before[name] = values
+		} else {
+			after[name] = values
+		}
+	}
+
+	// spaces are percent-encoded as %20 in the query, as some applications don't decode +, and + is
+	// percent-encoded in the label, as some applications decode it as a space
+	u := url.URL{
+		Scheme:  "otpauth",
+		Host:    k.Type,
+		Path:    "/" + label,
+		RawPath: "/" + strings.ReplaceAll(url.PathEscape(label), "+", "%2B"),
+	}
+	prefix, suffix := u.String()+"?", ""
+	if len(before) > 0 {
+		prefix += strings.ReplaceAll(before.Encode(), "+", "%20") + "&"
+	}
+	if len(after) > 0 {
+		suffix = "&" + strings.ReplaceAll(after.Encode(), "+", "%20")
+	}
+
+	// the buffer is allocated once, so that no copy of the secret is left behind by a reallocation
+	secret := appendSecret(nil, k.Secret, k.Encoding)
+	b := make([]byte, 0, len(prefix)+len("secret=")+3*len(secret)+len(suffix))
+	b = append(b, prefix+"secret="...)
+	b = appendQueryEscaped(b, secret)
+	b = append(b, suffix...)
+
+	uri := string(b)
+	WipeBytes(secret)
+	WipeBytes(b)
+	return uri
+}
+
+// appendQueryEscaped appends an encoded secret to b, percent-encoding the characters of base64 which are reserved
+// in queries.
+func appendQueryEscaped(b []byte, s []byte) []byte {
+	const upperhex = "0123456789ABCDEF"
+	for _, c := range s {
+		switch c {
+		case '+', '/', '=':
+			b = append(b, '%', upperhex[c>>4], upperhex[c&15])
+		default:
+			b = append(b, c)
+		}
+	}
+	return b
+}
+
+// StrictURI returns the otpauth URI of the key, as URI does, but guarantees that ParseURI reproduces the key
+// exactly. An error is returned when the key is invalid or can't be represented by a URI without losing
+// information, e.g. when its issuer contains a colon or a counter is set on a totp key.
+func (k Key) StrictURI() (string, error) {
+	if err := k.validate(); err != nil {
+		return "", err
+	}
+
+	var errs []error
+
+	if strings.Contains(k.Issuer, ":") {
+		errs = append(errs, fmt.Errorf("%w: issuer %q contains a colon", ErrInvalidURI, k.Issuer))
+	}
+	if k.Issuer == "" && strings.Contains(k.AccountName, ":") {
+		errs = append(errs, fmt.Errorf("%w: account name %q contains a colon, but issuer is empty", ErrInvalidURI, k.AccountName))
+	}
+	if k.Issuer != "" && strings.HasPrefix(k.AccountName, " ") {
+		errs = append(errs, fmt.Errorf("%w: account name %q starts with a space", ErrInvalidURI, k.AccountName))
+	}
+	if k.Type != TypeHOTP && k.Counter != 0 {
+		errs = append(errs, fmt.Errorf("%w: counter is set on a %s key", ErrInvalidCounter, k.Type))
+	}
+	if k.Algorithm != strings.ToUpper(k.Algorithm) {
+		errs = append(errs, fmt.Errorf("%w: %s is not in upper case", ErrInvalidAlgorithm, k.Algorithm))
+	}
+	if k.Encoding == EncodingBase32 {
+		errs = append(errs, fmt.Errorf("%w: %s is the default encoding, and is left empty by ParseURI", ErrInvalidSecretEncoding, EncodingBase32))
+	}
+	for name := range k.Params {
+		if slices.Contains(knownParameters, name) {
+			errs = append(errs, fmt.Errorf("%w: parameter %s is part of the Key URI format", ErrInvalidURI, name))
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return "", err
+	}
+	return k.URI(), nil
+}
+
+// IssuerPolicy selects the issuer kept by ParseURIWithOptions when the issuer prefix of the label and the issuer
+// parameter differ.
+type IssuerPolicy int
+
+const (
+	IssuerPreferLabel   IssuerPolicy = iota // keep the issuer prefix of the label
+	IssuerPreferParam                       // keep the issuer parameter
+	IssuerMismatchError                     // reject the URI
+)
+
+// ParseOptions selects the tolerance of ParseURIWithOptions.
+type ParseOptions struct {
+	// Strict rejects URIs which don't follow the Key URI format: a label without account name, unknown or repeated
+	// parameters, an issuer parameter different from the issuer prefix of the label, a hotp key without counter, a
+	// counter on a totp key or a period on a hotp key. The lenient mode accepts URIs without label, whose keys have
+	// an empty account name.
+	Strict bool
+	// IssuerPolicy selects the issuer kept when the label prefix and the parameter differ, as authenticator
+	// applications don't agree on it. It defaults to IssuerPreferLabel, and is ignored by strict parsing.
+	IssuerPolicy IssuerPolicy
+}
+
+// knownParameters are the parameters of the Key URI format, along with the encoding of the secret.
+var knownParameters = []string{"secret", "encoding", "issuer", "algorithm", "digits", "period", "counter"}
+
+// ParseURI parses an otpauth URI, as returned by Key.URI. It is lenient: parameters it doesn't know are kept in
+// Key.Params, and the issuer prefix of the label is preferred over the issuer parameter.
+func ParseURI(uri string) (Key, error) {
+	return ParseURIWithOptions(uri, ParseOptions{})
+}
+
+// ParseURIStrict parses an otpauth URI, rejecting URIs which don't follow the Key URI format.
+func ParseURIStrict(uri string) (Key, error) {
+	return ParseURIWithOptions(uri, ParseOptions{Strict: true})
+}
+
+// ParseURIWithOptions parses an otpauth URI, with the given tolerance. It never panics, whatever the URI, as URIs
+// may come from untrusted QR codes or imports.
+func ParseURIWithOptions(uri string, opts ParseOptions) (Key, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return Key{}, fmt.Errorf("%w: %w", ErrInvalidURI, err)
+	}
+	if u.Scheme != "otpauth" {
+		return Key{}, fmt.Errorf("%w: scheme %q is not otpauth", ErrInvalidURI, u.Scheme)
+	}
+
+	query := u.Query()
+	k := Key{
+		Type:      strings.ToLower(u.Host),
+		Algorithm: strings.ToUpper(query.Get("algorithm")),
+	}
+	k.Issuer, k.AccountName = parseLabel(u.Path)
+	if param := query.Get("issuer"); param != "" && param != k.Issuer {
+		switch {
+		case k.Issuer == "" || opts.IssuerPolicy == IssuerPreferParam:
+			k.Issuer = param
+		case opts.IssuerPolicy == IssuerMismatchError && !opts.Strict:
+			return Key{}, fmt.Errorf("%w: issuer parameter %q differs from label prefix %q", ErrInvalidURI, param, k.Issuer)
+		}
+	}
+
+	k.Encoding = strings.ToLower(query.Get("encoding"))
+	k.Secret, err = decodeSecret(query.Get("secret"), k.Encoding)
+	if err != nil {
+		return Key{}, err
+	}
+
+	if s := query.Get("digits"); s != "" {
+		digits, err := strconv.ParseUint(s, 10, 0)
+		if err != nil {
+			return Key{}, fmt.Errorf("%w: %w", ErrInvalidDigits, err)
+		}
+		k.Digits = uint(digits)
+	}
+
+	if s := query.Get("period"); s != "" {
+		k.Period, err = strconv.Atoi(s)
+		if err != nil {
+			return Key{}, fmt.Errorf("%w: %w", ErrInvalidPeriod, err)
+		}
+	}
+
+	if s := query.Get("counter"); s != "" {
+		k.Counter, err = strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			return Key{}, fmt.Errorf("%w: %w", ErrInvalidCounter, err)
+		}
+	}
+
+	for name := range query {
+		if !slices.Contains(knownParameters, name) {
+			if k.Params == nil {
+				k.Params = map[string]string{}
+			}
+			k.Params[name] = query.Get(name)
+		}
+	}
+
+	if err := k.validate(); err != nil {
+		return Key{}, err
+	}
+
+	if opts.Strict {
+		if err := checkStrict(k, u.Path, query); err != nil {
+			return Key{}, err
+		}
+	}
+	return k, nil
+}
+
+// checkStrict checks the parameters of a parsed URI against the Key URI format, and reports all the problems found.
+func checkStrict(k Key, path string, query url.Values) error {
+	var errs []error
+
+	if _, accountName := parseLabel(path); accountName == "" {
+		errs = append(errs, fmt.Errorf("%w: %w", ErrInvalidURI, ErrMissingLabel))
+	}
+
+	for name, values := range query {
+		if !slices.Contains(knownParameters, name) {
+			errs = append(errs, fmt.Errorf("%w: unknown parameter %s", ErrInvalidURI, name))
+		} else if len(values) > 1 {
+			errs = append(errs, fmt.Errorf("%w: repeated parameter %s", ErrInvalidURI, name))
+		}
+	}
+
+	if issuer, _ := parseLabel(path); issuer != "" && query.Has("issuer") && query.Get("issuer") != issuer {
+		errs = append(errs, fmt.Errorf("%w: issuer parameter %q differs from label prefix %q", ErrInvalidURI, query.Get("issuer"), issuer))
+	}
+
+	if k.Type == TypeHOTP && !query.Has("counter") {
+		errs = append(errs, fmt.Errorf("%w: counter is missing on a %s key", ErrInvalidCounter, TypeHOTP))
+	}
+
+	if k.Type == TypeTOTP && query.Has("counter") {
+		errs = append(errs, fmt.Errorf("%w: counter is set on a %s key", ErrInvalidCounter, TypeTOTP))
+	}
+
+	if k.Type == TypeHOTP && query.Has("period") {
+		errs = append(errs, fmt.Errorf("%w: period is set on a %s key", ErrInvalidPeriod, TypeHOTP))
+	}
+
+	return errors.Join(errs...)
+}
+
+// parseLabel splits the label of an otpauth URI, already percent-decoded, into the issuer and the account name.
+// The issuer prefix is optional, and the account name may be preceded by spaces. URIs without path, such as
+// otpauth://totp?secret=..., have an empty label.
+func parseLabel(path string) (issuer, accountName string) {
+	label := strings.TrimPrefix(path, "/")
+	issuer, accountName, found := strings.Cut(label, ":")
+	if !found {
+		return "", label
+	}
+	return issuer, strings.TrimLeft(accountName, " ")
+}
+
+// MarshalText implements encoding.TextMarshaler, using the otpauth URI of the key.
+func (k Key) MarshalText() ([]byte, error) {
+	return []byte(k.URI()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, parsing an otpauth URI.
+func (k *Key) UnmarshalText(text []byte) error {
+	res, err := ParseURI(string(text))
+	if err != nil {
+		return err
+	}
+	*k = res
+	return nil
+}
@@ -0,0 +1,79 @@
+//go:build !tinygo && !otpcore
+
+package otp
+
+// URIOption sets a field of the key built by BuildURI.
+type URIOption func(*Key)
+
+// WithSecret sets the secret of the key.
+func WithSecret(secret []byte) URIOption {
+	return func(k *Key) {
+		k.Secret = secret
+	}
+}
+
+// WithIssuer sets the issuer of the key.
+func WithIssuer(issuer string) URIOption {
+	return func(k *Key) {
+		k.Issuer = issuer
+	}
+}
+
+// WithAccount sets the account name of the key.
+func WithAccount(accountName string) URIOption {
+	return func(k *Key) {
+		k.AccountName = accountName
+	}
+}
+
+// WithDigits sets the number of digits of the codes.
+func WithDigits(digits uint) URIOption {
+	return func(k *Key) {
+		k.Digits = digits
+	}
+}
+
+// WithPeriod sets the time period in seconds of a totp key.
+func WithPeriod(period int) URIOption {
+	return func(k *Key) {
+		k.Period = period
+	}
+}
+
+// WithCounter sets the initial counter of a hotp key.
+func WithCounter(counter uint64) URIOption {
+	return func(k *Key) {
+		k.Counter = counter
+	}
+}
+
+// WithAlgorithm sets the algorithm name of the key, as registered with RegisterAlgorithm (e.g. "SHA256").
+func WithAlgorithm(name string) URIOption {
+	return func(k *Key) {
+		k.Algorithm = name
+	}
+}
+
+// WithImage sets the image parameter, the URL of a logo displayed by some authenticator applications.
+func WithImage(url string) URIOption {
+	return func(k *Key) {
+		if k.Params == nil {
+			k.Params = map[string]string{}
+		}
+		k.Params["image"] = url
+	}
+}
+
+// BuildURI returns the otpauth URI of a key of a given type, TypeHOTP or TypeTOTP, built from options.
+// The key is checked with Key.Validate, and must be represented exactly by its URI, as with Key.StrictURI.
+func BuildURI(typ string, opts ...URIOption) (string, error) {
+	k := Key{Type: typ}
+	for _, opt := range opts {
+		opt(&k)
+	}
+
+	if err := k.Validate(); err != nil {
+		return "", err
+	}
+	return k.StrictURI()
+}
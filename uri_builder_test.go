@@ -0,0 +1,58 @@
+//go:build !tinygo && !otpcore
+
+package otp
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestBuildURI(t *testing.T) {
+	uri, err := BuildURI(TypeTOTP,
+		WithSecret(hotpSecret),
+		WithIssuer("Example"),
+		WithAccount("alice@example.com"),
+		WithAlgorithm("SHA256"),
+		WithDigits(8),
+		WithPeriod(60),
+		WithImage("https://example.com/logo.png"),
+	)
+	if err != nil {
+		t.Fatalf("Error in BuildURI (err = %v)", err)
+	}
+
+	expected := "otpauth://totp/Example:alice@example.com?algorithm=SHA256&digits=8&image=https%3A%2F%2Fexample.com%2Flogo.png&issuer=Example&period=60&secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	if uri != expected {
+		t.Errorf("Error in BuildURI (expected = %s, got = %s)", expected, uri)
+	}
+
+	uri, err = BuildURI(TypeHOTP, WithSecret(hotpSecret), WithAccount("alice"), WithCounter(5))
+	if expected := "otpauth://hotp/alice?counter=5&secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"; err != nil || uri != expected {
+		t.Errorf("Error in BuildURI (expected = %s, got = %s, err = %v)", expected, uri, err)
+	}
+}
+
+func TestBuildURIInvalid(t *testing.T) {
+	invalid := []struct {
+		typ  string
+		opts []URIOption
+		err  error
+	}{
+		{"motp", []URIOption{WithSecret(hotpSecret)}, ErrInvalidType},
+		{TypeTOTP, nil, ErrInvalidSecret},
+		{TypeTOTP, []URIOption{WithSecret([]byte("12345"))}, ErrInvalidSecret},
+		{TypeTOTP, []URIOption{WithSecret(hotpSecret), WithDigits(12)}, ErrInvalidDigits},
+		{TypeTOTP, []URIOption{WithSecret(hotpSecret), WithCounter(1)}, ErrInvalidCounter},
+		{TypeHOTP, []URIOption{WithSecret(hotpSecret), WithPeriod(30)}, ErrInvalidPeriod},
+		{TypeTOTP, []URIOption{WithSecret(hotpSecret), WithAlgorithm("MD5")}, ErrInvalidAlgorithm},
+		// registered, but golang.org/x/crypto/blake2b isn't imported
+		{TypeTOTP, []URIOption{WithSecret(hotpSecret), WithAlgorithm("BLAKE2B256")}, ErrInvalidAlgorithm},
+		{TypeTOTP, []URIOption{WithSecret(hotpSecret), WithIssuer("Example:Corp")}, ErrInvalidURI},
+	}
+
+	for i, testValue := range invalid {
+		if _, err := BuildURI(testValue.typ, testValue.opts...); !errors.Is(err, testValue.err) {
+			t.Errorf("Error in BuildURIInvalid (i = %d, expected = %v, got = %v)", i, testValue.err, err)
+		}
+	}
+}
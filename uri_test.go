@@ -0,0 +1,328 @@
+//go:build !tinygo && !otpcore
+
+package otp
+
+import (
+	"bytes"
+	"encoding/base32"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestKeyURI(t *testing.T) {
+	expected := "otpauth://totp/Example:alice@example.com?algorithm=SHA1&digits=6&issuer=Example&period=30&secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	if res := testKey.URI(); res != expected {
+		t.Errorf("Error in KeyURI (expected = %s, got = %s)", expected, res)
+	}
+
+	hotpKey := Key{Type: TypeHOTP, AccountName: "alice smith", Secret: hotpSecret, Counter: 4}
+	expected = "otpauth://hotp/alice%20smith?counter=4&secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	if res := hotpKey.URI(); res != expected {
+		t.Errorf("Error in KeyURI (expected = %s, got = %s)", expected, res)
+	}
+}
+
+func TestParseURI(t *testing.T) {
+	for _, k := range []Key{testKey, {Type: TypeHOTP, AccountName: "alice smith", Secret: hotpSecret, Counter: 4}} {
+		res, err := ParseURI(k.URI())
+		if err != nil {
+			t.Errorf("Error in ParseURI (uri = %s, err = %v)", k.URI(), err)
+		} else if !res.Equal(k) {
+			t.Errorf("Error in ParseURI (expected = %#v, got = %#v)", k, res)
+		}
+	}
+}
+
+func TestParseURIInvalid(t *testing.T) {
+	invalid := []struct {
+		uri string
+		err error
+	}{
+		{"https://totp/alice?secret=GEZDGNBV", ErrInvalidURI},
+		{"otpauth://motp/alice?secret=GEZDGNBV", ErrInvalidType},
+		{"otpauth://totp/alice?secret=12345678", ErrInvalidSecretEncoding},
+		{"otpauth://totp/alice", ErrInvalidSecret},
+		{"otpauth://totp/alice?secret=GEZDGNBV&digits=six", ErrInvalidDigits},
+		{"otpauth://totp/alice?secret=GEZDGNBV&digits=11", ErrInvalidDigits},
+		{"otpauth://totp/alice?secret=GEZDGNBV&period=-30", ErrInvalidPeriod},
+		{"otpauth://totp/alice?secret=GEZDGNBV&algorithm=MD5", ErrInvalidAlgorithm},
+		{"otpauth://hotp/alice?secret=GEZDGNBV&counter=-1", ErrInvalidCounter},
+	}
+
+	for i, testValue := range invalid {
+		if _, err := ParseURI(testValue.uri); !errors.Is(err, testValue.err) {
+			t.Errorf("Error in ParseURIInvalid (i = %d, expected = %v, got = %v)", i, testValue.err, err)
+		}
+	}
+}
+
+func TestKeyText(t *testing.T) {
+	text, err := testKey.MarshalText()
+	if err != nil {
+		t.Fatalf("Error in KeyText (err = %v)", err)
+	}
+
+	var res Key
+	if err := res.UnmarshalText(text); err != nil {
+		t.Fatalf("Error in KeyText (err = %v)", err)
+	}
+	if !res.Equal(testKey) {
+		t.Errorf("Error in KeyText (expected = %#v, got = %#v)", testKey, res)
+	}
+}
+
+func TestParseURILabel(t *testing.T) {
+	testValues := []struct {
+		uri         string
+		issuer      string
+		accountName string
+	}{
+		{"otpauth://totp/alice@example.com?secret=GEZDGNBV", "", "alice@example.com"},
+		{"otpauth://totp/alice@example.com?secret=GEZDGNBV&issuer=Example", "Example", "alice@example.com"},
+		{"otpauth://totp/Example:alice@example.com?secret=GEZDGNBV", "Example", "alice@example.com"},
+		{"otpauth://totp/Example%3Aalice@example.com?secret=GEZDGNBV", "Example", "alice@example.com"},
+		{"otpauth://totp/Big%20Corporation:%20%20alice%20smith?secret=GEZDGNBV", "Big Corporation", "alice smith"},
+		{"otpauth://totp/Example:alice@example.com?secret=GEZDGNBV&issuer=Other", "Example", "alice@example.com"},
+		{"otpauth://totp?secret=GEZDGNBV", "", ""},
+		{"otpauth://totp/?secret=GEZDGNBV&issuer=Example", "Example", ""},
+		{"otpauth://totp/Example:?secret=GEZDGNBV", "Example", ""},
+		{"otpauth://totp/Example/alice?secret=GEZDGNBV", "", "Example/alice"},
+	}
+
+	for i, testValue := range testValues {
+		k, err := ParseURI(testValue.uri)
+		if err != nil {
+			t.Errorf("Error in ParseURILabel (i = %d, err = %v)", i, err)
+		} else if k.Issuer != testValue.issuer || k.AccountName != testValue.accountName {
+			t.Errorf("Error in ParseURILabel (i = %d, expected = %q %q, got = %q %q)", i, testValue.issuer, testValue.accountName, k.Issuer, k.AccountName)
+		}
+	}
+}
+
+func TestParseURIStrict(t *testing.T) {
+	for _, k := range []Key{testKey, {Type: TypeHOTP, AccountName: "alice", Secret: hotpSecret}} {
+		if _, err := ParseURIStrict(k.URI()); err != nil {
+			t.Errorf("Error in ParseURIStrict (uri = %s, err = %v)", k.URI(), err)
+		}
+	}
+
+	invalid := []struct {
+		uri string
+		err error
+	}{
+		{"otpauth://totp/alice?secret=GEZDGNBV&image=https://example.com/logo.png", ErrInvalidURI},
+		{"otpauth://totp/alice?secret=GEZDGNBV&digits=6&digits=8", ErrInvalidURI},
+		{"otpauth://totp/Example:alice?secret=GEZDGNBV&issuer=Other", ErrInvalidURI},
+		{"otpauth://hotp/alice?secret=GEZDGNBV", ErrInvalidCounter},
+		{"otpauth://totp/alice?secret=GEZDGNBV&counter=0", ErrInvalidCounter},
+		{"otpauth://hotp/alice?secret=GEZDGNBV&counter=0&period=30", ErrInvalidPeriod},
+		{"otpauth://totp?secret=GEZDGNBV", ErrMissingLabel},
+		{"otpauth://totp/Example:?secret=GEZDGNBV", ErrMissingLabel},
+		{"otpauth://totp/?secret=GEZDGNBV", ErrInvalidURI},
+	}
+
+	for i, testValue := range invalid {
+		if _, err := ParseURIStrict(testValue.uri); !errors.Is(err, testValue.err) {
+			t.Errorf("Error in ParseURIStrict (i = %d, expected = %v, got = %v)", i, testValue.err, err)
+		}
+		// the lenient mode accepts them
+		if _, err := ParseURI(testValue.uri); err != nil {
+			t.Errorf("Error in ParseURIStrict (i = %d, lenient err = %v)", i, err)
+		}
+	}
+}
+
+func TestParseURIParams(t *testing.T) {
+	uri := "otpauth://totp/Example:alice@example.com?color=blue&image=https%3A%2F%2Fexample.com%2Flogo.png&issuer=Example&secret=GEZDGNBV"
+	k, err := ParseURI(uri)
+	if err != nil {
+		t.Fatalf("Error in ParseURIParams (err = %v)", err)
+	}
+
+	if len(k.Params) != 2 || k.Params["image"] != "https://example.com/logo.png" || k.Params["color"] != "blue" {
+		t.Errorf("Error in ParseURIParams (got = %v)", k.Params)
+	}
+
+	if res := k.URI(); res != uri {
+		t.Errorf("Error in ParseURIParams (expected = %s, got = %s)", uri, res)
+	}
+}
+
+func TestParseURITolerantSecret(t *testing.T) {
+	k, err := ParseURI("otpauth://totp/alice?secret=gezd%20gnbv%20gy3t%20qojq%20gezd%20gnbv%20gy3t%20qojq%3D%3D%3D%3D")
+	if err != nil {
+		t.Fatalf("Error in ParseURITolerantSecret (err = %v)", err)
+	}
+	if string(k.Secret) != string(hotpSecret) {
+		t.Errorf("Error in ParseURITolerantSecret (expected = %s, got = %s)", hotpSecret, k.Secret)
+	}
+}
+
+func TestKeyURIEncoding(t *testing.T) {
+	testValues := []struct {
+		encoding string
+		uri      string
+	}{
+		{EncodingHex, "otpauth://totp/alice?encoding=hex&secret=3132333435363738393031323334353637383930"},
+		{EncodingBase64, "otpauth://totp/alice?encoding=base64&secret=MTIzNDU2Nzg5MDEyMzQ1Njc4OTA%3D"},
+	}
+
+	for i, testValue := range testValues {
+		k := Key{Type: TypeTOTP, AccountName: "alice", Secret: hotpSecret, Encoding: testValue.encoding}
+		if res := k.URI(); res != testValue.uri {
+			t.Errorf("Error in KeyURIEncoding (i = %d, expected = %s, got = %s)", i, testValue.uri, res)
+		}
+
+		res, err := ParseURI(testValue.uri)
+		if err != nil {
+			t.Errorf("Error in KeyURIEncoding (i = %d, err = %v)", i, err)
+		} else if !res.Equal(k) {
+			t.Errorf("Error in KeyURIEncoding (i = %d, expected = %#v, got = %#v)", i, k, res)
+		}
+	}
+
+	if _, err := ParseURI("otpauth://totp/alice?encoding=base58&secret=GEZDGNBV"); !errors.Is(err, ErrInvalidSecretEncoding) {
+		t.Errorf("Error in KeyURIEncoding (expected = %v, got = %v)", ErrInvalidSecretEncoding, err)
+	}
+}
+
+func TestKeyURISecretParameter(t *testing.T) {
+	// the secret is written between the parameters sorted by name, percent-encoded, and can't be overridden by
+	// Params
+	k := Key{
+		Type:        TypeTOTP,
+		AccountName: "alice",
+		Secret:      []byte{0xfb, 0xff},
+		Encoding:    EncodingBase64,
+		Params:      map[string]string{"secret": "GEZDGNBV", "type": "a b", "color": "blue"},
+	}
+	expected := "otpauth://totp/alice?color=blue&encoding=base64&secret=%2B%2F8%3D&type=a%20b"
+	if res := k.URI(); res != expected {
+		t.Errorf("Error in KeyURISecretParameter (expected = %s, got = %s)", expected, res)
+	}
+
+	res, err := ParseURI(expected)
+	if err != nil || !bytes.Equal(res.Secret, k.Secret) {
+		t.Errorf("Error in KeyURISecretParameter (expected = %x, got = %x, err = %v)", k.Secret, res.Secret, err)
+	}
+}
+
+func TestParseURIWrappedErrors(t *testing.T) {
+	_, err := ParseURI("otpauth://totp/alice?secret=GEZDGNBV&digits=six")
+	var numErr *strconv.NumError
+	if !errors.Is(err, ErrInvalidDigits) || !errors.As(err, &numErr) {
+		t.Errorf("Error in ParseURIWrappedErrors (expected = %v wrapping a *strconv.NumError, got = %v)", ErrInvalidDigits, err)
+	}
+
+	_, err = ParseURI("otpauth://totp/alice?secret=GEZDGNB1")
+	var corruptErr base32.CorruptInputError
+	if !errors.Is(err, ErrInvalidSecretEncoding) || !errors.As(err, &corruptErr) {
+		t.Errorf("Error in ParseURIWrappedErrors (expected = %v wrapping a base32.CorruptInputError, got = %v)", ErrInvalidSecretEncoding, err)
+	}
+}
+
+func TestKeyStrictURI(t *testing.T) {
+	keys := []Key{
+		testKey,
+		{Type: TypeTOTP, Issuer: "Big Corporation", AccountName: "alice smith+1@example.com", Secret: hotpSecret},
+		{Type: TypeHOTP, AccountName: "50% off & more?", Secret: hotpSecret, Digits: 8, Counter: 12, Params: map[string]string{"image": "https://example.com/a b.png"}},
+		{Type: TypeTOTP, AccountName: "alice", Secret: hotpSecret, Encoding: EncodingHex, Period: 60, Algorithm: "SHA256"},
+	}
+
+	for i, k := range keys {
+		uri, err := k.StrictURI()
+		if err != nil {
+			t.Errorf("Error in KeyStrictURI (i = %d, err = %v)", i, err)
+			continue
+		}
+		if strings.Contains(uri, "+") {
+			t.Errorf("Error in KeyStrictURI (i = %d, uri = %s, spaces encoded as +)", i, uri)
+		}
+
+		res, err := ParseURI(uri)
+		if err != nil {
+			t.Errorf("Error in KeyStrictURI (i = %d, err = %v)", i, err)
+		} else if !res.Equal(k) {
+			t.Errorf("Error in KeyStrictURI (i = %d, expected = %#v, got = %#v)", i, k, res)
+		}
+	}
+
+	invalid := []struct {
+		key Key
+		err error
+	}{
+		{Key{Type: TypeTOTP, Issuer: "Example:Corp", AccountName: "alice", Secret: hotpSecret}, ErrInvalidURI},
+		{Key{Type: TypeTOTP, AccountName: "alice:smith", Secret: hotpSecret}, ErrInvalidURI},
+		{Key{Type: TypeTOTP, Issuer: "Example", AccountName: " alice", Secret: hotpSecret}, ErrInvalidURI},
+		{Key{Type: TypeTOTP, AccountName: "alice", Secret: hotpSecret, Counter: 1}, ErrInvalidCounter},
+		{Key{Type: TypeTOTP, AccountName: "alice", Secret: hotpSecret, Algorithm: "sha1"}, ErrInvalidAlgorithm},
+		{Key{Type: TypeTOTP, AccountName: "alice", Secret: hotpSecret, Encoding: EncodingBase32}, ErrInvalidSecretEncoding},
+		{Key{Type: TypeTOTP, AccountName: "alice", Secret: hotpSecret, Params: map[string]string{"digits": "8"}}, ErrInvalidURI},
+		{Key{Type: TypeTOTP, AccountName: "alice"}, ErrInvalidSecret},
+	}
+
+	for i, testValue := range invalid {
+		if _, err := testValue.key.StrictURI(); !errors.Is(err, testValue.err) {
+			t.Errorf("Error in KeyStrictURI (i = %d, expected = %v, got = %v)", i, testValue.err, err)
+		}
+	}
+}
+
+func TestParseURIIssuerPolicy(t *testing.T) {
+	uri := "otpauth://totp/Example:alice?secret=GEZDGNBV&issuer=Other"
+
+	testValues := []struct {
+		policy IssuerPolicy
+		issuer string
+	}{
+		{IssuerPreferLabel, "Example"},
+		{IssuerPreferParam, "Other"},
+	}
+
+	for i, testValue := range testValues {
+		k, err := ParseURIWithOptions(uri, ParseOptions{IssuerPolicy: testValue.policy})
+		if err != nil {
+			t.Errorf("Error in ParseURIIssuerPolicy (i = %d, err = %v)", i, err)
+		} else if k.Issuer != testValue.issuer {
+			t.Errorf("Error in ParseURIIssuerPolicy (i = %d, expected = %s, got = %s)", i, testValue.issuer, k.Issuer)
+		}
+	}
+
+	if _, err := ParseURIWithOptions(uri, ParseOptions{IssuerPolicy: IssuerMismatchError}); !errors.Is(err, ErrInvalidURI) {
+		t.Errorf("Error in ParseURIIssuerPolicy (expected = %v, got = %v)", ErrInvalidURI, err)
+	}
+
+	// matching or missing issuers aren't mismatches
+	for _, uri := range []string{
+		"otpauth://totp/Example:alice?secret=GEZDGNBV&issuer=Example",
+		"otpauth://totp/Example:alice?secret=GEZDGNBV",
+		"otpauth://totp/alice?secret=GEZDGNBV&issuer=Example",
+	} {
+		k, err := ParseURIWithOptions(uri, ParseOptions{IssuerPolicy: IssuerMismatchError})
+		if err != nil || k.Issuer != "Example" {
+			t.Errorf("Error in ParseURIIssuerPolicy (uri = %s, issuer = %s, err = %v)", uri, k.Issuer, err)
+		}
+	}
+}
+
+func FuzzParseURI(f *testing.F) {
+	f.Add(testKey.URI())
+	f.Add("otpauth://totp?secret=GEZDGNBV")
+	f.Add("otpauth://hotp/Example:?secret=GEZDGNBV&counter=1&digits=8")
+	f.Add("otpauth:totp/alice?secret=GEZDGNBV")
+	f.Add("otpauth://totp/%zz?secret=")
+
+	f.Fuzz(func(t *testing.T, uri string) {
+		k, err := ParseURI(uri)
+		if err != nil {
+			return
+		}
+		// the URI of a parsed key is parsed again
+		if _, err := ParseURI(k.URI()); err != nil {
+			t.Errorf("Error in ParseURI (uri = %q, key uri = %q, err = %v)", uri, k.URI(), err)
+		}
+		ParseURIStrict(uri)
+	})
+}
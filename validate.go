@@ -0,0 +1,90 @@
+package otp
+
+import (
+	"crypto/subtle"
+	"time"
+)
+
+// Match describes the code accepted by VerifyTOTP, VerifyTOTPBetween and VerifyHOTP, so that callers can track the
+// drift of a device, audit the code or persist the counter of a key without computing the codes again.
+type Match struct {
+	Counter uint64 // counter of HOTP codes, or time step of TOTP codes
+	Skew    int    // difference between the counter of the code and the expected one, or the time step and the current one
+}
+
+// ValidateTOTP checks a code against the codes of the current time, given by opts.Clock, and of the window time
+// periods before and after it.
+func ValidateTOTP(key []byte, code string, window int, opts TOTPOptions) bool {
+	_, ok := VerifyTOTP(key, code, window, opts)
+	return ok
+}
+
+// VerifyTOTP checks a code as ValidateTOTP does, and returns the time step of the code along with its skew from the
+// current one, from -window to window.
+func VerifyTOTP(key []byte, code string, window int, opts TOTPOptions) (Match, bool) {
+	opts = opts.withDefaults()
+	if len(key) == 0 {
+		return Match{}, false
+	}
+
+	now := timePeriodCounter(opts.Clock.Now().Unix(), opts.TimeReference, opts.Period) + int64(opts.Step)
+	return verifyCounters(newGenerator(key, opts.HOTPOptions), code, uint64(now-int64(window)), 2*window+1, -window)
+}
+
+// MaxTOTPBetweenPeriods is the maximum number of time periods overlapped by the intervals of ValidateTOTPBetween and
+// VerifyTOTPBetween, which refuse the codes of longer intervals.
+const MaxTOTPBetweenPeriods = 100
+
+// ValidateTOTPBetween checks a code against the codes of every time period overlapping [notBefore, notAfter],
+// instead of a window around the current time, e.g. to accept the codes generated during the last 5 minutes of a
+// process. The interval should be short, as each time period it overlaps is a code accepted: intervals
+// overlapping more than MaxTOTPBetweenPeriods time periods are refused.
+func ValidateTOTPBetween(key []byte, code string, notBefore, notAfter time.Time, opts TOTPOptions) bool {
+	_, ok := VerifyTOTPBetween(key, code, notBefore, notAfter, opts)
+	return ok
+}
+
+// VerifyTOTPBetween checks a code as ValidateTOTPBetween does, and returns the time step of the code along with its
+// skew from the time step of notBefore.
+func VerifyTOTPBetween(key []byte, code string, notBefore, notAfter time.Time, opts TOTPOptions) (Match, bool) {
+	opts = opts.withDefaults()
+	if len(key) == 0 || notAfter.Before(notBefore) {
+		return Match{}, false
+	}
+
+	first := timePeriodCounter(notBefore.Unix(), opts.TimeReference, opts.Period) + int64(opts.Step)
+	last := timePeriodCounter(notAfter.Unix(), opts.TimeReference, opts.Period) + int64(opts.Step)
+	// the difference is negative if it overflows
+	if span := last - first; last < first || span < 0 || span >= MaxTOTPBetweenPeriods {
+		return Match{}, false
+	}
+	return verifyCounters(newGenerator(key, opts.HOTPOptions), code, uint64(first), int(last-first)+1, 0)
+}
+
+// VerifyHOTP checks a code against the codes of the expected counter and of the window counters following it, and
+// returns the counter of the code along with its skew from the expected one, from 0 to window. The counter
+// following the one returned should be persisted as the next expected counter, so that the code isn't accepted
+// again.
+func VerifyHOTP(key []byte, code string, counter uint64, window int, opts HOTPOptions) (Match, bool) {
+	opts = opts.withDefaults()
+	if len(key) == 0 || window < 0 {
+		return Match{}, false
+	}
+
+	return verifyCounters(newGenerator(key, opts), code, counter, window+1, 0)
+}
+
+// verifyCounters checks a code against the codes of count counters starting from first, the first counter having
+// the skew skew.
+func verifyCounters(g *Generator, code string, first uint64, count int, skew int) (Match, bool) {
+	var match Match
+	valid := false
+	for i := range count {
+		counter := first + uint64(i)
+		// every code is compared, so that the time taken doesn't depend on which one matched
+		if subtle.ConstantTimeCompare([]byte(g.HOTPString(counter)), []byte(code)) == 1 && !valid {
+			match, valid = Match{Counter: counter, Skew: skew + i}, true
+		}
+	}
+	return match, valid
+}
@@ -0,0 +1,125 @@
+package otp
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestValidateTOTP(t *testing.T) {
+	now := time.Unix(1111111109, 0)
+	opts := TOTPOptions{
+		Clock: ClockFunc(func() time.Time {
+			return now
+		}),
+	}
+
+	for step := -2; step <= 2; step++ {
+		code := TOTPString(totpSecretSha1, now.Add(time.Duration(step)*30*time.Second), TOTPOptions{})
+
+		res := ValidateTOTP(totpSecretSha1, code, 1, opts)
+		expected := step >= -1 && step <= 1
+		if res != expected {
+			t.Errorf("Error in ValidateTOTP (step = %d, expected = %t, got = %t)", step, expected, res)
+		}
+	}
+
+	if ValidateTOTP(totpSecretSha1, "", 1, opts) {
+		t.Errorf("Error in ValidateTOTP (empty code accepted)")
+	}
+}
+
+func TestValidateTOTPBetween(t *testing.T) {
+	notBefore := time.Unix(1111111109, 0)
+	notAfter := notBefore.Add(5 * time.Minute)
+
+	values := []struct {
+		t        time.Time
+		expected bool
+	}{
+		{notBefore.Add(-30 * time.Second), false},
+		{notBefore, true},
+		{notBefore.Add(2 * time.Minute), true},
+		{notAfter, true},
+		{notAfter.Add(30 * time.Second), false},
+	}
+
+	for i, v := range values {
+		code := TOTPString(totpSecretSha1, v.t, TOTPOptions{})
+		res := ValidateTOTPBetween(totpSecretSha1, code, notBefore, notAfter, TOTPOptions{})
+		if res != v.expected {
+			t.Errorf("Error in ValidateTOTPBetween (i = %d, expected = %t, got = %t)", i, v.expected, res)
+		}
+	}
+
+	code := TOTPString(totpSecretSha1, notBefore, TOTPOptions{})
+	if ValidateTOTPBetween(totpSecretSha1, code, notAfter, notBefore, TOTPOptions{}) {
+		t.Errorf("Error in ValidateTOTPBetween (code accepted in an empty interval)")
+	}
+	if ValidateTOTPBetween(totpSecretSha1, "", notBefore, notAfter, TOTPOptions{}) {
+		t.Errorf("Error in ValidateTOTPBetween (empty code accepted)")
+	}
+
+	// intervals overlapping more than MaxTOTPBetweenPeriods time periods are refused
+	longest := notBefore.Add((MaxTOTPBetweenPeriods - 1) * 30 * time.Second)
+	code = TOTPString(totpSecretSha1, longest, TOTPOptions{})
+	if !ValidateTOTPBetween(totpSecretSha1, code, notBefore, longest, TOTPOptions{}) {
+		t.Errorf("Error in ValidateTOTPBetween (code refused in an interval of %d periods)", MaxTOTPBetweenPeriods)
+	}
+	if ValidateTOTPBetween(totpSecretSha1, code, notBefore.Add(-30*time.Second), longest, TOTPOptions{}) {
+		t.Errorf("Error in ValidateTOTPBetween (code accepted in an interval of %d periods)", MaxTOTPBetweenPeriods+1)
+	}
+	if ValidateTOTPBetween(totpSecretSha1, code, time.Unix(math.MinInt64, 0), time.Unix(math.MaxInt64, 0), TOTPOptions{Period: 1}) {
+		t.Errorf("Error in ValidateTOTPBetween (code accepted in an overflowing interval)")
+	}
+}
+
+func TestVerifyTOTP(t *testing.T) {
+	now := time.Unix(1111111109, 0)
+	opts := TOTPOptions{
+		Clock: ClockFunc(func() time.Time {
+			return now
+		}),
+	}
+	current := uint64(TimePeriodCount(now, TOTPOptions{}))
+
+	for step := -2; step <= 2; step++ {
+		code := TOTPString(totpSecretSha1, now.Add(time.Duration(step)*30*time.Second), TOTPOptions{})
+
+		res, ok := VerifyTOTP(totpSecretSha1, code, 1, opts)
+		expected := Match{}
+		if step >= -1 && step <= 1 {
+			expected = Match{Counter: current + uint64(step), Skew: step}
+		}
+		if ok != (step >= -1 && step <= 1) || res != expected {
+			t.Errorf("Error in VerifyTOTP (step = %d, expected = %+v, got = %+v, %t)", step, expected, res, ok)
+		}
+	}
+
+	notBefore := now.Add(-5 * time.Minute)
+	code := TOTPString(totpSecretSha1, now, TOTPOptions{})
+	res, ok := VerifyTOTPBetween(totpSecretSha1, code, notBefore, now, TOTPOptions{})
+	expected := Match{Counter: current, Skew: 10}
+	if !ok || res != expected {
+		t.Errorf("Error in VerifyTOTPBetween (expected = %+v, got = %+v, %t)", expected, res, ok)
+	}
+}
+
+func TestVerifyHOTP(t *testing.T) {
+	for i, v := range hotpTestValues {
+		code := HOTPString(hotpSecret, v.Counter, HOTPOptions{})
+
+		res, ok := VerifyHOTP(hotpSecret, code, 3, 2, HOTPOptions{})
+		expected := Match{}
+		if v.Counter >= 3 && v.Counter <= 5 {
+			expected = Match{Counter: v.Counter, Skew: int(v.Counter) - 3}
+		}
+		if ok != (v.Counter >= 3 && v.Counter <= 5) || res != expected {
+			t.Errorf("Error in VerifyHOTP (i = %d, expected = %+v, got = %+v, %t)", i, expected, res, ok)
+		}
+	}
+
+	if _, ok := VerifyHOTP(hotpSecret, "", 0, 10, HOTPOptions{}); ok {
+		t.Errorf("Error in VerifyHOTP (empty code accepted)")
+	}
+}
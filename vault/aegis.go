@@ -0,0 +1,370 @@
+package vault
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/xrjr/otp"
+)
+
+// aegisExport is the JSON representation of the exports of Aegis Authenticator.
+type aegisExport struct {
+	Version int             `json:"version"`
+	Header  aegisHeader     `json:"header"`
+	DB      json.RawMessage `json:"db"`
+}
+
+type aegisHeader struct {
+	Slots  json.RawMessage `json:"slots"`
+	Params json.RawMessage `json:"params"`
+}
+
+// aegisSlot is a slot of an encrypted export, holding its master key encrypted with a key derived from a password.
+type aegisSlot struct {
+	Type      int            `json:"type"`
+	UUID      string         `json:"uuid"`
+	Key       string         `json:"key"`
+	KeyParams aegisKeyParams `json:"key_params"`
+	N         int            `json:"n"`
+	R         int            `json:"r"`
+	P         int            `json:"p"`
+	Salt      string         `json:"salt"`
+	Repaired  bool           `json:"repaired"`
+	IsBackup  bool           `json:"is_backup"`
+}
+
+// aegisKeyParams are the nonce and the tag of an AES-GCM ciphertext, in hex.
+type aegisKeyParams struct {
+	Nonce string `json:"nonce"`
+	Tag   string `json:"tag"`
+}
+
+type aegisDB struct {
+	Version int          `json:"version"`
+	Entries []aegisEntry `json:"entries"`
+}
+
+type aegisEntry struct {
+	Type   string    `json:"type"`
+	UUID   string    `json:"uuid,omitempty"`
+	Name   string    `json:"name"`
+	Issuer string    `json:"issuer"`
+	Note   string    `json:"note"`
+	Icon   *string   `json:"icon"`
+	Info   aegisInfo `json:"info"`
+}
+
+type aegisInfo struct {
+	Secret  string `json:"secret"`
+	Algo    string `json:"algo"`
+	Digits  uint   `json:"digits"`
+	Period  int    `json:"period,omitempty"`
+	Counter uint64 `json:"counter"`
+}
+
+// Aegis slot types and the scrypt parameters of the exports sealed by SealAegis, which are the ones of Aegis.
+const (
+	aegisSlotPassword = 1
+	aegisScryptN      = 1 << 15
+	aegisScryptR      = 8
+	aegisScryptP      = 1
+)
+
+// ErrEncryptedAegis is returned by ParseAegis for encrypted exports, which are opened by OpenAegis.
+var ErrEncryptedAegis = errors.New("vault: aegis export is encrypted")
+
+// IsAegis reports whether data looks like an export of Aegis Authenticator.
+func IsAegis(data []byte) bool {
+	var export aegisExport
+	return json.Unmarshal(data, &export) == nil && export.Version != 0 && len(export.DB) != 0
+}
+
+// ParseAegis parses the entries of an unencrypted export of Aegis Authenticator, named by Name. The entries which
+// aren't HOTP or TOTP keys, such as Steam entries, are skipped and reported by the returned error along with the
+// invalid ones, the other entries being returned anyway.
+func ParseAegis(data []byte) ([]Entry, error) {
+	var export aegisExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidFormat, err)
+	}
+	if export.Version != 1 {
+		return nil, fmt.Errorf("%w: aegis version %d is not supported", ErrInvalidFormat, export.Version)
+	}
+	if len(export.DB) > 0 && export.DB[0] == '"' {
+		return nil, ErrEncryptedAegis
+	}
+	return parseAegisDB(export.DB)
+}
+
+// OpenAegis parses the entries of an export of Aegis Authenticator encrypted with a password, as ParseAegis. It
+// returns ErrDecrypt if the password is wrong. Unencrypted exports are parsed as well.
+func OpenAegis(data []byte, password []byte) ([]Entry, error) {
+	var export aegisExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidFormat, err)
+	}
+	if export.Version != 1 {
+		return nil, fmt.Errorf("%w: aegis version %d is not supported", ErrInvalidFormat, export.Version)
+	}
+	if len(export.DB) == 0 || export.DB[0] != '"' {
+		return parseAegisDB(export.DB)
+	}
+
+	var slots []aegisSlot
+	var params aegisKeyParams
+	var encoded string
+	if err := errors.Join(json.Unmarshal(export.Header.Slots, &slots), json.Unmarshal(export.Header.Params, &params), json.Unmarshal(export.DB, &encoded)); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidFormat, err)
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidFormat, err)
+	}
+
+	// the master key is decrypted by the first password slot accepting the password
+	for _, slot := range slots {
+		if slot.Type != aegisSlotPassword {
+			continue
+		}
+		salt, err := hex.DecodeString(slot.Salt)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidFormat, err)
+		}
+		slotKey, err := scrypt(password, salt, slot.N, slot.R, slot.P, 32)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidFormat, err)
+		}
+		encryptedKey, err := hex.DecodeString(slot.Key)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidFormat, err)
+		}
+		masterKey, err := aegisOpen(slotKey, encryptedKey, slot.KeyParams)
+		otp.WipeBytes(slotKey)
+		if err != nil {
+			continue
+		}
+
+		db, err := aegisOpen(masterKey, ciphertext, params)
+		otp.WipeBytes(masterKey)
+		if err != nil {
+			return nil, err
+		}
+		defer otp.WipeBytes(db)
+		return parseAegisDB(db)
+	}
+	return nil, ErrDecrypt
+}
+
+// MarshalAegis returns an unencrypted export of entries, as imported by Aegis Authenticator.
+func MarshalAegis(entries []Entry) ([]byte, error) {
+	db, err := marshalAegisDB(entries)
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(aegisExport{
+		Version: 1,
+		Header:  aegisHeader{Slots: json.RawMessage("null"), Params: json.RawMessage("null")},
+		DB:      db,
+	}, "", "  ")
+}
+
+// SealAegis returns an export of entries encrypted with a password, as imported by Aegis Authenticator. The entries
+// are encrypted with a random master key, itself encrypted in a password slot with a key derived with scrypt.
+func SealAegis(entries []Entry, password []byte) ([]byte, error) {
+	db, err := marshalAegisDB(entries)
+	if err != nil {
+		return nil, err
+	}
+	defer otp.WipeBytes(db)
+
+	masterKey := make([]byte, 32)
+	salt := make([]byte, 32)
+	if _, err := rand.Read(masterKey); err != nil {
+		return nil, err
+	}
+	defer otp.WipeBytes(masterKey)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	slotKey, err := scrypt(password, salt, aegisScryptN, aegisScryptR, aegisScryptP, 32)
+	if err != nil {
+		return nil, err
+	}
+	defer otp.WipeBytes(slotKey)
+
+	encryptedKey, keyParams, err := aegisSeal(slotKey, masterKey)
+	if err != nil {
+		return nil, err
+	}
+	ciphertext, params, err := aegisSeal(masterKey, db)
+	if err != nil {
+		return nil, err
+	}
+
+	slots, err := json.Marshal([]aegisSlot{{
+		Type:      aegisSlotPassword,
+		UUID:      newUUID(),
+		Key:       hex.EncodeToString(encryptedKey),
+		KeyParams: keyParams,
+		N:         aegisScryptN,
+		R:         aegisScryptR,
+		P:         aegisScryptP,
+		Salt:      hex.EncodeToString(salt),
+		Repaired:  true,
+	}})
+	if err != nil {
+		return nil, err
+	}
+	header, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+	encoded, err := json.Marshal(base64.StdEncoding.EncodeToString(ciphertext))
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(aegisExport{
+		Version: 1,
+		Header:  aegisHeader{Slots: slots, Params: header},
+		DB:      encoded,
+	}, "", "  ")
+}
+
+// marshalAegisDB returns the JSON representation of the database of an export.
+func marshalAegisDB(entries []Entry) ([]byte, error) {
+	db := aegisDB{Version: 2, Entries: make([]aegisEntry, 0, len(entries))}
+	for _, e := range entries {
+		key := e.Key
+		if err := validate(key); err != nil {
+			return nil, fmt.Errorf("entry %s: %w", e.Name, err)
+		}
+
+		info := aegisInfo{
+			Secret:  base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(key.Secret),
+			Algo:    strings.ToUpper(key.Algorithm),
+			Digits:  key.Digits,
+			Period:  key.Period,
+			Counter: key.Counter,
+		}
+		if info.Algo == "" {
+			info.Algo = "SHA1"
+		}
+		if info.Digits == 0 {
+			info.Digits = 6
+		}
+		if key.Type == otp.TypeTOTP && info.Period == 0 {
+			info.Period = 30
+		}
+
+		name := key.AccountName
+		if name == "" {
+			name = e.Name
+		}
+		db.Entries = append(db.Entries, aegisEntry{Type: key.Type, UUID: newUUID(), Name: name, Issuer: key.Issuer, Info: info})
+	}
+	return json.Marshal(db)
+}
+
+// aegisSeal encrypts plaintext with AES-256-GCM, returning the ciphertext without its tag, as in Aegis exports.
+func aegisSeal(key, plaintext []byte) ([]byte, aegisKeyParams, error) {
+	aead, err := newAegisAEAD(key)
+	if err != nil {
+		return nil, aegisKeyParams{}, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, aegisKeyParams{}, err
+	}
+	sealed := aead.Seal(nil, nonce, plaintext, nil)
+	ciphertext, tag := sealed[:len(plaintext)], sealed[len(plaintext):]
+	return ciphertext, aegisKeyParams{Nonce: hex.EncodeToString(nonce), Tag: hex.EncodeToString(tag)}, nil
+}
+
+// aegisOpen decrypts a ciphertext encrypted by aegisSeal.
+func aegisOpen(key, ciphertext []byte, params aegisKeyParams) ([]byte, error) {
+	aead, err := newAegisAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	tag, err := hex.DecodeString(params.Tag)
+	if err != nil || len(tag) != aead.Overhead() {
+		return nil, fmt.Errorf("%w: invalid tag", ErrInvalidFormat)
+	}
+	nonce, err := hex.DecodeString(params.Nonce)
+	if err != nil || len(nonce) != aead.NonceSize() {
+		return nil, fmt.Errorf("%w: invalid nonce", ErrInvalidFormat)
+	}
+	plaintext, err := aead.Open(nil, nonce, append(ciphertext[:len(ciphertext):len(ciphertext)], tag...), nil)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return plaintext, nil
+}
+
+// newAegisAEAD returns the AES-GCM cipher of a key.
+func newAegisAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// newUUID returns a random (version 4) UUID.
+func newUUID() string {
+	var u [16]byte
+	rand.Read(u[:])
+	u[6] = u[6]&0x0f | 0x40
+	u[8] = u[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", u[0:4], u[4:6], u[6:8], u[8:10], u[10:16])
+}
+
+// parseAegisDB parses the entries of the database of an export.
+func parseAegisDB(data []byte) ([]Entry, error) {
+	var db aegisDB
+	if err := json.Unmarshal(data, &db); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidFormat, err)
+	}
+
+	var entries []Entry
+	var errs []error
+	for i, e := range db.Entries {
+		if e.Type != otp.TypeHOTP && e.Type != otp.TypeTOTP {
+			errs = append(errs, fmt.Errorf("entry %d (%s): %w: %s is not supported", i, e.Name, otp.ErrInvalidType, e.Type))
+			continue
+		}
+		secret, err := otp.ParseSecret(e.Info.Secret)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("entry %d (%s): %w", i, e.Name, err))
+			continue
+		}
+
+		key := otp.Key{
+			Type:        e.Type,
+			Issuer:      e.Issuer,
+			AccountName: e.Name,
+			Secret:      secret,
+			Algorithm:   strings.ToUpper(e.Info.Algo),
+			Digits:      e.Info.Digits,
+			Period:      e.Info.Period,
+			Counter:     e.Info.Counter,
+		}
+		if key.Type == otp.TypeHOTP {
+			key.Period = 0
+		}
+		if err := validate(key); err != nil {
+			errs = append(errs, fmt.Errorf("entry %d (%s): %w", i, e.Name, err))
+			continue
+		}
+		entries = append(entries, Entry{Name: Name(key), Key: key})
+	}
+	return entries, errors.Join(errs...)
+}
@@ -0,0 +1,93 @@
+package vault
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/xrjr/otp"
+)
+
+const aegisPlain = `{
+  "version": 1,
+  "header": {"slots": null, "params": null},
+  "db": {
+    "version": 2,
+    "entries": [
+      {"type": "totp", "uuid": "01234567-89ab-cdef-0123-456789abcdef", "name": "alice@example.com", "issuer": "Example", "note": "", "icon": null,
+       "info": {"secret": "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ", "algo": "SHA256", "digits": 8, "period": 60}},
+      {"type": "hotp", "uuid": "11234567-89ab-cdef-0123-456789abcdef", "name": "bob", "issuer": "", "note": "", "icon": null,
+       "info": {"secret": "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ", "algo": "SHA1", "digits": 6, "counter": 5}},
+      {"type": "steam", "uuid": "21234567-89ab-cdef-0123-456789abcdef", "name": "carol", "issuer": "Steam", "note": "", "icon": null,
+       "info": {"secret": "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ", "algo": "SHA1", "digits": 5, "period": 30}}
+    ]
+  }
+}`
+
+func TestParseAegis(t *testing.T) {
+	if !IsAegis([]byte(aegisPlain)) || IsAegis([]byte("otpauth://totp/alice?secret=GEZDGNBV")) {
+		t.Errorf("Error in IsAegis")
+	}
+
+	entries, err := ParseAegis([]byte(aegisPlain))
+	if !errors.Is(err, otp.ErrInvalidType) {
+		t.Errorf("Error in ParseAegis (expected = %v, got = %v)", otp.ErrInvalidType, err)
+	}
+
+	expected := []Entry{
+		{"Example:alice@example.com", otp.Key{Type: otp.TypeTOTP, Issuer: "Example", AccountName: "alice@example.com", Secret: []byte("12345678901234567890"), Algorithm: "SHA256", Digits: 8, Period: 60}},
+		{"bob", otp.Key{Type: otp.TypeHOTP, AccountName: "bob", Secret: []byte("12345678901234567890"), Algorithm: "SHA1", Digits: 6, Counter: 5}},
+	}
+	if len(entries) != len(expected) {
+		t.Fatalf("Error in ParseAegis (expected = %d entries, got = %d)", len(expected), len(entries))
+	}
+	for i := range expected {
+		if entries[i].Name != expected[i].Name || !entries[i].Key.Equal(expected[i].Key) {
+			t.Errorf("Error in ParseAegis (i = %d, expected = %v, got = %v)", i, expected[i], entries[i])
+		}
+	}
+}
+
+func TestParseAegisEncrypted(t *testing.T) {
+	encrypted := `{"version": 1, "header": {"slots": [], "params": {"nonce": "", "tag": ""}}, "db": "c2VjcmV0"}`
+	if _, err := ParseAegis([]byte(encrypted)); !errors.Is(err, ErrEncryptedAegis) {
+		t.Errorf("Error in ParseAegis (expected = %v, got = %v)", ErrEncryptedAegis, err)
+	}
+}
+
+func TestMarshalAegis(t *testing.T) {
+	entries, _ := ParseAegis([]byte(aegisPlain))
+
+	data, err := MarshalAegis(entries)
+	if err != nil {
+		t.Fatalf("Error in MarshalAegis (err = %v)", err)
+	}
+	sealed, err := SealAegis(entries, []byte("password"))
+	if err != nil {
+		t.Fatalf("Error in SealAegis (err = %v)", err)
+	}
+	if _, err := ParseAegis(sealed); !errors.Is(err, ErrEncryptedAegis) {
+		t.Errorf("Error in SealAegis (expected = %v, got = %v)", ErrEncryptedAegis, err)
+	}
+	if _, err := OpenAegis(sealed, []byte("wrong")); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("Error in OpenAegis (expected = %v, got = %v)", ErrDecrypt, err)
+	}
+
+	for i, open := range []func() ([]Entry, error){
+		func() ([]Entry, error) { return ParseAegis(data) },
+		func() ([]Entry, error) { return OpenAegis(data, nil) },
+		func() ([]Entry, error) { return OpenAegis(sealed, []byte("password")) },
+	} {
+		got, err := open()
+		if err != nil {
+			t.Fatalf("Error in OpenAegis (i = %d, err = %v)", i, err)
+		}
+		if len(got) != len(entries) {
+			t.Fatalf("Error in OpenAegis (i = %d, expected = %d entries, got = %d)", i, len(entries), len(got))
+		}
+		for j := range entries {
+			if got[j].Name != entries[j].Name || !got[j].Key.Equal(entries[j].Key) {
+				t.Errorf("Error in OpenAegis (i = %d, j = %d, expected = %v, got = %v)", i, j, entries[j], got[j])
+			}
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package vault
+
+import (
+	"encoding/binary"
+	"errors"
+	"math/bits"
+
+	"github.com/xrjr/otp"
+	"github.com/xrjr/otp/internal/pbkdf2"
+)
+
+// errInvalidScrypt is returned by scrypt for invalid cost parameters.
+var errInvalidScrypt = errors.New("vault: invalid scrypt parameters")
+
+// maxScryptCost is the maximum of n*r*p, the cost of the parameters read from files which aren't trusted: the
+// memory used is 128*n*r bytes, 256 MiB at most, and Aegis uses 2^15*8*1 by default.
+const maxScryptCost = 1 << 21
+
+// scrypt derives a key of keyLen bytes from a password, as defined by rfc 7914 with the CPU/memory cost n, the
+// block size r and the parallelization p.
+func scrypt(password, salt []byte, n, r, p, keyLen int) ([]byte, error) {
+	if n <= 1 || n&(n-1) != 0 || r <= 0 || p <= 0 || r > maxScryptCost || p > maxScryptCost || n > maxScryptCost ||
+		uint64(n)*uint64(r)*uint64(p) > maxScryptCost {
+		return nil, errInvalidScrypt
+	}
+
+	b := pbkdf2.Key(password, salt, 1, p*128*r)
+	x := make([]uint32, 32*r)
+	y := make([]uint32, 32*r)
+	v := make([]uint32, 32*r*n)
+	for i := range p {
+		smix(b[i*128*r:(i+1)*128*r], x, y, v, r, n)
+	}
+	dk := pbkdf2.Key(password, b, 1, keyLen)
+
+	otp.WipeBytes(b)
+	clear(x)
+	clear(y)
+	clear(v)
+	return dk, nil
+}
+
+// smix mixes the block b of 128*r bytes, as defined by section 5 of rfc 7914, using x and y of 32*r words and v of
+// 32*r*n words as working memory.
+func smix(b []byte, x, y, v []uint32, r, n int) {
+	for i := range x {
+		x[i] = binary.LittleEndian.Uint32(b[4*i:])
+	}
+	for i := range n {
+		copy(v[i*32*r:], x)
+		blockMix(x, y, r)
+	}
+	for range n {
+		j := int(x[(2*r-1)*16]) & (n - 1)
+		for k, w := range v[j*32*r : (j+1)*32*r] {
+			x[k] ^= w
+		}
+		blockMix(x, y, r)
+	}
+	for i, w := range x {
+		binary.LittleEndian.PutUint32(b[4*i:], w)
+	}
+}
+
+// blockMix mixes the 2*r blocks of 16 words of b with Salsa20/8, as defined by section 4 of rfc 7914, using y as
+// working memory.
+func blockMix(b, y []uint32, r int) {
+	var t [16]uint32
+	copy(t[:], b[(2*r-1)*16:])
+	for i := range 2 * r {
+		for k := range t {
+			t[k] ^= b[i*16+k]
+		}
+		salsa8(&t)
+		// the even blocks are moved to the first half, and the odd ones to the second half
+		copy(y[(i/2+(i%2)*r)*16:], t[:])
+	}
+	copy(b, y)
+}
+
+// salsa8 applies the Salsa20/8 core to a block, as defined by section 3 of rfc 7914.
+func salsa8(b *[16]uint32) {
+	x := *b
+	quarter := func(a, b, c, d int) {
+		x[b] ^= bits.RotateLeft32(x[a]+x[d], 7)
+		x[c] ^= bits.RotateLeft32(x[b]+x[a], 9)
+		x[d] ^= bits.RotateLeft32(x[c]+x[b], 13)
+		x[a] ^= bits.RotateLeft32(x[d]+x[c], 18)
+	}
+	for range 4 {
+		// columns
+		quarter(0, 4, 8, 12)
+		quarter(5, 9, 13, 1)
+		quarter(10, 14, 2, 6)
+		quarter(15, 3, 7, 11)
+		// rows
+		quarter(0, 1, 2, 3)
+		quarter(5, 6, 7, 4)
+		quarter(10, 11, 8, 9)
+		quarter(15, 12, 13, 14)
+	}
+	for i := range b {
+		b[i] += x[i]
+	}
+}
@@ -0,0 +1,36 @@
+package vault
+
+import (
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+func TestScrypt(t *testing.T) {
+	// test vectors of section 12 of rfc 7914
+	tests := []struct {
+		password, salt string
+		n, r, p        int
+		expected       string
+	}{
+		{"", "", 16, 1, 1, "77d6576238657b203b19ca42c18a0497f16b4844e3074ae8dfdffa3fede21442fcd0069ded0948f8326a753a0fc81f17e8d3e0fb2e0d3628cf35e20c38d18906"},
+		{"password", "NaCl", 1024, 8, 16, "fdbabe1c9d3472007856e7190d01e9fe7c6ad7cbc8237830e77376634b3731622eaf30d92e22a3886ff109279d9830dac727afb94a83ee6d8360cbdfa2cc0640"},
+		{"pleaseletmein", "SodiumChloride", 16384, 8, 1, "7023bdcb3afd7348461c06cd81fd38ebfda8fbba904f8e3ea9b543f6545da1f2d5432955613f0fcf62d49705242a9af9e61e85dc0d651e40dfcf017b45575887"},
+	}
+
+	for i, test := range tests {
+		dk, err := scrypt([]byte(test.password), []byte(test.salt), test.n, test.r, test.p, 64)
+		if err != nil {
+			t.Fatalf("Error in scrypt (i = %d, err = %v)", i, err)
+		}
+		if got := hex.EncodeToString(dk); got != test.expected {
+			t.Errorf("Error in scrypt (i = %d, expected = %s, got = %s)", i, test.expected, got)
+		}
+	}
+
+	for i, params := range [][3]int{{0, 1, 1}, {15, 1, 1}, {16, 0, 1}, {16, 1, 0}, {1 << 20, 8, 1}, {1 << 15, 8, 16}, {1 << 40, 1 << 40, 1}} {
+		if _, err := scrypt(nil, nil, params[0], params[1], params[2], 32); !errors.Is(err, errInvalidScrypt) {
+			t.Errorf("Error in scrypt (i = %d, expected = %v, got = %v)", i, errInvalidScrypt, err)
+		}
+	}
+}
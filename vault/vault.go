@@ -0,0 +1,258 @@
+// Package vault stores named keys in a file encrypted by a passphrase, for terminal authenticators such as the
+// otp command.
+//
+// The keys are encrypted with AES-256-GCM, under a key derived from the passphrase with PBKDF2-HMAC-SHA256. The
+// file is JSON, holding the parameters of the derivation next to the encrypted keys, so that the number of
+// iterations can be raised without breaking existing vaults.
+package vault
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/xrjr/otp"
+	"github.com/xrjr/otp/internal/pbkdf2"
+)
+
+// DefaultIterations is the number of PBKDF2 iterations of vaults which don't set Iterations, the default of
+// otp.DeriveSecret.
+const DefaultIterations = otp.DefaultKDFIterations
+
+// maxIterations is the maximum number of PBKDF2 iterations of vaults, so that files which aren't trusted can't make
+// Open derive their key for minutes.
+const maxIterations = 10 * DefaultIterations
+
+// version is the version of the format of the files.
+const version = 1
+
+var (
+	// ErrDecrypt is returned when a vault can't be decrypted, because the passphrase is wrong or the file was
+	// altered.
+	ErrDecrypt = errors.New("vault: wrong passphrase or corrupted vault")
+	// ErrInvalidFormat is returned when a file isn't a vault, or a vault of an unknown version.
+	ErrInvalidFormat = errors.New("vault: invalid format")
+	// ErrNotFound is returned when no entry has a name.
+	ErrNotFound = errors.New("vault: entry not found")
+	// ErrExists is returned when an entry is added with the name of another entry.
+	ErrExists = errors.New("vault: entry already exists")
+)
+
+// Entry is a key of a vault, with the name identifying it.
+type Entry struct {
+	Name string  `json:"name"`
+	Key  otp.Key `json:"key"`
+}
+
+// Vault is a set of entries, encrypted by Seal and decrypted by Open.
+type Vault struct {
+	Entries    []Entry
+	Iterations int // PBKDF2 iterations of Seal, defaults to DefaultIterations or the iterations of the opened vault
+}
+
+// file is the JSON representation of a sealed vault.
+type file struct {
+	Version    int    `json:"version"`
+	KDF        string `json:"kdf"`
+	Iterations int    `json:"iterations"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Data       []byte `json:"data"`
+}
+
+// kdf is the name of the key derivation of the files.
+const kdf = "pbkdf2-hmac-sha256"
+
+// Name returns the default name of the entry of a key: its issuer and account name separated by a colon, as in the
+// label of its URI, or the one which is set.
+func Name(key otp.Key) string {
+	switch {
+	case key.Issuer != "" && key.AccountName != "":
+		return key.Issuer + ":" + key.AccountName
+	case key.AccountName != "":
+		return key.AccountName
+	default:
+		return key.Issuer
+	}
+}
+
+// Get returns the entry of a name, or ErrNotFound.
+func (v *Vault) Get(name string) (Entry, error) {
+	i := v.index(name)
+	if i < 0 {
+		return Entry{}, fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	return v.Entries[i], nil
+}
+
+// Add adds an entry, or returns ErrExists when another entry has its name. The key of the entry is validated,
+// accepting secrets shorter than otp.MinSecretLength, which some services still issue.
+func (v *Vault) Add(e Entry) error {
+	if e.Name == "" {
+		return errors.New("vault: entry without name")
+	}
+	if v.index(e.Name) >= 0 {
+		return fmt.Errorf("%w: %s", ErrExists, e.Name)
+	}
+	if err := validate(e.Key); err != nil {
+		return err
+	}
+	v.Entries = append(v.Entries, e)
+	return nil
+}
+
+// Remove removes the entry of a name, or returns ErrNotFound.
+func (v *Vault) Remove(name string) error {
+	i := v.index(name)
+	if i < 0 {
+		return fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	v.Entries = slices.Delete(v.Entries, i, i+1)
+	return nil
+}
+
+// index returns the index of the entry of a name, or -1.
+func (v *Vault) index(name string) int {
+	return slices.IndexFunc(v.Entries, func(e Entry) bool {
+		return e.Name == name
+	})
+}
+
+// validate checks the type, the secret and the options of a key.
+func validate(key otp.Key) error {
+	if key.Type != otp.TypeHOTP && key.Type != otp.TypeTOTP {
+		return fmt.Errorf("%w: %q is neither %s nor %s", otp.ErrInvalidType, key.Type, otp.TypeHOTP, otp.TypeTOTP)
+	}
+	if len(key.Secret) == 0 {
+		return otp.ErrInvalidSecret
+	}
+	_, err := key.TOTPOptions()
+	return err
+}
+
+// Seal encrypts the vault with a passphrase, and returns the content of its file.
+func (v *Vault) Seal(passphrase []byte) ([]byte, error) {
+	f := file{Version: version, KDF: kdf, Iterations: v.Iterations, Salt: make([]byte, 16)}
+	if f.Iterations == 0 {
+		f.Iterations = DefaultIterations
+	}
+	if f.Iterations < 0 || f.Iterations > maxIterations {
+		return nil, fmt.Errorf("vault: invalid iterations: %d is not between 1 and %d", f.Iterations, maxIterations)
+	}
+	if _, err := rand.Read(f.Salt); err != nil {
+		return nil, err
+	}
+
+	entries := v.Entries
+	if entries == nil {
+		entries = []Entry{}
+	}
+	plaintext, err := json.Marshal(entries)
+	if err != nil {
+		return nil, err
+	}
+	defer otp.WipeBytes(plaintext)
+
+	aead, err := newAEAD(passphrase, f.Salt, f.Iterations)
+	if err != nil {
+		return nil, err
+	}
+	f.Nonce = make([]byte, aead.NonceSize())
+	if _, err := rand.Read(f.Nonce); err != nil {
+		return nil, err
+	}
+	f.Data = aead.Seal(nil, f.Nonce, plaintext, f.additionalData())
+
+	return json.MarshalIndent(f, "", "  ")
+}
+
+// Open decrypts the content of the file of a vault with its passphrase.
+func Open(data []byte, passphrase []byte) (*Vault, error) {
+	var f file
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidFormat, err)
+	}
+	if f.Version != version || f.KDF != kdf {
+		return nil, fmt.Errorf("%w: version %d with %s is not supported", ErrInvalidFormat, f.Version, f.KDF)
+	}
+	if f.Iterations <= 0 || f.Iterations > maxIterations {
+		return nil, fmt.Errorf("%w: %d iterations is not between 1 and %d", ErrInvalidFormat, f.Iterations, maxIterations)
+	}
+
+	aead, err := newAEAD(passphrase, f.Salt, f.Iterations)
+	if err != nil {
+		return nil, err
+	}
+	if len(f.Nonce) != aead.NonceSize() {
+		return nil, fmt.Errorf("%w: invalid nonce", ErrInvalidFormat)
+	}
+	plaintext, err := aead.Open(nil, f.Nonce, f.Data, f.additionalData())
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	defer otp.WipeBytes(plaintext)
+
+	v := &Vault{Iterations: f.Iterations}
+	if err := json.Unmarshal(plaintext, &v.Entries); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidFormat, err)
+	}
+	return v, nil
+}
+
+// additionalData returns the data authenticated with the entries, so that the parameters of the file can't be
+// altered either.
+func (f *file) additionalData() []byte {
+	ad := binary.BigEndian.AppendUint32(nil, uint32(f.Version))
+	ad = append(ad, f.KDF...)
+	ad = binary.BigEndian.AppendUint32(ad, uint32(f.Iterations))
+	return append(ad, f.Salt...)
+}
+
+// Load reads and decrypts the vault of a file. The error of a missing file matches os.ErrNotExist.
+func Load(path string, passphrase []byte) (*Vault, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Open(data, passphrase)
+}
+
+// Save encrypts the vault and writes it to a file, readable by its owner only. The new file replaces the previous
+// one atomically.
+func (v *Vault) Save(path string, passphrase []byte) error {
+	data, err := v.Seal(passphrase)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".vault-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(data)
+	if err := errors.Join(err, tmp.Chmod(0o600), tmp.Sync(), tmp.Close()); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// newAEAD returns the AES-256-GCM cipher of the key derived from a passphrase.
+func newAEAD(passphrase []byte, salt []byte, iterations int) (cipher.AEAD, error) {
+	key := pbkdf2.Key(passphrase, salt, iterations, 32)
+	defer otp.WipeBytes(key)
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,118 @@
+package vault
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/xrjr/otp"
+)
+
+var key = otp.Key{Type: otp.TypeTOTP, Issuer: "Example", AccountName: "alice@example.com", Secret: []byte("12345678901234567890")}
+
+func TestVaultEntries(t *testing.T) {
+	var v Vault
+	if err := v.Add(Entry{Name: Name(key), Key: key}); err != nil {
+		t.Fatalf("Error in Add (err = %v)", err)
+	}
+	if err := v.Add(Entry{Name: Name(key), Key: key}); !errors.Is(err, ErrExists) {
+		t.Errorf("Error in Add (expected = %v, got = %v)", ErrExists, err)
+	}
+	if err := v.Add(Entry{Name: "invalid", Key: otp.Key{Type: otp.TypeTOTP}}); !errors.Is(err, otp.ErrInvalidSecret) {
+		t.Errorf("Error in Add (expected = %v, got = %v)", otp.ErrInvalidSecret, err)
+	}
+
+	e, err := v.Get("Example:alice@example.com")
+	if err != nil || !e.Key.Equal(key) {
+		t.Errorf("Error in Get (expected = %v, got = %v, err = %v)", key, e.Key, err)
+	}
+
+	if err := v.Remove("Example:alice@example.com"); err != nil {
+		t.Errorf("Error in Remove (err = %v)", err)
+	}
+	if _, err := v.Get("Example:alice@example.com"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Error in Get (expected = %v, got = %v)", ErrNotFound, err)
+	}
+	if err := v.Remove("Example:alice@example.com"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Error in Remove (expected = %v, got = %v)", ErrNotFound, err)
+	}
+}
+
+func TestName(t *testing.T) {
+	tests := []struct {
+		key      otp.Key
+		expected string
+	}{
+		{otp.Key{Issuer: "Example", AccountName: "alice"}, "Example:alice"},
+		{otp.Key{AccountName: "alice"}, "alice"},
+		{otp.Key{Issuer: "Example"}, "Example"},
+	}
+
+	for i, test := range tests {
+		if got := Name(test.key); got != test.expected {
+			t.Errorf("Error in Name (i = %d, expected = %q, got = %q)", i, test.expected, got)
+		}
+	}
+}
+
+func TestSealOpen(t *testing.T) {
+	v := &Vault{Entries: []Entry{{Name: "alice", Key: key}}, Iterations: 10}
+	data, err := v.Seal([]byte("passphrase"))
+	if err != nil {
+		t.Fatalf("Error in Seal (err = %v)", err)
+	}
+
+	opened, err := Open(data, []byte("passphrase"))
+	if err != nil || len(opened.Entries) != 1 || !opened.Entries[0].Key.Equal(key) || opened.Iterations != 10 {
+		t.Errorf("Error in Open (unexpected vault %+v, err = %v)", opened, err)
+	}
+
+	if _, err := Open(data, []byte("wrong")); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("Error in Open (expected = %v, got = %v)", ErrDecrypt, err)
+	}
+
+	// lowering the iterations is detected, as they are authenticated
+	var f map[string]any
+	json.Unmarshal(data, &f)
+	f["iterations"] = 1
+	tampered, _ := json.Marshal(f)
+	if _, err := Open(tampered, []byte("passphrase")); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("Error in Open (expected = %v, got = %v)", ErrDecrypt, err)
+	}
+
+	if _, err := Open([]byte(`{"version": 2}`), []byte("passphrase")); !errors.Is(err, ErrInvalidFormat) {
+		t.Errorf("Error in Open (expected = %v, got = %v)", ErrInvalidFormat, err)
+	}
+
+	// the iterations of files which aren't trusted are bounded
+	f["iterations"] = maxIterations + 1
+	tampered, _ = json.Marshal(f)
+	if _, err := Open(tampered, []byte("passphrase")); !errors.Is(err, ErrInvalidFormat) {
+		t.Errorf("Error in Open (expected = %v, got = %v)", ErrInvalidFormat, err)
+	}
+	if _, err := (&Vault{Iterations: maxIterations + 1}).Seal([]byte("passphrase")); err == nil {
+		t.Errorf("Error in Seal (expected error with %d iterations)", maxIterations+1)
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vault.json")
+	if _, err := Load(path, []byte("passphrase")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Error in Load (expected = %v, got = %v)", os.ErrNotExist, err)
+	}
+
+	v := &Vault{Entries: []Entry{{Name: "alice", Key: key}}, Iterations: 10}
+	if err := v.Save(path, []byte("passphrase")); err != nil {
+		t.Fatalf("Error in Save (err = %v)", err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("Error in Save (unexpected file %v, err = %v)", info, err)
+	}
+
+	loaded, err := Load(path, []byte("passphrase"))
+	if err != nil || len(loaded.Entries) != 1 || !loaded.Entries[0].Key.Equal(key) {
+		t.Errorf("Error in Load (unexpected vault %+v, err = %v)", loaded, err)
+	}
+}
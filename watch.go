@@ -0,0 +1,46 @@
+package otp
+
+import (
+	"context"
+	"time"
+)
+
+// WatchTOTP sends the code of the current time period, then the new code at the start of each time period,
+// until the context is cancelled. The channel is closed once the context is cancelled.
+// The current time is given by opts.Clock.
+func WatchTOTP(ctx context.Context, key []byte, opts TOTPOptions) <-chan Code {
+	opts = opts.withDefaults()
+	ch := make(chan Code)
+
+	go func() {
+		defer close(ch)
+
+		sent := false
+		last := uint64(0)
+		for {
+			now := opts.Clock.Now()
+			code := TOTPCode(key, now, opts)
+
+			// the timer may fire slightly before the wall clock reaches the next time period
+			if !sent || code.Counter != last {
+				select {
+				case ch <- code:
+				case <-ctx.Done():
+					return
+				}
+				sent = true
+				last = code.Counter
+			}
+
+			timer := time.NewTimer(TimeRemaining(opts.Clock.Now(), opts))
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			}
+		}
+	}()
+
+	return ch
+}
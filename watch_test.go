@@ -0,0 +1,47 @@
+package otp
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestWatchTOTP(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	opts := TOTPOptions{Period: 1}
+	ch := WatchTOTP(ctx, totpSecretSha1, opts)
+
+	first := <-ch
+	if now := time.Now(); now.Before(first.ValidFrom) || !now.Before(first.ValidUntil.Add(100*time.Millisecond)) {
+		t.Errorf("Error in WatchTOTP (first code isn't the current one, now = %s, got = %+v)", now, first)
+	}
+
+	second := <-ch
+	if second.Counter != first.Counter+1 || second.Value != TOTPString(totpSecretSha1, second.ValidFrom, opts) {
+		t.Errorf("Error in WatchTOTP (expected next code after %+v, got = %+v)", first, second)
+	}
+
+	cancel()
+	for range ch {
+	}
+}
+
+func TestWatchTOTPClock(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	now := time.Unix(1111111109, 0)
+	opts := TOTPOptions{
+		Clock: ClockFunc(func() time.Time {
+			return now
+		}),
+	}
+
+	res := <-WatchTOTP(ctx, totpSecretSha1, opts)
+	expected := TOTPCode(totpSecretSha1, now, opts)
+	if res != expected {
+		t.Errorf("Error in WatchTOTPClock (expected = %+v, got = %+v)", expected, res)
+	}
+}
@@ -0,0 +1,60 @@
+package ykoath
+
+import (
+	"errors"
+)
+
+// errInvalidTLV is returned when a response of the applet can't be decoded.
+var errInvalidTLV = errors.New("ykoath: invalid tlv")
+
+// tlv is a tag-length-value record of the applet protocol.
+type tlv struct {
+	tag   byte
+	value []byte
+}
+
+// appendTLV appends the encoding of a tag-length-value record to b.
+func appendTLV(b []byte, tag byte, value []byte) []byte {
+	b = append(b, tag)
+	switch {
+	case len(value) < 0x80:
+		b = append(b, byte(len(value)))
+	case len(value) <= 0xff:
+		b = append(b, 0x81, byte(len(value)))
+	default:
+		b = append(b, 0x82, byte(len(value)>>8), byte(len(value)))
+	}
+	return append(b, value...)
+}
+
+// parseTLVs decodes a sequence of tag-length-value records.
+func parseTLVs(b []byte) ([]tlv, error) {
+	var res []tlv
+	for len(b) > 0 {
+		if len(b) < 2 {
+			return nil, errInvalidTLV
+		}
+		tag, length := b[0], int(b[1])
+		b = b[2:]
+
+		switch length {
+		case 0x81:
+			if len(b) < 1 {
+				return nil, errInvalidTLV
+			}
+			length, b = int(b[0]), b[1:]
+		case 0x82:
+			if len(b) < 2 {
+				return nil, errInvalidTLV
+			}
+			length, b = int(b[0])<<8|int(b[1]), b[2:]
+		}
+
+		if len(b) < length {
+			return nil, errInvalidTLV
+		}
+		res = append(res, tlv{tag: tag, value: b[:length]})
+		b = b[length:]
+	}
+	return res, nil
+}
@@ -0,0 +1,230 @@
+// Package ykoath lists the credentials of the OATH applet of a YubiKey and computes their codes on the device, using
+// the protocol of yubikey-manager (ykman).
+//
+// The package doesn't depend on a smart card library: a Card sends APDUs to the device over CCID, e.g. a
+// *scard.Card of github.com/ebfe/scard.
+package ykoath
+
+import (
+	"crypto"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xrjr/otp"
+)
+
+var (
+	// ErrPasswordProtected is returned when the applet requires a password, which isn't supported.
+	ErrPasswordProtected = errors.New("ykoath: applet is password protected")
+	// ErrNoSuchCredential is returned when the credential doesn't exist on the device.
+	ErrNoSuchCredential = errors.New("ykoath: no such credential")
+	// ErrTouchRequired is returned when the credential requires the user to touch the device.
+	ErrTouchRequired = errors.New("ykoath: touch required")
+)
+
+// Card transmits APDUs to a smart card and returns the responses, status word included.
+type Card interface {
+	Transmit(apdu []byte) ([]byte, error)
+}
+
+// Type is the type of a credential.
+type Type byte
+
+// Credential types, as encoded by the applet.
+const (
+	HOTP Type = 0x10
+	TOTP Type = 0x20
+)
+
+// Credential is a credential stored on the device.
+type Credential struct {
+	Name   string      // name of the credential, as stored by the applet (e.g. "60/Issuer:account")
+	Type   Type        // HOTP or TOTP
+	Hash   crypto.Hash // hash function of the hmac
+	Period int         // time period in seconds of TOTP credentials, 0 for HOTP credentials
+}
+
+// OATH is a session with the OATH applet of a device.
+type OATH struct {
+	card Card
+}
+
+// applet identifier and instructions
+var aid = []byte{0xa0, 0x00, 0x00, 0x05, 0x27, 0x21, 0x01}
+
+const (
+	insSelect        = 0xa4
+	insList          = 0xa1
+	insCalculate     = 0xa2
+	insSendRemaining = 0xa5
+)
+
+// tags of the tag-length-value records
+const (
+	tagName      = 0x71
+	tagNameList  = 0x72
+	tagChallenge = 0x74
+	tagTruncated = 0x76
+	tagTouch     = 0x7c
+)
+
+// status words
+const (
+	swSuccess  = 0x9000
+	swMoreData = 0x61
+	swNotFound = 0x6984
+)
+
+// New selects the OATH applet of the card.
+func New(card Card) (*OATH, error) {
+	o := &OATH{card: card}
+
+	resp, err := o.send(0x00, insSelect, 0x04, 0x00, aid)
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := parseTLVs(resp)
+	if err != nil {
+		return nil, err
+	}
+	for _, record := range records {
+		if record.tag == tagChallenge {
+			return nil, ErrPasswordProtected
+		}
+	}
+
+	return o, nil
+}
+
+// List returns the credentials stored on the device.
+func (o *OATH) List() ([]Credential, error) {
+	resp, err := o.send(0x00, insList, 0x00, 0x00, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := parseTLVs(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	var res []Credential
+	for _, record := range records {
+		if record.tag != tagNameList || len(record.value) < 1 {
+			continue
+		}
+
+		credential := Credential{
+			Name: string(record.value[1:]),
+			Type: Type(record.value[0] & 0xf0),
+		}
+		switch record.value[0] & 0x0f {
+		case 0x01:
+			credential.Hash = crypto.SHA1
+		case 0x02:
+			credential.Hash = crypto.SHA256
+		case 0x03:
+			credential.Hash = crypto.SHA512
+		}
+		if credential.Type == TOTP {
+			credential.Period = period(credential.Name)
+		}
+		res = append(res, credential)
+	}
+	return res, nil
+}
+
+// Calculate computes on the device the code of a credential at a given time. HOTP credentials ignore the time, and
+// their counter is incremented by the device, so Code.Counter is left to 0 for them.
+func (o *OATH) Calculate(credential Credential, t time.Time) (otp.Code, error) {
+	challenge := []byte{}
+	var code otp.Code
+	if credential.Type == TOTP {
+		p := int64(credential.Period)
+		if p == 0 {
+			p = 30
+		}
+		counter := t.Unix() / p
+		code.Counter = uint64(counter)
+		code.ValidFrom = time.Unix(counter*p, 0)
+		code.ValidUntil = time.Unix((counter+1)*p, 0)
+		challenge = binary.BigEndian.AppendUint64(nil, code.Counter)
+	}
+
+	data := appendTLV(nil, tagName, []byte(credential.Name))
+	data = appendTLV(data, tagChallenge, challenge)
+
+	// P2 = 0x01 asks for the truncated response
+	resp, err := o.send(0x00, insCalculate, 0x00, 0x01, data)
+	if err != nil {
+		return otp.Code{}, err
+	}
+
+	records, err := parseTLVs(resp)
+	if err != nil {
+		return otp.Code{}, err
+	}
+	if len(records) != 1 {
+		return otp.Code{}, errInvalidTLV
+	}
+
+	switch record := records[0]; {
+	case record.tag == tagTouch:
+		return otp.Code{}, ErrTouchRequired
+	case record.tag != tagTruncated || len(record.value) != 5:
+		return otp.Code{}, errInvalidTLV
+	default:
+		digits := uint(record.value[0])
+		value := uint(binary.BigEndian.Uint32(record.value[1:]) & 0x7fffffff)
+		code.Value = otp.DecimalEncoder.Encode(value, digits)
+		code.Digits = digits
+		return code, nil
+	}
+}
+
+// send transmits an APDU and returns the data of the response, following the responses chained by the applet.
+func (o *OATH) send(cla, ins, p1, p2 byte, data []byte) ([]byte, error) {
+	apdu := append([]byte{cla, ins, p1, p2, byte(len(data))}, data...)
+
+	var res []byte
+	for {
+		resp, err := o.card.Transmit(apdu)
+		if err != nil {
+			return nil, err
+		}
+		if len(resp) < 2 {
+			return nil, errInvalidTLV
+		}
+
+		sw := int(binary.BigEndian.Uint16(resp[len(resp)-2:]))
+		res = append(res, resp[:len(resp)-2]...)
+		switch {
+		case sw == swSuccess:
+			return res, nil
+		case sw>>8 == swMoreData:
+			apdu = []byte{0x00, insSendRemaining, 0x00, 0x00}
+		case sw == swNotFound:
+			return nil, ErrNoSuchCredential
+		default:
+			return nil, fmt.Errorf("ykoath: unexpected status word %04x", sw)
+		}
+	}
+}
+
+// period returns the time period of a TOTP credential, stored as a prefix of its name when it isn't 30 seconds.
+func period(name string) int {
+	prefix, _, found := strings.Cut(name, "/")
+	if !found {
+		return 30
+	}
+	p, err := strconv.Atoi(prefix)
+	if err != nil || p <= 0 {
+		return 30
+	}
+	return p
+}
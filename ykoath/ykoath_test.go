@@ -0,0 +1,157 @@
+package ykoath
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"hash"
+	"testing"
+	"time"
+
+	"github.com/xrjr/otp"
+)
+
+var totpSecret = []byte("12345678901234567890")
+var totpSecretSha256 = []byte("12345678901234567890123456789012")
+
+// fakeCard emulates the OATH applet of a device, computing the codes in software.
+// Responses are split in chunks of at most chunk bytes, to exercise the chaining of responses.
+type fakeCard struct {
+	selected bool
+	password bool
+	chunk    int
+	pending  []byte
+	counters map[string]uint64
+}
+
+func (c *fakeCard) Transmit(apdu []byte) ([]byte, error) {
+	switch apdu[1] {
+	case insSelect:
+		if !bytes.Equal(apdu[5:], aid) {
+			return []byte{0x6a, 0x82}, nil
+		}
+		c.selected = true
+		resp := appendTLV(nil, tagName, []byte{1, 2, 3, 4, 5, 6, 7, 8})
+		if c.password {
+			resp = appendTLV(resp, tagChallenge, []byte{1, 2, 3, 4, 5, 6, 7, 8})
+		}
+		return c.respond(resp), nil
+	case insSendRemaining:
+		return c.respond(c.pending), nil
+	}
+
+	if !c.selected {
+		return []byte{0x69, 0x85}, nil
+	}
+
+	switch apdu[1] {
+	case insList:
+		resp := appendTLV(nil, tagNameList, append([]byte{0x21}, "Example:alice@example.com"...))
+		resp = appendTLV(resp, tagNameList, append([]byte{0x22}, "60/Example:bob@example.com"...))
+		resp = appendTLV(resp, tagNameList, append([]byte{0x11}, "Example:carol@example.com"...))
+		return c.respond(resp), nil
+	case insCalculate:
+		records, err := parseTLVs(apdu[5:])
+		if err != nil || len(records) != 2 {
+			return []byte{0x6a, 0x80}, nil
+		}
+		name, challenge := string(records[0].value), records[1].value
+
+		var mac []byte
+		switch name {
+		case "Example:alice@example.com":
+			mac = sum(sha1.New, totpSecret, challenge)
+		case "60/Example:bob@example.com":
+			mac = sum(sha256.New, totpSecretSha256, challenge)
+		case "Example:carol@example.com":
+			mac = sum(sha1.New, totpSecret, binary.BigEndian.AppendUint64(nil, c.counters[name]))
+			c.counters[name]++
+		default:
+			return []byte{0x69, 0x84}, nil
+		}
+
+		offset := mac[len(mac)-1] & 0xf
+		return c.respond(appendTLV(nil, tagTruncated, append([]byte{8}, mac[offset:offset+4]...))), nil
+	}
+	return []byte{0x6d, 0x00}, nil
+}
+
+func (c *fakeCard) respond(resp []byte) []byte {
+	if c.chunk == 0 || len(resp) <= c.chunk {
+		c.pending = nil
+		return append(append([]byte{}, resp...), 0x90, 0x00)
+	}
+	c.pending = resp[c.chunk:]
+	return append(append([]byte{}, resp[:c.chunk]...), 0x61, byte(len(c.pending)))
+}
+
+func sum(hashFunc func() hash.Hash, key, msg []byte) []byte {
+	hasher := hmac.New(hashFunc, key)
+	hasher.Write(msg)
+	return hasher.Sum(nil)
+}
+
+func TestList(t *testing.T) {
+	o, err := New(&fakeCard{chunk: 16})
+	if err != nil {
+		t.Fatalf("Error in New (err = %v)", err)
+	}
+
+	credentials, err := o.List()
+	if err != nil {
+		t.Fatalf("Error in List (err = %v)", err)
+	}
+
+	expected := []Credential{
+		{Name: "Example:alice@example.com", Type: TOTP, Hash: crypto.SHA1, Period: 30},
+		{Name: "60/Example:bob@example.com", Type: TOTP, Hash: crypto.SHA256, Period: 60},
+		{Name: "Example:carol@example.com", Type: HOTP, Hash: crypto.SHA1},
+	}
+	if len(credentials) != len(expected) {
+		t.Fatalf("Error in List (expected = %d credentials, got = %d)", len(expected), len(credentials))
+	}
+	for i := range expected {
+		if credentials[i] != expected[i] {
+			t.Errorf("Error in List (i = %d, expected = %+v, got = %+v)", i, expected[i], credentials[i])
+		}
+	}
+}
+
+func TestCalculate(t *testing.T) {
+	card := &fakeCard{chunk: 4, counters: map[string]uint64{}}
+	o, err := New(card)
+	if err != nil {
+		t.Fatalf("Error in New (err = %v)", err)
+	}
+	credentials, _ := o.List()
+
+	now := time.Unix(1111111109, 0)
+	expected := []otp.Code{
+		otp.TOTPCode(totpSecret, now, otp.TOTPOptions{HOTPOptions: otp.HOTPOptions{Digits: 8}}),
+		otp.TOTPCode(totpSecretSha256, now, otp.TOTPOptions{HOTPOptions: otp.HOTPOptions{Digits: 8, Hash: crypto.SHA256}, Period: 60}),
+		otp.HOTPCode(totpSecret, 0, otp.HOTPOptions{Digits: 8}),
+	}
+
+	for i, credential := range credentials {
+		code, err := o.Calculate(credential, now)
+		if err != nil {
+			t.Errorf("Error in Calculate (i = %d, err = %v)", i, err)
+		} else if code != expected[i] {
+			t.Errorf("Error in Calculate (i = %d, expected = %+v, got = %+v)", i, expected[i], code)
+		}
+	}
+
+	if _, err := o.Calculate(Credential{Name: "unknown", Type: TOTP}, now); !errors.Is(err, ErrNoSuchCredential) {
+		t.Errorf("Error in Calculate (expected = %v, got = %v)", ErrNoSuchCredential, err)
+	}
+}
+
+func TestNewPasswordProtected(t *testing.T) {
+	if _, err := New(&fakeCard{password: true}); !errors.Is(err, ErrPasswordProtected) {
+		t.Errorf("Error in New (expected = %v, got = %v)", ErrPasswordProtected, err)
+	}
+}